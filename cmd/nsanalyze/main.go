@@ -0,0 +1,9 @@
+// Command nsanalyze checks NetScaler configurations for servers that lose reachability when SNIPs and VLANs move
+// to a trunk.
+package main
+
+import "github.com/ajenehall/vlanTrunkProject/internal/nsanalyze"
+
+func main() {
+	nsanalyze.Main()
+}
@@ -1,3 +1,19 @@
-module vlanTrunkProject
+module github.com/ajenehall/vlanTrunkProject
 
-go 1.18
+go 1.22
+
+require (
+	go.etcd.io/bbolt v1.3.11
+	golang.org/x/crypto v0.31.0
+	google.golang.org/grpc v1.60.0
+	google.golang.org/protobuf v1.31.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/golang/protobuf v1.5.3 // indirect
+	golang.org/x/net v0.21.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20231002182017-d307bd883b97 // indirect
+)
@@ -0,0 +1,284 @@
+package nsanalyze
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// freeTextOptions holds the options whose values are free text, such as comments saying who owns an object or
+// what it is for, in lower case. Their values are replaced as a whole rather than field by field.
+var freeTextOptions = map[string]bool{"-comment": true, "-ifalias": true, "-contact": true, "-location": true}
+
+// ipv4Pattern matches IPv4 addresses embedded anywhere within a configuration field, such as in a URL.
+var ipv4Pattern = regexp.MustCompile(`\b\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}\b`)
+
+// Anonymizer is a data structure that rewrites a NetScaler configuration so that it can be shared. IP
+// addresses are rewritten with a prefix preserving scheme, so two addresses that share an n-bit prefix still
+// share an n-bit prefix afterwards and subnet membership is unchanged. Names are replaced consistently.
+type Anonymizer struct {
+	key   []byte
+	ips   map[string]string
+	names map[string]string
+	count map[string]int
+	// dotted are the names with a dot in them, which are also replaced within fields, longest first so that a
+	// hostname is replaced before a domain it ends with.
+	dotted []string
+}
+
+// NewAnonymizer is a function that returns an anonymizer using the given key. Using the same key for two
+// configurations maps their addresses and names the same way.
+func NewAnonymizer(key []byte) *Anonymizer {
+	return &Anonymizer{
+		key:   key,
+		ips:   make(map[string]string),
+		names: make(map[string]string),
+		count: make(map[string]int),
+	}
+}
+
+// AnonymizeIP is a function that returns the prefix preserving replacement for an IP address. Every output bit
+// is the input bit flipped by a keyed hash of all the input bits before it.
+func (a *Anonymizer) AnonymizeIP(ip net.IP) net.IP {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	out := make(net.IP, len(ip))
+	prefix := make([]byte, len(ip))
+	for i := 0; i < len(ip)*8; i++ {
+		mac := hmac.New(sha256.New, a.key)
+		mac.Write([]byte{byte(len(ip)), byte(i)})
+		mac.Write(prefix)
+		flip := mac.Sum(nil)[0] & 1
+		bit := (ip[i/8] >> uint(7-i%8)) & 1
+		out[i/8] |= (bit ^ flip) << uint(7-i%8)
+		prefix[i/8] |= bit << uint(7-i%8)
+	}
+	return out
+}
+
+// anonymizeAddress is a function that returns the replacement for an address written as text. Subnet masks and
+// the all-zero and broadcast addresses carry no identifying information and are left as they are.
+func (a *Anonymizer) anonymizeAddress(address string) string {
+	if replacement, ok := a.ips[address]; ok {
+		return replacement
+	}
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return address
+	}
+	if _, isMask := subnetMasks[address]; isMask || ip.IsUnspecified() || ip.Equal(net.IPv4bcast) {
+		return address
+	}
+	replacement := a.AnonymizeIP(ip).String()
+	a.ips[address] = replacement
+	return replacement
+}
+
+// addName is a function that records a replacement for a name, numbering replacements per kind.
+func (a *Anonymizer) addName(name, kind string) {
+	if name == "" || a.names[name] != "" || net.ParseIP(name) != nil {
+		return
+	}
+	a.count[kind]++
+	switch kind {
+	case "host":
+		a.names[name] = fmt.Sprintf("host%d.example.net", a.count[kind])
+	case "cert", "key":
+		a.names[name] = fmt.Sprintf("%s%d%s", kind, a.count[kind], path.Ext(name))
+	default:
+		a.names[name] = fmt.Sprintf("%s%d", kind, a.count[kind])
+	}
+	if strings.Contains(name, ".") {
+		a.dotted = append(a.dotted, name)
+		sort.Slice(a.dotted, func(i, j int) bool {
+			if len(a.dotted[i]) != len(a.dotted[j]) {
+				return len(a.dotted[i]) > len(a.dotted[j])
+			}
+			return a.dotted[i] < a.dotted[j]
+		})
+	}
+}
+
+// learnNames is a function that collects the server names, hostnames and certificate names from a configuration
+// so that every later reference to them can be replaced the same way.
+func (a *Anonymizer) learnNames(file string) {
+	for _, line := range strings.Split(file, "\n") {
+		fields := SplitConfigLine(line)
+		switch {
+		case len(fields) > 3 && fields[0] == "add" && fields[1] == "server":
+			a.addName(fields[2], "server")
+			if net.ParseIP(fields[3]) == nil && !strings.HasPrefix(fields[3], "-") {
+				a.addName(fields[3], "host")
+			}
+		case len(fields) > 3 && fields[0] == "add" && fields[1] == "ssl" && strings.EqualFold(fields[2], "certKey"):
+			a.addName(fields[3], "certkey")
+			a.addName(GetOption(fields, "-cert"), "cert")
+			a.addName(GetOption(fields, "-key"), "key")
+		case len(fields) > 3 && fields[0] == "set" && fields[1] == "ns" && strings.EqualFold(fields[2], "hostName"):
+			a.addName(fields[3], "netscaler")
+		}
+		a.addName(GetOption(fields, "-domainName"), "host")
+	}
+}
+
+// anonymizeText is a function that returns the replacement for the value of a free text option: a keyed hash of
+// the text, so that the same text gets the same replacement in every configuration anonymized with the same key
+// while nothing of it is left.
+func (a *Anonymizer) anonymizeText(text string) string {
+	if text == "" {
+		return text
+	}
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte("text\x00" + text))
+	return fmt.Sprintf("text-%x", mac.Sum(nil)[:6])
+}
+
+// anonymizeField is a function that returns the replacement for a single field of a configuration line.
+func (a *Anonymizer) anonymizeField(field string) string {
+	if replacement, ok := a.names[field]; ok {
+		return replacement
+	}
+	field = ipv4Pattern.ReplaceAllStringFunc(field, a.anonymizeAddress)
+	if strings.Contains(field, ":") && net.ParseIP(field) != nil {
+		return a.anonymizeAddress(field)
+	}
+	for _, name := range a.dotted {
+		if strings.Contains(field, name) {
+			field = strings.Replace(field, name, a.names[name], -1)
+		}
+	}
+	return field
+}
+
+// networkAddresses is a function that returns the replacements for the network addresses on a line, such as
+// the destination of a route. A network address followed by its mask is replaced by the anonymized address
+// with the same mask applied, which is the network that the anonymized hosts within it belong to.
+func (a *Anonymizer) networkAddresses(fields []string) map[string]string {
+	networks := make(map[string]string)
+	for i := 0; i < len(fields)-1; i++ {
+		ip := net.ParseIP(fields[i]).To4()
+		mask := net.ParseIP(fields[i+1]).To4()
+		if ip == nil || mask == nil {
+			continue
+		}
+		if _, isMask := subnetMasks[fields[i+1]]; !isMask || !ip.Mask(net.IPMask(mask)).Equal(ip) {
+			continue
+		}
+		networks[fields[i]] = a.AnonymizeIP(ip).Mask(net.IPMask(mask)).String()
+	}
+	return networks
+}
+
+// AnonymizeConfig is a function that accepts the contents of a configuration file and returns the sanitized
+// configuration. The version banner and the layout of every line are kept. Other comment lines are emptied, as
+// they are free text that may name servers and addresses, and are left in place so that line numbers still match.
+// For the same reason the values of free text options such as -comment are replaced as a whole.
+func (a *Anonymizer) AnonymizeConfig(file string) string {
+	a.learnNames(file)
+	lines := strings.Split(file, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			if !firmwareBanner.MatchString(line) {
+				lines[i] = ""
+			}
+			continue
+		}
+		networks := a.networkAddresses(SplitConfigLine(line))
+		previous := ""
+		lines[i] = RewriteConfigLine(line, func(field string) string {
+			freeText := freeTextOptions[strings.ToLower(previous)]
+			previous = field
+			if freeText {
+				return a.anonymizeText(field)
+			}
+			if network, ok := networks[field]; ok {
+				return network
+			}
+			return a.anonymizeField(field)
+		})
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RewriteConfigLine is a function that applies a rewrite to every field of a configuration line while keeping
+// the spacing and quoting of the original line.
+func RewriteConfigLine(textLine string, rewrite func(field string) string) string {
+	var result strings.Builder
+	i := 0
+	for i < len(textLine) {
+		c := textLine[i]
+		if c == ' ' || c == '\t' || c == '\r' {
+			result.WriteByte(c)
+			i++
+			continue
+		}
+		start := i
+		inQuotes := false
+		for i < len(textLine) {
+			c = textLine[i]
+			if c == '\\' && inQuotes && i+1 < len(textLine) {
+				i += 2
+				continue
+			}
+			if c == '"' {
+				inQuotes = !inQuotes
+			} else if (c == ' ' || c == '\t' || c == '\r') && !inQuotes {
+				break
+			}
+			i++
+		}
+		raw := textLine[start:i]
+		fields := SplitConfigLine(raw)
+		if len(fields) != 1 {
+			result.WriteString(raw)
+			continue
+		}
+		replacement := rewrite(fields[0])
+		switch {
+		case replacement == fields[0]:
+			result.WriteString(raw)
+		case strings.Contains(raw, "\"") || strings.ContainsAny(replacement, " \t"):
+			result.WriteString("\"" + strings.Replace(replacement, "\"", "\\\"", -1) + "\"")
+		default:
+			result.WriteString(replacement)
+		}
+	}
+	return result.String()
+}
+
+// RunAnonymize is a function that runs the anonymize subcommand, writing the sanitized configuration to
+// standard output. The configuration is anonymized as it was read, before removals or quirks are applied, so that
+// every name on it is seen and replaced. Without a key a random one is used, so separate runs do not map addresses the same way.
+func RunAnonymize(args []string) error {
+	flags := flag.NewFlagSet("anonymize", flag.ContinueOnError)
+	key := flags.String("key", "", "secret used to derive replacements, reuse it to map several configs consistently")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: anonymize [-key key] filename")
+	}
+	file, err := GetRawFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	secret := []byte(*key)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprint(os.Stdout, NewAnonymizer(secret).AnonymizeConfig(file))
+	return err
+}
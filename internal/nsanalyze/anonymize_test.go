@@ -0,0 +1,110 @@
+package nsanalyze
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnonymizeConfig(t *testing.T) {
+	config := strings.Join([]string{
+		"#NS13.1 Build 37.38",
+		"# web1 moved from 10.1.2.3 by alice",
+		"set ns hostName lb01",
+		"add server web1 10.1.2.3",
+		"add server app1 app1.corp.example.com",
+		"add server app2 corp.example.com",
+		"add lb monitor mon HTTP -customHeaders \"Host: app1.corp.example.com\"",
+		"add route 10.1.0.0 255.255.0.0 10.1.0.1",
+	}, "\n")
+	tests := []struct {
+		name    string
+		line    int
+		want    string
+		without []string
+	}{
+		{"banner kept", 0, "#NS13.1 Build 37.38", nil},
+		{"comment emptied", 1, "", nil},
+		{"hostname", 2, "set ns hostName netscaler1", nil},
+		{"server", 3, "", []string{"web1", "10.1.2.3"}},
+		{"host within field", 6, "", []string{"app1", "corp.example.com"}},
+		{"route network keeps mask", 7, "", []string{"10.1.0.0"}},
+	}
+	lines := strings.Split(NewAnonymizer([]byte("key")).AnonymizeConfig(config), "\n")
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			line := lines[test.line]
+			if test.want != "" || test.without == nil {
+				if line != test.want {
+					t.Errorf("line %d = %q, want %q", test.line, line, test.want)
+				}
+			}
+			for _, leaked := range test.without {
+				if strings.Contains(line, leaked) {
+					t.Errorf("line %d = %q, leaks %q", test.line, line, leaked)
+				}
+			}
+		})
+	}
+}
+
+func TestAnonymizeConfigDeterministic(t *testing.T) {
+	config := strings.Join([]string{
+		"add server a a.example.com",
+		"add server b b.a.example.com",
+		"add server c example.com",
+		"add server d c.b.a.example.com",
+		"add lb monitor m HTTP -customHeaders \"Host: c.b.a.example.com b.a.example.com\"",
+	}, "\n")
+	want := NewAnonymizer([]byte("key")).AnonymizeConfig(config)
+	for i := 0; i < 50; i++ {
+		if got := NewAnonymizer([]byte("key")).AnonymizeConfig(config); got != want {
+			t.Fatalf("run %d differs:\n%s\nwant:\n%s", i, got, want)
+		}
+	}
+	if strings.Contains(want, "example.com") {
+		t.Errorf("hostnames leak:\n%s", want)
+	}
+}
+
+func TestAnonymizeRemovedObjects(t *testing.T) {
+	fileName := writeConfig(t,
+		"add server web2 10.1.2.4",
+		"add serviceGroup sg1 HTTP",
+		"bind serviceGroup sg1 web2 8080",
+		"unbind serviceGroup sg1 web2 8080",
+		"rm server web2",
+	)
+	file, err := GetRawFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := NewAnonymizer([]byte("key")).AnonymizeConfig(file)
+	for _, leaked := range []string{"web2", "10.1.2.4"} {
+		if strings.Contains(got, leaked) {
+			t.Errorf("%q leaks:\n%s", leaked, got)
+		}
+	}
+}
+
+func TestAnonymizeFreeText(t *testing.T) {
+	config := strings.Join([]string{
+		"add server web1 10.1.2.3 -comment \"owner alice app payroll\"",
+		"add server web2 10.1.2.4 -comment \"owner alice app payroll\"",
+		"set interface 1/1 -ifAlias \"uplink to core\"",
+	}, "\n")
+	lines := strings.Split(NewAnonymizer([]byte("key")).AnonymizeConfig(config), "\n")
+	for i, line := range lines {
+		for _, leaked := range []string{"alice", "payroll", "uplink", "core"} {
+			if strings.Contains(line, leaked) {
+				t.Errorf("line %d = %q, leaks %q", i, line, leaked)
+			}
+		}
+	}
+	first := GetOption(SplitConfigLine(lines[0]), "-comment")
+	if first == "" || first != GetOption(SplitConfigLine(lines[1]), "-comment") {
+		t.Errorf("comments = %q and %q, want the same placeholder", lines[0], lines[1])
+	}
+	if GetOption(SplitConfigLine(lines[2]), "-ifAlias") == "" {
+		t.Errorf("line 2 = %q, want an -ifAlias placeholder", lines[2])
+	}
+}
@@ -0,0 +1,90 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// memberServers is a function that returns the names of the servers a load balancing virtual server sends
+// traffic to through its own bindings, leaving out those it only reaches by failing over to its backup virtual
+// server.
+func memberServers(graph *Graph, vserverName string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, target := range graph.edges[Node{kind: NodeLbVserver, name: vserverName}] {
+		if target.kind == NodeLbVserver {
+			continue
+		}
+		for _, dependency := range append([]Node{target}, graph.Dependencies(target)...) {
+			if dependency.kind == NodeServer && !seen[dependency.name] {
+				seen[dependency.name] = true
+				names = append(names, dependency.name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetBackupVserverFindings is a function that accepts a file name as a parameter for input and then returns the
+// findings for load balancing virtual servers whose members are all covered while members of their backup virtual
+// server are not, since the traffic is lost once the virtual server fails over, or spills over, to its backup.
+func GetBackupVserverFindings(fileName string, networks []*net.IPNet, servers []Server) ([]Finding, error) {
+	vservers, err := GetLbVservers(fileName)
+	if err != nil {
+		return nil, err
+	}
+	graph, err := GetGraph(fileName)
+	if err != nil {
+		return nil, err
+	}
+	uncovered := make(map[string]bool)
+	for _, server := range GetUncoveredServers(networks, servers) {
+		uncovered[server.name] = true
+	}
+	var findings []Finding
+	for _, vserver := range vservers {
+		if vserver.backupVserver == "" {
+			continue
+		}
+		members := memberServers(graph, vserver.name)
+		if len(members) == 0 {
+			continue
+		}
+		covered := true
+		for _, member := range members {
+			if uncovered[member] {
+				covered = false
+			}
+		}
+		var uncoveredBackups []string
+		for _, member := range memberServers(graph, vserver.backupVserver) {
+			if uncovered[member] {
+				uncoveredBackups = append(uncoveredBackups, member)
+			}
+		}
+		if !covered || len(uncoveredBackups) == 0 {
+			continue
+		}
+		message := fmt.Sprintf("Virtual server %s is covered but its backup virtual server %s sends traffic to uncovered servers %s",
+			vserver.name, vserver.backupVserver, strings.Join(uncoveredBackups, ", "))
+		if vserver.SpillsOver() {
+			threshold := vserver.spilloverMethod + " spillover threshold"
+			if vserver.spilloverThreshold != "" {
+				threshold += " " + vserver.spilloverThreshold
+			}
+			message += fmt.Sprintf(", and takes the traffic beyond the %s while %s is up", threshold, vserver.name)
+		}
+		findings = append(findings, Finding{
+			rule:       RuleUncoveredBackupVserver,
+			message:    message,
+			object:     vserver.name,
+			objectType: NodeLbVserver,
+			fileName:   fileName,
+			line:       vserver.line,
+		})
+	}
+	return findings, nil
+}
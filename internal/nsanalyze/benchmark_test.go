@@ -0,0 +1,143 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// benchmarkSizes are the numbers of configuration lines that the benchmarks are run against.
+var benchmarkSizes = []int{10000, 100000, 1000000}
+
+// syntheticConfig is a function that returns a configuration of the given number of lines, made up of one SNIP
+// for every hundred lines and a server with a service for the rest. Every tenth server falls outside the SNIP
+// networks.
+func syntheticConfig(lines int) string {
+	var config strings.Builder
+	for i := 0; i < lines; i++ {
+		switch {
+		case i%100 == 0:
+			fmt.Fprintf(&config, "add ns ip 10.%d.%d.1 255.255.255.0 -type SNIP\n", i/100/256%256, i/100%256)
+		case i%2 == 1:
+			fmt.Fprintf(&config, "add server srv%d %s\n", i, syntheticServerAddress(i))
+		default:
+			fmt.Fprintf(&config, "add service svc%d srv%d HTTP 80\n", i, i-1)
+		}
+	}
+	return config.String()
+}
+
+// syntheticServerAddress is a function that returns the address of the server at a line of a synthetic
+// configuration.
+func syntheticServerAddress(i int) string {
+	if i%10 == 1 {
+		return fmt.Sprintf("172.16.%d.%d", i/254%256, i%254+1)
+	}
+	return fmt.Sprintf("10.%d.%d.%d", i/100/256%256, i/100%256, i%254+1)
+}
+
+// writeSyntheticConfig is a function that writes a synthetic configuration to a temporary file and returns its
+// name.
+func writeSyntheticConfig(b *testing.B, lines int) string {
+	b.Helper()
+	fileName := filepath.Join(b.TempDir(), "ns.conf")
+	if err := os.WriteFile(fileName, []byte(syntheticConfig(lines)), 0o644); err != nil {
+		b.Fatal(err)
+	}
+	return fileName
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, lines := range benchmarkSizes {
+		b.Run(fmt.Sprintf("lines=%d", lines), func(b *testing.B) {
+			fileName := writeSyntheticConfig(b, lines)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				ForgetFile(fileName)
+				ForgetParsedModels()
+				if _, err := GetServers(fileName); err != nil {
+					b.Fatal(err)
+				}
+				if _, err := GetSnips(fileName); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(lines)*float64(b.N)/b.Elapsed().Seconds(), "lines/s")
+		})
+	}
+}
+
+func BenchmarkGetNetworks(b *testing.B) {
+	for _, lines := range benchmarkSizes {
+		b.Run(fmt.Sprintf("lines=%d", lines), func(b *testing.B) {
+			fileName := writeSyntheticConfig(b, lines)
+			snips, err := GetSnips(fileName)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := GetNetworks(snips); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetUncoveredServers(b *testing.B) {
+	for _, lines := range benchmarkSizes {
+		b.Run(fmt.Sprintf("lines=%d", lines), func(b *testing.B) {
+			fileName := writeSyntheticConfig(b, lines)
+			networks, err := GetCoverageNetworks(fileName, AnalyzeOptions{})
+			if err != nil {
+				b.Fatal(err)
+			}
+			servers, err := GetServers(fileName)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				GetUncoveredServers(networks, servers)
+			}
+			b.ReportMetric(float64(len(servers))*float64(b.N)/b.Elapsed().Seconds(), "servers/s")
+		})
+	}
+}
+
+func BenchmarkAnalyzeParallel(b *testing.B) {
+	for _, lines := range benchmarkSizes[:1] {
+		b.Run(fmt.Sprintf("lines=%d", lines), func(b *testing.B) {
+			config := syntheticConfig(lines)
+			fileName := writeSyntheticConfig(b, lines)
+			var requests atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					inline := fmt.Sprintf("benchmark request %d", requests.Add(1))
+					if err := CacheFile(inline, config); err != nil {
+						b.Error(err)
+						return
+					}
+					for _, name := range []string{fileName, inline} {
+						findings, err := GetFindings(name, AnalyzeOptions{})
+						if err != nil {
+							b.Error(err)
+							return
+						}
+						if len(findings) == 0 {
+							b.Errorf("%s: no findings", name)
+							return
+						}
+					}
+					ForgetFile(inline)
+				}
+			})
+		})
+	}
+}
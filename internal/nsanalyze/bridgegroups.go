@@ -0,0 +1,121 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BridgeGroup is a data structure for a NetScaler bridge group along with the VLANs it bridges into a single
+// layer 2 domain and the addresses bound to it.
+type BridgeGroup struct {
+	id          string
+	vlans       []string
+	ipAddresses []string
+	line        int
+}
+
+// GetBridgeGroups is a function that accepts a file name as a parameter for input and then returns an array of
+// bridge groups, each with the VLANs and addresses bound to it.
+func GetBridgeGroups(fileName string) ([]BridgeGroup, error) {
+	var groups []BridgeGroup
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	addBridgeGroupLines, err := GetConfigLines(file, "(?i)(add bridgegroup ).*")
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]int)
+	for _, addBridgeGroupLine := range addBridgeGroupLines {
+		fields := SplitConfigLine(addBridgeGroupLine.text)[2:]
+		if len(fields) == 0 {
+			continue
+		}
+		index[fields[0]] = len(groups)
+		groups = append(groups, BridgeGroup{id: fields[0], line: addBridgeGroupLine.number})
+	}
+	bindBridgeGroupLines, err := GetConfigLines(file, "(?i)(bind bridgegroup ).*")
+	if err != nil {
+		return nil, err
+	}
+	for _, bindBridgeGroupLine := range bindBridgeGroupLines {
+		fields := SplitConfigLine(bindBridgeGroupLine.text)[2:]
+		if len(fields) == 0 {
+			continue
+		}
+		i, ok := index[fields[0]]
+		if !ok {
+			continue
+		}
+		groups[i].vlans = append(groups[i].vlans, GetOptionValues(fields, "-vlan")...)
+		if ipAddress := GetOption(fields, "-IPAddress"); ipAddress != "" {
+			groups[i].ipAddresses = append(groups[i].ipAddresses, ipAddress)
+		}
+	}
+	for i := range groups {
+		SortVlanIDs(groups[i].vlans)
+	}
+	return groups, nil
+}
+
+// GetTrunkVlans is a function that accepts a file name as a parameter for input and then returns the VLANs
+// carried by the trunk, which are the VLANs bound tagged or untagged to an interface that carries tagged VLANs.
+func GetTrunkVlans(fileName string) (map[string]bool, error) {
+	ports, err := GetTrunkPorts(fileName)
+	if err != nil {
+		return nil, err
+	}
+	vlans := make(map[string]bool)
+	for _, port := range ports {
+		if len(port.tagged) == 0 {
+			continue
+		}
+		for _, vlan := range append(port.tagged, port.untagged...) {
+			vlans[vlan] = true
+		}
+	}
+	return vlans, nil
+}
+
+// GetBridgeGroupFindings is a function that accepts a file name as a parameter for input and then returns the
+// findings for bridge groups that bridge VLANs on the trunk with VLANs that are not on it, since the group would
+// be split in two and hosts on either side would lose reachability to each other. Configurations without a trunk
+// have nothing to split.
+func GetBridgeGroupFindings(fileName string) ([]Finding, error) {
+	groups, err := GetBridgeGroups(fileName)
+	if err != nil {
+		return nil, err
+	}
+	trunkVlans, err := GetTrunkVlans(fileName)
+	if err != nil || len(trunkVlans) == 0 {
+		return nil, err
+	}
+	names, err := GetVlanNames(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, group := range groups {
+		var onTrunk, offTrunk []string
+		for _, vlan := range group.vlans {
+			if trunkVlans[vlan] {
+				onTrunk = append(onTrunk, DescribeVlan(vlan, names))
+			} else {
+				offTrunk = append(offTrunk, DescribeVlan(vlan, names))
+			}
+		}
+		if len(onTrunk) == 0 || len(offTrunk) == 0 {
+			continue
+		}
+		findings = append(findings, Finding{
+			rule: RuleSplitBridgeGroup,
+			message: fmt.Sprintf("Bridge group %s bridges VLAN %s on the trunk with VLAN %s not on it", group.id,
+				strings.Join(onTrunk, ", "), strings.Join(offTrunk, ", ")),
+			object:   group.id,
+			fileName: fileName,
+			line:     group.line,
+		})
+	}
+	return findings, nil
+}
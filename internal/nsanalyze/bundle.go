@@ -0,0 +1,236 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// VlanBundle is a data structure for the changes that a VLAN required by a remediation plan takes: the SNIPs
+// bound to it, the trunk interfaces it has to be carried on and whether the NetScaler has the VLAN already.
+type VlanBundle struct {
+	vlan    Vlan
+	exists  bool
+	trunks  []TrunkPort
+	actions []RemediationAction
+}
+
+// GetVlanBundles is a function that groups the SNIPs of a remediation plan that are bound to a VLAN by VLAN and
+// returns a bundle for each VLAN, in the order the VLANs first appear in the plan. The VLANs go on the interfaces
+// that carry tagged VLANs, or on the interfaces that carry untagged VLANs when there is no trunk yet.
+func GetVlanBundles(fileName string, actions []RemediationAction) ([]VlanBundle, error) {
+	ports, err := GetTrunkPorts(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var trunks, untagged []TrunkPort
+	for _, port := range ports {
+		if len(port.tagged) > 0 {
+			trunks = append(trunks, port)
+		} else if len(port.untagged) > 0 {
+			untagged = append(untagged, port)
+		}
+	}
+	if len(trunks) == 0 {
+		trunks = untagged
+	}
+	names, err := GetVlanNames(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var bundles []VlanBundle
+	index := make(map[string]int)
+	for _, action := range actions {
+		if action.kind != ActionSnip || action.vlan == "" {
+			continue
+		}
+		i, ok := index[action.vlan]
+		if !ok {
+			if len(trunks) == 0 {
+				return nil, fmt.Errorf("%s: no interface carries VLANs, so there is no trunk to carry VLAN %s on", fileName, action.vlan)
+			}
+			vlan, exists := names[action.vlan]
+			if !exists {
+				vlan = Vlan{id: action.vlan}
+			}
+			i = len(bundles)
+			index[action.vlan] = i
+			bundles = append(bundles, VlanBundle{vlan: vlan, exists: exists, trunks: trunks})
+		}
+		bundles[i].actions = append(bundles[i].actions, action)
+	}
+	return bundles, nil
+}
+
+// missingTrunks is a function that returns the trunk interfaces that do not carry the VLAN of the bundle tagged
+// yet, which the VLAN has to be bound to.
+func (bundle VlanBundle) missingTrunks() []TrunkPort {
+	var missing []TrunkPort
+	for _, trunk := range bundle.trunks {
+		if !containsString(trunk.tagged, bundle.vlan.id) {
+			missing = append(missing, trunk)
+		}
+	}
+	return missing
+}
+
+// Commands is a function that returns the NetScaler commands that add the VLAN of the bundle when the NetScaler
+// does not have it, bind it tagged to the trunk interfaces that do not carry it and then add its SNIPs.
+func (bundle VlanBundle) Commands() []string {
+	var commands []string
+	if !bundle.exists {
+		commands = append(commands, "add vlan "+bundle.vlan.id)
+	}
+	for _, trunk := range bundle.missingTrunks() {
+		commands = append(commands, fmt.Sprintf("bind vlan %s -ifnum %s -tagged", bundle.vlan.id, trunk.iface.name))
+	}
+	for _, action := range bundle.actions {
+		commands = append(commands, action.Commands()...)
+	}
+	return commands
+}
+
+// RollbackCommands is a function that returns the commands that undo the bundle, removing the SNIPs before the
+// VLAN they are bound to.
+func (bundle VlanBundle) RollbackCommands() []string {
+	var commands []string
+	for i := len(bundle.actions) - 1; i >= 0; i-- {
+		commands = append(commands, bundle.actions[i].RollbackCommands()...)
+	}
+	for _, trunk := range bundle.missingTrunks() {
+		commands = append(commands, fmt.Sprintf("unbind vlan %s -ifnum %s -tagged", bundle.vlan.id, trunk.iface.name))
+	}
+	if !bundle.exists {
+		commands = append(commands, "rm vlan "+bundle.vlan.id)
+	}
+	return commands
+}
+
+// trunkVlanStub is the data the switch side trunk templates are executed with.
+type trunkVlanStub struct {
+	Vlan  string
+	Name  string
+	Ports []string
+}
+
+// trunkVlanTemplates are the templates of the switch side configuration that adds a VLAN and allows it on the
+// trunk, by vendor. Adding a VLAN to the allowed VLANs of a trunk leaves the VLANs allowed already in place.
+var trunkVlanTemplates = map[string]*template.Template{
+	"cisco": template.Must(template.New("cisco").Parse(
+		`vlan {{.Vlan}}
+{{if .Name}} name {{.Name}}
+{{end}}!
+{{range .Ports}}interface {{.}}
+ switchport trunk allowed vlan add {{$.Vlan}}
+!
+{{end}}`)),
+	"arista": template.Must(template.New("arista").Parse(
+		`vlan {{.Vlan}}
+{{if .Name}}   name {{.Name}}
+{{end}}!
+{{range .Ports}}interface {{.}}
+   switchport trunk allowed vlan add {{$.Vlan}}
+!
+{{end}}`)),
+	"junos": template.Must(template.New("junos").Parse(
+		`set vlans {{if .Name}}{{.Name}}{{else}}vlan{{.Vlan}}{{end}} vlan-id {{.Vlan}}
+{{range .Ports}}set interfaces {{.}} unit 0 family ethernet-switching vlan members {{$.Vlan}}
+{{end}}`)),
+}
+
+// channelInterfacePrefixes are the names switches give their port-channel interfaces, by vendor, which are
+// followed by the number of the channel.
+var channelInterfacePrefixes = map[string]string{
+	"cisco":  "Port-channel",
+	"arista": "Port-Channel",
+	"junos":  "ae",
+}
+
+// WriteSwitchTrunkCommands is a function that writes the switch side configuration that carries the VLAN of a
+// bundle on the trunk with a vendor template. Channels are named after the port-channel they connect to and other
+// interfaces after the switch port recorded in their alias.
+func WriteSwitchTrunkCommands(w io.Writer, bundle VlanBundle, channels map[string]Channel, vendor string) error {
+	trunkVlan, ok := trunkVlanTemplates[vendor]
+	if !ok {
+		return fmt.Errorf("unsupported vendor %q, use one of %s", vendor, strings.Join(sortedTemplateNames(), ", "))
+	}
+	stub := trunkVlanStub{Vlan: bundle.vlan.id, Name: bundle.vlan.alias}
+	for _, trunk := range bundle.trunks {
+		if channel, ok := channels[trunk.iface.name]; ok {
+			stub.Ports = append(stub.Ports, channelInterfacePrefixes[vendor]+channel.Number())
+		} else if trunk.iface.alias != "" {
+			stub.Ports = append(stub.Ports, trunk.iface.alias)
+		} else {
+			stub.Ports = append(stub.Ports, "<"+Translate("switch port of")+" "+trunk.iface.name+">")
+		}
+	}
+	return trunkVlan.Execute(w, stub)
+}
+
+// WriteVlanBundles is a function that writes a directory per bundle for the change ticket of the VLAN, holding
+// the NetScaler commands, the commands that undo them, the switch side commands and the servers that motivate the
+// VLAN. It returns the names of the directories written.
+func WriteVlanBundles(dir, fileName, vendor string, bundles []VlanBundle) ([]string, error) {
+	if _, ok := trunkVlanTemplates[vendor]; !ok {
+		return nil, fmt.Errorf("unsupported vendor %q, use one of %s", vendor, strings.Join(sortedTemplateNames(), ", "))
+	}
+	channelList, err := GetChannels(fileName)
+	if err != nil {
+		return nil, err
+	}
+	channels := make(map[string]Channel)
+	for _, channel := range channelList {
+		channels[channel.name] = channel
+	}
+	var dirs []string
+	for _, bundle := range bundles {
+		bundleDir := filepath.Join(dir, "vlan-"+bundle.vlan.id)
+		if err := os.MkdirAll(bundleDir, 0755); err != nil {
+			return nil, err
+		}
+		err := writeFile(filepath.Join(bundleDir, "netscaler.txt"), func(w io.Writer) {
+			fmt.Fprintf(w, "# VLAN %s on %s\n", bundle.vlan.Describe(), fileName)
+			for _, command := range bundle.Commands() {
+				fmt.Fprintln(w, command)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		err = writeFile(filepath.Join(bundleDir, "rollback.txt"), func(w io.Writer) {
+			fmt.Fprintf(w, "# VLAN %s on %s\n", bundle.vlan.Describe(), fileName)
+			for _, command := range bundle.RollbackCommands() {
+				fmt.Fprintln(w, command)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		var switchErr error
+		err = writeFile(filepath.Join(bundleDir, "switch-"+vendor+".txt"), func(w io.Writer) {
+			switchErr = WriteSwitchTrunkCommands(w, bundle, channels, vendor)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if switchErr != nil {
+			return nil, switchErr
+		}
+		err = writeFile(filepath.Join(bundleDir, "servers.txt"), func(w io.Writer) {
+			for _, action := range bundle.actions {
+				fmt.Fprintf(w, "# %s: SNIP %s\n", action.network, action.address)
+				for _, server := range action.servers {
+					fmt.Fprintf(w, "%s %s\n", server.name, server.Describe())
+				}
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, bundleDir)
+	}
+	return dirs, nil
+}
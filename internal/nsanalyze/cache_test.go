@@ -0,0 +1,74 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetFindingsParallel(t *testing.T) {
+	var fileNames []string
+	for i := 0; i < 4; i++ {
+		fileNames = append(fileNames, writeConfig(t,
+			"add ns ip 10.0.0.10 255.255.255.0 -type SNIP",
+			fmt.Sprintf("add server web%d 10.0.0.%d", i, i+20),
+			fmt.Sprintf("add server far%d 172.16.0.%d", i, i+20),
+			"add vlan 30"))
+	}
+	var wg sync.WaitGroup
+	for round := 0; round < 8; round++ {
+		for i, fileName := range fileNames {
+			wg.Add(1)
+			go func(i int, fileName string) {
+				defer wg.Done()
+				findings, err := GetFindings(fileName, AnalyzeOptions{})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if !hasFinding(findings, "NS001", fmt.Sprintf("far%d", i)) || hasFinding(findings, "NS001", fmt.Sprintf("web%d", i)) {
+					t.Errorf("%s: findings = %v", fileName, findingKeys(findings))
+				}
+			}(i, fileName)
+		}
+	}
+	wg.Wait()
+}
+
+func TestGetFileConcurrentFetches(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		time.Sleep(200 * time.Millisecond)
+		fmt.Fprintf(w, "add server web1 10.0.0.5\n")
+	}))
+	defer server.Close()
+	fileNames := []string{server.URL + "/a.conf", server.URL + "/b.conf", server.URL + "/c.conf"}
+	for _, fileName := range fileNames {
+		defer ForgetFile(fileName)
+	}
+	started := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		for _, fileName := range fileNames {
+			wg.Add(1)
+			go func(fileName string) {
+				defer wg.Done()
+				if _, err := GetFile(fileName); err != nil {
+					t.Error(err)
+				}
+			}(fileName)
+		}
+	}
+	wg.Wait()
+	if got := requests.Load(); got != int32(len(fileNames)) {
+		t.Errorf("%d fetches, want one per configuration", got)
+	}
+	if elapsed := time.Since(started); elapsed > 500*time.Millisecond {
+		t.Errorf("fetches took %s, want them to run in parallel", elapsed)
+	}
+}
@@ -0,0 +1,45 @@
+package nsanalyze
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ansiEscape matches the ANSI escape sequences a terminal capture holds, such as colors and cursor movements.
+var ansiEscape = regexp.MustCompile(`\x1b(?:\[[0-9;?]*[ -/]*[@-~]|[()][0-9A-Za-z]|[=>78])`)
+
+// pagerPrompt matches the prompt the CLI pager writes between screens of "show running config", along with the
+// spaces, backspaces and carriage returns it erases the prompt with once a key is pressed.
+var pagerPrompt = regexp.MustCompile(`--+ ?More ?--+(?: ?\(\d+%\))?[ \t\x08]*(?:\r[ \t\x08]*)*`)
+
+// promptLine matches a line holding the CLI prompt, with the hostname the prompt may start with, the HA state a
+// node of a pair adds to it, such as "ns01 (Primary)>", and the command echoed after it, and the "Done" line the
+// CLI ends the output of a command with. No configuration line starts with either.
+var promptLine = regexp.MustCompile(`(?m)^(?:[\w.-]*(?: ?\((?:Primary|Secondary)\))?>(?: .*)?|[ \t]*Done[ \t]*)$`)
+
+// StripCaptureArtifacts is a function that removes the ANSI escape sequences of a terminal capture of "show
+// running config", along with its pager prompts and the backspaces left of them. The carriage returns the pager
+// erases its prompt with go too, so that the line it interrupted is joined again before line endings are turned
+// into newlines. A saved ns.conf holds none of these, so it passes through unchanged.
+func StripCaptureArtifacts(file string) string {
+	if strings.IndexByte(file, '\x1b') >= 0 {
+		file = ansiEscape.ReplaceAllString(file, "")
+	}
+	if strings.Contains(file, "More") {
+		file = pagerPrompt.ReplaceAllString(file, "")
+	}
+	if strings.IndexByte(file, '\b') >= 0 {
+		file = strings.ReplaceAll(file, "\b", "")
+	}
+	return file
+}
+
+// blankPromptLines is a function that blanks the lines of a capture with the CLI prompt and the echoed command or
+// the closing "Done", once its line endings are newlines. They are blanked rather than removed so that reported
+// line numbers still match the capture.
+func blankPromptLines(file string) string {
+	if !strings.Contains(file, ">") && !strings.Contains(file, "Done") {
+		return file
+	}
+	return promptLine.ReplaceAllString(file, "")
+}
@@ -0,0 +1,80 @@
+package nsanalyze
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeCapture(t *testing.T) {
+	tests := []struct {
+		name    string
+		capture string
+		want    []string
+	}{
+		{
+			"primary node of an HA pair",
+			"ns01 (Primary)> show running config\r\n" +
+				"#NS13.1 Build 49.15\r\n" +
+				"add server web1 10.0.0.20\r\n" +
+				"add policy expression big \"HTTP.REQ.CONTENT_LENGTH > 1000\"\r\n" +
+				" Done\r\n" +
+				"ns01 (Primary)> ",
+			[]string{"", "#NS13.1 Build 49.15", "add server web1 10.0.0.20",
+				"add policy expression big \"HTTP.REQ.CONTENT_LENGTH > 1000\"", "", ""},
+		},
+		{
+			"secondary node with colors",
+			"\x1b[1mns-02.dc1 (Secondary)>\x1b[0m show ns runningConfig\r\n" +
+				"add ns ip 10.0.0.10 255.255.255.0 -type SNIP\r\n" +
+				" Done\r\n" +
+				"\x1b[1mns-02.dc1 (Secondary)>\x1b[0m exit\r\n",
+			[]string{"", "add ns ip 10.0.0.10 255.255.255.0 -type SNIP", "", "", ""},
+		},
+		{
+			"pager of a standalone appliance",
+			"> show running config\r\n" +
+				"add server web1 10.0.0.20\r\n" +
+				"--More-- (12%)\r        \radd server web2 10.0.0.21\r\n" +
+				"add serv--More-- (50%)\b\b\b\b\b\b\b\b\b\b\b\b\b\b\b               \b\b\b\b\b\b\b\b\b\b\b\b\b\b\ber web3 10.0.0.22\r\n" +
+				" Done\r\n" +
+				">",
+			[]string{"", "add server web1 10.0.0.20", "add server web2 10.0.0.21", "add server web3 10.0.0.22", "", ""},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lines := strings.Split(NormalizeConfig(test.capture), "\n")
+			if len(lines) != len(test.want) {
+				t.Fatalf("lines = %q, want %q", lines, test.want)
+			}
+			for i := range lines {
+				if lines[i] != test.want[i] {
+					t.Errorf("line %d = %q, want %q", i+1, lines[i], test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBlankPromptLines(t *testing.T) {
+	tests := []struct {
+		line  string
+		blank bool
+	}{
+		{"> show running config", true},
+		{"ns01> show running config", true},
+		{"ns01 (Primary)> show running config", true},
+		{"ns01 (Secondary)> ", true},
+		{"ns01(Primary)>", true},
+		{" Done", true},
+		{"add server web1 10.0.0.20", false},
+		{"add policy expression big \"HTTP.REQ.CONTENT_LENGTH > 1000\"", false},
+		{"ns01 (Standby)> show ns ip", false},
+		{"set ns hostName \"ns01 (Primary)>\"", false},
+	}
+	for _, test := range tests {
+		if got := blankPromptLines(test.line) == ""; got != test.blank {
+			t.Errorf("blankPromptLines(%q) blanked = %v, want %v", test.line, got, test.blank)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package nsanalyze
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"text/tabwriter"
+)
+
+// Address classes.
+const (
+	ClassPrivate     = "private"
+	ClassPublic      = "public"
+	ClassLinkLocal   = "link-local"
+	ClassCGNAT       = "cgnat"
+	ClassMulticast   = "multicast"
+	ClassLoopback    = "loopback"
+	ClassUnspecified = "unspecified"
+	ClassInvalid     = "invalid"
+)
+
+// cgnatNetwork is the shared address space reserved for carrier grade NAT by RFC 6598.
+var cgnatNetwork = &net.IPNet{IP: net.IPv4(100, 64, 0, 0).To4(), Mask: net.CIDRMask(10, 32)}
+
+// ClassifyAddress is a function that returns the class of an address, which is invalid for anything that does
+// not parse as an IP address and for the limited broadcast address.
+func ClassifyAddress(ipAddress string) string {
+	ip := net.ParseIP(ipAddress)
+	switch {
+	case ip == nil || ip.Equal(net.IPv4bcast):
+		return ClassInvalid
+	case ip.IsUnspecified():
+		return ClassUnspecified
+	case ip.IsLoopback():
+		return ClassLoopback
+	case ip.IsMulticast():
+		return ClassMulticast
+	case ip.IsLinkLocalUnicast():
+		return ClassLinkLocal
+	case cgnatNetwork.Contains(ip):
+		return ClassCGNAT
+	case ip.IsPrivate():
+		return ClassPrivate
+	}
+	return ClassPublic
+}
+
+// IsBogusClass is a function that reports whether addresses of a class can never be a working server, VIP or
+// SNIP.
+func IsBogusClass(class string) bool {
+	return class == ClassInvalid || class == ClassUnspecified || class == ClassLoopback || class == ClassMulticast
+}
+
+// ClassifiedAddress is a data structure for an address of the configuration along with its class.
+type ClassifiedAddress struct {
+	kind      string
+	name      string
+	ipAddress string
+	class     string
+	line      int
+}
+
+// GetClassifiedAddresses is a function that accepts a file name as a parameter for input and then returns the
+// class of the address of every server, load balancing virtual server and NetScaler owned IP, along with the
+// literal addresses that set commands and profiles refer to and the addresses and subscriber networks of large
+// scale NAT.
+// Servers without an address and non-addressable virtual servers are left out.
+func GetClassifiedAddresses(fileName string, servers []Server) ([]ClassifiedAddress, error) {
+	var addresses []ClassifiedAddress
+	for _, server := range servers {
+		if server.ipAddress == "" {
+			continue
+		}
+		addresses = append(addresses, ClassifiedAddress{NodeServer, server.name, server.ipAddress,
+			ClassifyAddress(server.ipAddress), server.line})
+	}
+	vservers, err := GetLbVservers(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, vserver := range vservers {
+		class := ClassifyAddress(vserver.ipAddress)
+		if vserver.ipAddress == "" || class == ClassUnspecified {
+			continue
+		}
+		addresses = append(addresses, ClassifiedAddress{NodeLbVserver, vserver.name, vserver.ipAddress, class, vserver.line})
+	}
+	snips, err := GetSnips(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, snip := range snips {
+		addresses = append(addresses, ClassifiedAddress{snip.ipType, snip.ipAddress, snip.ipAddress,
+			ClassifyAddress(snip.ipAddress), snip.line})
+	}
+	setAddresses, err := GetSetCommandAddresses(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, address := range setAddresses {
+		class := ClassifyAddress(address.ipAddress)
+		if class == ClassUnspecified {
+			continue
+		}
+		addresses = append(addresses, ClassifiedAddress{address.kind, address.name, address.ipAddress, class, address.line})
+	}
+	profileAddresses, err := GetProfileAddresses(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, address := range profileAddresses {
+		addresses = append(addresses, ClassifiedAddress{address.kind, address.name, address.ipAddress,
+			ClassifyAddress(address.ipAddress), address.line})
+	}
+	lsnAddresses, err := GetLsnAddresses(fileName)
+	if err != nil {
+		return nil, err
+	}
+	return append(addresses, lsnAddresses...), nil
+}
+
+// GetAddressFindings is a function that returns a finding for every address of the configuration whose class
+// means it can never work.
+func GetAddressFindings(fileName string, servers []Server) ([]Finding, error) {
+	var findings []Finding
+	addresses, err := GetClassifiedAddresses(fileName, servers)
+	if err != nil {
+		return nil, err
+	}
+	for _, address := range addresses {
+		if !IsBogusClass(address.class) {
+			continue
+		}
+		findings = append(findings, Finding{
+			rule:       RuleBogusAddress,
+			message:    fmt.Sprintf("%s %s has %s address %s", address.kind, address.name, address.class, address.ipAddress),
+			object:     address.name,
+			objectType: address.kind,
+			key:        address.ipAddress,
+			fileName:   fileName,
+			line:       address.line,
+		})
+	}
+	return findings, nil
+}
+
+// GetSubnetEdgeFindings is a function that returns a finding for every server whose address is the network or
+// broadcast address of an IPv4 SNIP subnet, which is almost always a typo for a host address. Subnets of /31 and
+// /32 have neither, so they are left out.
+func GetSubnetEdgeFindings(fileName string, servers []Server, validSnips []Snip, networks []*net.IPNet) []Finding {
+	var findings []Finding
+	for _, server := range servers {
+		ip := net.ParseIP(server.ipAddress).To4()
+		if ip == nil {
+			continue
+		}
+		for i, network := range networks {
+			ones, bits := network.Mask.Size()
+			if bits != 32 || ones > 30 || !network.Contains(ip) {
+				continue
+			}
+			edge := ""
+			switch broadcast := BroadcastAddress(network); {
+			case ip.Equal(network.IP):
+				edge = "network"
+			case ip.Equal(broadcast):
+				edge = "broadcast"
+			default:
+				continue
+			}
+			findings = append(findings, Finding{
+				rule: RuleSubnetEdgeAddress,
+				message: fmt.Sprintf("Server %s has address %s, the %s address of SNIP network %s of %s", server.name,
+					server.ipAddress, edge, network, validSnips[i].ipAddress),
+				object:     server.name,
+				objectType: NodeServer,
+				fileName:   fileName,
+				line:       server.line,
+			})
+			break
+		}
+	}
+	return findings
+}
+
+// BroadcastAddress is a function that returns the last address of an IPv4 network, which is its broadcast
+// address.
+func BroadcastAddress(network *net.IPNet) net.IP {
+	ip := network.IP.To4()
+	mask := net.IP(network.Mask).To4()
+	if ip == nil || mask == nil {
+		return nil
+	}
+	broadcast := make(net.IP, net.IPv4len)
+	for i := range ip {
+		broadcast[i] = ip[i] | ^mask[i]
+	}
+	return broadcast
+}
+
+// PrintAddressReport is a function that writes every address of the configuration along with its class as a
+// table.
+func PrintAddressReport(w io.Writer, addresses []ClassifiedAddress) error {
+	table := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t\n", Translate("KIND"), Translate("NAME"), Translate("ADDRESS"),
+		Translate("CLASS"), Translate("LINE"))
+	for _, address := range addresses {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%d\t\n", address.kind, address.name, address.ipAddress, address.class,
+			address.line)
+	}
+	return table.Flush()
+}
+
+// RunAddresses is a function that runs the addresses subcommand.
+func RunAddresses(args []string, options AnalyzeOptions) error {
+	if len(args) != 1 {
+		return errors.New("usage: addresses filename")
+	}
+	servers, err := GetAnalysisServers(args[0], options)
+	if err != nil {
+		return err
+	}
+	addresses, err := GetClassifiedAddresses(args[0], servers)
+	if err != nil {
+		return err
+	}
+	return PrintAddressReport(os.Stdout, addresses)
+}
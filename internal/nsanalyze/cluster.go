@@ -0,0 +1,102 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// GetClusterNodes is a function that accepts a file name as a parameter for input and then returns the IDs of
+// the nodes added to the cluster, which is empty for a standalone appliance.
+func GetClusterNodes(fileName string) ([]string, error) {
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	addNodeLines, err := GetConfigLines(file, "(add cluster node ).*")
+	if err != nil {
+		return nil, err
+	}
+	var nodes []string
+	for _, addNodeLine := range addNodeLines {
+		fields := SplitConfigLine(RemoveConfigKeywords(addNodeLine.text, "add cluster node "))
+		if len(fields) > 0 {
+			nodes = append(nodes, fields[0])
+		}
+	}
+	return nodes, nil
+}
+
+// GetSpottedCoverageFindings is a function that returns a finding for every server that is only covered by SNIPs
+// spotted on some of the cluster nodes, since the other nodes have no address of their own to reach it from.
+// Nothing is reported when checking from a single node, as IPs spotted elsewhere are already left out.
+func GetSpottedCoverageFindings(fileName string, servers []Server, options AnalyzeOptions) ([]Finding, error) {
+	if options.node != "" {
+		return nil, nil
+	}
+	snips, err := GetSourceSnips(fileName, options)
+	if err != nil {
+		return nil, err
+	}
+	networks, err := GetNetworks(snips)
+	if err != nil {
+		return nil, err
+	}
+	policyNetworks, err := options.policy.CoveringNetworks(fileName)
+	if err != nil {
+		return nil, err
+	}
+	clusterNodes, err := GetClusterNodes(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, server := range servers {
+		ip := net.ParseIP(server.ipAddress)
+		if ip == nil || len(GetUncoveredServers(policyNetworks, []Server{server})) == 0 {
+			continue
+		}
+		owners := make(map[string]bool)
+		spottedOnly := false
+		for i, network := range networks {
+			if !network.Contains(ip) {
+				continue
+			}
+			if snips[i].ownerNode == "" {
+				spottedOnly = false
+				break
+			}
+			spottedOnly = true
+			owners[snips[i].ownerNode] = true
+		}
+		if !spottedOnly || (len(clusterNodes) > 0 && allNodes(owners, clusterNodes)) {
+			continue
+		}
+		var nodes []string
+		for node := range owners {
+			nodes = append(nodes, node)
+		}
+		sort.Strings(nodes)
+		findings = append(findings, Finding{
+			rule: RuleSpottedCoverage,
+			message: fmt.Sprintf("Server %s is only covered by SNIPs spotted on node %s, so other cluster nodes cannot reach it",
+				server.Describe(), strings.Join(nodes, ", ")),
+			object:     server.name,
+			objectType: NodeServer,
+			fileName:   fileName,
+			line:       server.line,
+		})
+	}
+	return findings, nil
+}
+
+// allNodes is a function that reports whether every cluster node is among the owners.
+func allNodes(owners map[string]bool, clusterNodes []string) bool {
+	for _, node := range clusterNodes {
+		if !owners[node] {
+			return false
+		}
+	}
+	return true
+}
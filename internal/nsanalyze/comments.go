@@ -0,0 +1,106 @@
+package nsanalyze
+
+import "strings"
+
+// applyComments is a function that applies the -comment of the "set" and "unset" lines of an object type to the
+// objects its "add" lines added, which index holds by name, so that the comment of every object is the one in
+// effect at the end of the configuration. A set line without a comment keeps the one the object has.
+func applyComments(file, objectType string, index map[string]int, comment func(i int) *string) error {
+	lines, err := GetConfigLines(file, "(?i)((set|unset) "+objectType+" ).*")
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		fields := SplitConfigLine(line.text)
+		if len(fields) < 3 {
+			continue
+		}
+		i, ok := index[fields[2]]
+		if !ok {
+			continue
+		}
+		if !strings.EqualFold(fields[0], "unset") {
+			if value := GetOption(fields, "-comment"); value != "" {
+				*comment(i) = value
+			}
+			continue
+		}
+		for _, option := range fields[3:] {
+			if strings.EqualFold(option, "-comment") {
+				*comment(i) = ""
+			}
+		}
+	}
+	return nil
+}
+
+// GetObjectComments is a function that returns the -comment of every server, service, service group and load
+// balancing and content switching virtual server that has one, by object type and name. Comments often say which
+// application an object belongs to or who owns it, so reports carry them to route findings to the right people.
+func GetObjectComments(fileName string, servers []Server) (map[Node]string, error) {
+	comments := make(map[Node]string)
+	add := func(kind, name, comment string) {
+		if comment != "" {
+			comments[Node{kind: kind, name: name}] = comment
+		}
+	}
+	for _, server := range servers {
+		add(NodeServer, server.name, server.comment)
+	}
+	services, err := GetServices(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, service := range services {
+		add(NodeService, service.name, service.comment)
+	}
+	groups, err := GetServiceGroups(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		add(NodeServiceGroup, group.name, group.comment)
+	}
+	vservers, err := GetLbVservers(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, vserver := range vservers {
+		add(NodeLbVserver, vserver.name, vserver.comment)
+	}
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	csVserverLines, err := GetConfigLines(file, "((add|set|unset) cs vserver ).*")
+	if err != nil {
+		return nil, err
+	}
+	for _, csVserverLine := range csVserverLines {
+		fields := SplitConfigLine(csVserverLine.text)
+		if len(fields) < 4 {
+			continue
+		}
+		if fields[0] != "unset" {
+			add(NodeCsVserver, fields[3], GetOption(fields, "-comment"))
+			continue
+		}
+		for _, option := range fields[4:] {
+			if strings.EqualFold(option, "-comment") {
+				delete(comments, Node{kind: NodeCsVserver, name: fields[3]})
+			}
+		}
+	}
+	return comments, nil
+}
+
+// annotateFindings is a function that gives every finding the comment of the object it is about, if any. Only
+// findings that say which type of object they are about get one, so that a service never gets the comment of a
+// server of the same name.
+func annotateFindings(findings []Finding, comments map[Node]string) {
+	for i := range findings {
+		if findings[i].objectType != "" {
+			findings[i].comment = comments[Node{kind: findings[i].objectType, name: findings[i].object}]
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package nsanalyze
+
+import "errors"
+
+// RunCompare is a function that runs the compare subcommand, which is ha-compare across any number of
+// configurations, such as an HA pair and its DR appliance.
+func RunCompare(args []string) error {
+	if len(args) < 2 {
+		return errors.New("usage: compare filename filename...")
+	}
+	return RunHACompare(args)
+}
@@ -0,0 +1,160 @@
+package nsanalyze
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// ApplianceCoverage is a data structure for the coverage of the servers of one appliance.
+type ApplianceCoverage struct {
+	device    string
+	servers   int
+	uncovered int
+}
+
+// ConsolidatedServer is a data structure for a backend address along with the names it has and the appliances
+// that reference it, merged across every configuration of a run.
+type ConsolidatedServer struct {
+	ipAddress   string
+	names       []string
+	appliances  []string
+	uncoveredOn []string
+}
+
+// GetConsolidatedServers is a function that merges the servers of several configurations by address and returns
+// the coverage of each appliance along with the merged servers in address order. Domain based servers without
+// an address cannot be merged and are left out. Configurations that cannot be analyzed are returned as failures.
+func GetConsolidatedServers(fileNames []string, options AnalyzeOptions) ([]ApplianceCoverage, []ConsolidatedServer, []FileFailure) {
+	var appliances []ApplianceCoverage
+	var failures []FileFailure
+	merged := make(map[string]*ConsolidatedServer)
+	devices := make(map[string]bool)
+	for _, fileName := range fileNames {
+		device, err := GetDeviceName(fileName)
+		if err != nil {
+			failures = append(failures, FileFailure{fileName, err})
+			continue
+		}
+		// HA pairs and copies of a configuration share a host name, so tell them apart by file.
+		if devices[device] {
+			device += " (" + filepath.Base(fileName) + ")"
+		}
+		devices[device] = true
+		coverage, err := consolidateFile(fileName, device, options, merged)
+		if err != nil {
+			failures = append(failures, FileFailure{fileName, err})
+			continue
+		}
+		appliances = append(appliances, coverage)
+	}
+	var servers []ConsolidatedServer
+	for _, server := range merged {
+		servers = append(servers, *server)
+	}
+	sort.Slice(servers, func(i, j int) bool {
+		a, b := net.ParseIP(servers[i].ipAddress), net.ParseIP(servers[j].ipAddress)
+		if (a.To4() != nil) != (b.To4() != nil) {
+			return a.To4() != nil
+		}
+		return string(a.To16()) < string(b.To16())
+	})
+	return appliances, servers, failures
+}
+
+// consolidateFile is a function that merges the servers of the configuration of an appliance into the merged
+// servers and returns the coverage of the appliance.
+func consolidateFile(fileName, device string, options AnalyzeOptions, merged map[string]*ConsolidatedServer) (ApplianceCoverage, error) {
+	networks, err := GetCoverageNetworks(fileName, options)
+	if err != nil {
+		return ApplianceCoverage{}, err
+	}
+	servers, err := GetAnalysisServers(fileName, options)
+	if err != nil {
+		return ApplianceCoverage{}, err
+	}
+	uncovered := make(map[string]bool)
+	for _, server := range GetUncoveredServers(networks, servers) {
+		uncovered[server.ipAddress] = true
+	}
+	coverage := ApplianceCoverage{device: device, uncovered: len(uncovered)}
+	seen := make(map[string]bool)
+	for _, server := range includedServers(servers) {
+		if server.ipAddress == "" {
+			continue
+		}
+		if !seen[server.ipAddress] {
+			seen[server.ipAddress] = true
+			coverage.servers++
+		}
+		consolidated, ok := merged[server.ipAddress]
+		if !ok {
+			consolidated = &ConsolidatedServer{ipAddress: server.ipAddress}
+			merged[server.ipAddress] = consolidated
+		}
+		consolidated.names = appendMissing(consolidated.names, server.name)
+		consolidated.appliances = appendMissing(consolidated.appliances, device)
+		if uncovered[server.ipAddress] {
+			consolidated.uncoveredOn = appendMissing(consolidated.uncoveredOn, device)
+		}
+	}
+	return coverage, nil
+}
+
+// appendMissing is a function that appends a string to an array of strings unless it is already present.
+func appendMissing(values []string, value string) []string {
+	if containsString(values, value) {
+		return values
+	}
+	return append(values, value)
+}
+
+// PrintConsolidation is a function that writes the coverage of each appliance followed by the merged servers as
+// a table and the coverage of the merged servers, where a server counts as uncovered when any appliance that
+// references it does not cover it.
+func PrintConsolidation(w io.Writer, appliances []ApplianceCoverage, servers []ConsolidatedServer) error {
+	for _, appliance := range appliances {
+		fmt.Fprintf(w, "%s %s\t"+Translate("%d of %d servers uncovered")+"\n", Translate("device"), appliance.device,
+			appliance.uncovered, appliance.servers)
+	}
+	table := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t\n", Translate("ADDRESS"), Translate("NAMES"), Translate("APPLIANCES"),
+		Translate("UNCOVERED ON"))
+	uncovered := 0
+	for _, server := range servers {
+		uncoveredOn := "-"
+		if len(server.uncoveredOn) > 0 {
+			uncovered++
+			uncoveredOn = strings.Join(server.uncoveredOn, ",")
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t\n", server.ipAddress, strings.Join(server.names, ","),
+			strings.Join(server.appliances, ","), uncoveredOn)
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, Translate("%d of %d servers uncovered")+"\n", uncovered, len(servers))
+	return err
+}
+
+// RunConsolidate is a function that runs the consolidate subcommand.
+func RunConsolidate(args []string, options AnalyzeOptions) error {
+	if len(args) == 0 {
+		return errors.New("usage: consolidate filename...")
+	}
+	appliances, servers, failures := GetConsolidatedServers(args, options)
+	if err := PrintConsolidation(os.Stdout, appliances, servers); err != nil {
+		return err
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	PrintFailures(os.Stderr, failures)
+	return fmt.Errorf("%d of %d configurations could not be analyzed", len(failures), len(args))
+}
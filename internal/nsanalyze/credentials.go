@@ -0,0 +1,170 @@
+package nsanalyze
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Environment variables the credentials of appliances are read from when their source URL does not give them.
+const (
+	userVariable     = "NSANALYZE_USER"
+	passwordVariable = "NSANALYZE_PASSWORD"
+)
+
+// vaultPrefix marks a credential that is a reference to a secret in HashiCorp Vault, written as
+// vault:path#field, such as vault:secret/data/netscaler#password.
+const vaultPrefix = "vault:"
+
+// vaultSecrets holds the secrets read from Vault so far, so that a run against many appliances sharing a secret
+// reads it once. The lock is not held while a secret is read, so a slow Vault holds up no other lookup.
+var vaultSecrets = struct {
+	sync.Mutex
+	values map[string]string
+}{values: make(map[string]string)}
+
+// sourcePasswords holds the environment variable the password of a source is read from, by the name of the
+// source, for the devices of the inventory. The password is only read once the source is opened, so that it never
+// becomes part of the name that reports and errors show the configuration by.
+var sourcePasswords = struct {
+	sync.Mutex
+	variables map[string]string
+}{variables: make(map[string]string)}
+
+// setSourcePasswordVariable is a function that records the environment variable the password of a source is read
+// from.
+func setSourcePasswordVariable(name, variable string) {
+	sourcePasswords.Lock()
+	defer sourcePasswords.Unlock()
+	sourcePasswords.variables[name] = variable
+}
+
+// sourcePasswordVariable is a function that returns the environment variable the password of a source is read
+// from, which is empty unless the source is that of a device of the inventory.
+func sourcePasswordVariable(name string) string {
+	sourcePasswords.Lock()
+	defer sourcePasswords.Unlock()
+	return sourcePasswords.variables[name]
+}
+
+// GetCredential is a function that returns the credential held by an environment variable. A value starting with
+// vault: is read from HashiCorp Vault instead, at the address in VAULT_ADDR with the token in VAULT_TOKEN, so that
+// CI systems can hand out a reference rather than the secret itself. An unset variable is an empty credential.
+func GetCredential(variable string) (string, error) {
+	value := os.Getenv(variable)
+	if !strings.HasPrefix(value, vaultPrefix) {
+		return value, nil
+	}
+	secret, err := ReadVaultSecret(strings.TrimPrefix(value, vaultPrefix))
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", variable, err)
+	}
+	return secret, nil
+}
+
+// ReadVaultSecret is a function that reads a field of a secret from HashiCorp Vault. Both versions of the key
+// value secrets engine are understood: version 2 nests the fields of the secret one level deeper.
+func ReadVaultSecret(reference string) (string, error) {
+	path, field, ok := strings.Cut(reference, "#")
+	if !ok || path == "" || field == "" {
+		return "", fmt.Errorf("vault reference %q is not of the form path#field", reference)
+	}
+	vaultSecrets.Lock()
+	secret, ok := vaultSecrets.values[reference]
+	vaultSecrets.Unlock()
+	if ok {
+		return secret, nil
+	}
+	address, token := strings.TrimSuffix(os.Getenv("VAULT_ADDR"), "/"), os.Getenv("VAULT_TOKEN")
+	if address == "" || token == "" {
+		return "", errors.New("VAULT_ADDR and VAULT_TOKEN must be set to read secrets from Vault")
+	}
+	request, err := http.NewRequestWithContext(runContext, http.MethodGet, address+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("X-Vault-Token", token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		request.Header.Set("X-Vault-Namespace", namespace)
+	}
+	client := &http.Client{Timeout: sourceTimeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault %s: %s", path, response.Status)
+	}
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("vault %s: %v", path, err)
+	}
+	fields := body.Data
+	if nested, ok := fields["data"].(map[string]interface{}); ok {
+		fields = nested
+	}
+	if secret, ok = fields[field].(string); !ok {
+		return "", fmt.Errorf("vault %s has no field %s", path, field)
+	}
+	vaultSecrets.Lock()
+	vaultSecrets.values[reference] = secret
+	vaultSecrets.Unlock()
+	return secret, nil
+}
+
+// resolveCredentials is a function that fills in the user and password of an appliance that its source URL
+// leaves out from NSANALYZE_USER and NSANALYZE_PASSWORD. The password of a device of the inventory is read from
+// the environment variable of the device instead, which must then be set.
+func resolveCredentials(user, password, passwordEnv string) (string, string, error) {
+	var err error
+	if user == "" {
+		if user, err = GetCredential(userVariable); err != nil {
+			return "", "", err
+		}
+	}
+	if password == "" && passwordEnv != "" {
+		if password, err = GetCredential(passwordEnv); err != nil {
+			return "", "", err
+		}
+		if password == "" {
+			return "", "", fmt.Errorf("%s is not set", passwordEnv)
+		}
+	}
+	if password == "" {
+		if password, err = GetCredential(passwordVariable); err != nil {
+			return "", "", err
+		}
+	}
+	return user, password, nil
+}
+
+// CheckCommandLineCredentials is a function that rejects command line arguments holding a URL with a password,
+// since the command line of a process can be read by every user of the machine, such as the other jobs of a
+// shared CI runner.
+func CheckCommandLineCredentials(args []string) error {
+	for _, arg := range args {
+		_, value, _ := strings.Cut(arg, "=")
+		if !strings.Contains(arg, "://") {
+			continue
+		}
+		for _, candidate := range []string{arg, value} {
+			location, err := url.Parse(candidate)
+			if err != nil || location.User == nil {
+				continue
+			}
+			if _, ok := location.User.Password(); ok {
+				return fmt.Errorf("%s: passwords on the command line are visible to other users, set %s instead",
+					redactSourceName(candidate), passwordVariable)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package nsanalyze
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CsBinding is a data structure for a load balancing virtual server that a content switching virtual server
+// switches traffic to, either as its default or as the target of one of its policies.
+type CsBinding struct {
+	vserverName   string
+	targetVserver string
+	line          int
+}
+
+// GetCsVservers is a function that accepts a file name as a parameter for input and then returns the names of
+// the content switching virtual servers.
+func GetCsVservers(fileName string) ([]ConfigLine, error) {
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	csVserverLines, err := GetConfigLines(file, "(add cs vserver ).*")
+	if err != nil {
+		return nil, err
+	}
+	var vservers []ConfigLine
+	for _, csVserverLine := range csVserverLines {
+		if fields := SplitConfigLine(csVserverLine.text); len(fields) > 3 {
+			vservers = append(vservers, ConfigLine{text: fields[3], number: csVserverLine.number})
+		}
+	}
+	return vservers, nil
+}
+
+// GetCsBindings is a function that accepts a file name as a parameter for input and then returns the load
+// balancing virtual servers every content switching virtual server switches to. A policy bound without a target
+// switches to the target of its content switching action.
+func GetCsBindings(fileName string) ([]CsBinding, error) {
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	csLines, err := GetConfigLines(file, "((add cs (action|policy)|bind cs vserver) ).*")
+	if err != nil {
+		return nil, err
+	}
+	actionTargets := make(map[string]string)
+	policyActions := make(map[string]string)
+	for _, csLine := range csLines {
+		fields := SplitConfigLine(csLine.text)
+		if len(fields) < 4 || fields[0] != "add" {
+			continue
+		}
+		if fields[2] == "action" {
+			actionTargets[fields[3]] = GetOption(fields, "-targetLBVserver")
+		} else {
+			policyActions[fields[3]] = GetOption(fields, "-action")
+		}
+	}
+	var bindings []CsBinding
+	for _, csLine := range csLines {
+		fields := SplitConfigLine(csLine.text)
+		if len(fields) < 4 || fields[0] != "bind" {
+			continue
+		}
+		target := GetOption(fields, "-lbvserver")
+		if target == "" {
+			target = GetOption(fields, "-targetLBVserver")
+		}
+		if target == "" {
+			target = actionTargets[policyActions[GetOption(fields, "-policyName")]]
+		}
+		if target != "" {
+			bindings = append(bindings, CsBinding{vserverName: fields[3], targetVserver: target, line: csLine.number})
+		}
+	}
+	return bindings, nil
+}
+
+// BlastRadius is a function that returns the objects that transitively depend on every server, which are the
+// services, service groups and virtual servers that fail along with it.
+func BlastRadius(graph *Graph, servers []Server) map[string][]Node {
+	radii := make(map[string][]Node)
+	for _, server := range servers {
+		radii[server.name] = graph.Dependents(server.name)
+	}
+	return radii
+}
+
+// DescribeBlastRadius is a function that returns the number of objects depending on a server followed by their
+// types and names, or an empty string when nothing depends on it.
+func DescribeBlastRadius(dependents []Node) string {
+	if len(dependents) == 0 {
+		return ""
+	}
+	var names []string
+	for _, node := range dependents {
+		names = append(names, node.kind+" "+node.name)
+	}
+	return Translate("blast radius") + " " + strconv.Itoa(len(dependents)) + ": " + strings.Join(names, ", ")
+}
+
+// SortByBlastRadius is a function that orders servers by how many objects depend on them, and then by weight,
+// so that the servers whose loss hurts most come first.
+func SortByBlastRadius(servers []Server, radii map[string][]Node) {
+	sort.SliceStable(servers, func(i, j int) bool {
+		a, b := len(radii[servers[i].name]), len(radii[servers[j].name])
+		if a != b {
+			return a > b
+		}
+		return servers[i].weight > servers[j].weight
+	})
+}
@@ -0,0 +1,154 @@
+package nsanalyze
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// maxCnameDepth is how many CNAME records a lookup follows before giving up, which also ends CNAME loops.
+const maxCnameDepth = 8
+
+// DnsRecord is a data structure for an address or CNAME record that the NetScaler serves from its own
+// configuration.
+type DnsRecord struct {
+	kind  string
+	name  string
+	value string
+	line  int
+}
+
+// LocalZone is a data structure for the DNS records of a configuration, indexed by name for lookups.
+type LocalZone struct {
+	addresses map[string][]string
+	cnames    map[string]string
+}
+
+// GetDnsRecords is a function that accepts a file name as a parameter for input and then returns the address
+// records added with "add dns addRec" and "add dns aaaaRec" and the CNAME records added with "add dns cnameRec".
+func GetDnsRecords(fileName string) ([]DnsRecord, error) {
+	return cachedModel(fileName, "DNS records", parseDnsRecords)
+}
+
+// parseDnsRecords is a function that parses the DNS records of a configuration for GetDnsRecords.
+func parseDnsRecords(fileName string) ([]DnsRecord, error) {
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := GetConfigLines(file, "(?i)(add dns (addRec|aaaaRec|cnameRec) ).*")
+	if err != nil {
+		return nil, err
+	}
+	var records []DnsRecord
+	for _, line := range lines {
+		fields := SplitConfigLine(line.text)
+		if len(fields) < 5 {
+			continue
+		}
+		record := DnsRecord{name: normalizeDnsName(fields[3]), value: fields[4], line: line.number}
+		switch strings.ToLower(fields[2]) {
+		case "addrec":
+			record.kind = "A"
+		case "aaaarec":
+			record.kind = "AAAA"
+		default:
+			record.kind = "CNAME"
+			record.value = normalizeDnsName(record.value)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// normalizeDnsName is a function that lowercases a DNS name and strips its trailing dot, as DNS names are case
+// insensitive and the configuration may give them either fully qualified or not.
+func normalizeDnsName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
+
+// NewLocalZone is a function that indexes DNS records by name.
+func NewLocalZone(records []DnsRecord) *LocalZone {
+	zone := &LocalZone{addresses: make(map[string][]string), cnames: make(map[string]string)}
+	for _, record := range records {
+		if record.kind == "CNAME" {
+			zone.cnames[record.name] = record.value
+		} else if net.ParseIP(record.value) != nil {
+			zone.addresses[record.name] = appendMissing(zone.addresses[record.name], record.value)
+		}
+	}
+	return zone
+}
+
+// LookupHost is a function that returns the addresses of a name from the DNS records, following CNAME records.
+// A name the records do not resolve returns no addresses.
+func (zone *LocalZone) LookupHost(name string) []string {
+	name = normalizeDnsName(name)
+	for depth := 0; depth <= maxCnameDepth; depth++ {
+		if addresses, ok := zone.addresses[name]; ok {
+			return addresses
+		}
+		canonical, ok := zone.cnames[name]
+		if !ok {
+			return nil
+		}
+		name = canonical
+	}
+	return nil
+}
+
+// ResolveServersLocally is a function that resolves the domain name of every domain based server from the DNS
+// records of the configuration and uses the first address as the server address. Servers the records do not
+// resolve keep an empty address, so that they can still be resolved through DNS.
+func ResolveServersLocally(servers []Server, zone *LocalZone) {
+	for i, server := range servers {
+		if !server.domainBased || server.domainName == "" || server.ipAddress != "" {
+			continue
+		}
+		if addresses := zone.LookupHost(server.domainName); len(addresses) > 0 {
+			servers[i].ipAddress = addresses[0]
+			servers[i].resolvedLocally = true
+		}
+	}
+}
+
+// GetDnsDiscrepancyFindings is a function that resolves every server resolved from the DNS records of the
+// configuration through DNS as well, and returns a finding for every server that DNS gives other addresses for.
+// Servers that DNS does not resolve are not reported, as the records of the configuration are all there is. It
+// fails once ctx is done.
+func GetDnsDiscrepancyFindings(ctx context.Context, fileName string, servers []Server, zone *LocalZone,
+	resolver *net.Resolver) ([]Finding, error) {
+	var findings []Finding
+	for _, server := range servers {
+		if !server.resolvedLocally {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, runContextError(redactSourceName(fileName), fmt.Errorf("analysis stopped: %w", err))
+		}
+		lookupContext, cancel := context.WithTimeout(ctx, resolveTimeout)
+		external, err := resolver.LookupHost(lookupContext, server.domainName)
+		cancel()
+		if err != nil || len(external) == 0 {
+			continue
+		}
+		local := append([]string(nil), zone.LookupHost(server.domainName)...)
+		sort.Strings(local)
+		sort.Strings(external)
+		if strings.Join(local, ",") == strings.Join(external, ",") {
+			continue
+		}
+		findings = append(findings, Finding{
+			rule: RuleDnsDiscrepancy,
+			message: fmt.Sprintf("Server %s resolves %s to %s from the configuration's DNS records but to %s from DNS",
+				server.name, server.domainName, strings.Join(local, ","), strings.Join(external, ",")),
+			object:     server.name,
+			objectType: NodeServer,
+			fileName:   fileName,
+			line:       server.line,
+		})
+	}
+	return findings, nil
+}
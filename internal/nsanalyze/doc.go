@@ -0,0 +1,6 @@
+// Package nsanalyze is the analysis engine of the nsanalyze command, which checks NetScaler configurations for
+// servers, endpoints and settings that lose reachability when SNIPs and VLANs move to a trunk.
+//
+// The package serves the command line tool, and its identifiers may change in any release. Programs outside the
+// module use the stable API of package github.com/ajenehall/vlanTrunkProject/pkg/nsanalyze instead.
+package nsanalyze
@@ -0,0 +1,84 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DsrServer is a data structure for a server that load balancing virtual servers forward requests to by MAC
+// address, for direct server return, along with those virtual servers.
+type DsrServer struct {
+	server   Server
+	vservers []string
+}
+
+// GetNonAdjacentDsrServers is a function that returns the servers of virtual servers in MAC redirection mode that
+// are not within the subnet of a SNIP the appliance sends server traffic from. MAC based forwarding only rewrites
+// the destination MAC address, so such a server has to be on a directly connected VLAN; a route or a network the
+// policy adds does not reach it. Domain based servers that are not resolved are left out.
+func GetNonAdjacentDsrServers(fileName string, servers []Server, options AnalyzeOptions) ([]DsrServer, error) {
+	vservers, err := GetLbVservers(fileName)
+	if err != nil {
+		return nil, err
+	}
+	macVservers := make(map[string][]string)
+	var graph *Graph
+	for _, vserver := range vservers {
+		if !vserver.ForwardsByMac() {
+			continue
+		}
+		if graph == nil {
+			if graph, err = GetGraph(fileName); err != nil {
+				return nil, err
+			}
+		}
+		for _, member := range memberServers(graph, vserver.name) {
+			macVservers[member] = append(macVservers[member], vserver.name)
+		}
+	}
+	if len(macVservers) == 0 {
+		return nil, nil
+	}
+	sourceSnips, err := GetSourceSnips(fileName, options)
+	if err != nil {
+		return nil, err
+	}
+	connected, err := GetNetworks(sourceSnips)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []Server
+	for _, server := range servers {
+		if len(macVservers[server.name]) > 0 && server.ipAddress != "" {
+			candidates = append(candidates, server)
+		}
+	}
+	var dsrServers []DsrServer
+	for _, server := range GetUncoveredServers(connected, candidates) {
+		dsrServers = append(dsrServers, DsrServer{server: server, vservers: macVservers[server.name]})
+	}
+	return dsrServers, nil
+}
+
+// GetDsrFindings is a function that accepts a file name as a parameter for input and then returns the findings
+// for servers of virtual servers in MAC redirection mode that are not on a directly connected subnet. They are
+// reported apart from uncovered servers, as a route towards them does not help.
+func GetDsrFindings(fileName string, servers []Server, options AnalyzeOptions) ([]Finding, error) {
+	dsrServers, err := GetNonAdjacentDsrServers(fileName, servers, options)
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, dsrServer := range dsrServers {
+		findings = append(findings, Finding{
+			rule: RuleNonAdjacentDsrServer,
+			message: fmt.Sprintf("Server %s of MAC mode virtual server %s is not on a directly connected SNIP subnet, so direct server return cannot reach it",
+				dsrServer.server.Describe(), strings.Join(dsrServer.vservers, ", ")),
+			object:     dsrServer.server.name,
+			objectType: NodeServer,
+			fileName:   fileName,
+			line:       dsrServer.server.line,
+		})
+	}
+	return findings, nil
+}
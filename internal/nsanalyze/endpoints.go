@@ -0,0 +1,421 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Endpoint is a data structure for an address that the NetScaler itself has to reach, such as a telemetry
+// collector, as opposed to a backend server that traffic is load balanced to.
+type Endpoint struct {
+	kind      string
+	name      string
+	ipAddress string
+	line      int
+}
+
+// EndpointExtractor is a data structure for a kind of endpoint, the rule reported when such an endpoint is not
+// reachable and the function that extracts endpoints of that kind from a configuration.
+type EndpointExtractor struct {
+	kind    string
+	rule    Rule
+	extract func(fileName string) ([]Endpoint, error)
+}
+
+// GetEndpointExtractors is a function that returns the extractor for every kind of endpoint.
+func GetEndpointExtractors() []EndpointExtractor {
+	return []EndpointExtractor{
+		{"AppFlow collector", RuleUnreachableCollector, GetAppflowCollectors},
+		{"SNMP manager", RuleUnreachableSnmp, GetSnmpManagers},
+		{"SNMP trap destination", RuleUnreachableSnmp, GetSnmpTrapDestinations},
+		{"Responder address", RulePolicyAddress, GetResponderAddresses},
+		{"Rewrite address", RulePolicyAddress, GetRewriteAddresses},
+		{"NTP server", RuleUnreachableInfrastructure, GetNtpServers},
+		{"DNS name server", RuleUnreachableInfrastructure, GetDnsNameServers},
+		{"Authentication server", RuleUnreachableAuthServer, GetAuthenticationServers},
+		{"Profile address", RuleProfileAddress, GetProfileAddresses},
+		{"Certificate validation endpoint", RuleUnreachableCertificateValidation, GetCertificateValidationEndpoints},
+	}
+}
+
+// GetAppflowCollectors is a function that accepts a file name as a parameter for input and then returns an
+// array of the AppFlow collectors that analytics records are exported to.
+func GetAppflowCollectors(fileName string) ([]Endpoint, error) {
+	var collectors []Endpoint
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	addCollectorLines, err := GetConfigLines(file, "(add appflow collector ).*")
+	if err != nil {
+		return nil, err
+	}
+	for _, addCollectorLine := range addCollectorLines {
+		fields := SplitConfigLine(RemoveConfigKeywords(addCollectorLine.text, "add appflow collector "))
+		ipAddress := GetOption(fields, "-IPAddress")
+		if len(fields) == 0 || ipAddress == "" {
+			continue
+		}
+		collectors = append(collectors, Endpoint{
+			kind:      "AppFlow collector",
+			name:      fields[0],
+			ipAddress: ipAddress,
+			line:      addCollectorLine.number,
+		})
+	}
+	return collectors, nil
+}
+
+// GetSnmpManagers is a function that accepts a file name as a parameter for input and then returns an array of
+// the SNMP managers allowed to query the NetScaler. Managers given by host name are left out.
+func GetSnmpManagers(fileName string) ([]Endpoint, error) {
+	return getAddressEndpoints(fileName, "SNMP manager", "add snmp manager ")
+}
+
+// GetSnmpTrapDestinations is a function that accepts a file name as a parameter for input and then returns an
+// array of the destinations that generic and specific SNMP traps are sent to.
+func GetSnmpTrapDestinations(fileName string) ([]Endpoint, error) {
+	var destinations []Endpoint
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	addTrapLines, err := GetConfigLines(file, "(add snmp trap ).*")
+	if err != nil {
+		return nil, err
+	}
+	for _, addTrapLine := range addTrapLines {
+		fields := SplitConfigLine(RemoveConfigKeywords(addTrapLine.text, "add snmp trap "))
+		if len(fields) < 2 || net.ParseIP(fields[1]) == nil {
+			continue
+		}
+		destinations = append(destinations, Endpoint{
+			kind:      "SNMP trap destination",
+			name:      fields[0] + " " + fields[1],
+			ipAddress: fields[1],
+			line:      addTrapLine.number,
+		})
+	}
+	return destinations, nil
+}
+
+// GetNtpServers is a function that accepts a file name as a parameter for input and then returns an array of
+// the NTP servers the NetScaler synchronizes its clock with. Servers given by host name are left out.
+func GetNtpServers(fileName string) ([]Endpoint, error) {
+	return getAddressEndpoints(fileName, "NTP server", "add ntp server ")
+}
+
+// GetDnsNameServers is a function that accepts a file name as a parameter for input and then returns an array of
+// the DNS name servers the NetScaler resolves names through. The resolver settings of "set dns parameter" only
+// tune retries and timeouts, so the addresses come from "add dns nameServer". Name servers that are local
+// virtual servers rather than addresses are left out.
+func GetDnsNameServers(fileName string) ([]Endpoint, error) {
+	return getAddressEndpoints(fileName, "DNS name server", "(?i)add dns nameServer ")
+}
+
+// GetAuthenticationServers is a function that accepts a file name as a parameter for input and then returns an
+// array of the LDAP, RADIUS and TACACS+ servers that authentication actions send requests to, which is how
+// administrators log on when system users are authenticated externally. Servers given by host name through
+// -serverName are left out.
+func GetAuthenticationServers(fileName string) ([]Endpoint, error) {
+	var servers []Endpoint
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	actionLines, err := GetConfigLines(file, "(?i)(add authentication (ldap|radius|tacacs)Action ).*")
+	if err != nil {
+		return nil, err
+	}
+	for _, actionLine := range actionLines {
+		fields := SplitConfigLine(actionLine.text)
+		ipAddress := GetOption(fields, "-serverIP")
+		if len(fields) < 4 || net.ParseIP(ipAddress) == nil {
+			continue
+		}
+		servers = append(servers, Endpoint{
+			kind:      "Authentication server",
+			name:      fields[3],
+			ipAddress: ipAddress,
+			line:      actionLine.number,
+		})
+	}
+	return servers, nil
+}
+
+// getAddressEndpoints is a function that returns an endpoint for every line starting with the given keywords
+// whose first field is an IP address.
+func getAddressEndpoints(fileName, kind, keywords string) ([]Endpoint, error) {
+	var endpoints []Endpoint
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := GetConfigLines(file, "("+keywords+").*")
+	if err != nil {
+		return nil, err
+	}
+	keywordCount := len(strings.Fields(strings.TrimPrefix(keywords, "(?i)")))
+	for _, line := range lines {
+		fields := SplitConfigLine(line.text)
+		if len(fields) <= keywordCount || net.ParseIP(fields[keywordCount]) == nil {
+			continue
+		}
+		endpoints = append(endpoints, Endpoint{
+			kind:      kind,
+			name:      fields[keywordCount],
+			ipAddress: fields[keywordCount],
+			line:      line.number,
+		})
+	}
+	return endpoints, nil
+}
+
+// literalAddress matches the IPv4 addresses embedded within policy expressions and action targets.
+var literalAddress = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+
+// GetResponderAddresses is a function that accepts a file name as a parameter for input and then returns the
+// literal addresses embedded within responder actions and policies, such as redirect targets.
+func GetResponderAddresses(fileName string) ([]Endpoint, error) {
+	return getPolicyAddresses(fileName, "Responder address", "responder")
+}
+
+// GetRewriteAddresses is a function that accepts a file name as a parameter for input and then returns the
+// literal addresses embedded within rewrite actions and policies, such as rewrite targets.
+func GetRewriteAddresses(fileName string) ([]Endpoint, error) {
+	return getPolicyAddresses(fileName, "Rewrite address", "rewrite")
+}
+
+// getPolicyAddresses is a function that returns every distinct literal address on the action and policy lines
+// of a feature, named after the action or policy it appears in.
+func getPolicyAddresses(fileName, kind, feature string) ([]Endpoint, error) {
+	return getEmbeddedAddresses(fileName, kind, "(add "+feature+" (action|policy) ).*")
+}
+
+// GetProfileAddresses is a function that accepts a file name as a parameter for input and then returns the
+// literal addresses embedded within TCP, HTTP and SSL profiles and syslog actions, such as syslog over TCP
+// servers. Profiles that do not refer to an address are left out.
+func GetProfileAddresses(fileName string) ([]Endpoint, error) {
+	return getEmbeddedAddresses(fileName, "Profile address",
+		"(?i)((add|set) (ns (tcp|http)Profile|ssl profile|audit syslogAction) ).*")
+}
+
+// GetCertificateValidationEndpoints is a function that accepts a file name as a parameter for input and then
+// returns the OCSP responders and CRL distribution points that client and server certificates are checked
+// against. An OCSP responder is reached through the host of its URL, and a CRL is refreshed from its -server or
+// the host of its -url. Host names are resolved from the DNS records of the configuration and left out when they
+// do not resolve.
+func GetCertificateValidationEndpoints(fileName string) ([]Endpoint, error) {
+	var endpoints []Endpoint
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	validationLines, err := GetConfigLines(file, "(?i)((add|set) ssl (ocspResponder|crl) ).*")
+	if err != nil {
+		return nil, err
+	}
+	records, err := GetDnsRecords(fileName)
+	if err != nil {
+		return nil, err
+	}
+	zone := NewLocalZone(records)
+	// A set line changes the -url or -server of the object its add line created rather than making another one,
+	// so the options are merged into that object by type and name.
+	type validationObject struct {
+		crl    bool
+		name   string
+		url    string
+		server string
+		line   int
+	}
+	var objects []*validationObject
+	byName := make(map[string]*validationObject)
+	for _, validationLine := range validationLines {
+		fields := SplitConfigLine(validationLine.text)
+		if len(fields) < 4 {
+			continue
+		}
+		crl := strings.EqualFold(fields[2], "crl")
+		key := strings.ToLower(fields[2]) + " " + fields[3]
+		object := byName[key]
+		if strings.EqualFold(fields[0], "add") {
+			if object == nil {
+				object = &validationObject{crl: crl, name: fields[3], line: validationLine.number}
+				byName[key] = object
+				objects = append(objects, object)
+			}
+		} else if object == nil {
+			continue
+		}
+		if value := GetOption(fields, "-url"); value != "" {
+			object.url = value
+		}
+		if value := GetOption(fields, "-server"); value != "" {
+			object.server = value
+		}
+	}
+	for _, object := range objects {
+		kind := "OCSP responder"
+		var ipAddresses []string
+		if object.crl {
+			kind = "CRL distribution point"
+			if net.ParseIP(object.server) != nil {
+				ipAddresses = append(ipAddresses, object.server)
+			}
+		}
+		if parsed, err := url.Parse(object.url); err == nil && parsed.Hostname() != "" {
+			if net.ParseIP(parsed.Hostname()) != nil {
+				ipAddresses = append(ipAddresses, parsed.Hostname())
+			} else {
+				ipAddresses = append(ipAddresses, zone.LookupHost(parsed.Hostname())...)
+			}
+		}
+		seen := make(map[string]bool)
+		for _, ipAddress := range ipAddresses {
+			if seen[ipAddress] {
+				continue
+			}
+			seen[ipAddress] = true
+			endpoints = append(endpoints, Endpoint{
+				kind:      kind,
+				name:      object.name,
+				ipAddress: ipAddress,
+				line:      object.line,
+			})
+		}
+	}
+	return endpoints, nil
+}
+
+// getEmbeddedAddresses is a function that returns every distinct literal address on the lines matching a
+// pattern, named after the object the line configures.
+func getEmbeddedAddresses(fileName, kind, pattern string) ([]Endpoint, error) {
+	var addresses []Endpoint
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	objectLines, err := GetConfigLines(file, pattern)
+	if err != nil {
+		return nil, err
+	}
+	for _, objectLine := range objectLines {
+		fields := SplitConfigLine(objectLine.text)
+		if len(fields) < 4 {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, ipAddress := range literalAddress.FindAllString(objectLine.text, -1) {
+			if seen[ipAddress] || net.ParseIP(ipAddress) == nil {
+				continue
+			}
+			seen[ipAddress] = true
+			addresses = append(addresses, Endpoint{
+				kind:      kind,
+				name:      fields[3],
+				ipAddress: ipAddress,
+				line:      objectLine.number,
+			})
+		}
+	}
+	return addresses, nil
+}
+
+// GetEndpointFindings is a function that returns a finding for every endpoint of the configuration that does
+// not fall within any of the networks, so would no longer be reachable.
+func GetEndpointFindings(fileName string, networks []*net.IPNet) ([]Finding, error) {
+	var findings []Finding
+	for _, extractor := range GetEndpointExtractors() {
+		endpoints, err := extractor.extract(fileName)
+		if err != nil {
+			return nil, err
+		}
+		var asServers []Server
+		for _, endpoint := range endpoints {
+			asServers = append(asServers, Server{name: endpoint.name, ipAddress: endpoint.ipAddress, line: endpoint.line})
+		}
+		for _, endpoint := range GetUncoveredServers(networks, asServers) {
+			findings = append(findings, Finding{
+				rule: extractor.rule,
+				message: fmt.Sprintf("%s %s (%s) is not covered by any SNIP network",
+					extractor.kind, endpoint.name, endpoint.ipAddress),
+				object:   endpoint.name,
+				key:      endpoint.ipAddress,
+				fileName: fileName,
+				line:     endpoint.line,
+			})
+		}
+	}
+	return findings, nil
+}
+
+// GetSetCommandAddresses is a function that accepts a file name as a parameter for input and then returns the
+// literal addresses that "set" commands and the options of "add lb vserver" refer to, such as the targets of
+// redirect URLs, which no other extractor looks at. The host of a URL that is a host name is resolved from the
+// DNS records of the configuration and left out when they do not resolve it. Netmasks are not addresses and are
+// left out, as is the name of the object that is set.
+func GetSetCommandAddresses(fileName string) ([]Endpoint, error) {
+	var addresses []Endpoint
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	setLines, err := GetConfigLines(file, "(set |add lb vserver ).*")
+	if err != nil {
+		return nil, err
+	}
+	records, err := GetDnsRecords(fileName)
+	if err != nil {
+		return nil, err
+	}
+	zone := NewLocalZone(records)
+	for _, setLine := range setLines {
+		fields := SplitConfigLine(setLine.text)
+		if len(fields) < 3 {
+			continue
+		}
+		object := fields[1] + " " + fields[2]
+		name := object
+		first := 3
+		if len(fields) > 3 && !strings.HasPrefix(fields[3], "-") {
+			name = fields[3]
+			first = 4
+		}
+		seen := make(map[string]bool)
+		option := ""
+		for _, field := range fields[first:] {
+			if strings.HasPrefix(field, "-") {
+				option = field
+				continue
+			}
+			if (option == "" && fields[0] == "add") || strings.Contains(strings.ToLower(option), "mask") {
+				continue
+			}
+			kind := object
+			if option != "" {
+				kind = object + " " + option
+			}
+			ipAddresses := literalAddress.FindAllString(field, -1)
+			if parsed, err := url.Parse(field); err == nil && parsed.Host != "" && net.ParseIP(parsed.Hostname()) == nil {
+				ipAddresses = append(ipAddresses, zone.LookupHost(parsed.Hostname())...)
+			}
+			for _, ipAddress := range ipAddresses {
+				if seen[ipAddress] || net.ParseIP(ipAddress) == nil {
+					continue
+				}
+				seen[ipAddress] = true
+				addresses = append(addresses, Endpoint{
+					kind:      kind,
+					name:      name,
+					ipAddress: ipAddress,
+					line:      setLine.number,
+				})
+			}
+		}
+	}
+	return addresses, nil
+}
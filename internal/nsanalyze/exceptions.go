@@ -0,0 +1,104 @@
+package nsanalyze
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// Exception is a data structure for a server that is known to be uncovered and accepted as such, such as a host
+// pending decommission, given by name, by address or by a network holding its address. The reason is the comment
+// on the line of the exception.
+type Exception struct {
+	value   string
+	network *net.IPNet
+	reason  string
+	line    int
+}
+
+// Exceptions is a data structure for the known exceptions read from an exceptions file.
+type Exceptions struct {
+	fileName   string
+	exceptions []Exception
+}
+
+// LoadExceptions is a function that reads an exceptions file. Each line holds a server name, an IP address or a
+// network in CIDR notation. Lines starting with # are comments, as is anything after a # preceded by a space,
+// which is kept as the reason for the exception.
+func LoadExceptions(fileName string) (*Exceptions, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	result := &Exceptions{fileName: fileName}
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		text, reason, _ := strings.Cut(scanner.Text(), " #")
+		text = strings.TrimSpace(text)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if len(strings.Fields(text)) != 1 {
+			return nil, fmt.Errorf("%s:%d: expected a server name, address or network", fileName, number)
+		}
+		exception := Exception{value: text, reason: strings.TrimSpace(reason), line: number}
+		if strings.Contains(text, "/") {
+			if _, exception.network, err = net.ParseCIDR(text); err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", fileName, number, err)
+			}
+		}
+		result.exceptions = append(result.exceptions, exception)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Match is a function that returns the exception a server falls under, if any. Having no exceptions matches
+// no server.
+func (exceptions *Exceptions) Match(server Server) (Exception, bool) {
+	if exceptions == nil {
+		return Exception{}, false
+	}
+	ip := net.ParseIP(server.ipAddress)
+	for _, exception := range exceptions.exceptions {
+		switch {
+		case exception.network != nil:
+			if ip != nil && exception.network.Contains(ip) {
+				return exception, true
+			}
+		case exception.value == server.name:
+			return exception, true
+		case ip != nil && ip.Equal(net.ParseIP(exception.value)):
+			return exception, true
+		}
+	}
+	return Exception{}, false
+}
+
+// Describe is a function that returns where an exception comes from along with its reason, so that a report can
+// show why a server was left out.
+func (exceptions *Exceptions) Describe(exception Exception) string {
+	description := fmt.Sprintf("%s:%d %s", exceptions.fileName, exception.line, exception.value)
+	if exception.reason != "" {
+		description += " (" + exception.reason + ")"
+	}
+	return description
+}
+
+// SuppressedBy is a function that returns why an uncovered server is left out of the uncovered report: the
+// suppression of its uncovered server finding or the exception it falls under. It is empty when the server is
+// reported.
+func (options AnalyzeOptions) SuppressedBy(server Server) string {
+	if suppression, ok := options.suppressions.Match(Finding{rule: RuleUncoveredServer, object: server.name}); ok {
+		return options.suppressions.Describe(suppression)
+	}
+	if exception, ok := options.exceptions.Match(server); ok {
+		return options.exceptions.Describe(exception)
+	}
+	return ""
+}
@@ -0,0 +1,181 @@
+package nsanalyze
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+// PrintExplanation is a function that writes everything the configuration knows about an address: the objects
+// that use it, the SNIP networks, routes and policy prefixes that cover it, with the routes its traffic actually
+// takes marked as effective, the VLANs and interfaces it is reached through and the objects that depend on it.
+func PrintExplanation(w io.Writer, fileName, ipAddress string, options AnalyzeOptions) error {
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return fmt.Errorf("%q is not an IP address", ipAddress)
+	}
+	fmt.Fprintf(w, "%s (%s)\n", ipAddress, ClassifyAddress(ipAddress))
+	servers, err := GetAnalysisServers(fileName, options)
+	if err != nil {
+		return err
+	}
+	addresses, err := GetClassifiedAddresses(fileName, servers)
+	if err != nil {
+		return err
+	}
+	graph, err := GetGraph(fileName)
+	if err != nil {
+		return err
+	}
+	var dependents []Node
+	fmt.Fprintf(w, "%s:\n", Translate("objects"))
+	found := false
+	for _, address := range addresses {
+		if !ip.Equal(net.ParseIP(address.ipAddress)) {
+			continue
+		}
+		found = true
+		fmt.Fprintf(w, "\t%s %s (%s %d)\n", address.kind, address.name, Translate("line"), address.line)
+		if address.kind == NodeServer || address.kind == NodeLbVserver {
+			dependents = append(dependents, graph.DependentsOf(Node{kind: address.kind, name: address.name})...)
+		}
+	}
+	for _, extractor := range GetEndpointExtractors() {
+		endpoints, err := extractor.extract(fileName)
+		if err != nil {
+			return err
+		}
+		for _, endpoint := range endpoints {
+			if ip.Equal(net.ParseIP(endpoint.ipAddress)) {
+				found = true
+				fmt.Fprintf(w, "\t%s %s (%s %d)\n", extractor.kind, endpoint.name, Translate("line"), endpoint.line)
+			}
+		}
+	}
+	members, err := GetSetMembers(fileName)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		if member.Contains(ip) {
+			found = true
+			fmt.Fprintf(w, "\t%s %s %s (%s %d)\n", member.kind, member.set, member.value, Translate("line"), member.line)
+		}
+	}
+	if !found {
+		fmt.Fprintf(w, "\t%s\n", Translate("none"))
+	}
+	validSnips, err := GetSourceSnips(fileName, options)
+	if err != nil {
+		return err
+	}
+	networks, err := GetNetworks(validSnips)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%s:\n", Translate("covered by"))
+	covered := false
+	vlans := make(map[string]bool)
+	for i, network := range networks {
+		if network.Contains(ip) {
+			covered = true
+			fmt.Fprintf(w, "\t%s %s %s %s %s (%s %d)\n", validSnips[i].ipType, Translate("network"), network,
+				Translate("of"), validSnips[i].ipAddress, Translate("line"), validSnips[i].line)
+			if validSnips[i].vlan != "" {
+				vlans[validSnips[i].vlan] = true
+			}
+		}
+	}
+	policyNetworks, err := options.policy.CoveringNetworks(fileName)
+	if err != nil {
+		return err
+	}
+	for _, network := range policyNetworks {
+		if network.Contains(ip) {
+			covered = true
+			fmt.Fprintf(w, "\t%s %s\n", Translate("policy prefix"), network)
+		}
+	}
+	if !covered {
+		fmt.Fprintf(w, "\t%s\n", Translate("none"))
+	}
+	routes, err := GetRoutes(fileName)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%s:\n", Translate("routes"))
+	routed := false
+	selected := SelectRoutes(routes, networks, ip)
+	for _, route := range selected {
+		if route.connected {
+			routed = true
+			fmt.Fprintf(w, "\t%s: %s\n", route, DescribeRouteSelection(route, selected))
+		}
+	}
+	for _, route := range routes {
+		if network := route.Network(); network != nil && network.Contains(ip) {
+			routed = true
+			fmt.Fprintf(w, "\t%s (%s %d): %s\n", route, Translate("line"), route.line, DescribeRouteSelection(route, selected))
+		}
+	}
+	if !routed {
+		fmt.Fprintf(w, "\t%s\n", Translate("none"))
+	}
+	bindings, err := GetVlanBindings(fileName)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%s:\n", Translate("vlans"))
+	if len(vlans) == 0 {
+		fmt.Fprintf(w, "\t%s\n", Translate("none"))
+	}
+	var vlanIDs []string
+	for vlan := range vlans {
+		vlanIDs = append(vlanIDs, vlan)
+	}
+	SortVlanIDs(vlanIDs)
+	names, err := GetVlanNames(fileName)
+	if err != nil {
+		return err
+	}
+	for _, vlan := range vlanIDs {
+		var interfaces []string
+		for _, binding := range bindings {
+			if binding.vlanID != vlan {
+				continue
+			}
+			if binding.tagged {
+				interfaces = append(interfaces, binding.interfaceName+" tagged")
+			} else {
+				interfaces = append(interfaces, binding.interfaceName+" untagged")
+			}
+		}
+		if len(interfaces) == 0 {
+			interfaces = append(interfaces, Translate("no interfaces"))
+		}
+		fmt.Fprintf(w, "\tvlan %s on %s\n", DescribeVlan(vlan, names), strings.Join(interfaces, ", "))
+	}
+	fmt.Fprintf(w, "%s:\n", Translate("dependents"))
+	if len(dependents) == 0 {
+		fmt.Fprintf(w, "\t%s\n", Translate("none"))
+	}
+	SortNodes(dependents)
+	for i, node := range dependents {
+		if i > 0 && node == dependents[i-1] {
+			continue
+		}
+		fmt.Fprintf(w, "\t%s %s\n", node.kind, node.name)
+	}
+	return nil
+}
+
+// RunExplain is a function that runs the explain subcommand.
+func RunExplain(args []string, options AnalyzeOptions) error {
+	if len(args) != 2 {
+		return errors.New("usage: explain ip filename")
+	}
+	return PrintExplanation(os.Stdout, args[1], args[0], options)
+}
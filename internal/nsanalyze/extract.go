@@ -0,0 +1,82 @@
+package nsanalyze
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// extractVerbs are the CLI verbs whose lines configure an object.
+var extractVerbs = []string{"add", "set", "bind", "unbind", "enable", "disable", "link"}
+
+// ExtractConfigLines is a function that returns the lines of a configuration that configure objects of a type,
+// such as "lb vserver". With a name only the lines of that object are returned, along with the bind lines of
+// other objects that bind it. Object names are only unique per type, so a bind line of another type naming an
+// object of the same name is returned as well.
+func ExtractConfigLines(fileName, objectType, name string) ([]ConfigLine, error) {
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	typeWords := strings.Fields(objectType)
+	var lines []ConfigLine
+	for number, text := range strings.Split(file, "\n") {
+		fields := SplitConfigLine(text)
+		if len(fields) <= len(typeWords) || !containsString(extractVerbs, fields[0]) {
+			continue
+		}
+		ofType := true
+		for i, word := range typeWords {
+			if !strings.EqualFold(fields[1+i], word) {
+				ofType = false
+				break
+			}
+		}
+		switch {
+		case ofType && (name == "" || (len(fields) > len(typeWords)+1 && fields[len(typeWords)+1] == name)):
+		case name != "" && fields[0] == "bind" && containsString(fields[2:], name):
+		default:
+			continue
+		}
+		lines = append(lines, ConfigLine{text: text, number: number + 1})
+	}
+	return lines, nil
+}
+
+// RunExtract is a function that runs the extract subcommand.
+func RunExtract(args []string) error {
+	flags := flag.NewFlagSet("extract", flag.ContinueOnError)
+	objectType := flags.String("type", "", "object type to extract, such as \"lb vserver\" or serviceGroup")
+	name := flags.String("name", "", "extract only the object with this name, along with what binds it")
+	numbers := flags.Bool("n", false, "prefix every line with its line number")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || strings.TrimSpace(*objectType) == "" {
+		return errors.New("usage: extract -type type [-name name] [-n] filename")
+	}
+	lines, err := ExtractConfigLines(flags.Arg(0), *objectType, *name)
+	if err != nil {
+		return err
+	}
+	if len(lines) == 0 && *name != "" {
+		return fmt.Errorf("%s: no %s %s", flags.Arg(0), *objectType, *name)
+	}
+	PrintConfigLines(os.Stdout, lines, *numbers)
+	return nil
+}
+
+// PrintConfigLines is a function that writes configuration lines as they are, or prefixed with their line
+// number.
+func PrintConfigLines(w io.Writer, lines []ConfigLine, numbers bool) {
+	for _, line := range lines {
+		if numbers {
+			fmt.Fprintf(w, "%d:%s\n", line.number, line.text)
+		} else {
+			fmt.Fprintln(w, line.text)
+		}
+	}
+}
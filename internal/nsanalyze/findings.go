@@ -0,0 +1,638 @@
+package nsanalyze
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Severity levels use the same names as SARIF result levels so findings can be written out unchanged.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityNote    = "note"
+)
+
+// Rule is a data structure for a check that the tool runs against a configuration.
+type Rule struct {
+	id          string
+	name        string
+	description string
+	severity    string
+}
+
+// Finding is a data structure for an issue detected in a configuration, along with the suppression or exception
+// it falls under when it is left out of the reports. The object type is the kind of the object the finding is
+// about, such as server, when that object can carry a comment.
+type Finding struct {
+	rule         Rule
+	message      string
+	object       string
+	objectType   string
+	key          string
+	fileName     string
+	line         int
+	comment      string
+	suppressedBy string
+}
+
+// Rules known to the tool. Rule IDs are stable and must not be reused once published.
+var (
+	RuleUncoveredServer                  = Rule{"NS001", "uncovered-server", "Server is not covered by any SNIP network", SeverityError}
+	RuleOverlappingSubnet                = Rule{"NS002", "overlapping-subnet", "SNIP network overlaps another SNIP network", SeverityWarning}
+	RuleUnknownMask                      = Rule{"NS003", "unknown-mask", "SNIP subnet mask is not a valid netmask", SeverityError}
+	RuleOrphanVlan                       = Rule{"NS004", "orphan-vlan", "VLAN is not bound to any interface", SeverityWarning}
+	RuleUnresolvedServer                 = Rule{"NS005", "unresolved-server", "Domain based server has no address to check", SeverityNote}
+	RuleNativeVlanConflict               = Rule{"NS006", "native-vlan-conflict", "Interface carries more than one untagged VLAN", SeverityError}
+	RuleNativeVlanMismatch               = Rule{"NS007", "native-vlan-mismatch", "Trunk native VLAN differs from the expected untagged VLAN", SeverityError}
+	RuleUnreachableCollector             = Rule{"NS008", "unreachable-collector", "AppFlow collector is not covered by any SNIP network", SeverityWarning}
+	RuleMissingServer                    = Rule{"NS009", "missing-server", "Service targets a server that is never added", SeverityError}
+	RuleBogusAddress                     = Rule{"NS010", "bogus-address", "Address is unspecified, loopback, multicast or invalid", SeverityError}
+	RulePartialPersistenceGroup          = Rule{"NS011", "partial-persistence-group", "Only part of an LB persistence group depends on uncovered servers", SeverityWarning}
+	RuleUnreachableSnmp                  = Rule{"NS012", "unreachable-snmp", "SNMP manager or trap destination is not covered by any SNIP network", SeverityWarning}
+	RulePolicyAddress                    = Rule{"NS013", "uncovered-policy-address", "Responder or rewrite policy embeds an address that is not covered by any SNIP network", SeverityWarning}
+	RuleUnresolvedReference              = Rule{"NS014", "unresolved-reference", "Object references another object that is not in the partial input", SeverityWarning}
+	RuleDanglingReference                = Rule{"NS015", "dangling-reference", "Object references another object that is never added", SeverityError}
+	RuleUnreachableInfrastructure        = Rule{"NS016", "unreachable-infrastructure", "NTP or DNS name server is not covered by any SNIP network", SeverityWarning}
+	RuleModeCaveat                       = Rule{"NS017", "mode-caveat", "Mode setting changes how servers are reached", SeverityWarning}
+	RuleUncoveredSetMember               = Rule{"NS018", "uncovered-set-member", "IP set or data set member is not covered by any SNIP network", SeverityWarning}
+	RuleSpottedCoverage                  = Rule{"NS019", "spotted-coverage", "Server is only covered by SNIPs spotted on some cluster nodes", SeverityWarning}
+	RuleUnreadableConfig                 = Rule{"NS020", "unreadable-config", "Configuration of a multi-file run could not be read or analyzed", SeverityError}
+	RuleUncoveredListenPolicy            = Rule{"NS021", "uncovered-listen-policy", "Listen policy refers to an address or subnet not covered by any SNIP network", SeverityWarning}
+	RuleDnsDiscrepancy                   = Rule{"NS022", "dns-discrepancy", "Server resolves differently from the configuration's DNS records than from DNS", SeverityWarning}
+	RuleMtuMismatch                      = Rule{"NS023", "mtu-mismatch", "Interface or VLAN MTU differs from the trunk MTU or exceeds the MTU of its interface", SeverityWarning}
+	RuleUnreachableRpcNode               = Rule{"NS024", "unreachable-rpc-node", "RPC node used for GSLB metric exchange or HA sync is neither covered nor routed, or is sourced from an address the NetScaler does not own", SeverityError}
+	RuleUnreachableAuthServer            = Rule{"NS025", "unreachable-auth-server", "LDAP, RADIUS or TACACS+ server of an authentication action is not covered by any SNIP network", SeverityError}
+	RuleSubnetEdgeAddress                = Rule{"NS026", "subnet-edge-address", "Server address is the network or broadcast address of a SNIP subnet", SeverityError}
+	RuleTrunkModeMismatch                = Rule{"NS027", "trunk-mode-mismatch", "Interface trunk or tag all setting is inconsistent with its VLAN bindings", SeverityWarning}
+	RuleProfileAddress                   = Rule{"NS028", "uncovered-profile-address", "TCP, HTTP or SSL profile or syslog action refers to an address not covered by any SNIP network", SeverityWarning}
+	RuleUnreachableCertificateValidation = Rule{"NS029", "unreachable-certificate-validation", "OCSP responder or CRL distribution point is not covered by any SNIP network, so certificate validation fails", SeverityError}
+	RuleSplitBridgeGroup                 = Rule{"NS030", "split-bridge-group", "Bridge group bridges VLANs on the trunk with VLANs that are not on it", SeverityWarning}
+	RuleUncoveredBackupVserver           = Rule{"NS031", "uncovered-backup-vserver", "Virtual server is covered but the members of its backup virtual server are not, so failover or spillover fails", SeverityWarning}
+	RuleNonAdjacentDsrServer             = Rule{"NS032", "non-adjacent-dsr-server", "Server of a MAC mode (direct server return) virtual server is not on a directly connected SNIP subnet", SeverityError}
+)
+
+// GetRules is a function that returns every rule known to the tool in rule ID order.
+func GetRules() []Rule {
+	return []Rule{
+		RuleUncoveredServer, RuleOverlappingSubnet, RuleUnknownMask, RuleOrphanVlan, RuleUnresolvedServer,
+		RuleNativeVlanConflict, RuleNativeVlanMismatch, RuleUnreachableCollector, RuleMissingServer,
+		RuleBogusAddress, RulePartialPersistenceGroup, RuleUnreachableSnmp,
+		RulePolicyAddress, RuleUnresolvedReference, RuleDanglingReference,
+		RuleUnreachableInfrastructure, RuleModeCaveat, RuleUncoveredSetMember,
+		RuleSpottedCoverage, RuleUnreadableConfig, RuleUncoveredListenPolicy, RuleDnsDiscrepancy,
+		RuleMtuMismatch, RuleUnreachableRpcNode, RuleUnreachableAuthServer, RuleSubnetEdgeAddress,
+		RuleTrunkModeMismatch, RuleProfileAddress, RuleUnreachableCertificateValidation,
+		RuleSplitBridgeGroup, RuleUncoveredBackupVserver, RuleNonAdjacentDsrServer,
+	}
+}
+
+// ID is a function that returns the stable ID of the rule, such as NS001.
+func (rule Rule) ID() string {
+	return rule.id
+}
+
+// Name is a function that returns the name of the rule, such as uncovered-server.
+func (rule Rule) Name() string {
+	return rule.name
+}
+
+// Description is a function that returns what the rule checks.
+func (rule Rule) Description() string {
+	return rule.description
+}
+
+// Severity is a function that returns the severity of the findings of the rule, error or warning.
+func (rule Rule) Severity() string {
+	return rule.severity
+}
+
+// Rule is a function that returns the rule the finding is for.
+func (finding Finding) Rule() Rule {
+	return finding.rule
+}
+
+// Message is a function that returns what the finding says about the object.
+func (finding Finding) Message() string {
+	return finding.message
+}
+
+// Object is a function that returns the name of the object the finding is about.
+func (finding Finding) Object() string {
+	return finding.object
+}
+
+// FileName is a function that returns the name of the configuration the finding is in.
+func (finding Finding) FileName() string {
+	return finding.fileName
+}
+
+// Line is a function that returns the line of the configuration the object of the finding is on.
+func (finding Finding) Line() int {
+	return finding.line
+}
+
+// Comment is a function that returns the -comment of the object the finding is about, if it has one.
+func (finding Finding) Comment() string {
+	return finding.comment
+}
+
+// GetFindings is a function that accepts a file name as a parameter for input and then returns every
+// finding for the configuration, ordered by line number. It fails once the context of the options is done.
+func GetFindings(fileName string, options AnalyzeOptions) ([]Finding, error) {
+	findings, err := getFindings(fileName, options, nil)
+	return ReportedFindings(findings), err
+}
+
+// getFindings is a function that returns the findings of GetFindings along with those left out by a suppression
+// or an exception, which are marked with why, so that the machine-readable reports can account for them. When
+// emit is given, it is handed the findings of every rule as soon as the rule is done, so that they can be
+// streamed before the rest of the analysis has run. Every rule is checked in one place, so the findings handed
+// over already have the IDs of the findings returned.
+func getFindings(fileName string, options AnalyzeOptions, emit func([]Finding) error) ([]Finding, error) {
+	if err := options.checkContext(); err != nil {
+		return nil, err
+	}
+	servers, err := GetAnalysisServers(fileName, options)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := GetObjectComments(fileName, servers)
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	finished := 0
+	// finish is a function that completes the findings added since it last ran, and hands them to emit.
+	finish := func() error {
+		added := findings[finished:]
+		annotateFindings(added, comments)
+		added = options.profile.FilterFindings(added)
+		options.suppressions.MarkFindings(added)
+		sort.SliceStable(added, func(i, j int) bool {
+			return added[i].line < added[j].line
+		})
+		disambiguateFindings(added)
+		findings = append(findings[:finished], added...)
+		finished = len(findings)
+		if emit == nil || len(added) == 0 {
+			return nil
+		}
+		return emit(added)
+	}
+	snips, err := GetSnips(fileName)
+	if err != nil {
+		return nil, err
+	}
+	validSnips, invalidSnips := FilterValidSnips(snips)
+	for _, snip := range invalidSnips {
+		findings = append(findings, Finding{
+			rule:     RuleUnknownMask,
+			message:  fmt.Sprintf("SNIP %s has unknown subnet mask %q", snip.ipAddress, snip.subnetMask),
+			object:   snip.ipAddress,
+			fileName: fileName,
+			line:     snip.line,
+		})
+	}
+	networks, err := GetNetworks(validSnips)
+	if err != nil {
+		return nil, err
+	}
+	for i, network := range networks {
+		for j := 0; j < i; j++ {
+			other := networks[j]
+			if network.String() == other.String() {
+				continue
+			}
+			if network.Contains(other.IP) || other.Contains(network.IP) {
+				findings = append(findings, Finding{
+					rule:     RuleOverlappingSubnet,
+					message:  fmt.Sprintf("SNIP network %s overlaps %s", network, other),
+					object:   validSnips[i].ipAddress,
+					key:      validSnips[j].ipAddress,
+					fileName: fileName,
+					line:     validSnips[i].line,
+				})
+			}
+		}
+	}
+	if err := finish(); err != nil {
+		return nil, err
+	}
+	coverageNetworks, err := GetCoverageNetworks(fileName, options)
+	if err != nil {
+		return nil, err
+	}
+	if options.resolve {
+		records, err := GetDnsRecords(fileName)
+		if err != nil {
+			return nil, err
+		}
+		dnsFindings, err := GetDnsDiscrepancyFindings(options.analysisContext(), fileName, servers,
+			NewLocalZone(records), NewResolver(options.resolver))
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, dnsFindings...)
+		if err := finish(); err != nil {
+			return nil, err
+		}
+	}
+	for _, server := range servers {
+		if server.ipAddress != "" || server.excluded {
+			continue
+		}
+		message := fmt.Sprintf("Server %s is domain based, use -resolve to check its coverage", server.name)
+		if options.resolve {
+			message = fmt.Sprintf("Server %s could not be resolved from %s", server.name, server.domainName)
+		}
+		findings = append(findings, Finding{
+			rule:       RuleUnresolvedServer,
+			message:    message,
+			object:     server.name,
+			objectType: NodeServer,
+			fileName:   fileName,
+			line:       server.line,
+		})
+	}
+	if err := finish(); err != nil {
+		return nil, err
+	}
+	checkCoverage := ChecksCoverage(coverageNetworks, options)
+	if checkCoverage {
+		uncovered := GetUncoveredServers(coverageNetworks, servers)
+		graph, err := GetGraph(fileName)
+		if err != nil {
+			return nil, err
+		}
+		radii := BlastRadius(graph, uncovered)
+		var probed map[string]bool
+		if options.prober != nil {
+			if probed, err = options.prober.ProbeServers(uncovered); err != nil {
+				return nil, err
+			}
+		}
+		for i, server := range uncovered {
+			if i%contextCheckInterval == 0 {
+				if err := options.checkContext(); err != nil {
+					return nil, err
+				}
+			}
+			var suppressedBy string
+			if exception, ok := options.exceptions.Match(server); ok {
+				suppressedBy = options.exceptions.Describe(exception)
+			}
+			message := fmt.Sprintf("Server %s is not covered by any SNIP network", server.Describe())
+			if owner := options.owners.Owner(server.ipAddress); owner != "" {
+				message += ", owned by " + owner
+			}
+			if options.prober != nil {
+				message += ", " + options.prober.Describe(probed[server.ipAddress])
+			}
+			if blastRadius := DescribeBlastRadius(radii[server.name]); blastRadius != "" {
+				message += ", " + blastRadius
+			}
+			findings = append(findings, Finding{
+				rule:         RuleUncoveredServer,
+				message:      message,
+				object:       server.name,
+				objectType:   NodeServer,
+				fileName:     fileName,
+				line:         server.line,
+				suppressedBy: suppressedBy,
+			})
+		}
+		if err := finish(); err != nil {
+			return nil, err
+		}
+	}
+	vlans, err := GetVlans(fileName)
+	if err != nil {
+		return nil, err
+	}
+	bindings, err := GetVlanBindings(fileName)
+	if err != nil {
+		return nil, err
+	}
+	boundVlans := make(map[string]bool)
+	for _, binding := range bindings {
+		boundVlans[binding.vlanID] = true
+	}
+	for _, vlan := range vlans {
+		if !boundVlans[vlan.id] {
+			findings = append(findings, Finding{
+				rule:     RuleOrphanVlan,
+				message:  fmt.Sprintf("VLAN %s is not bound to any interface", vlan.Describe()),
+				object:   vlan.id,
+				fileName: fileName,
+				line:     vlan.line,
+			})
+		}
+	}
+	// The rules run one after the other, and the context is checked before each, so that an analysis past its
+	// timeout or cancelled by its caller stops at the next rule rather than at the end.
+	checks := []func() ([]Finding, error){
+		func() ([]Finding, error) { return GetReferenceFindings(fileName, servers, options) },
+		func() ([]Finding, error) { return GetAddressFindings(fileName, servers) },
+		func() ([]Finding, error) { return GetSubnetEdgeFindings(fileName, servers, validSnips, networks), nil },
+	}
+	if checkCoverage {
+		checks = append(checks,
+			func() ([]Finding, error) { return GetPersistenceGroupFindings(fileName, coverageNetworks, servers) },
+			func() ([]Finding, error) { return GetEndpointFindings(fileName, coverageNetworks) },
+			func() ([]Finding, error) { return GetSetMemberFindings(fileName, coverageNetworks) },
+			func() ([]Finding, error) { return GetListenPolicyFindings(fileName, coverageNetworks) },
+			func() ([]Finding, error) { return GetSpottedCoverageFindings(fileName, servers, options) },
+			func() ([]Finding, error) { return GetRpcNodeFindings(fileName, coverageNetworks) },
+			func() ([]Finding, error) { return GetBackupVserverFindings(fileName, coverageNetworks, servers) },
+			func() ([]Finding, error) { return GetDsrFindings(fileName, servers, options) },
+		)
+	}
+	checks = append(checks,
+		func() ([]Finding, error) { return GetModeFindings(fileName) },
+		func() ([]Finding, error) { return GetNativeVlanFindings(fileName, options.nativeVlan) },
+		func() ([]Finding, error) { return GetTrunkModeFindings(fileName) },
+		func() ([]Finding, error) { return GetBridgeGroupFindings(fileName) },
+		func() ([]Finding, error) { return GetMtuFindings(fileName, options.mtu) },
+	)
+	for _, check := range checks {
+		if err := options.checkContext(); err != nil {
+			return nil, err
+		}
+		ruleFindings, err := check()
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, ruleFindings...)
+		if err := finish(); err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].line < findings[j].line
+	})
+	return findings, nil
+}
+
+// ReportedFindings is a function that returns the findings that are not left out by a suppression or an
+// exception.
+func ReportedFindings(findings []Finding) []Finding {
+	var reported []Finding
+	for _, finding := range findings {
+		if finding.suppressedBy == "" {
+			reported = append(reported, finding)
+		}
+	}
+	return reported
+}
+
+// SuppressedFindings is a function that returns the findings that are left out by a suppression or an exception.
+func SuppressedFindings(findings []Finding) []Finding {
+	var suppressed []Finding
+	for _, finding := range findings {
+		if finding.suppressedBy != "" {
+			suppressed = append(suppressed, finding)
+		}
+	}
+	return suppressed
+}
+
+// findingJSON is the JSON representation of a finding.
+type findingJSON struct {
+	ID           string `json:"id"`
+	RuleID       string `json:"ruleId"`
+	Rule         string `json:"rule"`
+	Severity     string `json:"severity"`
+	Message      string `json:"message"`
+	Object       string `json:"object"`
+	Comment      string `json:"comment,omitempty"`
+	File         string `json:"file"`
+	Line         int    `json:"line"`
+	SuppressedBy string `json:"suppressedBy,omitempty"`
+}
+
+// metadataJSON is the JSON representation of the metadata of a run.
+type metadataJSON struct {
+	ToolVersion string      `json:"toolVersion"`
+	Timestamp   string      `json:"timestamp"`
+	Inputs      []inputJSON `json:"inputs"`
+	Options     []string    `json:"options"`
+}
+
+// inputJSON is the JSON representation of a configuration analyzed by a run.
+type inputJSON struct {
+	File   string `json:"file"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// ReportFormatVersion is the version of the layout of the JSON and CSV reports. It goes up whenever a change to the
+// layout could break a consumer, such as a field or column that is removed, renamed or moved, and stays the same
+// when fields or columns are only added at the end.
+const ReportFormatVersion = 2
+
+// findingsDocumentJSON is the JSON representation of the findings of a run along with its metadata.
+type findingsDocumentJSON struct {
+	FormatVersion int           `json:"formatVersion"`
+	Metadata      *metadataJSON `json:"metadata,omitempty"`
+	Findings      []findingJSON `json:"findings"`
+	Suppressed    []findingJSON `json:"suppressed"`
+}
+
+// newMetadataJSON is a function that returns the JSON representation of the metadata of a run, which is nil
+// without metadata.
+func newMetadataJSON(metadata *RunMetadata) *metadataJSON {
+	if metadata == nil {
+		return nil
+	}
+	document := &metadataJSON{
+		ToolVersion: metadata.version,
+		Timestamp:   metadata.Timestamp(),
+		Inputs:      []inputJSON{},
+		Options:     append([]string{}, metadata.options...),
+	}
+	for _, input := range metadata.Checksums() {
+		document.Inputs = append(document.Inputs, inputJSON{File: input.fileName, SHA256: input.sha256})
+	}
+	return document
+}
+
+// newFindingJSON is a function that returns the JSON representation of a finding.
+func newFindingJSON(finding Finding) findingJSON {
+	return findingJSON{
+		ID:           redactSourceNames(finding.ID()),
+		RuleID:       finding.rule.id,
+		Rule:         finding.rule.name,
+		Severity:     finding.rule.severity,
+		Message:      redactSourceNames(finding.message),
+		Object:       redactSourceNames(finding.object),
+		Comment:      finding.comment,
+		File:         redactSourceName(finding.fileName),
+		Line:         finding.line,
+		SuppressedBy: finding.suppressedBy,
+	}
+}
+
+// WriteFindingsJSON is a function that writes findings as a JSON document holding the format version, the metadata
+// of the run, when there is any, an array of the findings reported and an array of those left out by a
+// suppression or an exception, each with why.
+func WriteFindingsJSON(w io.Writer, metadata *RunMetadata, findings []Finding) error {
+	document := findingsDocumentJSON{FormatVersion: ReportFormatVersion, Metadata: newMetadataJSON(metadata),
+		Findings: []findingJSON{}, Suppressed: []findingJSON{}}
+	for _, finding := range findings {
+		if finding.suppressedBy == "" {
+			document.Findings = append(document.Findings, newFindingJSON(finding))
+		} else {
+			document.Suppressed = append(document.Suppressed, newFindingJSON(finding))
+		}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+// The sarif types describe the subset of the SARIF 2.1.0 format that the tool produces.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations,omitempty"`
+	Artifacts   []sarifArtifact   `json:"artifacts,omitempty"`
+	Results     []sarifResult     `json:"results"`
+}
+
+type sarifInvocation struct {
+	Arguments           []string `json:"arguments"`
+	CommandLine         string   `json:"commandLine"`
+	StartTimeUTC        string   `json:"startTimeUtc"`
+	ExecutionSuccessful bool     `json:"executionSuccessful"`
+}
+
+type sarifArtifact struct {
+	Location sarifArtifactLocation `json:"location"`
+	Hashes   map[string]string     `json:"hashes,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version,omitempty"`
+	Rules   []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+	Properties          map[string]string `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// WriteSarif is a function that writes findings as a SARIF 2.1.0 log. The metadata of the run, when there is any,
+// is written where SARIF keeps it: the tool version in the driver, the command line and start time as the
+// invocation, which fails when a configuration could not be analyzed, and the checksum of every configuration as
+// an artifact.
+func WriteSarif(w io.Writer, metadata *RunMetadata, findings []Finding) error {
+	findings = ReportedFindings(findings)
+	run := sarifRun{
+		Tool:    sarifTool{Driver: sarifDriver{Name: "vlanTrunkProject"}},
+		Results: []sarifResult{},
+	}
+	if metadata != nil {
+		successful := true
+		for _, finding := range findings {
+			if finding.rule.id == RuleUnreadableConfig.id {
+				successful = false
+			}
+		}
+		run.Tool.Driver.Version = metadata.version
+		run.Invocations = []sarifInvocation{{
+			Arguments:           append([]string{}, metadata.options...),
+			CommandLine:         "vlanTrunkProject " + metadata.CommandLine(),
+			StartTimeUTC:        metadata.Timestamp(),
+			ExecutionSuccessful: successful,
+		}}
+		for _, input := range metadata.Checksums() {
+			artifact := sarifArtifact{Location: sarifArtifactLocation{URI: input.fileName}}
+			if input.sha256 != "" {
+				artifact.Hashes = map[string]string{"sha-256": input.sha256}
+			}
+			run.Artifacts = append(run.Artifacts, artifact)
+		}
+	}
+	for _, rule := range GetRules() {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:                   rule.id,
+			Name:                 rule.name,
+			ShortDescription:     sarifMessage{Text: rule.description},
+			DefaultConfiguration: sarifConfiguration{Level: rule.severity},
+		})
+	}
+	for _, finding := range findings {
+		var properties map[string]string
+		if finding.comment != "" {
+			properties = map[string]string{"comment": finding.comment}
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:  finding.rule.id,
+			Level:   finding.rule.severity,
+			Message: sarifMessage{Text: finding.message},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: redactSourceName(finding.fileName)},
+					Region:           sarifRegion{StartLine: finding.line},
+				},
+			}},
+			PartialFingerprints: map[string]string{"objectId/v1": finding.ID()},
+			Properties:          properties,
+		})
+	}
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
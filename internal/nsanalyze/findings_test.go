@@ -0,0 +1,198 @@
+package nsanalyze
+
+import "testing"
+
+func TestGetFindingsRules(t *testing.T) {
+	snip := "add ns ip 10.0.0.10 255.255.255.0 -type SNIP"
+	tests := []struct {
+		name   string
+		config []string
+		rule   string
+		object string
+		want   bool
+	}{
+		{"uncovered server", []string{snip, "add server far1 172.16.0.5"}, "NS001", "far1", true},
+		{"covered server", []string{snip, "add server web1 10.0.0.5"}, "NS001", "web1", false},
+		{"overlapping subnet", []string{snip, "add ns ip 10.0.0.20 255.255.0.0 -type SNIP"}, "NS002", "10.0.0.20", true},
+		{"unknown mask", []string{snip, "add ns ip 10.5.0.10 255.0.255.0 -type SNIP"}, "NS003", "10.5.0.10", true},
+		{"orphan vlan", []string{snip, "add vlan 30"}, "NS004", "30", true},
+		{"bound vlan", []string{snip, "add vlan 30", "bind vlan 30 -ifnum 1/1"}, "NS004", "30", false},
+		{"domain based server", []string{snip, "add server dns1 dns1.example.com"}, "NS005", "dns1", true},
+		{"native vlan conflict", []string{snip, "add vlan 40", "add vlan 41", "bind vlan 40 -ifnum 1/1",
+			"bind vlan 41 -ifnum 1/1"}, "NS006", "1/1", true},
+		{"tagged vlans", []string{snip, "add vlan 40", "add vlan 41", "bind vlan 40 -ifnum 1/1",
+			"bind vlan 41 -ifnum 1/1 -tagged"}, "NS006", "1/1", false},
+		{"missing server", []string{snip, "add service svc1 ghost HTTP 80"}, "NS009", "svc1", true},
+		{"added server", []string{snip, "add server ghost 10.0.0.6", "add service svc1 ghost HTTP 80"}, "NS009", "svc1", false},
+		{"bogus address", []string{snip, "add server zero 0.0.0.0"}, "NS010", "zero", true},
+		{"dangling binding", []string{snip, "add lb vserver vs1 HTTP 10.0.0.100 80", "bind lb vserver vs1 nosuchsvc"},
+			"NS015", "vs1", true},
+		{"dangling vlan", []string{snip, "bind vlan 41 -ifnum 1/1"}, "NS015", "1/1", true},
+		{"unchanged physical interface", []string{snip, "set interface 1/1 -haMonitor OFF", "add vlan 40",
+			"bind vlan 40 -ifnum 1/2"}, "NS015", "40", false},
+		{"dangling channel", []string{snip, "add vlan 40", "bind vlan 40 -ifnum LA/2"}, "NS015", "40", true},
+		{"added channel", []string{snip, "add channel LA/2 -ifnum 1/1 1/2", "add vlan 40", "bind vlan 40 -ifnum LA/2"},
+			"NS015", "40", false},
+		{"unrouted rpc node", []string{snip, "set ns rpcNode 198.51.100.7 -secure YES"}, "NS024", "198.51.100.7", true},
+		{"routed rpc node", []string{snip, "add route 198.51.100.0 255.255.255.0 10.0.0.1",
+			"set ns rpcNode 198.51.100.7 -secure YES"}, "NS024", "198.51.100.7", false},
+		{"default routed rpc node", []string{snip, "add route 0.0.0.0 0.0.0.0 10.0.0.1",
+			"set ns rpcNode 198.51.100.7 -secure YES"}, "NS024", "198.51.100.7", false},
+		{"rpc node routed through unreachable gateway", []string{snip, "add route 198.51.100.0 255.255.255.0 172.16.0.1",
+			"set ns rpcNode 198.51.100.7 -secure YES"}, "NS024", "198.51.100.7", true},
+		{"uncovered ocsp responder", []string{snip, "add ssl ocspResponder ocsp1 -url http://172.16.0.9/"}, "NS029",
+			"ocsp1", true},
+		{"ocsp responder moved by set", []string{snip, "add ssl ocspResponder ocsp1 -url http://172.16.0.9/",
+			"set ssl ocspResponder ocsp1 -url http://10.0.0.9/"}, "NS029", "ocsp1", false},
+		{"set of missing ocsp responder", []string{snip, "set ssl ocspResponder ocsp2 -url http://172.16.0.9/"}, "NS029",
+			"ocsp2", false},
+		{"uncovered backup vserver", backupConfig(snip), "NS031", "vs1", true},
+		{"unset backup vserver", append(backupConfig(snip), "unset lb vserver vs1 -backupVServer"), "NS031", "vs1", false},
+		{"lacp channel", []string{snip, "set interface 1/1 -lacpMode ACTIVE -lacpKey 2", "add vlan 40",
+			"bind vlan 40 -ifnum LA/2"}, "NS015", "40", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			findings, err := GetFindings(writeConfig(t, test.config...), AnalyzeOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hasFinding(findings, test.rule, test.object); got != test.want {
+				t.Errorf("%s %s found = %v, want %v, findings %v", test.rule, test.object, got, test.want, findingKeys(findings))
+			}
+		})
+	}
+}
+
+// backupConfig is a function that returns a configuration whose covered virtual server vs1 has a backup virtual
+// server sending traffic to an uncovered server.
+func backupConfig(snip string) []string {
+	return []string{snip, "add server web1 10.0.0.5", "add server far1 172.16.0.5",
+		"add service svc1 web1 HTTP 80", "add service svc2 far1 HTTP 80",
+		"add lb vserver vs1 HTTP 10.0.0.100 80", "add lb vserver vs2 HTTP 10.0.0.101 80",
+		"bind lb vserver vs1 svc1", "bind lb vserver vs2 svc2", "set lb vserver vs1 -backupVServer vs2"}
+}
+
+func TestUnsetLbVserver(t *testing.T) {
+	fileName := writeConfig(t, append(backupConfig("add ns ip 10.0.0.10 255.255.255.0 -type SNIP"),
+		"unset lb vserver vs1 -backupVServer -comment")...)
+	vservers, err := GetLbVservers(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, vserver := range vservers {
+		names = append(names, vserver.name)
+		if vserver.name == "vs1" && vserver.backupVserver != "" {
+			t.Errorf("vs1 backup virtual server = %q, want it unset", vserver.backupVserver)
+		}
+	}
+	if len(names) != 2 || names[0] != "vs1" || names[1] != "vs2" {
+		t.Errorf("load balancing virtual servers = %v, want vs1 and vs2", names)
+	}
+}
+
+func TestCertificateValidationFindingsOnce(t *testing.T) {
+	fileName := writeConfig(t, "add ns ip 10.0.0.10 255.255.255.0 -type SNIP",
+		"add ssl crl crl1 /var/crl1.crl -server 172.16.0.8 -method LDAP",
+		"set ssl crl crl1 -url ldap://172.16.0.8/ -refresh ENABLED",
+		"add ssl ocspResponder ocsp1 -url http://172.16.0.9/",
+		"set ssl ocspResponder ocsp1 -cache ENABLED")
+	findings, err := GetFindings(fileName, AnalyzeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]int)
+	ids := make(map[string]bool)
+	for _, finding := range findings {
+		if finding.rule.id != "NS029" {
+			continue
+		}
+		counts[finding.object]++
+		if ids[finding.ID()] {
+			t.Errorf("duplicate finding ID %s", finding.ID())
+		}
+		ids[finding.ID()] = true
+	}
+	if counts["crl1"] != 1 || counts["ocsp1"] != 1 {
+		t.Errorf("NS029 findings per object = %v, want one each for crl1 and ocsp1", counts)
+	}
+}
+
+func TestGetFindingsPartial(t *testing.T) {
+	fileName := writeConfig(t, "add service svc1 ghost HTTP 80")
+	findings, err := GetFindings(fileName, AnalyzeOptions{}.WithPartial())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasFinding(findings, "NS014", "svc1") || hasFinding(findings, "NS009", "svc1") {
+		t.Errorf("partial findings = %v, want NS014 svc1 only", findingKeys(findings))
+	}
+}
+
+func TestObjectComments(t *testing.T) {
+	tests := []struct {
+		name   string
+		config []string
+		object Node
+		want   string
+	}{
+		{"added server", []string{`add server web1 10.0.0.5 -comment "Team A"`}, Node{NodeServer, "web1"}, "Team A"},
+		{"set server", []string{"add server web2 10.0.0.6", `set server web2 -comment "Team B"`},
+			Node{NodeServer, "web2"}, "Team B"},
+		{"set server overrides add", []string{`add server web1 10.0.0.5 -comment "Team A"`,
+			`set server web1 -comment "changed later"`}, Node{NodeServer, "web1"}, "changed later"},
+		{"set server without comment", []string{`add server web1 10.0.0.5 -comment "Team A"`,
+			"set server web1 -state DISABLED"}, Node{NodeServer, "web1"}, "Team A"},
+		{"unset server", []string{`add server web1 10.0.0.5 -comment "Team A"`, "unset server web1 -comment"},
+			Node{NodeServer, "web1"}, ""},
+		{"set service", []string{"add service svc1 web1 HTTP 80", `set service svc1 -comment "Team C"`},
+			Node{NodeService, "svc1"}, "Team C"},
+		{"unset service", []string{`add service svc1 web1 HTTP 80 -comment "Team C"`, "unset service svc1 -comment"},
+			Node{NodeService, "svc1"}, ""},
+		{"set service group", []string{"add serviceGroup sg1 HTTP", `set serviceGroup sg1 -comment "Team D"`},
+			Node{NodeServiceGroup, "sg1"}, "Team D"},
+		{"unset service group", []string{`add serviceGroup sg1 HTTP -comment "Team D"`, "unset servicegroup sg1 -comment"},
+			Node{NodeServiceGroup, "sg1"}, ""},
+		{"service named as a server", []string{`add server app 10.0.0.5 -comment "server team"`,
+			`add service app app HTTP 80 -comment "service team"`}, Node{NodeService, "app"}, "service team"},
+		{"unset cs vserver", []string{`add cs vserver cs1 HTTP 10.0.0.50 80 -comment "Team E"`,
+			"unset cs vserver cs1 -comment"}, Node{NodeCsVserver, "cs1"}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fileName := writeConfig(t, test.config...)
+			servers, err := GetServers(fileName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			comments, err := GetObjectComments(fileName, servers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if comments[test.object] != test.want {
+				t.Errorf("comment of %s %s = %q, want %q", test.object.kind, test.object.name, comments[test.object], test.want)
+			}
+		})
+	}
+}
+
+func TestFindingCommentsByObjectType(t *testing.T) {
+	fileName := writeConfig(t, "add ns ip 10.0.0.10 255.255.255.0 -type SNIP",
+		`add server app 172.16.0.5 -comment "server team"`,
+		`add service app ghost HTTP 80 -comment "service team"`)
+	findings, err := GetFindings(fileName, AnalyzeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"NS001": "server team", "NS009": "service team"}
+	for _, finding := range findings {
+		if comment, ok := want[finding.rule.id]; ok && finding.comment != comment {
+			t.Errorf("%s comment = %q, want %q", finding.ID(), finding.comment, comment)
+		}
+		delete(want, finding.rule.id)
+	}
+	if len(want) != 0 {
+		t.Errorf("findings = %v, want NS001 and NS009 about app", findingKeys(findings))
+	}
+}
@@ -0,0 +1,77 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// FirmwareVersion is a data structure for the firmware release a configuration was saved by, taken from the
+// banner that the appliance writes on the first line of ns.conf.
+type FirmwareVersion struct {
+	release string
+	build   string
+}
+
+// firmwareBanner matches the banner written at the top of ns.conf, such as "#NS13.0 Build 85.15".
+var firmwareBanner = regexp.MustCompile(`^#NS(\d+\.\d+) Build (\S+)`)
+
+// knownReleases are the firmware families whose syntax the parser knows about.
+var knownReleases = map[string]bool{
+	"10.5": true, "11.0": true, "11.1": true, "12.0": true, "12.1": true, "13.0": true, "13.1": true, "14.1": true,
+}
+
+// GetFirmwareVersion is a function that returns the firmware version from the banner of a configuration, which is
+// empty when the configuration has no banner. Leading blank lines are skipped, as a ConfigMap leaves some where
+// its YAML was.
+func GetFirmwareVersion(file string) FirmwareVersion {
+	firstLine, _, _ := strings.Cut(strings.TrimLeft(file, "\n"), "\n")
+	match := firmwareBanner.FindStringSubmatch(firstLine)
+	if match == nil {
+		return FirmwareVersion{}
+	}
+	return FirmwareVersion{release: match[1], build: match[2]}
+}
+
+// Known is a function that reports whether the parser knows the syntax of the firmware family.
+func (version FirmwareVersion) Known() bool {
+	return knownReleases[version.release]
+}
+
+// String is a function that returns the version as the banner writes it.
+func (version FirmwareVersion) String() string {
+	return fmt.Sprintf("NS%s Build %s", version.release, version.build)
+}
+
+// VersionQuirk is a data structure for a syntax difference of older firmware, along with the releases that have
+// it and the rewrite that turns such a line into the syntax the parser expects.
+type VersionQuirk struct {
+	releases []string
+	pattern  *regexp.Regexp
+	rewrite  string
+}
+
+// versionQuirks are the syntax differences between firmware families that affect parsing. Rewrites keep every
+// line on its own line so that reported line numbers still match the original file.
+var versionQuirks = []VersionQuirk{
+	// 10.5 writes service bindings of LB virtual servers with a named option rather than positionally.
+	{[]string{"10.5"}, regexp.MustCompile(`(?m)^(bind lb vserver \S+) -serviceName `), "$1 "},
+	// 10.5 and 11.x name the class and destination of SNMP traps rather than giving them positionally.
+	{[]string{"10.5", "11.0", "11.1"}, regexp.MustCompile(`(?m)^add snmp trap -trapClass (\S+) -trapDestination `),
+		"add snmp trap $1 "},
+}
+
+// ApplyVersionQuirks is a function that rewrites the lines of a configuration saved by older firmware into the
+// syntax of current firmware. Configurations without a banner are taken to be current.
+func ApplyVersionQuirks(file string) string {
+	version := GetFirmwareVersion(file)
+	for _, quirk := range versionQuirks {
+		for _, release := range quirk.releases {
+			if release == version.release {
+				file = quirk.pattern.ReplaceAllString(file, quirk.rewrite)
+				break
+			}
+		}
+	}
+	return file
+}
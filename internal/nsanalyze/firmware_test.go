@@ -0,0 +1,27 @@
+package nsanalyze
+
+import "testing"
+
+func TestGetFirmwareVersion(t *testing.T) {
+	tests := []struct {
+		file    string
+		release string
+		build   string
+		known   bool
+	}{
+		{"#NS13.1 Build 37.38\nadd server a 10.0.0.1\n", "13.1", "37.38", true},
+		{"#NS14.1 Build 12.35\n", "14.1", "12.35", true},
+		{"\n\n#NS12.1 Build 65.25\n", "12.1", "65.25", true},
+		{"#NS10.5 Build 70.18.nc\n", "10.5", "70.18.nc", true},
+		{"#NS15.0 Build 1.1\n", "15.0", "1.1", false},
+		{"add server a 10.0.0.1\n#NS13.1 Build 37.38\n", "", "", false},
+		{"", "", "", false},
+	}
+	for _, test := range tests {
+		version := GetFirmwareVersion(test.file)
+		if version.release != test.release || version.build != test.build || version.Known() != test.known {
+			t.Errorf("GetFirmwareVersion(%q) = %+v known %v, want %s %s known %v", test.file, version, version.Known(),
+				test.release, test.build, test.known)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package nsanalyze
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FetchLimiter is a data structure for the rate limits of fetching remote configurations: the least time between
+// any two fetches and the least time between two fetches from the same host, so that a run across hundreds of
+// appliances neither floods the management network nor hits one appliance with retries back to back.
+type FetchLimiter struct {
+	sync.Mutex
+	interval     time.Duration
+	hostInterval time.Duration
+	last         time.Time
+	hostLast     map[string]time.Time
+}
+
+// NewFetchLimiter is a function that returns a limiter starting at most rate fetches per second, with at least
+// hostInterval between fetches from the same host. A rate or interval of zero leaves that limit off.
+func NewFetchLimiter(rate float64, hostInterval time.Duration) (*FetchLimiter, error) {
+	if rate < 0 {
+		return nil, fmt.Errorf("fetch rate must not be negative, got %g", rate)
+	}
+	if hostInterval < 0 {
+		return nil, fmt.Errorf("host interval must not be negative, got %s", hostInterval)
+	}
+	limiter := &FetchLimiter{hostInterval: hostInterval, hostLast: make(map[string]time.Time)}
+	if rate > 0 {
+		limiter.interval = time.Duration(float64(time.Second) / rate)
+	}
+	return limiter, nil
+}
+
+// runLimiter is the limiter every page request of a bulk Nitro fetch waits for, as a bulk fetch makes a request
+// per object type and page rather than the single one the fetch of a configuration waits for. It is set once by
+// main before any configuration is read and only read afterwards.
+var runLimiter *FetchLimiter
+
+// SetRunLimiter is a function that makes the page requests of bulk Nitro fetches for the rest of the run wait for
+// the limits of limiter too.
+func SetRunLimiter(limiter *FetchLimiter) {
+	runLimiter = limiter
+}
+
+// Wait is a function that waits until a configuration may be fetched from its source under both limits, and
+// returns at once for local files. It gives up with the timeout error once the run is past -timeout.
+func (limiter *FetchLimiter) Wait(fileName string) error {
+	if limiter == nil || !strings.Contains(fileName, "://") {
+		return nil
+	}
+	host := fileName
+	if location, err := url.Parse(fileName); err == nil {
+		host = location.Hostname()
+	}
+	limiter.Lock()
+	now := time.Now()
+	start := now
+	if next := limiter.last.Add(limiter.interval); next.After(start) {
+		start = next
+	}
+	if next := limiter.hostLast[host].Add(limiter.hostInterval); next.After(start) {
+		start = next
+	}
+	limiter.last = start
+	limiter.hostLast[host] = start
+	limiter.Unlock()
+	if !start.After(now) {
+		return nil
+	}
+	timer := time.NewTimer(start.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-runContext.Done():
+		return runContextError(redactSourceName(fileName), runContext.Err())
+	}
+}
+
+// Checkpoint is a data structure for the configurations of a multi-file run that were analyzed along with their
+// results, kept in a file so that a run that is interrupted can be resumed without fetching them again and still
+// report them. Configurations are recorded by their redacted name, so the file holds no credentials.
+type Checkpoint struct {
+	fileName  string
+	completed map[string]checkpointResultJSON
+}
+
+// checkpointJSON is the layout of a checkpoint file.
+type checkpointJSON struct {
+	Completed []checkpointResultJSON `json:"completed"`
+}
+
+// checkpointResultJSON is the layout of the results of a configuration within a checkpoint file: its findings,
+// suppressed ones included, the summary the index lists for it and the reports written for it.
+type checkpointResultJSON struct {
+	Name     string                  `json:"name"`
+	Findings []checkpointFindingJSON `json:"findings"`
+	Summary  *summaryRecord          `json:"summary,omitempty"`
+	Reports  []string                `json:"reports,omitempty"`
+}
+
+// checkpointFindingJSON is the layout of a finding within a checkpoint file, which keeps the key of the finding so
+// that it gets the same ID when it is read back.
+type checkpointFindingJSON struct {
+	RuleID       string `json:"ruleId"`
+	Message      string `json:"message"`
+	Object       string `json:"object"`
+	Key          string `json:"key,omitempty"`
+	Line         int    `json:"line,omitempty"`
+	Comment      string `json:"comment,omitempty"`
+	SuppressedBy string `json:"suppressedBy,omitempty"`
+}
+
+// LoadCheckpoint is a function that reads a checkpoint file, returning an empty checkpoint when the file does not
+// exist yet.
+func LoadCheckpoint(fileName string) (*Checkpoint, error) {
+	checkpoint := &Checkpoint{fileName: fileName, completed: make(map[string]checkpointResultJSON)}
+	data, err := os.ReadFile(fileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return checkpoint, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file checkpointJSON
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %v", fileName, err)
+	}
+	for _, result := range file.Completed {
+		for _, finding := range result.Findings {
+			if _, ok := GetRule(finding.RuleID); !ok {
+				return nil, fmt.Errorf("%s: %s: unknown rule %s", fileName, result.Name, finding.RuleID)
+			}
+		}
+		checkpoint.completed[result.Name] = result
+	}
+	return checkpoint, nil
+}
+
+// Completed is a function that reports whether a configuration was analyzed by an earlier run.
+func (checkpoint *Checkpoint) Completed(fileName string) bool {
+	_, ok := checkpoint.completed[redactSourceName(fileName)]
+	return ok
+}
+
+// Result is a function that returns the results an earlier run recorded for a configuration, as the index entry
+// of the configuration. The findings name the configuration by its redacted name, as reports do.
+func (checkpoint *Checkpoint) Result(fileName string) IndexEntry {
+	result := checkpoint.completed[redactSourceName(fileName)]
+	entry := IndexEntry{fileName: fileName, reports: result.Reports}
+	for _, finding := range result.Findings {
+		rule, _ := GetRule(finding.RuleID)
+		entry.findings = append(entry.findings, Finding{rule: rule, message: finding.Message, object: finding.Object,
+			key: finding.Key, fileName: result.Name, line: finding.Line, comment: finding.Comment,
+			suppressedBy: finding.SuppressedBy})
+	}
+	if result.Summary != nil {
+		entry.summary = result.Summary.summary()
+	}
+	return entry
+}
+
+// Complete is a function that records a configuration as analyzed along with its results and writes the
+// checkpoint file. The file is written next to itself and renamed into place, so an interruption never leaves it
+// half written.
+func (checkpoint *Checkpoint) Complete(entry IndexEntry) error {
+	name := redactSourceName(entry.fileName)
+	result := checkpointResultJSON{Name: name, Findings: []checkpointFindingJSON{}, Reports: entry.reports}
+	for _, finding := range entry.findings {
+		result.Findings = append(result.Findings, checkpointFindingJSON{RuleID: finding.rule.id,
+			Message: redactSourceNames(finding.message), Object: redactSourceNames(finding.object), Key: finding.key,
+			Line: finding.line, Comment: finding.comment, SuppressedBy: finding.suppressedBy})
+	}
+	if !entry.summary.time.IsZero() {
+		record := newSummaryRecord(entry.summary)
+		result.Summary = &record
+	}
+	checkpoint.completed[name] = result
+	file := checkpointJSON{Completed: []checkpointResultJSON{}}
+	for _, result := range checkpoint.completed {
+		file.Completed = append(file.Completed, result)
+	}
+	sort.Slice(file.Completed, func(i, j int) bool { return file.Completed[i].Name < file.Completed[j].Name })
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	temporary := filepath.Join(filepath.Dir(checkpoint.fileName), "."+filepath.Base(checkpoint.fileName)+".tmp")
+	if err := os.WriteFile(temporary, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(temporary, checkpoint.fileName)
+}
+
+// Remove is a function that removes the checkpoint file once every configuration of the run has been analyzed, so
+// that the next run starts over.
+func (checkpoint *Checkpoint) Remove() error {
+	if err := os.Remove(checkpoint.fileName); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Fetch statuses of the configurations of a multi-file run.
+const (
+	FetchSucceeded = "succeeded"
+	FetchFailed    = "failed"
+	FetchResumed   = "analyzed earlier"
+)
+
+// FetchStatus is a data structure for how a configuration of a multi-file run fared: analyzed, failed, or left out
+// as an earlier run analyzed it already.
+type FetchStatus struct {
+	fileName string
+	status   string
+}
+
+// PrintFetchStatus is a function that writes the status summary of a multi-file run, the number of configurations
+// per status followed by every configuration with its status. The errors of those that failed are in the failures
+// section.
+func PrintFetchStatus(w io.Writer, statuses []FetchStatus) {
+	counts := make(map[string]int)
+	for _, status := range statuses {
+		counts[status.status]++
+	}
+	fmt.Fprintf(w, "%s: %d %s, %d %s, %d %s\n", Translate("status"), counts[FetchSucceeded], Translate(FetchSucceeded),
+		counts[FetchFailed], Translate(FetchFailed), counts[FetchResumed], Translate(FetchResumed))
+	for _, status := range statuses {
+		fmt.Fprintf(w, "\t%s: %s\n", redactSourceName(status.fileName), Translate(status.status))
+	}
+}
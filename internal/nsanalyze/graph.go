@@ -0,0 +1,197 @@
+package nsanalyze
+
+import (
+	"sort"
+)
+
+// Node types used within the dependency graph.
+const (
+	NodeCsVserver    = "cs vserver"
+	NodeLbGroup      = "lb group"
+	NodeLbVserver    = "lb vserver"
+	NodeService      = "service"
+	NodeServiceGroup = "serviceGroup"
+	NodeServer       = "server"
+)
+
+// Node is a data structure for an object within the dependency graph. NetScaler names are only unique per
+// object type, so a node is identified by both.
+type Node struct {
+	kind string
+	name string
+}
+
+// Graph is a data structure for the bindings between content switching virtual servers, load balancing groups,
+// virtual servers, services, service groups and servers.
+// An edge points from an object to the object it depends on, for example from a service to its server, or from a
+// virtual server to the backup virtual server it fails over to.
+type Graph struct {
+	nodes      map[Node]bool
+	edges      map[Node][]Node
+	dependents map[Node][]Node
+}
+
+// NewGraph is a function that returns an empty dependency graph.
+func NewGraph() *Graph {
+	return &Graph{
+		nodes:      make(map[Node]bool),
+		edges:      make(map[Node][]Node),
+		dependents: make(map[Node][]Node),
+	}
+}
+
+// AddNode is a function that adds an object to the graph.
+func (g *Graph) AddNode(node Node) {
+	g.nodes[node] = true
+}
+
+// AddEdge is a function that records that one object depends on another, adding both objects to the graph.
+func (g *Graph) AddEdge(from, to Node) {
+	g.AddNode(from)
+	g.AddNode(to)
+	g.edges[from] = append(g.edges[from], to)
+	g.dependents[to] = append(g.dependents[to], from)
+}
+
+// Nodes is a function that returns every object in the graph, sorted by type and name.
+func (g *Graph) Nodes() []Node {
+	var nodes []Node
+	for node := range g.nodes {
+		nodes = append(nodes, node)
+	}
+	SortNodes(nodes)
+	return nodes
+}
+
+// Dependencies is a function that returns every object that a node transitively depends on.
+func (g *Graph) Dependencies(node Node) []Node {
+	return g.walk(node, g.edges)
+}
+
+// DependentsOf is a function that returns every object that transitively depends on a node.
+func (g *Graph) DependentsOf(node Node) []Node {
+	return g.walk(node, g.dependents)
+}
+
+// Dependents is a function that returns every service, service group, virtual server and group that transitively
+// depends on a server, which is the blast radius of losing that server.
+func (g *Graph) Dependents(serverName string) []Node {
+	return g.DependentsOf(Node{kind: NodeServer, name: serverName})
+}
+
+// walk is a function that performs a breadth first traversal from a node along the given adjacency map.
+func (g *Graph) walk(start Node, adjacency map[Node][]Node) []Node {
+	visited := map[Node]bool{start: true}
+	queue := []Node{start}
+	var result []Node
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, next := range adjacency[node] {
+			if visited[next] {
+				continue
+			}
+			visited[next] = true
+			result = append(result, next)
+			queue = append(queue, next)
+		}
+	}
+	SortNodes(result)
+	return result
+}
+
+// SortNodes is a function that sorts nodes by type and then by name.
+func SortNodes(nodes []Node) {
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].kind != nodes[j].kind {
+			return nodes[i].kind < nodes[j].kind
+		}
+		return nodes[i].name < nodes[j].name
+	})
+}
+
+// GetGraph is a function that accepts a file name as a parameter for input and then returns the dependency
+// graph for the configuration.
+func GetGraph(fileName string) (*Graph, error) {
+	graph := NewGraph()
+	servers, err := GetServers(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, server := range servers {
+		graph.AddNode(Node{kind: NodeServer, name: server.name})
+	}
+	services, err := GetServices(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, service := range services {
+		graph.AddEdge(Node{kind: NodeService, name: service.name}, Node{kind: NodeServer, name: service.serverName})
+	}
+	groups, err := GetServiceGroups(fileName)
+	if err != nil {
+		return nil, err
+	}
+	groupNames := make(map[string]bool)
+	for _, group := range groups {
+		groupNames[group.name] = true
+		graph.AddNode(Node{kind: NodeServiceGroup, name: group.name})
+	}
+	members, err := GetServiceGroupMembers(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		graph.AddEdge(Node{kind: NodeServiceGroup, name: member.groupName}, Node{kind: NodeServer, name: member.serverName})
+	}
+	vservers, err := GetLbVservers(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, vserver := range vservers {
+		graph.AddNode(Node{kind: NodeLbVserver, name: vserver.name})
+		if vserver.backupVserver != "" {
+			graph.AddEdge(Node{kind: NodeLbVserver, name: vserver.name}, Node{kind: NodeLbVserver, name: vserver.backupVserver})
+		}
+	}
+	bindings, err := GetLbBindings(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, binding := range bindings {
+		target := Node{kind: NodeService, name: binding.serviceName}
+		if groupNames[binding.serviceName] {
+			target.kind = NodeServiceGroup
+		}
+		graph.AddEdge(Node{kind: NodeLbVserver, name: binding.vserverName}, target)
+	}
+	lbGroups, err := GetLbGroups(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, lbGroup := range lbGroups {
+		graph.AddNode(Node{kind: NodeLbGroup, name: lbGroup.name})
+	}
+	lbGroupMembers, err := GetLbGroupMembers(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range lbGroupMembers {
+		graph.AddEdge(Node{kind: NodeLbGroup, name: member.groupName}, Node{kind: NodeLbVserver, name: member.vserverName})
+	}
+	csVservers, err := GetCsVservers(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, csVserver := range csVservers {
+		graph.AddNode(Node{kind: NodeCsVserver, name: csVserver.text})
+	}
+	csBindings, err := GetCsBindings(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, binding := range csBindings {
+		graph.AddEdge(Node{kind: NodeCsVserver, name: binding.vserverName}, Node{kind: NodeLbVserver, name: binding.targetVserver})
+	}
+	return graph, nil
+}
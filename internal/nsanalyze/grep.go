@@ -0,0 +1,85 @@
+package nsanalyze
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// defaultGrepTimeout is how long the grep subcommand matches a pattern against a configuration before giving up.
+const defaultGrepTimeout = 10 * time.Second
+
+// grepMatchJSON is the JSON representation of a match of the grep subcommand, with the fields of the match split
+// the way the extractors split configuration lines.
+type grepMatchJSON struct {
+	Line   int      `json:"line"`
+	Match  string   `json:"match"`
+	Fields []string `json:"fields"`
+}
+
+// GrepConfig is a function that returns the matches of a pattern given by a user within a configuration, the same
+// way the extractors find the lines of the objects they model, so that objects the tool does not model yet can
+// be queried as well.
+func GrepConfig(fileName, pattern string, timeout time.Duration) ([]ConfigLine, error) {
+	compiled, err := CompileUserPattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	return GetConfigLinesWithin(file, compiled, timeout)
+}
+
+// PrintGrepMatches is a function that writes the matches of the grep subcommand as text, JSON or CSV.
+func PrintGrepMatches(w io.Writer, matches []ConfigLine, format string, numbers bool) error {
+	switch format {
+	case "text":
+		PrintConfigLines(w, matches, numbers)
+		return nil
+	case "json":
+		results := []grepMatchJSON{}
+		for _, match := range matches {
+			results = append(results, grepMatchJSON{Line: match.number, Match: match.text, Fields: SplitConfigLine(match.text)})
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	case "csv":
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"line", "match"})
+		for _, match := range matches {
+			writer.Write([]string{strconv.Itoa(match.number), match.text})
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+	return fmt.Errorf("unsupported format %q, use text, json or csv", format)
+}
+
+// RunGrep is a function that runs the grep subcommand.
+func RunGrep(args []string) error {
+	flags := flag.NewFlagSet("grep", flag.ContinueOnError)
+	pattern := flags.String("pattern", "", "regular expression to match configuration lines against, such as '(add cs action ).*'")
+	format := flags.String("format", "text", "output format: text, json or csv")
+	numbers := flags.Bool("n", false, "prefix every text match with its line number")
+	timeout := flags.Duration("timeout", defaultGrepTimeout, "how long to match before giving up, 0 for no limit")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || *pattern == "" {
+		return errors.New("usage: grep -pattern regexp [-format text|json|csv] [-n] filename")
+	}
+	matches, err := GrepConfig(flags.Arg(0), *pattern, *timeout)
+	if err != nil {
+		return err
+	}
+	return PrintGrepMatches(os.Stdout, matches, *format, *numbers)
+}
@@ -0,0 +1,260 @@
+package nsanalyze
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net"
+	"strings"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ajenehall/vlanTrunkProject/pkg/api"
+)
+
+// analysisServer is a data structure for the gRPC service of the analysis engine. Requests are served in
+// parallel: configurations and parsed models are cached behind locks and handed out as copies, and every request
+// analyzes with options of its own, so no request sees the state of another.
+type analysisServer struct {
+	api.UnimplementedAnalysisServer
+	allowFiles bool
+	resolvers  map[string]bool
+}
+
+// inlineConfigs counts the configurations sent inline, so that each request gets a cache entry of its own.
+var inlineConfigs atomic.Int64
+
+// open is a function that returns the file name and options to analyze a request with, bound by the context of
+// the request, along with a function that releases the configuration once the request is done. Configurations
+// sent inline are cached under a name that cannot be mistaken for a source. A request may only name a DNS server
+// that the server allows, so that it cannot have the server send queries wherever it likes.
+func (server *analysisServer) open(ctx context.Context, request *api.AnalyzeRequest) (string, AnalyzeOptions, func(), error) {
+	options := AnalyzeOptions{
+		resolve:    request.GetResolve(),
+		resolver:   request.GetResolver(),
+		nativeVlan: request.GetNativeVlan(),
+		partial:    request.GetPartial(),
+		ctx:        ctx,
+	}
+	if options.resolver != "" && !server.resolvers[options.resolver] {
+		return "", options, nil, status.Errorf(codes.PermissionDenied,
+			"resolver %s is not allowed, leave it empty or start the server with -resolvers", options.resolver)
+	}
+	switch source := request.GetSource().(type) {
+	case *api.AnalyzeRequest_Config:
+		fileName := fmt.Sprintf("grpc request %d", inlineConfigs.Add(1))
+		if err := CacheFile(fileName, string(source.Config)); err != nil {
+			return "", options, nil, analysisError(err)
+		}
+		return fileName, options, func() { ForgetFile(fileName) }, nil
+	case *api.AnalyzeRequest_FileName:
+		if !server.allowFiles {
+			return "", options, nil, status.Error(codes.PermissionDenied, "file names are not allowed, send the configuration inline")
+		}
+		return source.FileName, options, func() {}, nil
+	}
+	return "", options, nil, status.Error(codes.InvalidArgument, "either file_name or config is required")
+}
+
+// Analyze is a function that parses a configuration and returns its servers, SNIPs, networks, uncovered servers,
+// findings and warnings.
+func (server *analysisServer) Analyze(ctx context.Context, request *api.AnalyzeRequest) (*api.AnalyzeResponse, error) {
+	fileName, options, release, err := server.open(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	servers, err := GetAnalysisServers(fileName, options)
+	if err != nil {
+		return nil, analysisError(err)
+	}
+	snips, err := GetSnips(fileName)
+	if err != nil {
+		return nil, analysisError(err)
+	}
+	networks, err := GetCoverageNetworks(fileName, options)
+	if err != nil {
+		return nil, analysisError(err)
+	}
+	findings, err := GetFindings(fileName, options)
+	if err != nil {
+		return nil, analysisError(err)
+	}
+	warnings, err := GetWarnings(fileName, options)
+	if err != nil {
+		return nil, analysisError(err)
+	}
+	response := &api.AnalyzeResponse{
+		Servers:  serverMessages(includedServers(servers)),
+		Snips:    snipMessages(snips),
+		Findings: findingMessages(findings),
+		Warnings: warningMessages(warnings),
+	}
+	for _, network := range networks {
+		response.Networks = append(response.Networks, &api.Network{Cidr: network.String()})
+	}
+	if ChecksCoverage(networks, options) {
+		response.Uncovered = serverMessages(GetUncoveredServers(networks, servers))
+	}
+	return response, nil
+}
+
+// GetServers is a function that returns the servers of a configuration.
+func (server *analysisServer) GetServers(ctx context.Context, request *api.AnalyzeRequest) (*api.ServersResponse, error) {
+	fileName, options, release, err := server.open(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	servers, err := GetAnalysisServers(fileName, options)
+	if err != nil {
+		return nil, analysisError(err)
+	}
+	return &api.ServersResponse{Servers: serverMessages(includedServers(servers))}, nil
+}
+
+// GetSnips is a function that returns the NetScaler owned IPs of a configuration.
+func (server *analysisServer) GetSnips(ctx context.Context, request *api.AnalyzeRequest) (*api.SnipsResponse, error) {
+	fileName, _, release, err := server.open(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	snips, err := GetSnips(fileName)
+	if err != nil {
+		return nil, analysisError(err)
+	}
+	return &api.SnipsResponse{Snips: snipMessages(snips)}, nil
+}
+
+// GetFindings is a function that returns every finding for a configuration along with its warnings.
+func (server *analysisServer) GetFindings(ctx context.Context, request *api.AnalyzeRequest) (*api.FindingsResponse, error) {
+	fileName, options, release, err := server.open(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	findings, err := GetFindings(fileName, options)
+	if err != nil {
+		return nil, analysisError(err)
+	}
+	warnings, err := GetWarnings(fileName, options)
+	if err != nil {
+		return nil, analysisError(err)
+	}
+	return &api.FindingsResponse{Findings: findingMessages(findings), Warnings: warningMessages(warnings)}, nil
+}
+
+// analysisError is a function that turns an error of the analysis into a gRPC status: DeadlineExceeded or
+// Canceled when the request or the server ran out of time, NotFound when a named file does not exist, and
+// Internal otherwise.
+func analysisError(err error) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, ErrTimedOut):
+		code = codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	case errors.Is(err, fs.ErrNotExist):
+		code = codes.NotFound
+	}
+	return status.Error(code, redactSourceNames(err.Error()))
+}
+
+// serverMessages is a function that converts servers into their protobuf models.
+func serverMessages(servers []Server) []*api.Server {
+	var messages []*api.Server
+	for _, server := range servers {
+		messages = append(messages, &api.Server{
+			Name:            server.name,
+			IpAddress:       server.ipAddress,
+			DomainName:      server.domainName,
+			TranslationIp:   server.translationIP,
+			TranslationMask: server.translationMask,
+			Ports:           server.ports,
+			Line:            int32(server.line),
+		})
+	}
+	return messages
+}
+
+// snipMessages is a function that converts SNIPs into their protobuf models.
+func snipMessages(snips []Snip) []*api.Snip {
+	var messages []*api.Snip
+	for _, snip := range snips {
+		messages = append(messages, &api.Snip{
+			IpAddress:  snip.ipAddress,
+			SubnetMask: snip.subnetMask,
+			Type:       snip.ipType,
+			Vlan:       snip.vlan,
+			Td:         snip.td,
+			Line:       int32(snip.line),
+		})
+	}
+	return messages
+}
+
+// findingMessages is a function that converts findings into their protobuf models.
+func findingMessages(findings []Finding) []*api.Finding {
+	var messages []*api.Finding
+	for _, finding := range findings {
+		messages = append(messages, &api.Finding{
+			RuleId:   finding.rule.id,
+			Rule:     finding.rule.name,
+			Severity: finding.rule.severity,
+			Message:  finding.message,
+			Object:   finding.object,
+			File:     finding.fileName,
+			Line:     int32(finding.line),
+		})
+	}
+	return messages
+}
+
+// warningMessages is a function that converts warnings into their protobuf models.
+func warningMessages(warnings []Warning) []*api.Warning {
+	var messages []*api.Warning
+	for _, warning := range warnings {
+		messages = append(messages, &api.Warning{
+			Kind:    warning.kind,
+			Message: warning.message,
+			Object:  warning.object,
+			File:    warning.fileName,
+			Line:    int32(warning.line),
+		})
+	}
+	return messages
+}
+
+// RunServe is a function that runs the serve subcommand, which serves the analysis engine over gRPC until the
+// listener fails.
+func RunServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	listen := flags.String("listen", "localhost:50051", "address to serve gRPC on")
+	allowFiles := flags.Bool("allow-files", false, "allow requests to name files and URLs to read on this machine")
+	resolvers := flags.String("resolvers", "", "comma separated DNS servers that requests may resolve domain based servers through")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: serve [-listen address] [-allow-files] [-resolvers list]")
+	}
+	allowed := make(map[string]bool)
+	for _, resolver := range strings.Split(*resolvers, ",") {
+		if resolver = strings.TrimSpace(resolver); resolver != "" {
+			allowed[resolver] = true
+		}
+	}
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return err
+	}
+	server := grpc.NewServer()
+	api.RegisterAnalysisServer(server, &analysisServer{allowFiles: *allowFiles, resolvers: allowed})
+	return server.Serve(listener)
+}
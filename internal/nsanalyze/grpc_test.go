@@ -0,0 +1,51 @@
+package nsanalyze
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ajenehall/vlanTrunkProject/pkg/api"
+)
+
+func TestAnalysisServerErrors(t *testing.T) {
+	config := []byte("add ns ip 10.0.0.10 255.255.255.0 -type SNIP\nadd server web1 10.0.0.5\n")
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	server := &analysisServer{allowFiles: true, resolvers: map[string]bool{"10.0.0.53": true}}
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		request *api.AnalyzeRequest
+		code    codes.Code
+	}{
+		{"inline", context.Background(), &api.AnalyzeRequest{Source: &api.AnalyzeRequest_Config{Config: config}}, codes.OK},
+		{"no source", context.Background(), &api.AnalyzeRequest{}, codes.InvalidArgument},
+		{"cancelled", cancelled, &api.AnalyzeRequest{Source: &api.AnalyzeRequest_Config{Config: config}}, codes.Canceled},
+		{"missing file", context.Background(), &api.AnalyzeRequest{Source: &api.AnalyzeRequest_FileName{
+			FileName: filepath.Join(t.TempDir(), "missing.conf")}}, codes.NotFound},
+		{"resolver not allowed", context.Background(), &api.AnalyzeRequest{Resolver: "192.0.2.1",
+			Source: &api.AnalyzeRequest_Config{Config: config}}, codes.PermissionDenied},
+		{"resolver allowed", context.Background(), &api.AnalyzeRequest{Resolver: "10.0.0.53",
+			Source: &api.AnalyzeRequest_Config{Config: config}}, codes.OK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := server.GetFindings(test.ctx, test.request)
+			if got := status.Code(err); got != test.code {
+				t.Errorf("GetFindings error = %v, want code %s", err, test.code)
+			}
+		})
+	}
+}
+
+func TestAnalysisServerFilesNotAllowed(t *testing.T) {
+	server := &analysisServer{}
+	_, err := server.GetFindings(context.Background(), &api.AnalyzeRequest{Source: &api.AnalyzeRequest_FileName{FileName: "ns.conf"}})
+	if got := status.Code(err); got != codes.PermissionDenied {
+		t.Errorf("GetFindings error = %v, want code %s", err, codes.PermissionDenied)
+	}
+}
@@ -0,0 +1,244 @@
+package nsanalyze
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// ComparedObject is a data structure for an object that should be identical on every node of an HA pair and on
+// its DR appliances, along with the values of it that are compared.
+type ComparedObject struct {
+	kind  string
+	name  string
+	value string
+}
+
+// GetComparedObjects is a function that accepts a file name as a parameter for input and then returns the
+// servers, SNIPs, VLANs, VLAN bindings and virtual servers of the configuration keyed by type and name.
+func GetComparedObjects(fileName string) (map[Node]ComparedObject, error) {
+	objects := make(map[Node]ComparedObject)
+	add := func(kind, name, value string) {
+		objects[Node{kind: kind, name: name}] = ComparedObject{kind: kind, name: name, value: value}
+	}
+	servers, err := GetServers(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, server := range servers {
+		add(NodeServer, server.name, server.ipAddress+server.domainName)
+	}
+	snips, err := GetSnips(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, snip := range snips {
+		value := fmt.Sprintf("mask %s, type %s, vlan %s, td %s", snip.subnetMask, snip.ipType, snip.vlan, snip.td)
+		if snip.vserver != "" {
+			value += ", vServer " + snip.vserver
+		}
+		if len(snip.vservers) > 0 {
+			value += ", bound to " + strings.Join(snip.vservers, " ")
+		}
+		add("ns ip", snip.ipAddress, value)
+	}
+	snip6s, err := GetSnip6s(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, snip := range snip6s {
+		add("ns ip6", snip.ipAddress, fmt.Sprintf("prefix /%s, type %s, vlan %s, td %s", snip.prefixLength,
+			snip.ipType, snip.vlan, snip.td))
+	}
+	vlans, err := GetVlans(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, vlan := range vlans {
+		add("vlan", vlan.id, "")
+	}
+	bindings, err := GetVlanBindings(fileName)
+	if err != nil {
+		return nil, err
+	}
+	interfaces := make(map[string][]string)
+	for _, binding := range bindings {
+		mode := "untagged"
+		if binding.tagged {
+			mode = "tagged"
+		}
+		interfaces[binding.vlanID] = append(interfaces[binding.vlanID], binding.interfaceName+" "+mode)
+	}
+	for vlan, bound := range interfaces {
+		sort.Strings(bound)
+		add("vlan binding", vlan, strings.Join(bound, ", "))
+	}
+	vservers, err := GetLbVservers(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, vserver := range vservers {
+		add(NodeLbVserver, vserver.name, fmt.Sprintf("%s %s:%s", vserver.protocol, vserver.ipAddress, vserver.port))
+	}
+	return objects, nil
+}
+
+// ComparisonRow is a data structure for an object compared across several configurations, holding for each
+// configuration the index of the variant of the object it has, or -1 when it does not have the object.
+type ComparisonRow struct {
+	key      Node
+	variants []string
+	cells    []int
+}
+
+// Consistent is a function that reports whether every configuration has the object with the same values.
+func (row ComparisonRow) Consistent() bool {
+	for _, cell := range row.cells {
+		if cell != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// CompareConfigs is a function that accepts the file names of several configurations as parameters for input
+// and then returns a row for every object found in any of them, ordered by type and name. Variants are numbered
+// in order of the configurations they are first seen in, so the first configuration having an object always has
+// variant 0 of it.
+func CompareConfigs(fileNames []string) ([]ComparisonRow, error) {
+	var configs []map[Node]ComparedObject
+	keys := make(map[Node]bool)
+	for _, fileName := range fileNames {
+		objects, err := GetComparedObjects(fileName)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, objects)
+		for key := range objects {
+			keys[key] = true
+		}
+	}
+	var sortedKeys []Node
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	SortNodes(sortedKeys)
+	var rows []ComparisonRow
+	for _, key := range sortedKeys {
+		row := ComparisonRow{key: key}
+		for _, objects := range configs {
+			object, ok := objects[key]
+			if !ok {
+				row.cells = append(row.cells, -1)
+				continue
+			}
+			variant := slices.Index(row.variants, object.value)
+			if variant == -1 {
+				variant = len(row.variants)
+				row.variants = append(row.variants, object.value)
+			}
+			row.cells = append(row.cells, variant)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// variantLetter is a function that returns the letter a variant is shown as in the comparison matrix, or "-"
+// for a configuration that does not have the object.
+func variantLetter(variant int) string {
+	if variant < 0 {
+		return "-"
+	}
+	if variant < 26 {
+		return string(rune('A' + variant))
+	}
+	return fmt.Sprintf("%d", variant+1)
+}
+
+// PrintHACompare is a function that writes the differences between configurations that should hold the same
+// objects, such as the nodes of an HA pair and their DR appliance. Two configurations are written as the objects
+// present on only one of them and the objects whose values differ, more as the matrix of PrintComparisonMatrix.
+func PrintHACompare(w io.Writer, fileNames []string) error {
+	rows, err := CompareConfigs(fileNames)
+	if err != nil {
+		return err
+	}
+	if len(fileNames) != 2 {
+		return PrintComparisonMatrix(w, fileNames, rows)
+	}
+	differences := 0
+	for _, row := range rows {
+		key := row.key
+		switch {
+		case row.cells[1] == -1:
+			fmt.Fprintln(w, strings.TrimSpace(fmt.Sprintf("%s: %s %s %s", Translate("only on primary"), key.kind, key.name, row.variants[0])))
+		case row.cells[0] == -1:
+			fmt.Fprintln(w, strings.TrimSpace(fmt.Sprintf("%s: %s %s %s", Translate("only on secondary"), key.kind, key.name, row.variants[0])))
+		case !row.Consistent():
+			fmt.Fprintf(w, "%s: %s %s: %s %s, %s %s\n", Translate("differs"), key.kind, key.name, Translate("primary"),
+				row.variants[0], Translate("secondary"), row.variants[1])
+		default:
+			continue
+		}
+		differences++
+	}
+	fmt.Fprintf(w, "%d %s\n", differences, Translate("difference(s)"))
+	return nil
+}
+
+// PrintComparisonMatrix is a function that writes a matrix of the compared objects that are not the same on every
+// configuration, with a column per configuration. A configuration is
+// marked "-" when it does not have the object and otherwise with the letter of the variant of the object it has,
+// and the values of the objects with more than one variant are listed after the matrix.
+func PrintComparisonMatrix(w io.Writer, fileNames []string, rows []ComparisonRow) error {
+	var inconsistent []ComparisonRow
+	for _, row := range rows {
+		if !row.Consistent() {
+			inconsistent = append(inconsistent, row)
+		}
+	}
+	if len(inconsistent) > 0 {
+		table := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintf(table, "%s\t%s\t%s\t\n", Translate("KIND"), Translate("NAME"), strings.Join(fileNames, "\t"))
+		for _, row := range inconsistent {
+			var cells []string
+			for _, cell := range row.cells {
+				cells = append(cells, variantLetter(cell))
+			}
+			fmt.Fprintf(table, "%s\t%s\t%s\t\n", row.key.kind, row.key.name, strings.Join(cells, "\t"))
+		}
+		if err := table.Flush(); err != nil {
+			return err
+		}
+		heading := false
+		for _, row := range inconsistent {
+			if len(row.variants) < 2 {
+				continue
+			}
+			if !heading {
+				fmt.Fprintf(w, "%s:\n", Translate("values"))
+				heading = true
+			}
+			fmt.Fprintf(w, "\t%s %s\n", row.key.kind, row.key.name)
+			for i, value := range row.variants {
+				fmt.Fprintln(w, strings.TrimRight(fmt.Sprintf("\t\t%s: %s", variantLetter(i), value), " "))
+			}
+		}
+	}
+	fmt.Fprintf(w, "%d %s\n", len(inconsistent), Translate("difference(s)"))
+	return nil
+}
+
+// RunHACompare is a function that runs the ha-compare subcommand.
+func RunHACompare(args []string) error {
+	if len(args) < 2 {
+		return errors.New("usage: ha-compare primary secondary [filename...]")
+	}
+	return PrintHACompare(os.Stdout, args)
+}
@@ -0,0 +1,45 @@
+package nsanalyze
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrintHACompare(t *testing.T) {
+	primary := writeConfig(t, "add server web1 10.0.0.5", "add server web2 10.0.0.6", "add vlan 10")
+	secondary := writeConfig(t, "add server web1 10.0.0.9", "add vlan 10", "add vlan 20")
+	dr := writeConfig(t, "add server web1 10.0.0.5", "add vlan 10")
+	tests := []struct {
+		name      string
+		fileNames []string
+		want      []string
+	}{
+		{"pair", []string{primary, secondary}, []string{
+			"differs: server web1: primary 10.0.0.5, secondary 10.0.0.9",
+			"only on primary: server web2 10.0.0.6",
+			"only on secondary: vlan 20",
+			"3 difference(s)",
+		}},
+		{"identical pair", []string{primary, primary}, []string{"0 difference(s)"}},
+		{"pair and dr", []string{primary, secondary, dr}, []string{
+			"server  web1  A",
+			"server  web2  A",
+			"vlan    20    -",
+			"\t\tB: 10.0.0.9",
+			"3 difference(s)",
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := PrintHACompare(&out, test.fileNames); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range test.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output lacks %q:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+package nsanalyze
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// RunSummary is a data structure for the outcome of one analysis run against a device. The names of the covered
+// servers are kept along with the uncovered ones, so that a server the device did not have can be told apart.
+type RunSummary struct {
+	device    string
+	time      time.Time
+	servers   int
+	covered   []string
+	uncovered []string
+}
+
+// summaryRecord is the representation of a run summary within the history store.
+type summaryRecord struct {
+	Device    string    `json:"device"`
+	Time      time.Time `json:"time"`
+	Servers   int       `json:"servers"`
+	Covered   []string  `json:"covered,omitempty"`
+	Uncovered []string  `json:"uncovered"`
+}
+
+// newSummaryRecord is a function that returns the representation of a run summary within the history store.
+func newSummaryRecord(summary RunSummary) summaryRecord {
+	return summaryRecord{
+		Device:    summary.device,
+		Time:      summary.time.UTC(),
+		Servers:   summary.servers,
+		Covered:   summary.covered,
+		Uncovered: summary.uncovered,
+	}
+}
+
+// summary is a function that returns the run summary a record of the history store represents.
+func (record summaryRecord) summary() RunSummary {
+	return RunSummary{
+		device:    record.Device,
+		time:      record.Time,
+		servers:   record.Servers,
+		covered:   record.Covered,
+		uncovered: record.Uncovered,
+	}
+}
+
+// Coverage is a function that returns the percentage of servers that were covered during the run.
+func (summary RunSummary) Coverage() float64 {
+	if summary.servers == 0 {
+		return 100
+	}
+	return float64(summary.servers-len(summary.uncovered)) * 100 / float64(summary.servers)
+}
+
+// HistoryStore is a data structure for the embedded database that run summaries are recorded in. Each device
+// has its own bucket keyed by run time so that runs are kept in order.
+type HistoryStore struct {
+	db *bolt.DB
+}
+
+// OpenHistoryStore is a function that opens the history store at the given path, creating it if needed.
+func OpenHistoryStore(path string) (*HistoryStore, error) {
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	return &HistoryStore{db: db}, nil
+}
+
+// Close is a function that closes the history store.
+func (store *HistoryStore) Close() error {
+	return store.db.Close()
+}
+
+// Record is a function that adds a run summary to the history store.
+func (store *HistoryStore) Record(summary RunSummary) error {
+	value, err := json.Marshal(newSummaryRecord(summary))
+	if err != nil {
+		return err
+	}
+	return store.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(summary.device))
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(summary.time.UTC().Format(time.RFC3339Nano)), value)
+	})
+}
+
+// Devices is a function that returns the names of every device with recorded runs.
+func (store *HistoryStore) Devices() ([]string, error) {
+	var devices []string
+	err := store.db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			devices = append(devices, string(name))
+			return nil
+		})
+	})
+	return devices, err
+}
+
+// Runs is a function that returns every recorded run for a device, oldest first.
+func (store *HistoryStore) Runs(device string) ([]RunSummary, error) {
+	var runs []RunSummary
+	err := store.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(device))
+		if bucket == nil {
+			return fmt.Errorf("no history recorded for device %s", device)
+		}
+		return bucket.ForEach(func(_, value []byte) error {
+			var record summaryRecord
+			if err := json.Unmarshal(value, &record); err != nil {
+				return err
+			}
+			runs = append(runs, record.summary())
+			return nil
+		})
+	})
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].time.Before(runs[j].time)
+	})
+	return runs, err
+}
+
+// GetDeviceName is a function that returns the name that history is recorded under for a configuration file,
+// which is the host name set in the configuration or the file name when there is none.
+func GetDeviceName(fileName string) (string, error) {
+	file, err := GetFile(fileName)
+	if err != nil {
+		return "", err
+	}
+	hostNameLines, err := GetConfig(file, "(?i)(set ns hostName ).*")
+	if err != nil {
+		return "", err
+	}
+	for _, hostNameLine := range hostNameLines {
+		if fields := SplitConfigLine(hostNameLine); len(fields) > 3 {
+			return fields[3], nil
+		}
+	}
+	return filepath.Base(fileName), nil
+}
+
+// RecordHistory is a function that runs the coverage analysis for a configuration file and records the
+// summary of the run in the history store at the given path.
+func RecordHistory(path, fileName string, options AnalyzeOptions) error {
+	summary, err := SummarizeRun(fileName, options)
+	if err != nil {
+		return err
+	}
+	store, err := OpenHistoryStore(path)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	return store.Record(summary)
+}
+
+// SummarizeRun is a function that runs the coverage analysis for a configuration file and returns the summary
+// of the run: the device, how many servers have an address and which of them are covered and uncovered.
+func SummarizeRun(fileName string, options AnalyzeOptions) (RunSummary, error) {
+	networks, err := GetCoverageNetworks(fileName, options)
+	if err != nil {
+		return RunSummary{}, err
+	}
+	servers, err := GetAnalysisServers(fileName, options)
+	if err != nil {
+		return RunSummary{}, err
+	}
+	device, err := GetDeviceName(fileName)
+	if err != nil {
+		return RunSummary{}, err
+	}
+	summary := RunSummary{device: device, time: time.Now()}
+	uncovered := make(map[string]bool)
+	for _, server := range GetUncoveredServers(networks, servers) {
+		summary.uncovered = append(summary.uncovered, server.name)
+		uncovered[server.name] = true
+	}
+	for _, server := range includedServers(servers) {
+		if server.ipAddress == "" {
+			continue
+		}
+		summary.servers++
+		if !uncovered[server.name] {
+			summary.covered = append(summary.covered, server.name)
+		}
+	}
+	return summary, nil
+}
+
+// UncoveredSince is a function that returns, for every server uncovered in the latest run, the time of the
+// run where it most recently became uncovered.
+func UncoveredSince(runs []RunSummary) map[string]time.Time {
+	since := make(map[string]time.Time)
+	for _, run := range runs {
+		current := make(map[string]bool)
+		for _, name := range run.uncovered {
+			current[name] = true
+			if _, ok := since[name]; !ok {
+				since[name] = run.time
+			}
+		}
+		for name := range since {
+			if !current[name] {
+				delete(since, name)
+			}
+		}
+	}
+	return since
+}
+
+// PrintHistory is a function that writes the coverage trend of a device along with when each currently
+// uncovered server became uncovered. When a server name is given the state of that server in each run is shown,
+// which is absent for the runs where the device did not have it.
+func PrintHistory(w io.Writer, device string, runs []RunSummary, server string) {
+	fmt.Fprintf(w, "%s %s\n", Translate("device"), device)
+	previous := -1.0
+	for _, run := range runs {
+		if server != "" {
+			state := "absent"
+			switch {
+			case containsString(run.uncovered, server):
+				state = "uncovered"
+			case containsString(run.covered, server):
+				state = "covered"
+			}
+			fmt.Fprintf(w, "%s\t%s %s\n", run.time.Format(time.RFC3339), server, Translate(state))
+			continue
+		}
+		trend := ""
+		if previous >= 0 {
+			trend = fmt.Sprintf(" (%+.1f)", run.Coverage()-previous)
+		}
+		previous = run.Coverage()
+		fmt.Fprintf(w, "%s\t%s %.1f%%%s\t"+Translate("%d of %d servers uncovered")+"\n",
+			run.time.Format(time.RFC3339), Translate("coverage"), run.Coverage(), trend, len(run.uncovered), run.servers)
+	}
+	if server != "" {
+		return
+	}
+	since := UncoveredSince(runs)
+	var names []string
+	for name := range since {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s %s\t%s\n", Translate("uncovered since"), since[name].Format(time.RFC3339), name)
+	}
+}
+
+// containsString is a function that reports whether a string is present in an array of strings.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// RunHistory is a function that runs the history subcommand.
+func RunHistory(args []string) error {
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	path := flags.String("db", "history.db", "history store to read")
+	server := flags.String("server", "", "only show the coverage of this server")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return errors.New("usage: history [-db file] [-server name] [device]")
+	}
+	if _, err := os.Stat(*path); err != nil {
+		return err
+	}
+	store, err := OpenHistoryStore(*path)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	devices := flags.Args()
+	if len(devices) == 0 {
+		if devices, err = store.Devices(); err != nil {
+			return err
+		}
+	}
+	for i, device := range devices {
+		runs, err := store.Runs(device)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			fmt.Fprintln(os.Stdout, strings.Repeat("-", 40))
+		}
+		PrintHistory(os.Stdout, device, runs, *server)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package nsanalyze
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrintHistoryServer(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	runs := []RunSummary{
+		{device: "lb01", time: start, servers: 1, covered: []string{"a"}},
+		{device: "lb01", time: start.Add(time.Hour), servers: 2, covered: []string{"a", "b"}},
+		{device: "lb01", time: start.Add(2 * time.Hour), servers: 2, covered: []string{"a"}, uncovered: []string{"b"}},
+	}
+	var output bytes.Buffer
+	PrintHistory(&output, "lb01", runs, "b")
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	want := []string{"b absent", "b covered", "b uncovered"}
+	if len(lines) != len(want)+1 {
+		t.Fatalf("got %q, want a line per run", output.String())
+	}
+	for i, state := range want {
+		if !strings.HasSuffix(lines[i+1], "\t"+state) {
+			t.Errorf("line %d = %q, want %q", i+1, lines[i+1], state)
+		}
+	}
+}
+
+func TestSummarizeRunNamesServers(t *testing.T) {
+	fileName := writeConfig(t,
+		"add ns ip 10.0.0.10 255.255.255.0 -type SNIP",
+		"add server a 10.0.0.5",
+		"add server b 10.9.0.5")
+	summary, err := SummarizeRun(fileName, AnalyzeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(summary.covered, ",") != "a" || strings.Join(summary.uncovered, ",") != "b" {
+		t.Errorf("covered %v, uncovered %v, want a and b", summary.covered, summary.uncovered)
+	}
+}
+
+func TestHistoryStore(t *testing.T) {
+	store, err := OpenHistoryStore(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	later := RunSummary{device: "lb01", time: start.Add(time.Hour), servers: 2, covered: []string{"a"},
+		uncovered: []string{"b"}}
+	earlier := RunSummary{device: "lb01", time: start, servers: 1, covered: []string{"a"}}
+	for _, summary := range []RunSummary{later, earlier, {device: "lb02", time: start, servers: 0}} {
+		if err := store.Record(summary); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runs, err := store.Runs("lb01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []RunSummary{earlier, later}; !reflect.DeepEqual(runs, want) {
+		t.Errorf("runs = %+v, want %+v", runs, want)
+	}
+	if _, err := store.Runs("lb03"); err == nil {
+		t.Error("runs of an unknown device returned no error")
+	}
+}
@@ -0,0 +1,298 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// language is the language that report headings and labels are written in. It is selected once at startup,
+// before any analysis runs, and only read afterwards.
+var language = "en"
+
+// translations maps each supported language other than English to the translation of every report heading and
+// label, keyed by the English text. Labels without a translation are written in English.
+var translations = map[string]map[string]string{
+	"es": {
+		"interface":                      "interfaz",
+		"native vlan":                    "vlan nativa",
+		"allowed vlan":                   "vlan permitidas",
+		"subnets":                        "subredes",
+		"unknown":                        "desconocido",
+		"NETWORK":                        "RED",
+		"SIZE":                           "TAMAÑO",
+		"SERVERS":                        "SERVIDORES",
+		"USED":                           "USO",
+		"NEAR EXHAUSTION":                "CASI AGOTADA",
+		"KIND":                           "TIPO",
+		"NAME":                           "NOMBRE",
+		"ADDRESS":                        "DIRECCIÓN",
+		"CLASS":                          "CLASE",
+		"LINE":                           "LÍNEA",
+		"target(s) on uncovered servers": "destino(s) en servidores sin cobertura",
+		"targets of servers that are never added": "destinos de servidores que nunca se añaden",
+		"line":                       "línea",
+		"objects":                    "objetos",
+		"covered by":                 "cubierta por",
+		"routes":                     "rutas",
+		"policy prefix":              "prefijo de la política",
+		"no interfaces":              "ninguna interfaz",
+		"dependents":                 "dependientes",
+		"none":                       "ninguno",
+		"only on primary":            "solo en el primario",
+		"only on secondary":          "solo en el secundario",
+		"differs":                    "difiere",
+		"primary":                    "primario",
+		"secondary":                  "secundario",
+		"difference(s)":              "diferencia(s)",
+		"Coverage findings":          "Hallazgos de cobertura",
+		"finding(s)":                 "hallazgo(s)",
+		"Rule":                       "Regla",
+		"Severity":                   "Severidad",
+		"Message":                    "Mensaje",
+		"Object":                     "Objeto",
+		"Location":                   "Ubicación",
+		"device":                     "dispositivo",
+		"absent":                     "ausente",
+		"covered":                    "cubierto",
+		"uncovered":                  "sin cobertura",
+		"coverage":                   "cobertura",
+		"%d of %d servers uncovered": "%d de %d servidores sin cobertura",
+		"uncovered since":            "sin cobertura desde",
+		"unowned":                    "sin propietario",
+		"removed":                    "eliminado",
+		"servers":                    "servidores",
+		"dependent virtual servers":  "servidores virtuales dependientes",
+		"listen policies":            "políticas de escucha",
+		"failures":                   "fallos",
+		"tool version":               "versión de la herramienta",
+		"run at":                     "ejecutado el",
+		"input":                      "entrada",
+		"options":                    "opciones",
+		"unreadable":                 "ilegible",
+		"NAMES":                      "NOMBRES",
+		"APPLIANCES":                 "DISPOSITIVOS",
+		"UNCOVERED ON":               "SIN COBERTURA EN",
+		"Estate coverage":            "Cobertura del parque",
+		"configuration(s)":           "configuración(es)",
+		"Device":                     "Dispositivo",
+		"Configuration":              "Configuración",
+		"Servers":                    "Servidores",
+		"Uncovered":                  "Sin cobertura",
+		"Coverage":                   "Cobertura",
+		"Findings":                   "Hallazgos",
+		"Reports":                    "Informes",
+		"translation prefixes":       "prefijos de traducción",
+		"not translated by NAT64":    "no traducido por NAT64",
+		"NAT64 rules":                "reglas NAT64",
+		"source":                     "origen",
+		"before":                     "antes",
+		"after":                      "después",
+		"SNIPs added":                "SNIP añadidas",
+		"SNIPs removed":              "SNIP eliminadas",
+		"routes added":               "rutas añadidas",
+		"routes removed":             "rutas eliminadas",
+		"reaches uncovered":          "alcanza sin cobertura",
+		"newly uncovered":            "nuevos sin cobertura",
+		"newly covered":              "nuevos con cobertura",
+		"STATUS":                     "ESTADO",
+		"OWNER":                      "PROPIETARIO",
+		"suppressed":                 "suprimido",
+		"suppressed findings":        "hallazgos suprimidos",
+		"unresolved":                 "sin resolver",
+		"RPC nodes":                  "nodos RPC",
+		"routed":                     "enrutado",
+		"switch port of":             "puerto del switch de",
+		"not in IPAM":                "no está en el IPAM",
+		"mask mismatches":            "máscaras distintas",
+		"VLAN mismatches":            "VLAN distintas",
+		"not on the NetScaler":       "no está en el NetScaler",
+		"row":                        "fila",
+		"authentication servers":     "servidores de autenticación",
+		"matched":                    "coincide",
+		"no match":                   "no coincide",
+		"no networks to test":        "no hay redes que probar",
+		"values":                     "valores",
+		"DEPENDENTS":                 "DEPENDIENTES",
+		"blast radius":               "radio de impacto",
+		"comment":                    "comentario",
+		"certificate validation":     "validación de certificados",
+		"vlans":                      "vlans",
+		"addresses":                  "direcciones",
+		"route":                      "ruta",
+		"effective":                  "efectiva",
+		"connected":                  "conectada",
+		"of traffic":                 "del tráfico",
+		"not used":                   "no se usa",
+		"statistics":                 "estadísticas",
+		"configurations":             "configuraciones",
+		"lines":                      "líneas",
+		"lines scanned":              "líneas recorridas",
+		"parse time":                 "tiempo de análisis sintáctico",
+		"analysis time":              "tiempo de análisis",
+		"peak memory":                "memoria máxima",
+		"MAC mode servers not on a directly connected subnet": "servidores en modo MAC fuera de una subred conectada directamente",
+		"VIPs with ARP disabled on moving subnets":            "VIP con ARP desactivado en subredes que cambian de VLAN",
+		"ICMP disabled":    "ICMP desactivado",
+		"status":           "estado",
+		"succeeded":        "correctos",
+		"failed":           "fallidos",
+		"network":          "red",
+		"of":               "de",
+		"via":              "vía",
+		"VIPs":             "VIP",
+		"analyzed earlier": "analizados antes",
+	},
+	"de": {
+		"interface":                      "Schnittstelle",
+		"native vlan":                    "natives VLAN",
+		"allowed vlan":                   "erlaubte VLANs",
+		"subnets":                        "Subnetze",
+		"unknown":                        "unbekannt",
+		"NETWORK":                        "NETZWERK",
+		"SIZE":                           "GRÖSSE",
+		"SERVERS":                        "SERVER",
+		"USED":                           "BELEGT",
+		"NEAR EXHAUSTION":                "FAST ERSCHÖPFT",
+		"KIND":                           "ART",
+		"NAME":                           "NAME",
+		"ADDRESS":                        "ADRESSE",
+		"CLASS":                          "KLASSE",
+		"LINE":                           "ZEILE",
+		"target(s) on uncovered servers": "Ziel(e) auf nicht abgedeckten Servern",
+		"targets of servers that are never added": "Ziele von Servern, die nie hinzugefügt werden",
+		"line":                       "Zeile",
+		"objects":                    "Objekte",
+		"covered by":                 "abgedeckt durch",
+		"routes":                     "Routen",
+		"policy prefix":              "Präfix der Richtlinie",
+		"no interfaces":              "keine Schnittstellen",
+		"dependents":                 "abhängige Objekte",
+		"none":                       "keine",
+		"only on primary":            "nur auf dem Primärknoten",
+		"only on secondary":          "nur auf dem Sekundärknoten",
+		"differs":                    "abweichend",
+		"primary":                    "primär",
+		"secondary":                  "sekundär",
+		"difference(s)":              "Unterschied(e)",
+		"Coverage findings":          "Abdeckungsbefunde",
+		"finding(s)":                 "Befund(e)",
+		"Rule":                       "Regel",
+		"Severity":                   "Schweregrad",
+		"Message":                    "Meldung",
+		"Object":                     "Objekt",
+		"Location":                   "Ort",
+		"device":                     "Gerät",
+		"absent":                     "nicht vorhanden",
+		"covered":                    "abgedeckt",
+		"uncovered":                  "nicht abgedeckt",
+		"coverage":                   "Abdeckung",
+		"%d of %d servers uncovered": "%d von %d Servern nicht abgedeckt",
+		"uncovered since":            "nicht abgedeckt seit",
+		"unowned":                    "ohne Eigentümer",
+		"removed":                    "entfernt",
+		"servers":                    "Server",
+		"dependent virtual servers":  "abhängige virtuelle Server",
+		"listen policies":            "Listen-Richtlinien",
+		"failures":                   "Fehlschläge",
+		"tool version":               "Werkzeugversion",
+		"run at":                     "ausgeführt am",
+		"input":                      "Eingabe",
+		"options":                    "Optionen",
+		"unreadable":                 "unlesbar",
+		"NAMES":                      "NAMEN",
+		"APPLIANCES":                 "GERÄTE",
+		"UNCOVERED ON":               "NICHT ABGEDECKT AUF",
+		"Estate coverage":            "Abdeckung des Bestands",
+		"configuration(s)":           "Konfiguration(en)",
+		"Device":                     "Gerät",
+		"Configuration":              "Konfiguration",
+		"Servers":                    "Server",
+		"Uncovered":                  "Nicht abgedeckt",
+		"Coverage":                   "Abdeckung",
+		"Findings":                   "Befunde",
+		"Reports":                    "Berichte",
+		"translation prefixes":       "Übersetzungspräfixe",
+		"not translated by NAT64":    "nicht durch NAT64 übersetzt",
+		"NAT64 rules":                "NAT64-Regeln",
+		"source":                     "Quelle",
+		"before":                     "vorher",
+		"after":                      "nachher",
+		"SNIPs added":                "hinzugefügte SNIPs",
+		"SNIPs removed":              "entfernte SNIPs",
+		"routes added":               "hinzugefügte Routen",
+		"routes removed":             "entfernte Routen",
+		"reaches uncovered":          "erreicht nicht abgedeckten",
+		"newly uncovered":            "neu nicht abgedeckt",
+		"newly covered":              "neu abgedeckt",
+		"STATUS":                     "STATUS",
+		"OWNER":                      "EIGENTÜMER",
+		"suppressed":                 "unterdrückt",
+		"suppressed findings":        "unterdrückte Befunde",
+		"unresolved":                 "nicht aufgelöst",
+		"RPC nodes":                  "RPC-Knoten",
+		"routed":                     "geroutet",
+		"switch port of":             "Switch-Port von",
+		"not in IPAM":                "nicht im IPAM",
+		"mask mismatches":            "abweichende Masken",
+		"VLAN mismatches":            "abweichende VLANs",
+		"not on the NetScaler":       "nicht auf dem NetScaler",
+		"row":                        "Zeile",
+		"authentication servers":     "Authentifizierungsserver",
+		"matched":                    "passt",
+		"no match":                   "passt nicht",
+		"no networks to test":        "keine Netze zu prüfen",
+		"values":                     "Werte",
+		"DEPENDENTS":                 "ABHÄNGIGE",
+		"blast radius":               "Auswirkungsradius",
+		"comment":                    "Kommentar",
+		"certificate validation":     "Zertifikatsprüfung",
+		"vlans":                      "VLANs",
+		"addresses":                  "Adressen",
+		"route":                      "Route",
+		"effective":                  "wirksam",
+		"connected":                  "direkt verbunden",
+		"of traffic":                 "des Verkehrs",
+		"not used":                   "nicht verwendet",
+		"statistics":                 "Statistik",
+		"configurations":             "Konfigurationen",
+		"lines":                      "Zeilen",
+		"lines scanned":              "durchsuchte Zeilen",
+		"parse time":                 "Einlesezeit",
+		"analysis time":              "Analysezeit",
+		"peak memory":                "Spitzenspeicher",
+		"MAC mode servers not on a directly connected subnet": "Server im MAC-Modus außerhalb eines direkt verbundenen Subnetzes",
+		"VIPs with ARP disabled on moving subnets":            "VIPs mit deaktiviertem ARP in Subnetzen, die das VLAN wechseln",
+		"ICMP disabled":    "ICMP deaktiviert",
+		"status":           "Status",
+		"succeeded":        "erfolgreich",
+		"failed":           "fehlgeschlagen",
+		"network":          "Netz",
+		"of":               "von",
+		"via":              "über",
+		"VIPs":             "VIPs",
+		"analyzed earlier": "zuvor analysiert",
+	},
+}
+
+// SetLanguage is a function that selects the language that report headings and labels are written in.
+func SetLanguage(lang string) error {
+	if _, ok := translations[lang]; !ok && lang != "en" {
+		languages := []string{"en"}
+		for supported := range translations {
+			languages = append(languages, supported)
+		}
+		sort.Strings(languages)
+		return fmt.Errorf("unsupported language %q, use one of %s", lang, strings.Join(languages, ", "))
+	}
+	language = lang
+	return nil
+}
+
+// Translate is a function that returns a report heading or label in the selected language.
+func Translate(label string) string {
+	if translated, ok := translations[language][label]; ok {
+		return translated
+	}
+	return label
+}
@@ -0,0 +1,51 @@
+package nsanalyze
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTranslationsComplete(t *testing.T) {
+	for lang, catalog := range translations {
+		for other, otherCatalog := range translations {
+			for label := range otherCatalog {
+				if _, ok := catalog[label]; !ok {
+					t.Errorf("%q has a %s translation but no %s one", label, other, lang)
+				}
+			}
+		}
+	}
+}
+
+func TestTranslatedExplanation(t *testing.T) {
+	fileName := writeConfig(t,
+		"add ns ip 10.0.0.10 255.255.255.0 -type SNIP",
+		"bind vlan 20 -IPAddress 10.0.0.10 255.255.255.0",
+		"add route 10.0.0.0 255.255.0.0 10.0.0.1",
+	)
+	tests := []struct {
+		lang string
+		want []string
+	}{
+		{"en", []string{"SNIP network 10.0.0.0/24 of 10.0.0.10", "via 10.0.0.1", "vlans:"}},
+		{"es", []string{"SNIP red 10.0.0.0/24 de 10.0.0.10", "vía 10.0.0.1"}},
+		{"de", []string{"SNIP Netz 10.0.0.0/24 von 10.0.0.10", "über 10.0.0.1", "VLANs:"}},
+	}
+	defer SetLanguage("en")
+	for _, test := range tests {
+		t.Run(test.lang, func(t *testing.T) {
+			if err := SetLanguage(test.lang); err != nil {
+				t.Fatal(err)
+			}
+			var out strings.Builder
+			if err := PrintExplanation(&out, fileName, "10.0.0.5", AnalyzeOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range test.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("explanation lacks %q:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+}
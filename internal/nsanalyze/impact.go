@@ -0,0 +1,326 @@
+package nsanalyze
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+// Impact is a data structure for what would lose reachability if SNIPs were removed: the servers and VIPs that
+// are covered now but would not be afterwards, the virtual servers that depend on those servers, the virtual
+// servers whose listen policy refers to addresses or subnets that would no longer be covered, the NAT64 rules
+// whose translated traffic is sourced from a removed SNIP, the RPC nodes that would no longer be reached, the
+// authentication servers administrators would no longer be able to log on through and the OCSP responders and CRL
+// distribution points certificates would no longer be validated against.
+type Impact struct {
+	removed     []Snip
+	servers     []Server
+	vips        []LbVserver
+	vservers    []Node
+	listens     []ListenPolicy
+	nat64s      []Nat64
+	rpcNodes    []RpcNode
+	authServers []Endpoint
+	validations []Endpoint
+}
+
+// GetImpact is a function that recomputes coverage without the SNIP with the given address, or without every
+// SNIP bound to the given VLAN, and returns what would lose reachability.
+func GetImpact(fileName, removeSnip, removeVlan string, options AnalyzeOptions) (Impact, error) {
+	var impact Impact
+	snips, err := GetSourceSnips(fileName, options)
+	if err != nil {
+		return impact, err
+	}
+	var kept []Snip
+	for _, snip := range snips {
+		if (removeSnip != "" && snip.ipAddress == removeSnip) || (removeVlan != "" && snip.vlan == removeVlan) {
+			impact.removed = append(impact.removed, snip)
+		} else {
+			kept = append(kept, snip)
+		}
+	}
+	if len(impact.removed) == 0 && removeSnip != "" {
+		return impact, fmt.Errorf("%s: no SNIP %s", fileName, removeSnip)
+	}
+	if len(impact.removed) == 0 {
+		return impact, fmt.Errorf("%s: no SNIP is bound to vlan %s", fileName, removeVlan)
+	}
+	policyNetworks, err := options.policy.CoveringNetworks(fileName)
+	if err != nil {
+		return impact, err
+	}
+	before, err := GetNetworks(snips)
+	if err != nil {
+		return impact, err
+	}
+	after, err := GetNetworks(kept)
+	if err != nil {
+		return impact, err
+	}
+	before = append(before, policyNetworks...)
+	after = append(after, policyNetworks...)
+	servers, err := GetAnalysisServers(fileName, options)
+	if err != nil {
+		return impact, err
+	}
+	impact.servers = lostCoverage(before, after, servers)
+	vservers, err := GetLbVservers(fileName)
+	if err != nil {
+		return impact, err
+	}
+	var vips []Server
+	for _, vserver := range vservers {
+		if vserver.ipAddress != "" && ClassifyAddress(vserver.ipAddress) != ClassUnspecified {
+			vips = append(vips, Server{name: vserver.name, ipAddress: vserver.ipAddress, line: vserver.line})
+		}
+	}
+	lostVips := make(map[string]bool)
+	for _, vip := range lostCoverage(before, after, vips) {
+		lostVips[vip.name] = true
+	}
+	for _, vserver := range vservers {
+		if lostVips[vserver.name] {
+			impact.vips = append(impact.vips, vserver)
+		}
+	}
+	graph, err := GetGraph(fileName)
+	if err != nil {
+		return impact, err
+	}
+	seen := make(map[Node]bool)
+	for _, server := range impact.servers {
+		for _, node := range graph.Dependents(server.name) {
+			if node.kind == NodeLbVserver && !seen[node] {
+				seen[node] = true
+				impact.vservers = append(impact.vservers, node)
+			}
+		}
+	}
+	SortNodes(impact.vservers)
+	policies, err := GetListenPolicies(fileName)
+	if err != nil {
+		return impact, err
+	}
+	for _, policy := range policies {
+		uncoveredBefore := make(map[string]bool)
+		for _, network := range policy.UncoveredNetworks(before) {
+			uncoveredBefore[network.String()] = true
+		}
+		lost := policy
+		lost.networks = nil
+		for _, network := range policy.UncoveredNetworks(after) {
+			if !uncoveredBefore[network.String()] {
+				lost.networks = append(lost.networks, network)
+			}
+		}
+		if len(lost.networks) > 0 {
+			impact.listens = append(impact.listens, lost)
+		}
+	}
+	rules, err := GetNat64s(fileName)
+	if err != nil {
+		return impact, err
+	}
+	profiles, err := GetNetProfiles(fileName)
+	if err != nil {
+		return impact, err
+	}
+	for _, rule := range rules {
+		source := Nat64Source(rule, profiles)
+		for _, snip := range impact.removed {
+			if source != "" && SourcesFrom(source, snip.ipAddress) {
+				impact.nat64s = append(impact.nat64s, rule)
+				break
+			}
+		}
+	}
+	if impact.rpcNodes, err = lostRpcNodes(fileName, before, after, impact.removed); err != nil {
+		return impact, err
+	}
+	authServers, err := GetAuthenticationServers(fileName)
+	if err != nil {
+		return impact, err
+	}
+	impact.authServers = lostEndpoints(before, after, authServers)
+	validations, err := GetCertificateValidationEndpoints(fileName)
+	if err != nil {
+		return impact, err
+	}
+	impact.validations = lostEndpoints(before, after, validations)
+	return impact, nil
+}
+
+// lostEndpoints is a function that returns the endpoints covered by the networks before that are not covered by
+// the networks after.
+func lostEndpoints(before, after []*net.IPNet, endpoints []Endpoint) []Endpoint {
+	servers := make([]Server, len(endpoints))
+	for i, endpoint := range endpoints {
+		servers[i] = Server{name: endpoint.name, ipAddress: endpoint.ipAddress, line: endpoint.line}
+	}
+	coveredBefore := GetCoverage(before, servers)
+	coveredAfter := GetCoverage(after, servers)
+	var lost []Endpoint
+	for i, endpoint := range endpoints {
+		if coveredBefore[i] && !coveredAfter[i] {
+			lost = append(lost, endpoint)
+		}
+	}
+	return lost
+}
+
+// lostCoverage is a function that returns the servers covered by the networks before that are not covered by the
+// networks after.
+func lostCoverage(before, after []*net.IPNet, servers []Server) []Server {
+	uncoveredBefore := make(map[string]bool)
+	for _, server := range GetUncoveredServers(before, servers) {
+		uncoveredBefore[server.name] = true
+	}
+	var lost []Server
+	for _, server := range GetUncoveredServers(after, servers) {
+		if !uncoveredBefore[server.name] {
+			lost = append(lost, server)
+		}
+	}
+	return lost
+}
+
+// lostRpcNodes is a function that returns the RPC nodes reached with the networks before that would no longer be
+// reached with the networks after and without the removed SNIPs, which they may be sourced from.
+func lostRpcNodes(fileName string, before, after []*net.IPNet, removed []Snip) ([]RpcNode, error) {
+	nodes, err := GetRpcNodes(fileName)
+	if err != nil || len(nodes) == 0 {
+		return nil, err
+	}
+	if before, err = GetRpcNodeNetworks(fileName, before); err != nil {
+		return nil, err
+	}
+	if after, err = GetRpcNodeNetworks(fileName, after); err != nil {
+		return nil, err
+	}
+	owned, err := GetOwnedAddresses(fileName)
+	if err != nil {
+		return nil, err
+	}
+	routes, err := GetRoutes(fileName)
+	if err != nil {
+		return nil, err
+	}
+	unreachableBefore := make(map[string]bool)
+	unreachable, _ := UnreachableRpcNodes(nodes, before, routes, owned)
+	for _, node := range unreachable {
+		unreachableBefore[node.ipAddress] = true
+	}
+	for _, snip := range removed {
+		delete(owned, snip.ipAddress)
+	}
+	var lost []RpcNode
+	unreachable, _ = UnreachableRpcNodes(nodes, after, routes, owned)
+	for _, node := range unreachable {
+		if !unreachableBefore[node.ipAddress] {
+			lost = append(lost, node)
+		}
+	}
+	return lost, nil
+}
+
+// PrintImpact is a function that writes the SNIPs removed and everything that would lose reachability.
+func PrintImpact(w io.Writer, impact Impact) {
+	fmt.Fprintf(w, "%s:\n", Translate("removed"))
+	for _, snip := range impact.removed {
+		fmt.Fprintf(w, "\t%s %s %s (%s %d)\n", snip.ipType, snip.ipAddress, snip.subnetMask, Translate("line"), snip.line)
+	}
+	fmt.Fprintf(w, "%s:\n", Translate("servers"))
+	if len(impact.servers) == 0 {
+		fmt.Fprintf(w, "\t%s\n", Translate("none"))
+	}
+	for _, server := range impact.servers {
+		fmt.Fprintf(w, "\t%s (%s %d)\n", server.Describe(), Translate("line"), server.line)
+	}
+	fmt.Fprintf(w, "%s:\n", Translate("VIPs"))
+	if len(impact.vips) == 0 {
+		fmt.Fprintf(w, "\t%s\n", Translate("none"))
+	}
+	for _, vip := range impact.vips {
+		fmt.Fprintf(w, "\t%s %s (%s %d)\n", vip.name, vip.ipAddress, Translate("line"), vip.line)
+	}
+	fmt.Fprintf(w, "%s:\n", Translate("dependent virtual servers"))
+	if len(impact.vservers) == 0 {
+		fmt.Fprintf(w, "\t%s\n", Translate("none"))
+	}
+	for _, node := range impact.vservers {
+		fmt.Fprintf(w, "\t%s %s\n", node.kind, node.name)
+	}
+	fmt.Fprintf(w, "%s:\n", Translate("listen policies"))
+	if len(impact.listens) == 0 {
+		fmt.Fprintf(w, "\t%s\n", Translate("none"))
+	}
+	for _, policy := range impact.listens {
+		var networks []string
+		for _, network := range policy.networks {
+			networks = append(networks, DescribeNetwork(network))
+		}
+		fmt.Fprintf(w, "\t%s %s: %s (%s %d)\n", NodeLbVserver, policy.vserverName, strings.Join(networks, ", "),
+			Translate("line"), policy.line)
+	}
+	fmt.Fprintf(w, "%s:\n", Translate("NAT64 rules"))
+	if len(impact.nat64s) == 0 {
+		fmt.Fprintf(w, "\t%s\n", Translate("none"))
+	}
+	for _, rule := range impact.nat64s {
+		fmt.Fprintf(w, "\t%s acl6 %s netProfile %s (%s %d)\n", rule.name, rule.acl6Name, rule.netProfile,
+			Translate("line"), rule.line)
+	}
+	fmt.Fprintf(w, "%s:\n", Translate("authentication servers"))
+	if len(impact.authServers) == 0 {
+		fmt.Fprintf(w, "\t%s\n", Translate("none"))
+	}
+	for _, authServer := range impact.authServers {
+		fmt.Fprintf(w, "\t%s %s (%s %d)\n", authServer.name, authServer.ipAddress, Translate("line"), authServer.line)
+	}
+	fmt.Fprintf(w, "%s:\n", Translate("certificate validation"))
+	if len(impact.validations) == 0 {
+		fmt.Fprintf(w, "\t%s\n", Translate("none"))
+	}
+	for _, validation := range impact.validations {
+		fmt.Fprintf(w, "\t%s %s %s (%s %d)\n", validation.kind, validation.name, validation.ipAddress,
+			Translate("line"), validation.line)
+	}
+	fmt.Fprintf(w, "%s:\n", Translate("RPC nodes"))
+	if len(impact.rpcNodes) == 0 {
+		fmt.Fprintf(w, "\t%s\n", Translate("none"))
+	}
+	for _, node := range impact.rpcNodes {
+		fmt.Fprintf(w, "\t%s", node.ipAddress)
+		if node.sourceIP != "" {
+			fmt.Fprintf(w, " %s %s", Translate("source"), node.sourceIP)
+		}
+		if node.secure {
+			fmt.Fprint(w, " secure")
+		}
+		fmt.Fprintf(w, " (%s %d)\n", Translate("line"), node.line)
+	}
+}
+
+// RunImpact is a function that runs the impact subcommand.
+func RunImpact(args []string, options AnalyzeOptions) error {
+	flags := flag.NewFlagSet("impact", flag.ContinueOnError)
+	removeSnip := flags.String("remove-snip", "", "address of the SNIP to remove")
+	removeVlan := flags.String("remove-vlan", "", "VLAN whose SNIPs to remove")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || (*removeSnip == "") == (*removeVlan == "") {
+		return errors.New("usage: impact -remove-snip ip|-remove-vlan id filename")
+	}
+	impact, err := GetImpact(flags.Arg(0), *removeSnip, *removeVlan, options)
+	if err != nil {
+		return err
+	}
+	PrintImpact(os.Stdout, impact)
+	return nil
+}
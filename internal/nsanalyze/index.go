@@ -0,0 +1,224 @@
+package nsanalyze
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// defaultIndexFile is the index written when directories of configurations are analyzed without -index.
+const defaultIndexFile = "index.html"
+
+// IndexEntry is a data structure for a configuration of a multi-file run as listed in the index: the summary of
+// its coverage, its findings and the reports written for it, or the error it could not be analyzed with.
+type IndexEntry struct {
+	fileName string
+	summary  RunSummary
+	findings []Finding
+	reports  []string
+	err      error
+}
+
+// indexJSON is the JSON representation of the index of a multi-file run.
+type indexJSON struct {
+	Metadata *metadataJSON     `json:"metadata,omitempty"`
+	Devices  []indexDeviceJSON `json:"devices"`
+}
+
+// indexDeviceJSON is the JSON representation of a configuration within the index.
+type indexDeviceJSON struct {
+	Device        string            `json:"device"`
+	Configuration string            `json:"configuration"`
+	Servers       int               `json:"servers"`
+	Uncovered     int               `json:"uncovered"`
+	Coverage      float64           `json:"coverage"`
+	Findings      int               `json:"findings"`
+	Errors        int               `json:"errors"`
+	Warnings      int               `json:"warnings"`
+	Reports       map[string]string `json:"reports"`
+	Error         string            `json:"error,omitempty"`
+}
+
+// ExpandConfigPaths is a function that replaces every directory among the configurations given with the files
+// directly within it, in name order, and reports whether there was a directory. Hidden files, subdirectories and
+// the reports of earlier runs are left out. Anything that is not a local directory is kept as given.
+func ExpandConfigPaths(fileNames []string) ([]string, bool, error) {
+	var expanded []string
+	directories := false
+	for _, fileName := range fileNames {
+		info, err := os.Stat(fileName)
+		if err != nil || !info.IsDir() {
+			expanded = append(expanded, fileName)
+			continue
+		}
+		directories = true
+		entries, err := os.ReadDir(fileName)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || isGeneratedReport(entry.Name()) {
+				continue
+			}
+			expanded = append(expanded, filepath.Join(fileName, entry.Name()))
+		}
+	}
+	return expanded, directories, nil
+}
+
+// isGeneratedReport is a function that reports whether a file name is that of a report written next to a
+// configuration by an earlier run, so that analyzing a directory again does not pick it up as a configuration.
+func isGeneratedReport(name string) bool {
+	return strings.Contains(name, "-findings.") || strings.HasSuffix(name, "-server-output.txt") ||
+		strings.HasSuffix(name, "-covered-output.txt")
+}
+
+// NewIndexDevice is a function that returns the JSON representation of an index entry, with the links to its
+// reports made relative to the directory of the index.
+func NewIndexDevice(entry IndexEntry, indexDir string) indexDeviceJSON {
+	device := indexDeviceJSON{
+		Device:        entry.summary.device,
+		Configuration: redactSourceName(entry.fileName),
+		Servers:       entry.summary.servers,
+		Uncovered:     len(entry.summary.uncovered),
+		Coverage:      entry.summary.Coverage(),
+		Findings:      len(ReportedFindings(entry.findings)),
+		Reports:       map[string]string{},
+	}
+	if device.Device == "" {
+		device.Device = filepath.Base(entry.fileName)
+	}
+	for _, finding := range ReportedFindings(entry.findings) {
+		switch finding.rule.severity {
+		case "error":
+			device.Errors++
+		case "warning":
+			device.Warnings++
+		}
+	}
+	for _, report := range entry.reports {
+		link := report
+		if absolute, err := filepath.Abs(report); err == nil {
+			if relative, err := filepath.Rel(indexDir, absolute); err == nil {
+				link = relative
+			}
+		}
+		device.Reports[reportFormat(report)] = filepath.ToSlash(link)
+	}
+	if entry.err != nil {
+		device.Error = redactSourceNames(entry.err.Error())
+		device.Coverage = 0
+	}
+	return device
+}
+
+// reportFormat is a function that returns the name a report is linked under in the index: "uncovered" and
+// "covered" for the text output files, which share an extension, and the extension for the others.
+func reportFormat(report string) string {
+	switch {
+	case strings.HasSuffix(report, "-server-output.txt"):
+		return "uncovered"
+	case strings.HasSuffix(report, "-covered-output.txt"):
+		return "covered"
+	}
+	return strings.TrimPrefix(filepath.Ext(report), ".")
+}
+
+// indexPage is the template for the HTML index, which receives the metadata lines of the run and the entries
+// converted to indexDeviceJSON.
+var indexPage = template.Must(template.New("index").Funcs(template.FuncMap{
+	"t":       Translate,
+	"formats": sortedKeys,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{t "Estate coverage"}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+td.number { text-align: right; }
+.failed { color: #b00; }
+.metadata { color: #555; font-size: smaller; }
+</style>
+</head>
+<body>
+<h1>{{t "Estate coverage"}}</h1>
+{{with .Metadata}}<ul class="metadata">
+{{range .}}<li>{{.}}</li>
+{{end}}</ul>
+{{end}}<p>{{len .Devices}} {{t "configuration(s)"}}</p>
+<table>
+<tr><th>{{t "Device"}}</th><th>{{t "Configuration"}}</th><th>{{t "Servers"}}</th><th>{{t "Uncovered"}}</th><th>{{t "Coverage"}}</th><th>{{t "Findings"}}</th><th>{{t "Reports"}}</th></tr>
+{{range .Devices}}{{if .Error}}<tr class="failed"><td>{{.Device}}</td><td>{{.Configuration}}</td><td colspan="5">{{t "unreadable"}}: {{.Error}}</td></tr>
+{{else}}<tr><td>{{.Device}}</td><td>{{.Configuration}}</td><td class="number">{{.Servers}}</td><td class="number">{{.Uncovered}}</td><td class="number">{{printf "%.1f%%" .Coverage}}</td><td class="number">{{.Findings}}</td><td>{{$reports := .Reports}}{{range formats .Reports}}<a href="{{index $reports .}}">{{.}}</a> {{end}}</td></tr>
+{{end}}{{end}}</table>
+</body>
+</html>
+`))
+
+// sortedKeys is a function that returns the keys of a map in order.
+func sortedKeys(values map[string]string) []string {
+	var keys []string
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// WriteIndex is a function that writes the index of a multi-file run, linking the reports of every configuration
+// along with its headline coverage numbers. An index whose name ends in .json is written as JSON, any other as an
+// HTML page.
+func WriteIndex(fileName string, metadata *RunMetadata, entries []IndexEntry) error {
+	indexDir, err := filepath.Abs(filepath.Dir(fileName))
+	if err != nil {
+		return err
+	}
+	devices := []indexDeviceJSON{}
+	for _, entry := range entries {
+		devices = append(devices, NewIndexDevice(entry, indexDir))
+	}
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	RecordGeneratedFile(fileName)
+	if strings.EqualFold(filepath.Ext(fileName), ".json") {
+		err = writeIndexJSON(file, metadata, devices)
+	} else {
+		err = writeIndexHTML(file, metadata, devices)
+	}
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("%s: %v", fileName, err)
+	}
+	return file.Close()
+}
+
+// writeIndexJSON is a function that writes the index of a multi-file run as a JSON document.
+func writeIndexJSON(w io.Writer, metadata *RunMetadata, devices []indexDeviceJSON) error {
+	document := indexJSON{Metadata: newMetadataJSON(metadata), Devices: devices}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+// writeIndexHTML is a function that writes the index of a multi-file run as a standalone HTML page.
+func writeIndexHTML(w io.Writer, metadata *RunMetadata, devices []indexDeviceJSON) error {
+	var page struct {
+		Metadata []string
+		Devices  []indexDeviceJSON
+	}
+	if metadata != nil {
+		page.Metadata = metadata.Lines()
+	}
+	page.Devices = devices
+	return indexPage.Execute(w, page)
+}
@@ -0,0 +1,265 @@
+package nsanalyze
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Interface is a data structure for NetScaler interface data. Channels count as interfaces. An MTU of zero means
+// the MTU is not set, so the interface uses the default of 1500 bytes. The trunk, tag all and HA monitoring
+// settings are ON or OFF as set, and empty when the configuration leaves them at their default.
+type Interface struct {
+	name      string
+	alias     string
+	lldpMode  string
+	mtu       int
+	trunk     string
+	tagAll    string
+	haMonitor string
+	line      int
+}
+
+// Vlan is a data structure for NetScaler VLAN data. An MTU of zero means the MTU is not set, so the VLAN uses the
+// MTU of the interfaces it is bound to. The alias is the name given through -aliasName, such as DMZ-Web.
+type Vlan struct {
+	id    string
+	mtu   int
+	alias string
+	line  int
+}
+
+// Describe is a function that returns the ID of the VLAN followed by its alias name when it has one, such as
+// "120 (DMZ-Web)", which is how network engineers refer to it.
+func (vlan Vlan) Describe() string {
+	if vlan.alias == "" {
+		return vlan.id
+	}
+	return vlan.id + " (" + vlan.alias + ")"
+}
+
+// VlanBinding is a data structure for the binding of a NetScaler VLAN to an interface. The NSVLAN set through
+// "set ns config -nsvlan" is represented as a binding as well.
+type VlanBinding struct {
+	vlanID        string
+	interfaceName string
+	tagged        bool
+	nsvlan        bool
+	line          int
+}
+
+// GetInterfaces is a function that accepts a file name as a parameter for input and then returns an array of
+// interfaces, including the channels added to the configuration. Interfaces that are only referenced by VLAN
+// bindings are included with an empty alias.
+func GetInterfaces(fileName string) ([]Interface, error) {
+	return cachedModel(fileName, "interfaces", parseInterfaces)
+}
+
+// parseInterfaces is a function that parses the interfaces of a configuration for GetInterfaces.
+func parseInterfaces(fileName string) ([]Interface, error) {
+	var interfaces []Interface
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	setInterfaceLines, err := GetConfigLines(file, "(set interface |(add|set) channel ).*")
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]int)
+	for _, setInterfaceLine := range setInterfaceLines {
+		fields := SplitConfigLine(setInterfaceLine.text)
+		if len(fields) < 3 {
+			continue
+		}
+		var iface Interface
+		iface.name = fields[2]
+		iface.alias = GetOption(fields, "-ifAlias")
+		iface.lldpMode = strings.ToUpper(GetOption(fields, "-lldpmode"))
+		iface.mtu, _ = strconv.Atoi(GetOption(fields, "-mtu"))
+		iface.trunk = strings.ToUpper(GetOption(fields, "-trunk"))
+		iface.tagAll = strings.ToUpper(GetOption(fields, "-tagall"))
+		iface.haMonitor = strings.ToUpper(GetOption(fields, "-haMonitor"))
+		iface.line = setInterfaceLine.number
+		if index, ok := seen[iface.name]; ok {
+			if iface.alias != "" {
+				interfaces[index].alias = iface.alias
+			}
+			if iface.lldpMode != "" {
+				interfaces[index].lldpMode = iface.lldpMode
+			}
+			if iface.trunk != "" {
+				interfaces[index].trunk = iface.trunk
+			}
+			if iface.tagAll != "" {
+				interfaces[index].tagAll = iface.tagAll
+			}
+			if iface.haMonitor != "" {
+				interfaces[index].haMonitor = iface.haMonitor
+			}
+			if iface.mtu != 0 {
+				interfaces[index].mtu = iface.mtu
+				interfaces[index].line = iface.line
+			}
+			continue
+		}
+		seen[iface.name] = len(interfaces)
+		interfaces = append(interfaces, iface)
+	}
+	bindings, err := GetVlanBindings(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, binding := range bindings {
+		if _, ok := seen[binding.interfaceName]; ok {
+			continue
+		}
+		seen[binding.interfaceName] = len(interfaces)
+		interfaces = append(interfaces, Interface{name: binding.interfaceName, line: binding.line})
+	}
+	sort.Slice(interfaces, func(i, j int) bool {
+		return interfaces[i].name < interfaces[j].name
+	})
+	return interfaces, nil
+}
+
+// GetVlanBindings is a function that accepts a file name as a parameter for input and then returns an array of
+// VLAN to interface bindings, including the bindings of the NSVLAN.
+func GetVlanBindings(fileName string) ([]VlanBinding, error) {
+	return cachedModel(fileName, "VLAN bindings", parseVlanBindings)
+}
+
+// parseVlanBindings is a function that parses the VLAN bindings of a configuration for GetVlanBindings.
+func parseVlanBindings(fileName string) ([]VlanBinding, error) {
+	var bindings []VlanBinding
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	bindVlanLines, err := GetConfigLines(file, "(bind vlan ).*")
+	if err != nil {
+		return nil, err
+	}
+	for _, bindVlanLine := range bindVlanLines {
+		vlanLine := RemoveConfigKeywords(bindVlanLine.text, "bind vlan ")
+		fields := SplitConfigLine(vlanLine)
+		interfaceName := GetOption(fields, "-ifnum")
+		if len(fields) == 0 || interfaceName == "" {
+			continue
+		}
+		var binding VlanBinding
+		binding.vlanID = fields[0]
+		binding.interfaceName = interfaceName
+		binding.tagged = HasOption(fields, "-tagged")
+		binding.line = bindVlanLine.number
+		bindings = append(bindings, binding)
+	}
+	nsConfigLines, err := GetConfigLines(file, "(set ns config ).*")
+	if err != nil {
+		return nil, err
+	}
+	for _, nsConfigLine := range nsConfigLines {
+		fields := SplitConfigLine(nsConfigLine.text)
+		vlanID := GetOption(fields, "-nsvlan")
+		if vlanID == "" {
+			continue
+		}
+		for _, interfaceName := range GetOptionValues(fields, "-ifnum") {
+			bindings = append(bindings, VlanBinding{
+				vlanID:        vlanID,
+				interfaceName: interfaceName,
+				tagged:        strings.EqualFold(GetOption(fields, "-tagged"), "YES"),
+				nsvlan:        true,
+				line:          nsConfigLine.number,
+			})
+		}
+	}
+	return bindings, nil
+}
+
+// GetVlans is a function that accepts a file name as a parameter for input and then returns an array of the
+// VLANs added to the configuration, with the MTU given when they are added or set later.
+func GetVlans(fileName string) ([]Vlan, error) {
+	return cachedModel(fileName, "VLANs", parseVlans)
+}
+
+// parseVlans is a function that parses the VLANs of a configuration for GetVlans.
+func parseVlans(fileName string) ([]Vlan, error) {
+	var vlans []Vlan
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	vlanLines, err := GetConfigLines(file, "((add|set) vlan ).*")
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]int)
+	for _, vlanLine := range vlanLines {
+		fields := SplitConfigLine(vlanLine.text)
+		if len(fields) < 3 {
+			continue
+		}
+		mtu, _ := strconv.Atoi(GetOption(fields, "-mtu"))
+		alias := GetOption(fields, "-aliasName")
+		if fields[0] == "set" {
+			if i, ok := index[fields[2]]; ok {
+				if mtu != 0 {
+					vlans[i].mtu = mtu
+				}
+				if alias != "" {
+					vlans[i].alias = alias
+				}
+			}
+			continue
+		}
+		index[fields[2]] = len(vlans)
+		vlans = append(vlans, Vlan{id: fields[2], mtu: mtu, alias: alias, line: vlanLine.number})
+	}
+	return vlans, nil
+}
+
+// GetVlanNames is a function that accepts a file name as a parameter for input and then returns the VLANs of the
+// configuration by ID, so that reports can describe a VLAN they only know the ID of by its alias name as well.
+func GetVlanNames(fileName string) (map[string]Vlan, error) {
+	vlans, err := GetVlans(fileName)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]Vlan)
+	for _, vlan := range vlans {
+		names[vlan.id] = vlan
+	}
+	return names, nil
+}
+
+// DescribeVlan is a function that returns a VLAN ID followed by the alias name of the VLAN when it has one. VLANs
+// the configuration does not add, such as the default VLAN 1, are described by their ID alone.
+func DescribeVlan(vlanID string, names map[string]Vlan) string {
+	if vlan, ok := names[vlanID]; ok {
+		return vlan.Describe()
+	}
+	return vlanID
+}
+
+// GetDefinedChannels is a function that accepts a file name as a parameter for input and then returns the names
+// of the link aggregation channels that the configuration adds, or forms through the LACP settings of their
+// members.
+func GetDefinedChannels(fileName string) (map[string]bool, error) {
+	channels, err := GetChannels(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defined := make(map[string]bool)
+	for _, channel := range channels {
+		defined[channel.name] = true
+	}
+	return defined, nil
+}
+
+// IsChannelInterface is a function that reports whether an interface name is that of a link aggregation or
+// redundant interface channel, which only exists once the configuration adds it, unlike a physical interface.
+func IsChannelInterface(name string) bool {
+	prefix := strings.ToUpper(name)
+	return strings.HasPrefix(prefix, "LA/") || strings.HasPrefix(prefix, "LR/")
+}
@@ -0,0 +1,87 @@
+package nsanalyze
+
+import (
+	"net"
+	"strings"
+)
+
+// Snip6 is a data structure for NetScaler IPv6 address data added through "add ns ip6", which carries its
+// prefix length along with the address.
+type Snip6 struct {
+	ipAddress    string
+	prefixLength string
+	ipType       string
+	vlan         string
+	td           string
+	line         int
+}
+
+// Network is a function that returns the network of the IPv6 address, or nil when the prefix is not valid.
+func (snip Snip6) Network() *net.IPNet {
+	_, network, err := net.ParseCIDR(snip.ipAddress + "/" + snip.prefixLength)
+	if err != nil {
+		return nil
+	}
+	return network
+}
+
+// GetSnip6s is a function that accepts a file name as a parameter for input and then returns an array of IPv6
+// addresses, with the VLAN taken from either the "-vlan" option or an IPv6 VLAN binding.
+func GetSnip6s(fileName string) ([]Snip6, error) {
+	var snips []Snip6
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	addNsIp6Lines, err := GetConfigLines(file, "(add ns ip6 ).*")
+	if err != nil {
+		return nil, err
+	}
+	for _, addNsIp6Line := range addNsIp6Lines {
+		fields := SplitConfigLine(RemoveConfigKeywords(addNsIp6Line.text, "add ns ip6 "))
+		if len(fields) == 0 {
+			continue
+		}
+		var snip Snip6
+		snip.ipAddress, snip.prefixLength = splitPrefix(fields[0])
+		snip.ipType = strings.ToUpper(GetOption(fields, "-type"))
+		if snip.ipType == "" {
+			snip.ipType = "SNIP"
+		}
+		snip.vlan = GetOption(fields, "-vlan")
+		snip.td = GetOption(fields, "-td")
+		if snip.td == "" {
+			snip.td = "0"
+		}
+		snip.line = addNsIp6Line.number
+		snips = append(snips, snip)
+	}
+	index := make(map[string]int)
+	for i, snip := range snips {
+		index[snip.ipAddress] = i
+	}
+	bindVlanLines, err := GetConfigLines(file, "(bind vlan ).*")
+	if err != nil {
+		return nil, err
+	}
+	for _, bindVlanLine := range bindVlanLines {
+		fields := SplitConfigLine(RemoveConfigKeywords(bindVlanLine.text, "bind vlan "))
+		ipAddress, prefixLength := splitPrefix(GetOption(fields, "-IPAddress"))
+		if i, ok := index[ipAddress]; ok && prefixLength != "" {
+			snips[i].vlan = fields[0]
+		}
+	}
+	return snips, nil
+}
+
+// splitPrefix is a function that splits an address in prefix notation into the address and the prefix length.
+// IPv6 addresses without a prefix length default to /64, the prefix length the NetScaler assumes.
+func splitPrefix(address string) (string, string) {
+	if i := strings.Index(address, "/"); i >= 0 {
+		return address[:i], address[i+1:]
+	}
+	if strings.Contains(address, ":") {
+		return address, "64"
+	}
+	return address, ""
+}
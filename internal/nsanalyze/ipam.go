@@ -0,0 +1,319 @@
+package nsanalyze
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// IpamSubnet is a data structure for a subnet that the IPAM is authoritative for, along with the VLAN it is
+// assigned to when the IPAM records one.
+type IpamSubnet struct {
+	network *net.IPNet
+	vlan    string
+	row     int
+}
+
+// ipamColumns holds the header names the columns of an IPAM export are recognized by, in lower case.
+var ipamColumns = map[string][]string{
+	"subnet": {"subnet", "network", "cidr", "prefix", "subnet address"},
+	"mask":   {"mask", "netmask", "prefix length", "bits"},
+	"vlan":   {"vlan", "vlan id", "vlanid", "vlan_id", "vlan number"},
+}
+
+// ipamLayout is a data structure for the columns of an IPAM export holding the subnet, its mask when the subnet
+// column holds the bare network address, and its VLAN ID. A column that is not there is -1.
+type ipamLayout struct {
+	subnet int
+	mask   int
+	vlan   int
+}
+
+// newIpamLayout is a function that returns the columns of an IPAM export from its header row, matching the
+// names case insensitively, and reports whether the row is a header naming a subnet column at all.
+func newIpamLayout(header []string) (ipamLayout, bool) {
+	layout := ipamLayout{subnet: -1, mask: -1, vlan: -1}
+	columns := map[string]*int{"subnet": &layout.subnet, "mask": &layout.mask, "vlan": &layout.vlan}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		for column, names := range ipamColumns {
+			if *columns[column] == -1 && containsString(names, name) {
+				*columns[column] = i
+			}
+		}
+	}
+	return layout, layout.subnet != -1
+}
+
+// field is a function that returns a column of a row, which is empty when the row is too short or the column is
+// not there.
+func (layout ipamLayout) field(record []string, column int) string {
+	if column < 0 || column >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[column])
+}
+
+// LoadIpamSubnets is a function that reads the subnets exported from an IPAM or a spreadsheet as CSV. A header
+// row names the columns holding the subnet, either in CIDR notation or as a network address with its mask in a
+// column of its own, and optionally the VLAN ID, in any order and along with any other columns, such as the
+// descriptions exports carry. Without a header row the subnet in CIDR notation is the first column and the VLAN
+// ID the second. Files that separate columns with semicolons, as spreadsheets do in some locales, are read as well.
+func LoadIpamSubnets(fileName string) ([]IpamSubnet, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	buffered := bufio.NewReader(file)
+	start, _ := buffered.Peek(4096)
+	reader := csv.NewReader(buffered)
+	if firstLine, _, _ := strings.Cut(string(start), "\n"); strings.Contains(firstLine, ";") && !strings.Contains(firstLine, ",") {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	layout := ipamLayout{subnet: 0, mask: -1, vlan: 1}
+	var subnets []IpamSubnet
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return subnets, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", fileName, err)
+		}
+		if row == 1 {
+			if header, ok := newIpamLayout(record); ok {
+				layout = header
+				continue
+			}
+		}
+		cidr := layout.field(record, layout.subnet)
+		if cidr == "" {
+			continue
+		}
+		if mask := layout.field(record, layout.mask); mask != "" && !strings.Contains(cidr, "/") {
+			cidr += "/" + ipamPrefixLength(mask)
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			if row == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("%s: row %d: %v", fileName, row, err)
+		}
+		subnets = append(subnets, IpamSubnet{network: network, vlan: layout.field(record, layout.vlan), row: row})
+	}
+}
+
+// ipamPrefixLength is a function that returns the prefix length of a mask column, which holds either the prefix
+// length itself or a dotted netmask.
+func ipamPrefixLength(mask string) string {
+	mask = strings.TrimPrefix(mask, "/")
+	if ip := net.ParseIP(mask).To4(); ip != nil {
+		if ones, bits := net.IPMask(ip).Size(); bits != 0 {
+			return strconv.Itoa(ones)
+		}
+	}
+	return mask
+}
+
+// ConfigSubnet is a data structure for a subnet the NetScaler has an address in, along with the VLAN the
+// address is bound to.
+type ConfigSubnet struct {
+	network   *net.IPNet
+	vlan      string
+	ipAddress string
+	line      int
+}
+
+// GetConfigSubnets is a function that accepts a file name as a parameter for input and then returns the IPv4
+// and IPv6 subnets of the NSIP, SNIPs and MIPs of the configuration, once per subnet. VIPs are left out, as they
+// are host addresses within subnets rather than subnets of their own.
+func GetConfigSubnets(fileName string) ([]ConfigSubnet, error) {
+	var subnets []ConfigSubnet
+	seen := make(map[string]bool)
+	add := func(subnet ConfigSubnet) {
+		if !seen[subnet.network.String()] {
+			seen[subnet.network.String()] = true
+			subnets = append(subnets, subnet)
+		}
+	}
+	snips, err := GetSnips(fileName)
+	if err != nil {
+		return nil, err
+	}
+	nsip, found, err := GetNsip(fileName)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		snips = append(snips, nsip)
+	}
+	validSnips, _ := FilterValidSnips(snips)
+	for _, snip := range validSnips {
+		if snip.ipType != "SNIP" && snip.ipType != "MIP" && snip.ipType != "NSIP" {
+			continue
+		}
+		networks, err := GetNetworks([]Snip{snip})
+		if err != nil {
+			return nil, err
+		}
+		add(ConfigSubnet{network: networks[0], vlan: snip.vlan, ipAddress: snip.ipAddress, line: snip.line})
+	}
+	snip6s, err := GetSnip6s(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, snip := range snip6s {
+		if network := snip.Network(); network != nil && snip.ipType != "VIP" {
+			add(ConfigSubnet{network: network, vlan: snip.vlan, ipAddress: snip.ipAddress, line: snip.line})
+		}
+	}
+	return subnets, nil
+}
+
+// SubnetMismatch is a data structure for a subnet of the configuration along with the IPAM subnet it disagrees
+// with.
+type SubnetMismatch struct {
+	subnet ConfigSubnet
+	ipam   IpamSubnet
+}
+
+// IpamDiscrepancies is a data structure for the differences between the subnets of the IPAM and those the
+// NetScaler has addresses in: subnets the IPAM does not know, subnets known with another prefix length or VLAN,
+// and IPAM subnets the NetScaler has no address in.
+type IpamDiscrepancies struct {
+	notInIpam      []ConfigSubnet
+	maskMismatches []SubnetMismatch
+	vlanMismatches []SubnetMismatch
+	notInConfig    []IpamSubnet
+}
+
+// CompareIpam is a function that returns the discrepancies between the subnets of the IPAM and those of the
+// configuration. A subnet of the configuration within a larger IPAM subnet is present in the IPAM, as IPAMs often
+// hold supernets that are split further on the network, and counts against the most specific of them. A subnet of
+// the configuration holding a smaller IPAM subnet has a mask mismatch. VLANs are only compared for subnets known
+// with the same prefix length, when both sides record one.
+func CompareIpam(ipam []IpamSubnet, subnets []ConfigSubnet) IpamDiscrepancies {
+	var discrepancies IpamDiscrepancies
+	used := make(map[int]bool)
+	for _, subnet := range subnets {
+		exact, supernet, within := -1, -1, -1
+		for j, ipamSubnet := range ipam {
+			switch {
+			case ipamSubnet.network.String() == subnet.network.String():
+				exact = j
+			case containsNetwork(ipamSubnet.network, subnet.network):
+				if supernet == -1 || containsNetwork(ipam[supernet].network, ipamSubnet.network) {
+					supernet = j
+				}
+			case within == -1 && subnet.network.Contains(ipamSubnet.network.IP):
+				within = j
+			}
+			if exact != -1 {
+				break
+			}
+		}
+		switch {
+		case exact != -1:
+			used[exact] = true
+			if ipam[exact].vlan != "" && subnet.vlan != "" && ipam[exact].vlan != subnet.vlan {
+				discrepancies.vlanMismatches = append(discrepancies.vlanMismatches, SubnetMismatch{subnet, ipam[exact]})
+			}
+		case supernet != -1:
+			used[supernet] = true
+		case within != -1:
+			used[within] = true
+			discrepancies.maskMismatches = append(discrepancies.maskMismatches, SubnetMismatch{subnet, ipam[within]})
+		default:
+			discrepancies.notInIpam = append(discrepancies.notInIpam, subnet)
+		}
+	}
+	for j, ipamSubnet := range ipam {
+		if !used[j] {
+			discrepancies.notInConfig = append(discrepancies.notInConfig, ipamSubnet)
+		}
+	}
+	return discrepancies
+}
+
+// containsNetwork is a function that reports whether a network holds another network of a longer prefix.
+func containsNetwork(outer, inner *net.IPNet) bool {
+	outerOnes, outerBits := outer.Mask.Size()
+	innerOnes, innerBits := inner.Mask.Size()
+	return outerBits == innerBits && outerOnes < innerOnes && outer.Contains(inner.IP)
+}
+
+// PrintIpamDiscrepancies is a function that writes the discrepancies between the IPAM and the configuration.
+func PrintIpamDiscrepancies(w io.Writer, discrepancies IpamDiscrepancies) {
+	fmt.Fprintf(w, "%s:\n", Translate("not in IPAM"))
+	if len(discrepancies.notInIpam) == 0 {
+		fmt.Fprintf(w, "\t%s\n", Translate("none"))
+	}
+	for _, subnet := range discrepancies.notInIpam {
+		fmt.Fprintf(w, "\t%s (ip %s, %s %d)\n", subnet.network, subnet.ipAddress, Translate("line"), subnet.line)
+	}
+	fmt.Fprintf(w, "%s:\n", Translate("mask mismatches"))
+	if len(discrepancies.maskMismatches) == 0 {
+		fmt.Fprintf(w, "\t%s\n", Translate("none"))
+	}
+	for _, mismatch := range discrepancies.maskMismatches {
+		fmt.Fprintf(w, "\t%s, IPAM %s (%s %d, %s %d)\n", mismatch.subnet.network, mismatch.ipam.network, Translate("line"),
+			mismatch.subnet.line, Translate("row"), mismatch.ipam.row)
+	}
+	fmt.Fprintf(w, "%s:\n", Translate("VLAN mismatches"))
+	if len(discrepancies.vlanMismatches) == 0 {
+		fmt.Fprintf(w, "\t%s\n", Translate("none"))
+	}
+	for _, mismatch := range discrepancies.vlanMismatches {
+		fmt.Fprintf(w, "\t%s vlan %s, IPAM vlan %s (%s %d, %s %d)\n", mismatch.subnet.network, mismatch.subnet.vlan,
+			mismatch.ipam.vlan, Translate("line"), mismatch.subnet.line, Translate("row"), mismatch.ipam.row)
+	}
+	fmt.Fprintf(w, "%s:\n", Translate("not on the NetScaler"))
+	if len(discrepancies.notInConfig) == 0 {
+		fmt.Fprintf(w, "\t%s\n", Translate("none"))
+	}
+	for _, subnet := range discrepancies.notInConfig {
+		fmt.Fprintf(w, "\t%s", subnet.network)
+		if subnet.vlan != "" {
+			fmt.Fprintf(w, " vlan %s", subnet.vlan)
+		}
+		fmt.Fprintf(w, " (%s %d)\n", Translate("row"), subnet.row)
+	}
+}
+
+// RunIpam is a function that runs the ipam subcommand.
+func RunIpam(args []string) error {
+	flags := flag.NewFlagSet("ipam", flag.ContinueOnError)
+	subnetsFile := flags.String("subnets", "", "CSV of the subnets the IPAM is authoritative for, with their VLAN IDs")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || *subnetsFile == "" {
+		return errors.New("usage: ipam -subnets file filename")
+	}
+	ipam, err := LoadIpamSubnets(*subnetsFile)
+	if err != nil {
+		return err
+	}
+	subnets, err := GetConfigSubnets(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	PrintIpamDiscrepancies(os.Stdout, CompareIpam(ipam, subnets))
+	vips, err := GetMovingArpVips(flags.Arg(0), ipam)
+	if err != nil {
+		return err
+	}
+	PrintMovingArpVips(os.Stdout, vips)
+	return nil
+}
@@ -0,0 +1,169 @@
+package nsanalyze
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadIpamSubnets(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+		want []string
+	}{
+		{"no header", "10.0.0.0/24,10\n10.1.0.0/24,11\n", []string{"10.0.0.0/24 10", "10.1.0.0/24 11"}},
+		{"positional header", "subnet,vlan\n10.0.0.0/24,10\n", []string{"10.0.0.0/24 10"}},
+		{"columns by name", "Description,VLAN ID,Network\nweb,10,10.0.0.0/24\ndb,,10.1.0.0/24\n",
+			[]string{"10.0.0.0/24 10", "10.1.0.0/24 "}},
+		{"separate mask", "\ufeffSection;Subnet;Mask;VLAN\nDC1;10.0.0.0;24;10\nDC1;10.2.0.0;255.255.0.0;20\n",
+			[]string{"10.0.0.0/24 10", "10.2.0.0/16 20"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fileName := filepath.Join(t.TempDir(), "subnets.csv")
+			if err := os.WriteFile(fileName, []byte(test.csv), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			subnets, err := LoadIpamSubnets(fileName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, subnet := range subnets {
+				got = append(got, subnet.network.String()+" "+subnet.vlan)
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("subnets = %q, want %q", got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Errorf("subnet %d = %q, want %q", i, got[i], test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCompareIpam(t *testing.T) {
+	network := func(cidr string) *net.IPNet {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return network
+	}
+	tests := []struct {
+		name                                             string
+		ipam                                             []string
+		config                                           []string
+		notInIpam, maskMismatches, vlanMismatches, stale int
+	}{
+		{"exact", []string{"10.0.0.0/24"}, []string{"10.0.0.0/24"}, 0, 0, 0, 0},
+		{"supernet", []string{"10.0.0.0/16"}, []string{"10.0.1.0/24", "10.0.2.0/24"}, 0, 0, 0, 0},
+		{"most specific supernet", []string{"10.0.0.0/8", "10.0.0.0/16"}, []string{"10.0.1.0/24"}, 0, 0, 0, 1},
+		{"smaller ipam subnet", []string{"10.0.1.0/25"}, []string{"10.0.1.0/24"}, 0, 1, 0, 0},
+		{"missing", []string{"10.0.0.0/24"}, []string{"192.168.1.0/24"}, 1, 0, 0, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var ipam []IpamSubnet
+			for i, cidr := range test.ipam {
+				ipam = append(ipam, IpamSubnet{network: network(cidr), row: i + 1})
+			}
+			var subnets []ConfigSubnet
+			for i, cidr := range test.config {
+				subnets = append(subnets, ConfigSubnet{network: network(cidr), line: i + 1})
+			}
+			discrepancies := CompareIpam(ipam, subnets)
+			if len(discrepancies.notInIpam) != test.notInIpam || len(discrepancies.maskMismatches) != test.maskMismatches ||
+				len(discrepancies.vlanMismatches) != test.vlanMismatches || len(discrepancies.notInConfig) != test.stale {
+				t.Errorf("discrepancies = %+v", discrepancies)
+			}
+		})
+	}
+}
+
+func TestCompareIpamVlan(t *testing.T) {
+	_, network, _ := net.ParseCIDR("10.0.0.0/24")
+	ipam := []IpamSubnet{{network: network, vlan: "10", row: 1}}
+	subnets := []ConfigSubnet{{network: network, vlan: "20", line: 1}}
+	if discrepancies := CompareIpam(ipam, subnets); len(discrepancies.vlanMismatches) != 1 {
+		t.Errorf("discrepancies = %+v, want a VLAN mismatch", discrepancies)
+	}
+}
+
+func TestWritePhpIpamExportTables(t *testing.T) {
+	_, network, _ := net.ParseCIDR("10.0.0.0/24")
+	subnets := []ConfigSubnet{{network: network, vlan: "10", line: 1}}
+	addresses := []IpamAddress{{ip: net.ParseIP("10.0.0.5"), hostname: "web1", kind: NodeServer, subnet: network, line: 2}}
+	tests := []struct {
+		table  string
+		header string
+		rows   int
+	}{
+		{"subnets", "Section,Subnet,Mask,VLAN,Description", 1},
+		{"addresses", "Section,IP address,Hostname,Description,Subnet", 1},
+	}
+	for _, test := range tests {
+		t.Run(test.table, func(t *testing.T) {
+			var buffer bytes.Buffer
+			if err := WritePhpIpamExport(&buffer, "ns.conf", "csv", test.table, "NetScaler", subnets, addresses); err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+			if lines[0] != test.header || len(lines) != test.rows+1 {
+				t.Errorf("%s export = %q, want header %q and %d rows", test.table, lines, test.header, test.rows)
+			}
+		})
+	}
+	if err := WritePhpIpamExport(&bytes.Buffer{}, "ns.conf", "csv", "", "NetScaler", subnets, addresses); err == nil {
+		t.Error("export without a table succeeded, want an error")
+	}
+}
+
+func TestGetMovingArpVipsMostSpecific(t *testing.T) {
+	fileName := writeConfig(t,
+		"add ns ip 10.0.0.10 255.255.0.0 -type SNIP",
+		"add ns ip 10.0.1.10 255.255.255.0 -type SNIP",
+		"add ns ip 10.0.1.50 255.255.255.255 -type VIP -arp DISABLED",
+		"bind vlan 10 -IPAddress 10.0.0.10 255.255.0.0",
+		"bind vlan 20 -IPAddress 10.0.1.10 255.255.255.0")
+	tests := []struct {
+		name   string
+		ipam   [][2]string
+		moving string
+	}{
+		{"unchanged", [][2]string{{"10.0.0.0/8", "30"}, {"10.0.1.0/24", "20"}}, ""},
+		{"unchanged listed first", [][2]string{{"10.0.1.0/24", "20"}, {"10.0.0.0/8", "30"}}, ""},
+		{"moving", [][2]string{{"10.0.0.0/16", "10"}, {"10.0.1.0/24", "40"}}, "20 40 2"},
+		{"supernet only", [][2]string{{"10.0.0.0/16", "10"}}, "20 10 1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var ipam []IpamSubnet
+			for i, subnet := range test.ipam {
+				_, network, err := net.ParseCIDR(subnet[0])
+				if err != nil {
+					t.Fatal(err)
+				}
+				ipam = append(ipam, IpamSubnet{network: network, vlan: subnet[1], row: i + 1})
+			}
+			vips, err := GetMovingArpVips(fileName, ipam)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var moving string
+			for _, vip := range vips {
+				moving = fmt.Sprintf("%s %s %d", vip.from, vip.to, vip.row)
+			}
+			if len(vips) > 1 || moving != test.moving {
+				t.Errorf("GetMovingArpVips() = %+v, want %q", vips, test.moving)
+			}
+		})
+	}
+}
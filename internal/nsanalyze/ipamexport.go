@@ -0,0 +1,263 @@
+package nsanalyze
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+)
+
+// IpamAddress is a data structure for an address of the discovered address space, along with the host name it
+// is known by, if any, and the most specific subnet of the configuration it lies within, if any.
+type IpamAddress struct {
+	ip       net.IP
+	hostname string
+	kind     string
+	subnet   *net.IPNet
+	line     int
+}
+
+// GetIpamAddresses is a function that returns every address of the configuration that can be imported into an
+// IPAM, once per address. Servers and virtual servers are known by their names unless they are named after their
+// address, and addresses that can never work are left out.
+func GetIpamAddresses(fileName string, servers []Server, subnets []ConfigSubnet) ([]IpamAddress, error) {
+	classified, err := GetClassifiedAddresses(fileName, servers)
+	if err != nil {
+		return nil, err
+	}
+	var addresses []IpamAddress
+	seen := make(map[string]bool)
+	for _, address := range classified {
+		ip := net.ParseIP(address.ipAddress)
+		if ip == nil || IsBogusClass(address.class) || seen[ip.String()] {
+			continue
+		}
+		seen[ip.String()] = true
+		ipamAddress := IpamAddress{ip: ip, kind: address.kind, line: address.line}
+		if (address.kind == NodeServer || address.kind == NodeLbVserver) && net.ParseIP(address.name) == nil {
+			ipamAddress.hostname = address.name
+		}
+		longest := -1
+		for _, subnet := range subnets {
+			if ones, _ := subnet.network.Mask.Size(); subnet.network.Contains(ip) && ones > longest {
+				ipamAddress.subnet, longest = subnet.network, ones
+			}
+		}
+		addresses = append(addresses, ipamAddress)
+	}
+	return addresses, nil
+}
+
+// phpIpamSubnetJSON and phpIpamAddressJSON are the JSON representations of subnets and addresses as the phpIPAM
+// API takes them.
+type phpIpamSubnetJSON struct {
+	Subnet      string `json:"subnet"`
+	Mask        string `json:"mask"`
+	Section     string `json:"section"`
+	VlanID      string `json:"vlanId,omitempty"`
+	Description string `json:"description"`
+}
+
+type phpIpamAddressJSON struct {
+	IP          string `json:"ip"`
+	Hostname    string `json:"hostname,omitempty"`
+	Description string `json:"description"`
+	Subnet      string `json:"subnet,omitempty"`
+	Section     string `json:"section"`
+}
+
+// infobloxNetworkJSON and infobloxHostJSON are the JSON representations of networks and host records as the
+// Infoblox WAPI takes them.
+type infobloxNetworkJSON struct {
+	Network string `json:"network"`
+	Comment string `json:"comment"`
+}
+
+type infobloxHostJSON struct {
+	Name            string              `json:"name"`
+	Ipv4Addrs       []map[string]string `json:"ipv4addrs,omitempty"`
+	Ipv6Addrs       []map[string]string `json:"ipv6addrs,omitempty"`
+	ConfigureForDns bool                `json:"configure_for_dns"`
+	Comment         string              `json:"comment"`
+}
+
+// prefixLength is a function that returns the prefix length of a network as a string.
+func prefixLength(network *net.IPNet) string {
+	ones, _ := network.Mask.Size()
+	return strconv.Itoa(ones)
+}
+
+// describeIpamObject is a function that returns the description an object is imported with, naming the
+// configuration and line it was discovered on.
+func describeIpamObject(kind, fileName string, line int) string {
+	return fmt.Sprintf("%s, %s line %d", kind, fileName, line)
+}
+
+// WritePhpIpamExport is a function that writes subnets or addresses in the shape the phpIPAM CSV import of
+// subnets or of addresses expects, or subnets and addresses as JSON objects for its API. phpIPAM imports subnets
+// and addresses separately, so a CSV holds only the table named, subnets or addresses, and can be imported as is.
+func WritePhpIpamExport(w io.Writer, fileName, format, table, section string, subnets []ConfigSubnet, addresses []IpamAddress) error {
+	switch format {
+	case "csv":
+		writer := csv.NewWriter(w)
+		switch table {
+		case "subnets":
+			writer.Write([]string{"Section", "Subnet", "Mask", "VLAN", "Description"})
+			for _, subnet := range subnets {
+				writer.Write([]string{section, subnet.network.IP.String(), prefixLength(subnet.network), subnet.vlan,
+					describeIpamObject("subnet", fileName, subnet.line)})
+			}
+		case "addresses":
+			writer.Write([]string{"Section", "IP address", "Hostname", "Description", "Subnet"})
+			for _, address := range addresses {
+				subnet := ""
+				if address.subnet != nil {
+					subnet = address.subnet.String()
+				}
+				writer.Write([]string{section, address.ip.String(), address.hostname,
+					describeIpamObject(address.kind, fileName, address.line), subnet})
+			}
+		default:
+			return fmt.Errorf("unsupported table %q, use subnets or addresses", table)
+		}
+		writer.Flush()
+		return writer.Error()
+	case "json":
+		export := struct {
+			Subnets   []phpIpamSubnetJSON  `json:"subnets"`
+			Addresses []phpIpamAddressJSON `json:"addresses"`
+		}{[]phpIpamSubnetJSON{}, []phpIpamAddressJSON{}}
+		for _, subnet := range subnets {
+			export.Subnets = append(export.Subnets, phpIpamSubnetJSON{subnet.network.IP.String(),
+				prefixLength(subnet.network), section, subnet.vlan, describeIpamObject("subnet", fileName, subnet.line)})
+		}
+		for _, address := range addresses {
+			entry := phpIpamAddressJSON{IP: address.ip.String(), Hostname: address.hostname, Section: section,
+				Description: describeIpamObject(address.kind, fileName, address.line)}
+			if address.subnet != nil {
+				entry.Subnet = address.subnet.String()
+			}
+			export.Addresses = append(export.Addresses, entry)
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(export)
+	}
+	return fmt.Errorf("unsupported format %q, use csv or json", format)
+}
+
+// WriteInfobloxExport is a function that writes subnets as networks and named addresses as host records in the
+// shape the Infoblox CSV import expects, with a header row per object type, or as JSON objects for the WAPI. Host
+// records are not configured for DNS, so they can be imported without a matching zone, and addresses without a
+// host name are left out, as Infoblox has no object for a bare address.
+func WriteInfobloxExport(w io.Writer, fileName, format string, subnets []ConfigSubnet, addresses []IpamAddress) error {
+	switch format {
+	case "csv":
+		writer := csv.NewWriter(w)
+		var networks, ipv6Networks []ConfigSubnet
+		for _, subnet := range subnets {
+			if subnet.network.IP.To4() != nil {
+				networks = append(networks, subnet)
+			} else {
+				ipv6Networks = append(ipv6Networks, subnet)
+			}
+		}
+		if len(networks) > 0 {
+			writer.Write([]string{"header-network", "address*", "netmask*", "comment"})
+		}
+		for _, subnet := range networks {
+			writer.Write([]string{"network", subnet.network.IP.String(), net.IP(subnet.network.Mask).String(),
+				describeIpamObject("subnet", fileName, subnet.line)})
+		}
+		if len(ipv6Networks) > 0 {
+			writer.Write([]string{"header-ipv6network", "address*", "cidr*", "comment"})
+		}
+		for _, subnet := range ipv6Networks {
+			writer.Write([]string{"ipv6network", subnet.network.IP.String(), prefixLength(subnet.network),
+				describeIpamObject("subnet", fileName, subnet.line)})
+		}
+		heading := false
+		for _, address := range addresses {
+			if address.hostname == "" {
+				continue
+			}
+			if !heading {
+				writer.Write([]string{"header-hostrecord", "fqdn*", "addresses", "ipv6_addresses", "configure_for_dns", "comment"})
+				heading = true
+			}
+			ipv4, ipv6 := address.ip.String(), ""
+			if address.ip.To4() == nil {
+				ipv4, ipv6 = "", address.ip.String()
+			}
+			writer.Write([]string{"hostrecord", address.hostname, ipv4, ipv6, "False",
+				describeIpamObject(address.kind, fileName, address.line)})
+		}
+		writer.Flush()
+		return writer.Error()
+	case "json":
+		export := struct {
+			Networks []infobloxNetworkJSON `json:"networks"`
+			Hosts    []infobloxHostJSON    `json:"hosts"`
+		}{[]infobloxNetworkJSON{}, []infobloxHostJSON{}}
+		for _, subnet := range subnets {
+			export.Networks = append(export.Networks, infobloxNetworkJSON{subnet.network.String(),
+				describeIpamObject("subnet", fileName, subnet.line)})
+		}
+		for _, address := range addresses {
+			if address.hostname == "" {
+				continue
+			}
+			host := infobloxHostJSON{Name: address.hostname, Comment: describeIpamObject(address.kind, fileName, address.line)}
+			if address.ip.To4() != nil {
+				host.Ipv4Addrs = []map[string]string{{"ipv4addr": address.ip.String()}}
+			} else {
+				host.Ipv6Addrs = []map[string]string{{"ipv6addr": address.ip.String()}}
+			}
+			export.Hosts = append(export.Hosts, host)
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(export)
+	}
+	return fmt.Errorf("unsupported format %q, use csv or json", format)
+}
+
+// RunIpamExport is a function that runs the ipam-export subcommand.
+func RunIpamExport(args []string, options AnalyzeOptions) error {
+	flags := flag.NewFlagSet("ipam-export", flag.ContinueOnError)
+	target := flags.String("target", "", "IPAM to export for: phpipam or infoblox")
+	format := flags.String("format", "csv", "output format: csv or json")
+	section := flags.String("section", "NetScaler", "phpIPAM section to import subnets and addresses into")
+	table := flags.String("table", "", "phpIPAM table to write as CSV: subnets or addresses")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || (*target != "phpipam" && *target != "infoblox") {
+		return errors.New("usage: ipam-export -target phpipam|infoblox [-format csv|json] [-table subnets|addresses] [-section name] filename")
+	}
+	if *target == "phpipam" && *format == "csv" && *table == "" {
+		return errors.New("phpIPAM imports subnets and addresses separately, pick one with -table subnets|addresses")
+	}
+	fileName := flags.Arg(0)
+	subnets, err := GetConfigSubnets(fileName)
+	if err != nil {
+		return err
+	}
+	servers, err := GetAnalysisServers(fileName, options)
+	if err != nil {
+		return err
+	}
+	addresses, err := GetIpamAddresses(fileName, servers, subnets)
+	if err != nil {
+		return err
+	}
+	if *target == "phpipam" {
+		return WritePhpIpamExport(os.Stdout, fileName, *format, *table, *section, subnets, addresses)
+	}
+	return WriteInfobloxExport(os.Stdout, fileName, *format, subnets, addresses)
+}
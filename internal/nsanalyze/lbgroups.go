@@ -0,0 +1,127 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// LbGroup is a data structure for a NetScaler load balancing group, which shares persistence between the
+// virtual servers bound to it.
+type LbGroup struct {
+	name            string
+	persistenceType string
+	line            int
+}
+
+// LbGroupMember is a data structure for the binding of a load balancing virtual server to a load balancing group.
+type LbGroupMember struct {
+	groupName   string
+	vserverName string
+	line        int
+}
+
+// GetLbGroups is a function that accepts a file name as a parameter for input and then returns an array of load
+// balancing groups.
+func GetLbGroups(fileName string) ([]LbGroup, error) {
+	var groups []LbGroup
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	addLbGroupLines, err := GetConfigLines(file, "(add lb group ).*")
+	if err != nil {
+		return nil, err
+	}
+	for _, addLbGroupLine := range addLbGroupLines {
+		fields := SplitConfigLine(RemoveConfigKeywords(addLbGroupLine.text, "add lb group "))
+		if len(fields) == 0 {
+			continue
+		}
+		groups = append(groups, LbGroup{
+			name:            fields[0],
+			persistenceType: GetOption(fields, "-persistenceType"),
+			line:            addLbGroupLine.number,
+		})
+	}
+	return groups, nil
+}
+
+// GetLbGroupMembers is a function that accepts a file name as a parameter for input and then returns an array of
+// the virtual servers bound to load balancing groups.
+func GetLbGroupMembers(fileName string) ([]LbGroupMember, error) {
+	var members []LbGroupMember
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	bindLbGroupLines, err := GetConfigLines(file, "(bind lb group ).*")
+	if err != nil {
+		return nil, err
+	}
+	for _, bindLbGroupLine := range bindLbGroupLines {
+		fields := SplitConfigLine(RemoveConfigKeywords(bindLbGroupLine.text, "bind lb group "))
+		if len(fields) < 2 || strings.HasPrefix(fields[1], "-") {
+			continue
+		}
+		members = append(members, LbGroupMember{
+			groupName:   fields[0],
+			vserverName: fields[1],
+			line:        bindLbGroupLine.number,
+		})
+	}
+	return members, nil
+}
+
+// GetPersistenceGroupFindings is a function that returns a finding for every load balancing group where some,
+// but not all, of the virtual servers depend on an uncovered server. Persistence then breaks for clients whose
+// session moves between the affected and unaffected virtual servers.
+func GetPersistenceGroupFindings(fileName string, networks []*net.IPNet, servers []Server) ([]Finding, error) {
+	var findings []Finding
+	groups, err := GetLbGroups(fileName)
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, nil
+	}
+	graph, err := GetGraph(fileName)
+	if err != nil {
+		return nil, err
+	}
+	uncovered := make(map[string]bool)
+	for _, server := range GetUncoveredServers(networks, servers) {
+		uncovered[server.name] = true
+	}
+	for _, group := range groups {
+		var affected, unaffected []string
+		for _, node := range graph.Dependencies(Node{kind: NodeLbGroup, name: group.name}) {
+			if node.kind != NodeLbVserver {
+				continue
+			}
+			isAffected := false
+			for _, dependency := range graph.Dependencies(node) {
+				if dependency.kind == NodeServer && uncovered[dependency.name] {
+					isAffected = true
+				}
+			}
+			if isAffected {
+				affected = append(affected, node.name)
+			} else {
+				unaffected = append(unaffected, node.name)
+			}
+		}
+		if len(affected) == 0 || len(unaffected) == 0 {
+			continue
+		}
+		findings = append(findings, Finding{
+			rule: RulePartialPersistenceGroup,
+			message: fmt.Sprintf("LB group %s has virtual servers %s depending on uncovered servers while %s do not",
+				group.name, strings.Join(affected, ", "), strings.Join(unaffected, ", ")),
+			object:   group.name,
+			fileName: fileName,
+			line:     group.line,
+		})
+	}
+	return findings, nil
+}
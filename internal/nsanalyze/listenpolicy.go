@@ -0,0 +1,114 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// ListenPolicy is a data structure for the listen policy of a load balancing virtual server along with the
+// addresses and subnets its expression refers to. Addresses are held as networks of one address.
+type ListenPolicy struct {
+	vserverName string
+	expression  string
+	networks    []*net.IPNet
+	line        int
+}
+
+// literalSubnet matches the IPv4 addresses and subnets embedded within policy expressions, such as the argument
+// of CLIENT.IP.SRC.IN_SUBNET(10.1.0.0/16).
+var literalSubnet = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}(?:/\d{1,2})?\b`)
+
+// GetListenPolicies is a function that accepts a file name as a parameter for input and then returns the listen
+// policy of every load balancing virtual server that has one, whether it is given when the virtual server is
+// added or set later. A later line replaces the policy of an earlier one.
+func GetListenPolicies(fileName string) ([]ListenPolicy, error) {
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := GetConfigLines(file, "((add|set) lb vserver ).*")
+	if err != nil {
+		return nil, err
+	}
+	var policies []ListenPolicy
+	index := make(map[string]int)
+	for _, line := range lines {
+		fields := SplitConfigLine(line.text)[3:]
+		expression := GetOption(fields, "-listenPolicy")
+		if len(fields) == 0 || expression == "" {
+			continue
+		}
+		policy := ListenPolicy{vserverName: fields[0], expression: expression, line: line.number}
+		for _, literal := range literalSubnet.FindAllString(expression, -1) {
+			if !strings.Contains(literal, "/") {
+				literal += "/32"
+			}
+			if _, network, err := net.ParseCIDR(literal); err == nil {
+				policy.networks = append(policy.networks, network)
+			}
+		}
+		if i, ok := index[policy.vserverName]; ok {
+			policies[i] = policy
+			continue
+		}
+		index[policy.vserverName] = len(policies)
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// UncoveredNetworks is a function that returns the addresses and subnets of the listen policy that do not lie
+// entirely within any of the networks.
+func (policy ListenPolicy) UncoveredNetworks(networks []*net.IPNet) []*net.IPNet {
+	var uncovered []*net.IPNet
+	for _, referenced := range policy.networks {
+		covered := false
+		for _, network := range networks {
+			if NetworkWithin(referenced, network) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			uncovered = append(uncovered, referenced)
+		}
+	}
+	return uncovered
+}
+
+// DescribeNetwork is a function that returns a network in CIDR notation, or just its address when it holds a
+// single address.
+func DescribeNetwork(network *net.IPNet) string {
+	if ones, bits := network.Mask.Size(); ones == bits {
+		return network.IP.String()
+	}
+	return network.String()
+}
+
+// GetListenPolicyFindings is a function that returns a finding for every listen policy that refers to an address
+// or subnet that does not lie within any of the networks, so that will no longer exist once they are the only
+// networks left.
+func GetListenPolicyFindings(fileName string, networks []*net.IPNet) ([]Finding, error) {
+	policies, err := GetListenPolicies(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, policy := range policies {
+		for _, network := range policy.UncoveredNetworks(networks) {
+			findings = append(findings, Finding{
+				rule: RuleUncoveredListenPolicy,
+				message: fmt.Sprintf("Listen policy of lb vserver %s refers to %s, which is not covered by any SNIP network",
+					policy.vserverName, DescribeNetwork(network)),
+				object:     policy.vserverName,
+				objectType: NodeLbVserver,
+				key:        DescribeNetwork(network),
+				fileName:   fileName,
+				line:       policy.line,
+			})
+		}
+	}
+	return findings, nil
+}
@@ -0,0 +1,194 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// LsnPool is a data structure for a large scale NAT pool added through "add lsn pool", along with the addresses
+// bound to it, which are single addresses, ranges such as "203.0.113.1-203.0.113.14" or subnets such as
+// "203.0.113.0/28". Subscriber traffic translated by the pool leaves the NetScaler from those addresses.
+type LsnPool struct {
+	name      string
+	addresses []ConfigLine
+	line      int
+}
+
+// LsnClient is a data structure for a large scale NAT client added through "add lsn client", along with the
+// subscriber networks bound to it.
+type LsnClient struct {
+	name     string
+	networks []ConfigLine
+	line     int
+}
+
+// GetLsnPools is a function that accepts a file name as a parameter for input and then returns an array of the
+// large scale NAT pools with the addresses bound to them.
+func GetLsnPools(fileName string) ([]LsnPool, error) {
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	lsnPoolLines, err := GetConfigLines(file, "((add|bind) lsn pool ).*")
+	if err != nil {
+		return nil, err
+	}
+	var pools []LsnPool
+	index := make(map[string]int)
+	for _, lsnPoolLine := range lsnPoolLines {
+		fields := SplitConfigLine(lsnPoolLine.text)
+		if len(fields) < 4 {
+			continue
+		}
+		i, ok := index[fields[3]]
+		if !ok {
+			i = len(pools)
+			index[fields[3]] = i
+			pools = append(pools, LsnPool{name: fields[3], line: lsnPoolLine.number})
+		}
+		if fields[0] == "add" {
+			pools[i].line = lsnPoolLine.number
+		} else if len(fields) > 4 && !strings.HasPrefix(fields[4], "-") {
+			pools[i].addresses = append(pools[i].addresses, ConfigLine{text: fields[4], number: lsnPoolLine.number})
+		}
+	}
+	return pools, nil
+}
+
+// GetLsnClients is a function that accepts a file name as a parameter for input and then returns an array of
+// the large scale NAT clients with the subscriber networks bound to them, in CIDR notation.
+func GetLsnClients(fileName string) ([]LsnClient, error) {
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	lsnClientLines, err := GetConfigLines(file, "((add|bind) lsn client ).*")
+	if err != nil {
+		return nil, err
+	}
+	var clients []LsnClient
+	index := make(map[string]int)
+	for _, lsnClientLine := range lsnClientLines {
+		fields := SplitConfigLine(lsnClientLine.text)
+		if len(fields) < 4 {
+			continue
+		}
+		i, ok := index[fields[3]]
+		if !ok {
+			i = len(clients)
+			index[fields[3]] = i
+			clients = append(clients, LsnClient{name: fields[3], line: lsnClientLine.number})
+		}
+		network := GetOption(fields, "-network")
+		if fields[0] != "bind" || network == "" {
+			continue
+		}
+		if netmask := GetOption(fields, "-netmask"); netmask != "" {
+			network += ConvertMask(netmask)
+		}
+		clients[i].networks = append(clients[i].networks, ConfigLine{text: network, number: lsnClientLine.number})
+	}
+	return clients, nil
+}
+
+// LsnStartAddress is a function that returns the first address of a pool address, range or subnet, which is nil
+// when it does not parse.
+func LsnStartAddress(address string) net.IP {
+	if ip, _, err := net.ParseCIDR(address); err == nil {
+		return ip
+	}
+	first, _, _ := strings.Cut(address, "-")
+	return net.ParseIP(first)
+}
+
+// LsnVlan is a function that returns the VLAN whose SNIP subnet an LSN address, range or subnet starts in, which
+// is where the upstream router reaches it over the trunk. It is empty when no SNIP subnet holds the address, so
+// the address has to be routed to the NetScaler instead.
+func LsnVlan(address string, snips []Snip) string {
+	ip := LsnStartAddress(address)
+	if ip == nil {
+		return ""
+	}
+	validSnips, _ := FilterValidSnips(snips)
+	networks, err := GetNetworks(validSnips)
+	if err != nil {
+		return ""
+	}
+	for i, network := range networks {
+		if network.Contains(ip) && validSnips[i].vlan != "" {
+			return validSnips[i].vlan
+		}
+	}
+	return ""
+}
+
+// GetLsnAddresses is a function that accepts a file name as a parameter for input and then returns the
+// addresses of the large scale NAT pools and the subscriber networks of the large scale NAT clients, classified
+// by the address they start with.
+func GetLsnAddresses(fileName string) ([]ClassifiedAddress, error) {
+	pools, err := GetLsnPools(fileName)
+	if err != nil {
+		return nil, err
+	}
+	clients, err := GetLsnClients(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var addresses []ClassifiedAddress
+	for _, pool := range pools {
+		for _, address := range pool.addresses {
+			addresses = append(addresses, ClassifiedAddress{"LSN pool", pool.name, address.text,
+				ClassifyAddress(LsnStartAddress(address.text).String()), address.number})
+		}
+	}
+	for _, client := range clients {
+		for _, network := range client.networks {
+			addresses = append(addresses, ClassifiedAddress{"LSN client", client.name, network.text,
+				ClassifyAddress(LsnStartAddress(network.text).String()), network.number})
+		}
+	}
+	return addresses, nil
+}
+
+// PrintLsnPlan is a function that writes the VLAN that every LSN pool address and subscriber network is reached
+// through, or that it is routed when no SNIP subnet holds it, so that the trunk carries the VLANs large scale NAT
+// depends on.
+func PrintLsnPlan(w io.Writer, fileName string) error {
+	pools, err := GetLsnPools(fileName)
+	if err != nil {
+		return err
+	}
+	clients, err := GetLsnClients(fileName)
+	if err != nil {
+		return err
+	}
+	snips, err := GetSnips(fileName)
+	if err != nil {
+		return err
+	}
+	names, err := GetVlanNames(fileName)
+	if err != nil {
+		return err
+	}
+	printAddress := func(kind, name, address string) {
+		fmt.Fprintf(w, "%s %s %s", kind, name, address)
+		if vlan := LsnVlan(address, snips); vlan != "" {
+			fmt.Fprintf(w, " vlan %s\n", DescribeVlan(vlan, names))
+		} else {
+			fmt.Fprintf(w, " (%s)\n", Translate("routed"))
+		}
+	}
+	for _, pool := range pools {
+		for _, address := range pool.addresses {
+			printAddress("lsn pool", pool.name, address.text)
+		}
+	}
+	for _, client := range clients {
+		for _, network := range client.networks {
+			printAddress("lsn client", client.name, network.text)
+		}
+	}
+	return nil
+}
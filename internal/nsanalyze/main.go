@@ -0,0 +1,1483 @@
+package nsanalyze
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"maps"
+	"net"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is a data structure for NetScaler server data. The ports of a server are the ports of the services
+// and service group members that bind it, since NetScaler servers do not carry a port themselves.
+type Server struct {
+	name            string
+	ipAddress       string
+	domainName      string
+	domainBased     bool
+	translationIP   string
+	translationMask string
+	ports           []string
+	weight          int
+	implicit        bool
+	resolvedLocally bool
+	excluded        bool
+	comment         string
+	line            int
+}
+
+// Snip is a data structure for NetScaler IP data.
+type Snip struct {
+	ipAddress  string
+	subnetMask string
+	ipType     string
+	vlan       string
+	td         string
+	ownerNode  string
+	vserver    string
+	vservers   []string
+	arp        string
+	icmp       string
+	line       int
+}
+
+// ConfigLine is a data structure for a line of a NetScaler configuration along with its line number.
+type ConfigLine struct {
+	text   string
+	number int
+}
+
+// fileCache holds the contents of every configuration read so far, so that a source such as standard input or
+// a remote appliance is only read once even though each extractor asks for the file again. The SHA-256 checksum
+// of each configuration as read is kept alongside it for the report metadata. A configuration is never changed
+// once cached, so analyses running in parallel can share it. The lock is only held to look up and store
+// configurations, never while one is read, so that reading one configuration does not hold up the others.
+var fileCache = struct {
+	sync.Mutex
+	files     map[string]string
+	checksums map[string]string
+	loading   map[string]*fileLoad
+}{files: make(map[string]string), checksums: make(map[string]string), loading: make(map[string]*fileLoad)}
+
+// fileLoad is a data structure for a configuration being read, which every caller asking for it meanwhile waits
+// for instead of reading it again. Once done is closed, file or err holds the outcome.
+type fileLoad struct {
+	done chan struct{}
+	file string
+	err  error
+}
+
+// GetFile is a function that gets access to a file based on the file name. The name is resolved to a
+// ConfigSource, so it may also be "-" for standard input or the URL of a remote configuration.
+func GetFile(fileName string) (string, error) {
+	fileCache.Lock()
+	if file, ok := fileCache.files[fileName]; ok {
+		fileCache.Unlock()
+		return file, nil
+	}
+	if load, ok := fileCache.loading[fileName]; ok {
+		fileCache.Unlock()
+		<-load.done
+		return load.file, load.err
+	}
+	load := &fileLoad{done: make(chan struct{})}
+	fileCache.loading[fileName] = load
+	fileCache.Unlock()
+	var raw []byte
+	raw, load.err = readConfigSource(fileName)
+	if load.err == nil {
+		load.file, load.err = ApplyRemovals(ApplyVersionQuirks(ApplyPlatformQuirks(NormalizeConfig(string(raw)))))
+	}
+	fileCache.Lock()
+	if load.err == nil {
+		fileCache.files[fileName] = load.file
+		fileCache.checksums[fileName] = checksum(raw)
+	}
+	delete(fileCache.loading, fileName)
+	fileCache.Unlock()
+	close(load.done)
+	return load.file, load.err
+}
+
+// GetRawFile is a function that reads a configuration the way GetFile does, but with only its line endings, byte
+// order mark and capture artifacts normalized: the objects that later lines remove are still there and no quirk
+// of the platform or firmware is applied. It is not cached, as only commands that have to see the configuration
+// as written, such as anonymize, read it this way.
+func GetRawFile(fileName string) (string, error) {
+	raw, err := readConfigSource(fileName)
+	if err != nil {
+		return "", err
+	}
+	return NormalizeConfig(string(raw)), nil
+}
+
+// readConfigSource is a function that reads a configuration from the source its name resolves to, for GetFile.
+func readConfigSource(fileName string) ([]byte, error) {
+	source, err := NewConfigSource(fileName)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := source.Open(runContext)
+	if err != nil {
+		return nil, runContextError(source.Name(), err)
+	}
+	defer reader.Close()
+	file, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, runContextError(source.Name(), err)
+	}
+	return file, nil
+}
+
+// GetFileChecksum is a function that returns the SHA-256 checksum of a configuration read so far as a
+// hexadecimal string, or an empty string when it has not been read.
+func GetFileChecksum(fileName string) string {
+	fileCache.Lock()
+	defer fileCache.Unlock()
+	return fileCache.checksums[fileName]
+}
+
+// CacheFile is a function that stores the contents of a configuration under a name, so that the extractors read
+// it from memory instead of resolving the name to a source. It fails once the run is past its timeout.
+func CacheFile(fileName, file string) error {
+	normalized, err := ApplyRemovals(ApplyVersionQuirks(ApplyPlatformQuirks(NormalizeConfig(file))))
+	if err != nil {
+		return err
+	}
+	fileCache.Lock()
+	defer fileCache.Unlock()
+	fileCache.files[fileName] = normalized
+	fileCache.checksums[fileName] = checksum([]byte(file))
+	return nil
+}
+
+// ForgetFile is a function that drops a configuration from the cache so that the next access reads it again. The
+// objects parsed from it are kept, so they are only parsed again when the configuration has changed.
+func ForgetFile(fileName string) {
+	fileCache.Lock()
+	defer fileCache.Unlock()
+	delete(fileCache.files, fileName)
+	delete(fileCache.checksums, fileName)
+}
+
+// NormalizeConfig is a function that strips a leading UTF-8 byte order mark and turns Windows and old Mac line
+// endings into newlines, so that configurations saved by Windows editors parse the same as those taken from
+// the appliance. The artifacts of a terminal capture of "show running config" are stripped as well, so that a
+// capture can be pasted in as it is.
+func NormalizeConfig(file string) string {
+	file = strings.TrimPrefix(file, "\ufeff")
+	file = StripCaptureArtifacts(file)
+	file = strings.ReplaceAll(file, "\r\n", "\n")
+	return blankPromptLines(strings.ReplaceAll(file, "\r", "\n"))
+}
+
+// GetConfig is a function that takes the contents of a file as a parameter as well as
+// a pattern to use as a filter to return results as strings.
+func GetConfig(file, pattern string) ([]string, error) {
+	lines, err := GetConfigLines(file, pattern)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]string, len(lines))
+	for i, line := range lines {
+		results[i] = line.text
+	}
+	return results, nil
+}
+
+// GetConfigLines is a function that works like GetConfig but also returns the line number that each result
+// was found on so that it can be reported back to the user.
+// The file is scanned line by line and the regular expression only run against the lines that contain the literal
+// every match needs, since running it over the whole of a large configuration is what parsing spends most of its
+// time on. The results are substrings of the file, so no line is copied. Scanning gives up with an error once the
+// run is past -timeout.
+func GetConfigLines(file, pattern string) ([]ConfigLine, error) {
+	compiled, err := GetConfigPattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckRunContext(); err != nil {
+		return nil, err
+	}
+	var results []ConfigLine
+	lineNumber := 1
+	for start := 0; start < len(file); lineNumber++ {
+		if lineNumber%contextCheckInterval == 0 {
+			if err := CheckRunContext(); err != nil {
+				return nil, err
+			}
+		}
+		end := strings.IndexByte(file[start:], '\n')
+		if end < 0 {
+			end = len(file)
+		} else {
+			end += start
+		}
+		results = compiled.AppendMatches(results, file[start:end], lineNumber)
+		start = end + 1
+	}
+	linesScanned.Add(int64(lineNumber - 1))
+	return results, nil
+}
+
+// RemoveConfigKeywords is a function that removes the CLI keywords from within a NetScaler configuration.
+// Lines that start with the keywords, which is nearly every line, are cut rather than copied.
+func RemoveConfigKeywords(textLine, pattern string) string {
+	if strings.HasPrefix(textLine, pattern) {
+		return textLine[len(pattern):]
+	}
+	result := strings.Replace(textLine, pattern, "", 1)
+	return result
+}
+
+// SplitConfigLine is a function that splits a NetScaler configuration line into its fields. Values wrapped
+// in double quotes are kept together as a single field with the quotes removed.
+func SplitConfigLine(textLine string) []string {
+	return AppendConfigFields(make([]string, 0, countConfigFields(textLine)), textLine)
+}
+
+// AppendConfigFields is a function that works like SplitConfigLine but appends the fields to an array, so that
+// an extractor can reuse one array for every line. Fields are substrings of the line unless they hold an escaped
+// character, so splitting into a reused array does not allocate.
+func AppendConfigFields(fields []string, textLine string) []string {
+	var field strings.Builder
+	inQuotes := false
+	inField := false
+	escaped := false
+	start := 0
+	for i := 0; i < len(textLine); i++ {
+		c := textLine[i]
+		switch {
+		case c == '\\' && inQuotes && i+1 < len(textLine):
+			if !escaped {
+				field.WriteString(unquoteField(textLine[start:i]))
+				escaped = true
+			}
+			i++
+			field.WriteByte(textLine[i])
+		case c == '"':
+			if !inField {
+				start = i
+			}
+			inQuotes = !inQuotes
+			inField = true
+		case (c == ' ' || c == '\t' || c == '\r') && !inQuotes:
+			if inField {
+				fields = append(fields, endConfigField(textLine[start:i], &field, escaped))
+				inField, escaped = false, false
+			}
+		default:
+			if !inField {
+				start = i
+			} else if escaped {
+				field.WriteByte(c)
+			}
+			inField = true
+		}
+	}
+	if inField {
+		fields = append(fields, endConfigField(textLine[start:], &field, escaped))
+	}
+	return fields
+}
+
+// endConfigField is a function that returns a field of a configuration line once its end has been reached: the
+// text of the field with its quotes removed, or what was written to the builder when the field holds an escaped
+// character.
+func endConfigField(text string, field *strings.Builder, escaped bool) string {
+	if !escaped {
+		return unquoteField(text)
+	}
+	value := field.String()
+	field.Reset()
+	return value
+}
+
+// unquoteField is a function that removes the double quotes from the text of a field without escaped characters.
+// Quotes usually wrap the whole field, which needs no copy.
+func unquoteField(text string) string {
+	if !strings.Contains(text, `"`) {
+		return text
+	}
+	if len(text) >= 2 && text[0] == '"' && text[len(text)-1] == '"' && !strings.Contains(text[1:len(text)-1], `"`) {
+		return text[1 : len(text)-1]
+	}
+	return strings.ReplaceAll(text, `"`, "")
+}
+
+// countConfigFields is a function that returns an upper bound of the number of fields of a configuration line, so
+// that the array of fields is only allocated once.
+func countConfigFields(textLine string) int {
+	count := 1
+	for i := 0; i < len(textLine); i++ {
+		if c := textLine[i]; c == ' ' || c == '\t' || c == '\r' {
+			count++
+		}
+	}
+	return count
+}
+
+// GetOption is a function that returns the value following a CLI option such as "-ifAlias" within the
+// fields of a configuration line. NetScaler options are case insensitive.
+func GetOption(fields []string, option string) string {
+	for i := 0; i < len(fields)-1; i++ {
+		if strings.EqualFold(fields[i], option) {
+			return fields[i+1]
+		}
+	}
+	return ""
+}
+
+// optionState is a function that returns the ENABLED or DISABLED state a CLI option sets, upper cased, or the
+// default when the line leaves the option out or sets it to anything else.
+func optionState(fields []string, option, defaultState string) string {
+	if state := strings.ToUpper(GetOption(fields, option)); state == "ENABLED" || state == "DISABLED" {
+		return state
+	}
+	return defaultState
+}
+
+// GetOptionValues is a function that returns every value following a CLI option that accepts a list, such as
+// "-ifnum 1/1 1/2", up to the next option.
+func GetOptionValues(fields []string, option string) []string {
+	var values []string
+	for i := 0; i < len(fields); i++ {
+		if !strings.EqualFold(fields[i], option) {
+			continue
+		}
+		for _, value := range fields[i+1:] {
+			if strings.HasPrefix(value, "-") {
+				break
+			}
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// HasOption is a function that reports whether a CLI flag such as "-tagged" is present within the fields
+// of a configuration line.
+func HasOption(fields []string, option string) bool {
+	for _, field := range fields {
+		if strings.EqualFold(field, option) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetServers is a function that accepts a file name as a parameter for input and then returns an array of servers.
+func GetServers(fileName string) ([]Server, error) {
+	return cachedModel(fileName, "servers", parseServers)
+}
+
+// parseServers is a function that parses the servers of a configuration for GetServers.
+func parseServers(fileName string) ([]Server, error) {
+	var servers []Server
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	addServerLines, err := GetConfigLines(file, "(add server ).*")
+	if err != nil {
+		return nil, err
+	}
+	servers = make([]Server, 0, len(addServerLines))
+	var serverLineArray []string
+	for _, addServerLine := range addServerLines {
+		serverLine := RemoveConfigKeywords(addServerLine.text, "add server ")
+		serverLineArray = AppendConfigFields(serverLineArray[:0], serverLine)
+		if len(serverLineArray) < 2 {
+			continue
+		}
+		var server Server
+		server.name = serverLineArray[0]
+		server.ipAddress = serverLineArray[1]
+		server.domainName = GetOption(serverLineArray, "-domainName")
+		if net.ParseIP(server.ipAddress) == nil {
+			server.domainBased = true
+			if !strings.HasPrefix(server.ipAddress, "-") {
+				server.domainName = server.ipAddress
+			}
+			server.ipAddress = ""
+		}
+		server.translationIP = GetOption(serverLineArray, "-translationIp")
+		server.translationMask = GetOption(serverLineArray, "-translationMask")
+		server.comment = GetOption(serverLineArray, "-comment")
+		server.line = addServerLine.number
+		servers = append(servers, server)
+	}
+	index := make(map[string]int)
+	for i, server := range servers {
+		index[server.name] = i
+	}
+	if err := applyComments(file, "server", index, func(i int) *string { return &servers[i].comment }); err != nil {
+		return nil, err
+	}
+	servers, err = AddImplicitServers(fileName, servers)
+	if err != nil {
+		return nil, err
+	}
+	return AddServerPorts(fileName, servers)
+}
+
+// AddImplicitServers is a function that adds a server for every address that a service group member or a
+// service is bound to directly rather than through a server name. The appliance creates such servers named after
+// their address, but they are missing from batch files and partial configurations.
+func AddImplicitServers(fileName string, servers []Server) ([]Server, error) {
+	known := make(map[string]bool)
+	for _, server := range servers {
+		known[server.name] = true
+	}
+	addImplicit := func(address string, line int) {
+		if known[address] || net.ParseIP(address) == nil {
+			return
+		}
+		known[address] = true
+		servers = append(servers, Server{name: address, ipAddress: address, implicit: true, line: line})
+	}
+	members, err := GetServiceGroupMembers(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		addImplicit(member.serverName, member.line)
+	}
+	services, err := GetServices(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, service := range services {
+		addImplicit(service.serverName, service.line)
+	}
+	return servers, nil
+}
+
+// AddServerPorts is a function that fills in the ports of each server from the services and service group
+// members of a configuration that bind it, along with its aggregate weight: the weight of every service group
+// binding of the server plus that of every virtual server binding of its services. The weight is how much traffic
+// depends on the server, so how much losing it hurts.
+func AddServerPorts(fileName string, servers []Server) ([]Server, error) {
+	services, err := GetServices(fileName)
+	if err != nil {
+		return nil, err
+	}
+	members, err := GetServiceGroupMembers(fileName)
+	if err != nil {
+		return nil, err
+	}
+	bindings, err := GetLbBindings(fileName)
+	if err != nil {
+		return nil, err
+	}
+	serviceWeights := make(map[string]int)
+	for _, binding := range bindings {
+		serviceWeights[binding.serviceName] += binding.weight
+	}
+	weights := make(map[string]int)
+	ports := make(map[string][]string)
+	addPort := func(serverName, port string) {
+		if port == "" {
+			return
+		}
+		for _, existing := range ports[serverName] {
+			if existing == port {
+				return
+			}
+		}
+		ports[serverName] = append(ports[serverName], port)
+	}
+	for _, service := range services {
+		addPort(service.serverName, service.port)
+		weights[service.serverName] += serviceWeights[service.name]
+	}
+	for _, member := range members {
+		addPort(member.serverName, member.port)
+		weights[member.serverName] += member.weight
+	}
+	for i := range servers {
+		servers[i].ports = ports[servers[i].name]
+		servers[i].weight = weights[servers[i].name]
+	}
+	return servers, nil
+}
+
+// Name is a function that returns the name of the server.
+func (server Server) Name() string {
+	return server.name
+}
+
+// IPAddress is a function that returns the address of the server, which is empty for a domain based server that is not resolved.
+func (server Server) IPAddress() string {
+	return server.ipAddress
+}
+
+// Line is a function that returns the line of the configuration the server is added on.
+func (server Server) Line() int {
+	return server.line
+}
+
+// IPAddress is a function that returns the address of the SNIP.
+func (snip Snip) IPAddress() string {
+	return snip.ipAddress
+}
+
+// SubnetMask is a function that returns the subnet mask of the SNIP as written in the configuration.
+func (snip Snip) SubnetMask() string {
+	return snip.subnetMask
+}
+
+// Line is a function that returns the line of the configuration the SNIP is added on.
+func (snip Snip) Line() int {
+	return snip.line
+}
+
+// Describe is a function that returns a description of a server listing its address along with any domain
+// name, NAT translation and ports configured for it, and its aggregate weight when that is above the default.
+func (server Server) Describe() string {
+	details := []string{server.ipAddress + " " + ClassifyAddress(server.ipAddress)}
+	if server.domainName != "" {
+		details = append(details, "domain "+server.domainName)
+	}
+	if server.translationIP != "" {
+		details = append(details, "translation "+server.translationIP+" "+server.translationMask)
+	}
+	if server.domainBased && server.ipAddress == "" {
+		details[0] = "unresolved"
+	}
+	if len(server.ports) > 0 {
+		details = append(details, "ports "+strings.Join(server.ports, ","))
+	}
+	if server.weight > 1 {
+		details = append(details, "weight "+strconv.Itoa(server.weight))
+	}
+	if server.implicit {
+		details = append(details, "implicit")
+	}
+	return server.name + " (" + strings.Join(details, ", ") + ")"
+}
+
+// GetSnips is a function that accepts a file name as a parameter for input and then returns an array of SNIPs.
+func GetSnips(fileName string) ([]Snip, error) {
+	model, err := getSnipModel(fileName)
+	return model.snips, err
+}
+
+// snipModel is a data structure for the SNIPs of a configuration along with a warning for every ns ip annotation
+// the parser left out of them, which are parsed together so that the lines are read once.
+type snipModel struct {
+	snips    []Snip
+	warnings []Warning
+}
+
+// getSnipModel is a function that returns the SNIPs of a configuration and the warnings about their annotations,
+// parsing them only when the configuration has not been parsed with the same contents before. The cached warnings
+// name no configuration, since configurations with the same contents share them, and are given the name of the
+// one asked for here.
+func getSnipModel(fileName string) (snipModel, error) {
+	models, err := cachedModel(fileName, "SNIPs", func(fileName string) ([]snipModel, error) {
+		model, err := parseSnips(fileName)
+		return []snipModel{model}, err
+	})
+	if err != nil || len(models) == 0 {
+		return snipModel{}, err
+	}
+	model := snipModel{slices.Clone(models[0].snips), slices.Clone(models[0].warnings)}
+	for i := range model.warnings {
+		model.warnings[i].fileName = fileName
+	}
+	return model, nil
+}
+
+// parseSnips is a function that parses the SNIPs of a configuration for GetSnips.
+func parseSnips(fileName string) (snipModel, error) {
+	var snips []Snip
+	file, err := GetFile(fileName)
+	if err != nil {
+		return snipModel{}, err
+	}
+	addNsIpLines, err := GetConfigLines(file, "(add ns ip ).*")
+	if err != nil {
+		return snipModel{}, err
+	}
+	snips = make([]Snip, 0, len(addNsIpLines))
+	var warnings []Warning
+	var fields []string
+	for _, addNsIpLine := range addNsIpLines {
+		nsIpLine := RemoveConfigKeywords(addNsIpLine.text, "add ns ip ")
+		address, rest, _ := strings.Cut(nsIpLine, " ")
+		mask, _, _ := strings.Cut(rest, " ")
+		var snip Snip
+		snip.ipAddress = address
+		snip.subnetMask = mask
+		fields = AppendConfigFields(fields[:0], nsIpLine)
+		snip.ipType = strings.ToUpper(GetOption(fields, "-type"))
+		if snip.ipType == "" {
+			snip.ipType = "SNIP"
+		}
+		snip.td = GetOption(fields, "-td")
+		if snip.td == "" {
+			snip.td = "0"
+		}
+		if ownerNode := GetOption(fields, "-ownerNode"); ValidOwnerNode(ownerNode) {
+			snip.ownerNode = clusterOwner(ownerNode)
+		} else if ownerNode != "" {
+			warnings = append(warnings, invalidOwnerNodeWarning(addNsIpLine, address, ownerNode))
+		}
+		if vserver := GetOption(fields, "-vServer"); ValidVserverState(strings.ToUpper(vserver)) {
+			snip.vserver = strings.ToUpper(vserver)
+		} else if vserver != "" {
+			warnings = append(warnings, invalidVserverStateWarning(addNsIpLine, address, vserver))
+		}
+		snip.arp = optionState(fields, "-arp", "ENABLED")
+		snip.icmp = optionState(fields, "-icmp", "ENABLED")
+		snip.line = addNsIpLine.number
+		snips = append(snips, snip)
+	}
+	snips, overlayWarnings, err := ApplySnipOverlays(file, snips)
+	if err != nil {
+		return snipModel{}, err
+	}
+	return snipModel{snips, append(warnings, overlayWarnings...)}, nil
+}
+
+// ApplySnipOverlays is a function that applies the "set ns ip" lines, the "bind ns ip" annotations and the VLAN IP
+// bindings of a configuration to the SNIPs added by "add ns ip" lines, so that the SNIPs reflect the effective
+// configuration. It returns a warning for every annotation it leaves out.
+func ApplySnipOverlays(file string, snips []Snip) ([]Snip, []Warning, error) {
+	index := make(map[string]int)
+	for i, snip := range snips {
+		index[snip.ipAddress] = i
+	}
+	setNsIpLines, err := GetConfigLines(file, "(set ns ip ).*")
+	if err != nil {
+		return nil, nil, err
+	}
+	var warnings []Warning
+	for _, setNsIpLine := range setNsIpLines {
+		fields := SplitConfigLine(RemoveConfigKeywords(setNsIpLine.text, "set ns ip "))
+		if len(fields) == 0 {
+			continue
+		}
+		ownerNode := GetOption(fields, "-ownerNode")
+		if ownerNode != "" && !ValidOwnerNode(ownerNode) {
+			warnings = append(warnings, invalidOwnerNodeWarning(setNsIpLine, fields[0], ownerNode))
+		}
+		vserver := GetOption(fields, "-vServer")
+		if vserver != "" && !ValidVserverState(strings.ToUpper(vserver)) {
+			warnings = append(warnings, invalidVserverStateWarning(setNsIpLine, fields[0], vserver))
+		}
+		i, ok := index[fields[0]]
+		if !ok {
+			continue
+		}
+		if netmask := GetOption(fields, "-netmask"); netmask != "" {
+			snips[i].subnetMask = netmask
+		}
+		if ipType := GetOption(fields, "-type"); ipType != "" {
+			snips[i].ipType = strings.ToUpper(ipType)
+		}
+		if ValidOwnerNode(ownerNode) {
+			snips[i].ownerNode = clusterOwner(ownerNode)
+		}
+		if ValidVserverState(strings.ToUpper(vserver)) {
+			snips[i].vserver = strings.ToUpper(vserver)
+		}
+		snips[i].arp = optionState(fields, "-arp", snips[i].arp)
+		snips[i].icmp = optionState(fields, "-icmp", snips[i].icmp)
+	}
+	annotationWarnings, err := applySnipAnnotations(file, snips, index)
+	if err != nil {
+		return nil, nil, err
+	}
+	warnings = append(warnings, annotationWarnings...)
+	bindVlanLines, err := GetConfigLines(file, "(bind vlan ).*")
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, bindVlanLine := range bindVlanLines {
+		fields := SplitConfigLine(RemoveConfigKeywords(bindVlanLine.text, "bind vlan "))
+		if i, ok := index[GetOption(fields, "-IPAddress")]; ok {
+			snips[i].vlan = fields[0]
+		}
+	}
+	return snips, warnings, nil
+}
+
+// ConvertMask is a function that converts subnet masks from decimal notation to CIDR notation.
+func ConvertMask(mask string) string {
+	return "/" + subnetMasks[mask]
+}
+
+// GetNetworks is a function that accepts an array of SNIPs as a parameter for input and then returns an array
+// of networks based off of the SNIPs.
+func GetNetworks(snips []Snip) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, snip := range snips {
+		_, network, err := net.ParseCIDR(snip.ipAddress + ConvertMask(snip.subnetMask))
+		if err != nil {
+			return []*net.IPNet{}, err
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// FilterValidSnips is a function that splits an array of SNIPs into those with a subnet mask that can be
+// converted to CIDR notation and those without.
+func FilterValidSnips(snips []Snip) ([]Snip, []Snip) {
+	var valid, invalid []Snip
+	for _, snip := range snips {
+		if _, ok := subnetMasks[snip.subnetMask]; ok {
+			valid = append(valid, snip)
+		} else {
+			invalid = append(invalid, snip)
+		}
+	}
+	return valid, invalid
+}
+
+// GetCoverageNetworks is a function that accepts a file name as a parameter for input and then returns the
+// networks of the SNIPs that server traffic can be sent from along with any networks the policy adds.
+func GetCoverageNetworks(fileName string, options AnalyzeOptions) ([]*net.IPNet, error) {
+	sourceSnips, err := GetSourceSnips(fileName, options)
+	if err != nil {
+		return nil, err
+	}
+	networks, err := GetNetworks(sourceSnips)
+	if err != nil {
+		return nil, err
+	}
+	policyNetworks, err := options.policy.CoveringNetworks(fileName)
+	if err != nil {
+		return nil, err
+	}
+	return append(networks, policyNetworks...), nil
+}
+
+// GetUncoveredServers is a function that accepts an array of networks and an array of servers as parameters
+// for input and then returns the servers that do not fall within any of the networks. Domain based servers that
+// have not been resolved to an address are left out since their coverage cannot be known.
+func GetUncoveredServers(networks []*net.IPNet, servers []Server) []Server {
+	covered := GetCoverage(networks, servers)
+	var uncovered []Server
+	for i, server := range servers {
+		if server.ipAddress == "" || server.excluded {
+			continue
+		}
+		if !covered[i] {
+			uncovered = append(uncovered, server)
+		}
+	}
+	return uncovered
+}
+
+// coverageShardSize is the smallest number of servers worth handing to a goroutine of its own.
+const coverageShardSize = 1024
+
+// GetCoverage is a function that reports for each server whether it falls within any of the networks. Large
+// server lists are split into shards that are checked concurrently, one goroutine per CPU. Each goroutine only
+// writes to the part of the result that belongs to its shard, so the result is the same on every run.
+func GetCoverage(networks []*net.IPNet, servers []Server) []bool {
+	covered := make([]bool, len(servers))
+	workers := runtime.NumCPU()
+	if shards := (len(servers) + coverageShardSize - 1) / coverageShardSize; shards < workers {
+		workers = shards
+	}
+	if workers < 1 {
+		return covered
+	}
+	shardSize := (len(servers) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < len(servers); start += shardSize {
+		end := min(start+shardSize, len(servers))
+		wg.Add(1)
+		go func(shard []Server, result []bool) {
+			defer wg.Done()
+			for i, server := range shard {
+				serverIP := net.ParseIP(server.ipAddress)
+				if serverIP == nil {
+					continue
+				}
+				for _, network := range networks {
+					if network.Contains(serverIP) {
+						result[i] = true
+						break
+					}
+				}
+			}
+		}(servers[start:end], covered[start:end])
+	}
+	wg.Wait()
+	return covered
+}
+
+// subnetMasks is the map of subnet masks built once at start up, since masks are converted for every SNIP. It is
+// never written to afterwards, so it can be read by analyses running in parallel.
+var subnetMasks = newSubnetMaskMap()
+
+// SubnetMaskMap is a function that returns a map of subnet masks that map decimal notation to their
+// equivalent CIDR notation. The map is a copy, so callers may modify it.
+func SubnetMaskMap() map[string]string {
+	return maps.Clone(subnetMasks)
+}
+
+// newSubnetMaskMap is a function that builds the map of subnet masks returned by SubnetMaskMap.
+func newSubnetMaskMap() map[string]string {
+	subnetMap := make(map[string]string)
+	subnetMap["255.0.0.0"] = "8"
+	subnetMap["255.128.0.0"] = "9"
+	subnetMap["255.192.0.0"] = "10"
+	subnetMap["255.224.0.0"] = "11"
+	subnetMap["255.240.0.0"] = "12"
+	subnetMap["255.248.0.0"] = "13"
+	subnetMap["255.252.0.0"] = "14"
+	subnetMap["255.254.0.0"] = "15"
+	subnetMap["255.255.0.0"] = "16"
+	subnetMap["255.255.128.0"] = "17"
+	subnetMap["255.255.192.0"] = "18"
+	subnetMap["255.255.224.0"] = "19"
+	subnetMap["255.255.240.0"] = "20"
+	subnetMap["255.255.248.0"] = "21"
+	subnetMap["255.255.252.0"] = "22"
+	subnetMap["255.255.254.0"] = "23"
+	subnetMap["255.255.255.0"] = "24"
+	subnetMap["255.255.255.128"] = "25"
+	subnetMap["255.255.255.192"] = "26"
+	subnetMap["255.255.255.224"] = "27"
+	subnetMap["255.255.255.240"] = "28"
+	subnetMap["255.255.255.248"] = "29"
+	subnetMap["255.255.255.252"] = "30"
+	subnetMap["255.255.255.254"] = "31"
+	subnetMap["255.255.255.255"] = "32"
+	return subnetMap
+}
+
+// CreateFile is a fucntion that accepts a file name as a parameter and returns a pointer to a file. A file left by
+// an earlier run is truncated, so that its lines are not written twice.
+func CreateFile(fileName string) (*os.File, error) {
+	file, err := os.OpenFile(fileName, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	RecordGeneratedFile(fileName)
+	return file, nil
+}
+
+// AnalyzeOptions is a data structure for the command line options that control the coverage analysis. The
+// analysis only reads the options, so the same options can be used by analyses running in parallel.
+type AnalyzeOptions struct {
+	format       string
+	resolve      bool
+	resolver     string
+	history      string
+	nativeVlan   string
+	policy       *Policy
+	partial      bool
+	owners       *OwnerMap
+	prober       *Prober
+	node         string
+	profile      *Profile
+	metadata     *RunMetadata
+	suppressions *Suppressions
+	platform     string
+	index        string
+	mtu          int
+	color        bool
+	explainAll   bool
+	show         string
+	exceptions   *Exceptions
+	stats        *RunStats
+	stream       *NDJSONStream
+	limiter      *FetchLimiter
+	checkpoint   *Checkpoint
+	ctx          context.Context
+}
+
+// WithResolver is a function that returns the options with domain based servers resolved through DNS before their
+// coverage is checked, using the given DNS server as host:port or the system resolver when it is empty.
+func (options AnalyzeOptions) WithResolver(resolver string) AnalyzeOptions {
+	options.resolve = true
+	options.resolver = resolver
+	return options
+}
+
+// WithNativeVlan is a function that returns the options with the VLAN expected untagged on trunk interfaces, which
+// the VLAN bindings are checked against.
+func (options AnalyzeOptions) WithNativeVlan(vlan string) AnalyzeOptions {
+	options.nativeVlan = vlan
+	return options
+}
+
+// WithPartial is a function that returns the options for a partial configuration or batch file, whose references
+// to objects missing from it are warnings rather than findings.
+func (options AnalyzeOptions) WithPartial() AnalyzeOptions {
+	options.partial = true
+	return options
+}
+
+// WithProfile is a function that returns the options with findings limited to the rules of a profile, as returned
+// by GetProfile.
+func (options AnalyzeOptions) WithProfile(profile *Profile) AnalyzeOptions {
+	options.profile = profile
+	return options
+}
+
+// WithContext is a function that returns the options with the analysis bound by a context, so that it stops with
+// an error once the context is cancelled or its deadline passes. Without one the analysis is only bound by
+// -timeout.
+func (options AnalyzeOptions) WithContext(ctx context.Context) AnalyzeOptions {
+	options.ctx = ctx
+	return options
+}
+
+// GetAnalysisServers is a function that accepts a file name as a parameter for input and then returns the
+// servers to check for coverage, marking those the policy excludes. Excluded servers are kept, as objects still
+// reference them, and are only left out of the coverage results. Domain based servers are resolved from the DNS
+// records of the configuration first, and the rest through DNS when that has been requested.
+func GetAnalysisServers(fileName string, options AnalyzeOptions) ([]Server, error) {
+	servers, err := GetServers(fileName)
+	if err != nil {
+		return nil, err
+	}
+	records, err := GetDnsRecords(fileName)
+	if err != nil {
+		return nil, err
+	}
+	ResolveServersLocally(servers, NewLocalZone(records))
+	if options.resolve {
+		ResolveServers(options.analysisContext(), servers, NewResolver(options.resolver))
+		if err := options.checkContext(); err != nil {
+			return nil, err
+		}
+	}
+	for i := range servers {
+		servers[i].excluded = options.policy.Excludes(servers[i])
+	}
+	return servers, nil
+}
+
+// includedServers is a function that returns the servers that the policy does not exclude, which are those that
+// coverage is reported for.
+func includedServers(servers []Server) []Server {
+	var included []Server
+	for _, server := range servers {
+		if !server.excluded {
+			included = append(included, server)
+		}
+	}
+	return included
+}
+
+// RunAnalyze is a function that runs the coverage analysis for a configuration file and writes the results in
+// the requested formats. A single format other than text is written to standard output, while several formats
+// are each written to their own file named after the configuration. Results that are streamed have been written
+// by the analysis already.
+func RunAnalyze(filename string, options AnalyzeOptions) error {
+	reporters, text, err := GetFormatReporters(options.format)
+	if err != nil {
+		return err
+	}
+	findings, err := AnalyzeFile(filename, options, len(reporters) > 0, text)
+	if err != nil || options.stream != nil {
+		return err
+	}
+	return WriteReports(OutputBaseName(filename), options.format, reporters, options.metadata, findings)
+}
+
+// GetFormatReporters is a function that returns the reporter for every format of a comma separated format list
+// other than text, along with whether text output was requested.
+func GetFormatReporters(format string) ([]Reporter, bool, error) {
+	var reporters []Reporter
+	text := false
+	for _, format := range ParseFormats(format) {
+		if format == "text" {
+			text = true
+			continue
+		}
+		reporter, err := GetReporter(format)
+		if err != nil {
+			return nil, false, err
+		}
+		reporters = append(reporters, reporter)
+	}
+	return reporters, text, nil
+}
+
+// WriteReports is a function that writes findings with every reporter, to standard output when a single format
+// was requested and otherwise to a file per format named after the base name.
+func WriteReports(baseName, format string, reporters []Reporter, metadata *RunMetadata, findings []Finding) error {
+	if len(reporters) == 1 && len(ParseFormats(format)) == 1 {
+		return reporters[0].Report(os.Stdout, metadata, findings)
+	}
+	for _, reporter := range reporters {
+		if err := WriteReport(baseName+"-findings."+reporter.Extension(), reporter, metadata, findings); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AnalyzeFile is a function that runs the coverage analysis for a configuration file, recording it in the
+// history store when one is set, writing the uncovered servers to a text file named after the configuration when
+// text output is requested, heaviest first and leaving out those whose uncovered-server finding is suppressed, and
+// returning the findings when they are requested, those left out by a suppression or an exception among them
+// marked as such for the reports to account for. Text output also writes the coverage of every server to standard
+// output as a table, colored when options.color is set.
+func AnalyzeFile(filename string, options AnalyzeOptions, withFindings, text bool) ([]Finding, error) {
+	if options.stats != nil {
+		started := time.Now()
+		if err := options.stats.ParseObjects(filename); err != nil {
+			return nil, err
+		}
+		parsed := time.Now()
+		defer func() { options.stats.AddDurations(parsed.Sub(started), time.Since(parsed)) }()
+	}
+	if options.history != "" {
+		if err := RecordHistory(options.history, filename, options); err != nil {
+			return nil, err
+		}
+	}
+	networks, err := GetCoverageNetworks(filename, options)
+	if err != nil {
+		return nil, err
+	}
+	warnings, err := GetWarnings(filename, options)
+	if err != nil {
+		return nil, err
+	}
+	PrintWarnings(os.Stderr, warnings)
+	var emit func([]Finding) error
+	if options.stream != nil {
+		if err := streamObjects(filename, networks, warnings, options); err != nil {
+			return nil, err
+		}
+		emit = func(findings []Finding) error {
+			return options.stream.WriteFindings(ReportedFindings(findings))
+		}
+	}
+	var findings []Finding
+	if withFindings {
+		if findings, err = getFindings(filename, options, emit); err != nil {
+			return nil, err
+		}
+	}
+	if !text || !ChecksCoverage(networks, options) {
+		return findings, nil
+	}
+	servers, err := GetAnalysisServers(filename, options)
+	if err != nil {
+		return nil, err
+	}
+	graph, err := GetGraph(filename)
+	if err != nil {
+		return nil, err
+	}
+	radii := BlastRadius(graph, servers)
+	PrintTerminalReport(os.Stdout, filename, networks, servers, radii, options, options.color)
+	if options.explainAll {
+		if err := PrintCoverageTrace(os.Stdout, filename, servers, options); err != nil {
+			return nil, err
+		}
+	}
+	if options.show != "covered" {
+		if err := WriteUncoveredServers(filename, networks, servers, radii, options); err != nil {
+			return nil, err
+		}
+	}
+	if options.show == "covered" || options.show == "all" {
+		if err := WriteCoveredServers(filename, networks, servers, radii, options); err != nil {
+			return nil, err
+		}
+	}
+	return findings, nil
+}
+
+// streamObjects is a function that writes the servers, SNIPs and warnings of a configuration to the NDJSON stream
+// of the run, ahead of its findings, which are written rule by rule as the analysis finds them.
+func streamObjects(filename string, networks []*net.IPNet, warnings []Warning, options AnalyzeOptions) error {
+	servers, err := GetAnalysisServers(filename, options)
+	if err != nil {
+		return err
+	}
+	snips, err := GetSnips(filename)
+	if err != nil {
+		return err
+	}
+	return options.stream.WriteObjects(filename, servers, networks, snips, warnings)
+}
+
+// WriteUncoveredServers is a function that writes the address of every uncovered server that is not suppressed
+// to the server output file of a configuration, those with the largest blast radius first, along with their
+// owner, probe result, blast radius and comment when known. The blast radius and the comment are marked with
+// their name, so that they can be told apart whichever of the columns before them a row has. No file is written
+// when every server is covered.
+func WriteUncoveredServers(filename string, networks []*net.IPNet, servers []Server, radii map[string][]Node,
+	options AnalyzeOptions) error {
+	var uncovered []Server
+	for _, server := range GetUncoveredServers(networks, servers) {
+		if options.SuppressedBy(server) == "" {
+			uncovered = append(uncovered, server)
+		}
+	}
+	if len(uncovered) == 0 {
+		return nil
+	}
+	SortByBlastRadius(uncovered, radii)
+	file, err := CreateFile(OutputBaseName(filename) + "-server-output.txt")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := WriteMetadataComments(file, options.metadata.ForInput(filename)); err != nil {
+		return err
+	}
+	var probed map[string]bool
+	if options.prober != nil {
+		if probed, err = options.prober.ProbeServers(uncovered); err != nil {
+			return err
+		}
+	}
+	for _, server := range uncovered {
+		columns := []string{server.ipAddress}
+		if options.owners != nil {
+			columns = append(columns, ownerOrUnowned(options.owners, server))
+		}
+		if options.prober != nil {
+			columns = append(columns, options.prober.Describe(probed[server.ipAddress]))
+		}
+		if blastRadius := DescribeBlastRadius(radii[server.name]); blastRadius != "" {
+			columns = append(columns, blastRadius)
+		}
+		if server.comment != "" {
+			columns = append(columns, Translate("comment")+": "+server.comment)
+		}
+		fmt.Fprintln(file, strings.Join(columns, "\t"))
+	}
+	return nil
+}
+
+// WriteCoveredServers is a function that writes the address of every covered server to the covered output file
+// of a configuration, those with the largest blast radius first, along with their owner, blast radius and comment
+// when known, the last two marked with their name as in the server output file. These are the servers that are
+// safe to migrate.
+func WriteCoveredServers(filename string, networks []*net.IPNet, servers []Server, radii map[string][]Node,
+	options AnalyzeOptions) error {
+	var covered []Server
+	for i, isCovered := range GetCoverage(networks, servers) {
+		if isCovered && servers[i].ipAddress != "" && !servers[i].excluded {
+			covered = append(covered, servers[i])
+		}
+	}
+	SortByBlastRadius(covered, radii)
+	file, err := CreateFile(OutputBaseName(filename) + "-covered-output.txt")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := WriteMetadataComments(file, options.metadata.ForInput(filename)); err != nil {
+		return err
+	}
+	for _, server := range covered {
+		columns := []string{server.ipAddress}
+		if options.owners != nil {
+			columns = append(columns, ownerOrUnowned(options.owners, server))
+		}
+		if blastRadius := DescribeBlastRadius(radii[server.name]); blastRadius != "" {
+			columns = append(columns, blastRadius)
+		}
+		if server.comment != "" {
+			columns = append(columns, Translate("comment")+": "+server.comment)
+		}
+		fmt.Fprintln(file, strings.Join(columns, "\t"))
+	}
+	return nil
+}
+
+// Main contains the business logic of the application, which the nsanalyze command runs.
+func Main() {
+	var options AnalyzeOptions
+	flag.StringVar(&options.format, "format", "text", "comma separated output formats: text, json, ndjson, sarif, csv or html, ndjson alone streaming to standard output")
+	flag.BoolVar(&options.resolve, "resolve", false, "resolve domain based servers through DNS before checking coverage")
+	flag.StringVar(&options.resolver, "resolver", "", "DNS server to resolve with as host:port, defaults to the system resolver")
+	flag.StringVar(&options.history, "history", "", "record a summary of the run in this history store")
+	flag.StringVar(&options.nativeVlan, "native-vlan", "", "VLAN expected untagged on trunk interfaces")
+	flag.IntVar(&options.mtu, "mtu", 0, "MTU the trunk is standardized on, such as 9000 for jumbo frames")
+	flag.BoolVar(&options.partial, "partial", false, "input is a partial configuration or batch file, so missing objects are warnings")
+	flag.StringVar(&options.show, "show", "uncovered", "servers to write to the output files: uncovered, covered or all")
+	flag.BoolVar(&options.explainAll, "explain-all", false, "trace every server through the networks it was tested against and which matched")
+	flag.StringVar(&options.node, "node", "", "cluster node to check coverage from, leaving out IPs spotted on other nodes")
+	platform := flag.String("platform", "auto", "deployment the configuration comes from: auto, mpx, vpx, cpx or blx")
+	lang := flag.String("lang", "en", "language of report headings and labels: en, es or de")
+	policyFile := flag.String("policy", "", "JSON policy file with covering prefixes, excluded servers and default route handling")
+	probe := flag.String("probe", "", "probe uncovered servers from this machine with icmp or tcp:<port>")
+	probeTimeout := flag.Duration("probe-timeout", 2*time.Second, "how long to wait for each probe to be answered")
+	showVersion := flag.Bool("version", false, "print the version of the tool and exit")
+	showStats := flag.Bool("stats", false, "print the lines scanned, objects per type, parse and analysis time and peak memory of the analysis to standard error")
+	timeout := flag.Duration("timeout", 0, "how long fetching and analyzing may take before the run fails, such as 5m, 0 for no limit; not applied to serve")
+	probeRate := flag.Int("probe-rate", 10, "most probes to start per second")
+	fetchRate := flag.Float64("fetch-rate", 0, "most remote configurations to start fetching per second across every host, 0 for no limit")
+	hostInterval := flag.Duration("host-interval", 0, "least time between two fetches from the same host, retries included, such as 10s")
+	checkpointFile := flag.String("checkpoint", "", "record the configurations analyzed and their results in this file, and report those results without reading the configurations again when the run is resumed")
+	profile := flag.String("profile", "", "run only the rules of a profile: coverage-only, full-audit, vlan-migration or security")
+	manifest := flag.String("manifest", "", "write a SHA-256 manifest of the report files to this file")
+	signKey := flag.String("sign-key", "", "private key to write a detached signature of the manifest with")
+	ownersFile := flag.String("owners", "", "CSV or JSON file mapping addresses and networks to their owners")
+	flag.StringVar(&options.index, "index", "", "write an index linking the report of every configuration to this .html or .json file, defaults to "+defaultIndexFile+" for directories")
+	colorMode := flag.String("color", "auto", "color the terminal report: auto, always or never, auto coloring only a terminal without NO_COLOR set")
+	configFile := flag.String("config", "", "settings file with default options, devices and policy, defaults to ~/"+defaultSettingsFile)
+	exceptionsFile := flag.String("exceptions", "", "file of server names, addresses and networks known to be uncovered, to leave out of the uncovered report")
+	ignoreFile := flag.String("ignore", "", "file of rule and object pairs whose findings to suppress, defaults to "+defaultSuppressionFile)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] filename|directory|device...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s [options] -|http(s)://...|s3://bucket/key[?region=r]|ssh://user@host|scp://user@host/path|nitro://user@host[?pagesize=n]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s trunk filename\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s port-channel [-vendor cisco|arista|junos] filename\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s anonymize [-key key] filename\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s history [-db file] [-server name] [device]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s remediate [-prefix length] [-gateway ip] [-vlan id] [-bundle dir [-vendor name]] [-interactive [-plan file]] filename\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s remediate -render plan [-bundle dir [-vendor name]]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s utilization [-threshold percent] filename\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s services filename\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s servicenow -instance url [-table name] [-dry-run] filename\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s addresses filename\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s ipam -subnets file filename\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s ipam-export -target phpipam|infoblox [-format csv|json] [-table subnets|addresses] [-section name] filename\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s explain ip filename\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s ha-compare primary secondary [filename...]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s compare filename filename...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s -owners file owners filename\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s impact -remove-snip ip|-remove-vlan id filename\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s serve [-listen address] [-allow-files]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s consolidate filename...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s nat64 filename\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s simulate -patch file filename\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s extract -type type [-name name] [-n] filename\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s grep -pattern regexp [-format text|json|csv] [-n] filename\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Options:")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if err := CheckCommandLineCredentials(os.Args[1:]); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if *showVersion {
+		fmt.Println(Version())
+		return
+	}
+	settingsFile := *configFile
+	if settingsFile == "" {
+		settingsFile = DefaultSettingsPath()
+	}
+	settings, err := LoadSettings(settingsFile, *configFile != "")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err := settings.ApplyDefaults(flag.CommandLine); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if flag.NArg() < 1 && !settings.HasDevices() {
+		flag.Usage()
+		os.Exit(1)
+	}
+	if err := SetLanguage(*lang); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if flag.Arg(0) != "serve" {
+		cancel := SetRunTimeout(*timeout)
+		defer cancel()
+	}
+	selectedPlatform, err := ParsePlatform(*platform)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	options.platform = selectedPlatform
+	if options.color, err = ParseColorMode(*colorMode, os.Stdout); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if options.mtu != 0 && (options.mtu < 500 || options.mtu > 9216) {
+		fmt.Println("-mtu must be between 500 and 9216")
+		os.Exit(1)
+	}
+	if options.show != "uncovered" && options.show != "covered" && options.show != "all" {
+		fmt.Println("-show must be uncovered, covered or all")
+		os.Exit(1)
+	}
+	if *policyFile != "" {
+		policy, err := LoadPolicy(*policyFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		options.policy = policy
+	} else {
+		options.policy = settings.policy
+	}
+	if *ownersFile != "" {
+		owners, err := LoadOwnerMap(*ownersFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		options.owners = owners
+	}
+	if *probe != "" {
+		prober, err := NewProber(*probe, *probeTimeout, *probeRate)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		options.prober = prober
+	}
+	if *fetchRate != 0 || *hostInterval != 0 {
+		limiter, err := NewFetchLimiter(*fetchRate, *hostInterval)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		options.limiter = limiter
+		SetRunLimiter(limiter)
+	}
+	if *checkpointFile != "" {
+		checkpoint, err := LoadCheckpoint(*checkpointFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		options.checkpoint = checkpoint
+	}
+	if *profile != "" {
+		selected, err := GetProfile(*profile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		options.profile = selected
+	}
+	suppressionFile := *ignoreFile
+	if suppressionFile == "" {
+		suppressionFile = defaultSuppressionFile
+	}
+	suppressions, err := LoadSuppressions(suppressionFile, *ignoreFile != "")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	options.suppressions = suppressions
+	if *exceptionsFile != "" {
+		if options.exceptions, err = LoadExceptions(*exceptionsFile); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	var signer crypto.Signer
+	if *signKey != "" {
+		if *manifest == "" {
+			fmt.Println("-sign-key requires -manifest")
+			os.Exit(1)
+		}
+		key, err := LoadSigningKey(*signKey)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		signer = key
+	}
+	switch flag.Arg(0) {
+	case "trunk":
+		err = RunTrunk(flag.Args()[1:])
+	case "port-channel":
+		err = RunPortChannel(flag.Args()[1:])
+	case "ipam":
+		err = RunIpam(flag.Args()[1:])
+	case "ipam-export":
+		err = RunIpamExport(flag.Args()[1:], options)
+	case "anonymize":
+		err = RunAnonymize(flag.Args()[1:])
+	case "history":
+		err = RunHistory(flag.Args()[1:])
+	case "remediate":
+		err = RunRemediate(flag.Args()[1:], options)
+	case "utilization":
+		err = RunUtilization(flag.Args()[1:])
+	case "services":
+		err = RunServices(flag.Args()[1:], options)
+	case "servicenow":
+		err = RunServiceNow(flag.Args()[1:], options)
+	case "addresses":
+		err = RunAddresses(flag.Args()[1:], options)
+	case "explain":
+		err = RunExplain(flag.Args()[1:], options)
+	case "ha-compare":
+		err = RunHACompare(flag.Args()[1:])
+	case "compare":
+		err = RunCompare(flag.Args()[1:])
+	case "owners":
+		err = RunOwners(flag.Args()[1:], options)
+	case "impact":
+		err = RunImpact(flag.Args()[1:], options)
+	case "consolidate":
+		err = RunConsolidate(flag.Args()[1:], options)
+	case "serve":
+		err = RunServe(flag.Args()[1:])
+	case "nat64":
+		err = RunNat64(flag.Args()[1:])
+	case "simulate":
+		err = RunSimulate(flag.Args()[1:], options)
+	case "extract":
+		err = RunExtract(flag.Args()[1:])
+	case "grep":
+		err = RunGrep(flag.Args()[1:])
+	default:
+		sources, resolveErr := settings.ResolveDevices(flag.Args())
+		if resolveErr != nil {
+			err = resolveErr
+			break
+		}
+		fileNames, directories, expandErr := ExpandConfigPaths(sources)
+		if expandErr != nil {
+			err = expandErr
+			break
+		}
+		if len(fileNames) == 0 {
+			err = errors.New("no configurations to analyze")
+			break
+		}
+		if directories && options.index == "" {
+			options.index = defaultIndexFile
+		}
+		options.metadata = NewRunMetadata(os.Args[1:], fileNames)
+		if *showStats {
+			options.stats = NewRunStats()
+		}
+		err = RunAnalyzeFiles(fileNames, options)
+		if options.stats != nil {
+			PrintStats(os.Stderr, options.stats)
+		}
+	}
+	if *manifest != "" {
+		if manifestErr := WriteManifest(*manifest, signer); manifestErr != nil && err == nil {
+			err = manifestErr
+		}
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
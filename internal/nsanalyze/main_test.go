@@ -0,0 +1,266 @@
+package nsanalyze
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfig is a function that writes the lines of a configuration to a temporary file and returns its name.
+func writeConfig(t testing.TB, lines ...string) string {
+	t.Helper()
+	fileName := filepath.Join(t.TempDir(), "ns.conf")
+	if err := os.WriteFile(fileName, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return fileName
+}
+
+// findingKeys is a function that returns the rule and object of every finding, for comparison in tests.
+func findingKeys(findings []Finding) []string {
+	var keys []string
+	for _, finding := range findings {
+		keys = append(keys, finding.rule.id+" "+finding.object)
+	}
+	return keys
+}
+
+// hasFinding is a function that reports whether the findings hold one of a rule about an object.
+func hasFinding(findings []Finding, rule, object string) bool {
+	for _, finding := range findings {
+		if finding.rule.id == rule && finding.object == object {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSplitConfigLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"", nil},
+		{"add server web1 10.0.0.1", []string{"add", "server", "web1", "10.0.0.1"}},
+		{"add  server\tweb1 \r", []string{"add", "server", "web1"}},
+		{`add server "web 1" 10.0.0.1`, []string{"add", "server", "web 1", "10.0.0.1"}},
+		{`set ns hostName "a\"b"`, []string{"set", "ns", "hostName", `a"b`}},
+		{`add lb monitor m HTTP -send "GET / HTTP/1.1\\r\\n"`, []string{"add", "lb", "monitor", "m", "HTTP", "-send", `GET / HTTP/1.1\r\n`}},
+		{`-comment ""`, []string{"-comment", ""}},
+	}
+	for _, test := range tests {
+		t.Run(test.line, func(t *testing.T) {
+			got := SplitConfigLine(test.line)
+			if strings.Join(got, "|") != strings.Join(test.want, "|") || len(got) != len(test.want) {
+				t.Errorf("SplitConfigLine(%q) = %q, want %q", test.line, got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetOption(t *testing.T) {
+	fields := SplitConfigLine("add vlan 10 -aliasName prod -ifnum 1/1 1/2 -tagged")
+	tests := []struct {
+		option string
+		value  string
+		values []string
+		has    bool
+	}{
+		{"-aliasName", "prod", []string{"prod"}, true},
+		{"-ALIASNAME", "prod", []string{"prod"}, true},
+		{"-ifnum", "1/1", []string{"1/1", "1/2"}, true},
+		{"-tagged", "", nil, true},
+		{"-mtu", "", nil, false},
+	}
+	for _, test := range tests {
+		t.Run(test.option, func(t *testing.T) {
+			if got := GetOption(fields, test.option); got != test.value {
+				t.Errorf("GetOption(%s) = %q, want %q", test.option, got, test.value)
+			}
+			if got := GetOptionValues(fields, test.option); strings.Join(got, " ") != strings.Join(test.values, " ") {
+				t.Errorf("GetOptionValues(%s) = %q, want %q", test.option, got, test.values)
+			}
+			if got := HasOption(fields, test.option); got != test.has {
+				t.Errorf("HasOption(%s) = %v, want %v", test.option, got, test.has)
+			}
+		})
+	}
+}
+
+func TestGetConfigLines(t *testing.T) {
+	file := "#NS13.1 Build 1.1\nadd server a 10.0.0.1\n\nadd server b 10.0.0.2\nadd service s a HTTP 80"
+	lines, err := GetConfigLines(file, "(add server).*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ConfigLine{{"add server a 10.0.0.1", 2}, {"add server b 10.0.0.2", 4}}
+	if len(lines) != len(want) {
+		t.Fatalf("GetConfigLines = %v, want %v", lines, want)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %v, want %v", i, lines[i], want[i])
+		}
+	}
+}
+
+func TestNormalizeConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want string
+	}{
+		{"unchanged", "add server a 10.0.0.1\n", "add server a 10.0.0.1\n"},
+		{"byte order mark", "\ufeffadd server a 10.0.0.1\n", "add server a 10.0.0.1\n"},
+		{"windows line endings", "add server a 10.0.0.1\r\nadd server b 10.0.0.2\r\n", "add server a 10.0.0.1\nadd server b 10.0.0.2\n"},
+		{"old mac line endings", "add server a 10.0.0.1\radd server b 10.0.0.2", "add server a 10.0.0.1\nadd server b 10.0.0.2"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := NormalizeConfig(test.file); got != test.want {
+				t.Errorf("NormalizeConfig(%q) = %q, want %q", test.file, got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetServers(t *testing.T) {
+	fileName := writeConfig(t,
+		"add server web1 10.0.0.1 -comment \"payments web\"",
+		"add server app1 app1.example.com",
+		"add server db1 -domainName db1.example.com",
+		"add server nat1 10.0.0.9 -translationIp 192.168.0.9 -translationMask 255.255.255.255",
+		"add server",
+		"add serviceGroup sg1 HTTP",
+		"bind serviceGroup sg1 10.0.0.7 80",
+	)
+	servers, err := GetServers(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]Server)
+	for _, server := range servers {
+		byName[server.name] = server
+	}
+	tests := []struct {
+		name        string
+		ipAddress   string
+		domainName  string
+		domainBased bool
+		implicit    bool
+		comment     string
+	}{
+		{"web1", "10.0.0.1", "", false, false, "payments web"},
+		{"app1", "", "app1.example.com", true, false, ""},
+		{"db1", "", "db1.example.com", true, false, ""},
+		{"nat1", "10.0.0.9", "", false, false, ""},
+		{"10.0.0.7", "10.0.0.7", "", false, true, ""},
+	}
+	if len(servers) != len(tests) {
+		t.Errorf("GetServers returns %d servers, want %d", len(servers), len(tests))
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, ok := byName[test.name]
+			if !ok {
+				t.Fatalf("server %s is missing", test.name)
+			}
+			if server.ipAddress != test.ipAddress || server.domainName != test.domainName ||
+				server.domainBased != test.domainBased || server.implicit != test.implicit || server.comment != test.comment {
+				t.Errorf("server %s = %+v", test.name, server)
+			}
+		})
+	}
+	if nat := byName["nat1"]; nat.translationIP != "192.168.0.9" || nat.translationMask != "255.255.255.255" {
+		t.Errorf("translation of nat1 = %s %s", nat.translationIP, nat.translationMask)
+	}
+}
+
+func TestGetSnips(t *testing.T) {
+	fileName := writeConfig(t,
+		"add ns ip 10.0.0.10 255.255.255.0 -type SNIP",
+		"add ns ip 10.0.1.10 255.255.255.0",
+		"add ns ip 10.0.2.10 255.255.255.255 -type VIP -arp DISABLED",
+		"add ns ip 10.0.3.10 255.255.255.0 -td 5",
+		"set ns ip 10.0.1.10 -netmask 255.255.254.0 -icmp DISABLED",
+		"bind vlan 20 -IPAddress 10.0.0.10 255.255.255.0",
+	)
+	snips, err := GetSnips(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		ipAddress  string
+		subnetMask string
+		ipType     string
+		vlan       string
+		td         string
+		arp        string
+		icmp       string
+	}{
+		{"10.0.0.10", "255.255.255.0", "SNIP", "20", "0", "ENABLED", "ENABLED"},
+		{"10.0.1.10", "255.255.254.0", "SNIP", "", "0", "ENABLED", "DISABLED"},
+		{"10.0.2.10", "255.255.255.255", "VIP", "", "0", "DISABLED", "ENABLED"},
+		{"10.0.3.10", "255.255.255.0", "SNIP", "", "5", "ENABLED", "ENABLED"},
+	}
+	if len(snips) != len(tests) {
+		t.Fatalf("GetSnips returns %d SNIPs, want %d", len(snips), len(tests))
+	}
+	for i, test := range tests {
+		t.Run(test.ipAddress, func(t *testing.T) {
+			snip := snips[i]
+			got := []string{snip.ipAddress, snip.subnetMask, snip.ipType, snip.vlan, snip.td, snip.arp, snip.icmp}
+			want := []string{test.ipAddress, test.subnetMask, test.ipType, test.vlan, test.td, test.arp, test.icmp}
+			if strings.Join(got, " ") != strings.Join(want, " ") {
+				t.Errorf("SNIP = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestWriteUncoveredServersComment(t *testing.T) {
+	fileName := writeConfig(t, "add ns ip 10.0.0.10 255.255.255.0 -type SNIP",
+		`add server far1 172.16.0.5 -comment "Team A"`,
+		"add server far2 172.16.0.6",
+		"add service svc2 far2 HTTP 80")
+	if _, err := AnalyzeFile(fileName, AnalyzeOptions{}, false, true); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(fileName + "-server-output.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"172.16.0.6\tblast radius 1: service svc2", "172.16.0.5\tcomment: Team A"}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("server output = %q, want %q", lines, want)
+	}
+}
+
+func TestWriteUncoveredServersRerun(t *testing.T) {
+	fileName := writeConfig(t, "add ns ip 10.0.0.10 255.255.255.0 -type SNIP", "add server far1 172.16.0.5")
+	for i := 0; i < 2; i++ {
+		if _, err := AnalyzeFile(fileName, AnalyzeOptions{}, false, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(fileName + "-server-output.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 1 || lines[0] != "172.16.0.5" {
+		t.Errorf("server output after two runs = %q, want the server once", lines)
+	}
+}
+
+func TestSubnetMaskMapCopy(t *testing.T) {
+	masks := SubnetMaskMap()
+	masks["255.255.255.0"] = "8"
+	delete(masks, "255.255.0.0")
+	if got := ConvertMask("255.255.255.0"); got != "/24" {
+		t.Errorf("mask of 255.255.255.0 = %q after modifying the returned map, want /24", got)
+	}
+	if _, ok := SubnetMaskMap()["255.255.0.0"]; !ok {
+		t.Error("255.255.0.0 is gone from the map after deleting it from a returned one")
+	}
+}
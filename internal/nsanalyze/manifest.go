@@ -0,0 +1,113 @@
+package nsanalyze
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// generatedFiles holds the name of every report file written so far, in the order they were first written.
+var generatedFiles = struct {
+	sync.Mutex
+	names []string
+}{}
+
+// RecordGeneratedFile is a function that adds a report file to those listed in the manifest.
+func RecordGeneratedFile(fileName string) {
+	generatedFiles.Lock()
+	defer generatedFiles.Unlock()
+	if !containsString(generatedFiles.names, fileName) {
+		generatedFiles.names = append(generatedFiles.names, fileName)
+	}
+}
+
+// IsGeneratedFile is a function that reports whether a report file was written by this run.
+func IsGeneratedFile(fileName string) bool {
+	generatedFiles.Lock()
+	defer generatedFiles.Unlock()
+	return containsString(generatedFiles.names, fileName)
+}
+
+// LoadSigningKey is a function that reads the private key that manifests are signed with. PKCS#8, PKCS#1, SEC 1
+// and OpenSSH keys are accepted, as long as they are not encrypted.
+func LoadSigningKey(fileName string) (crypto.Signer, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ssh.ParseRawPrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fileName, err)
+	}
+	if edKey, ok := key.(*ed25519.PrivateKey); ok {
+		key = *edKey
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported key type %T", fileName, key)
+	}
+	return signer, nil
+}
+
+// WriteManifest is a function that writes the SHA-256 checksum of every report file written so far in the format
+// of sha256sum, so that it can be checked with "sha256sum -c". With a signer the manifest is also signed and the
+// detached signature written next to it with a .sig extension: Ed25519 keys sign the manifest itself, RSA and
+// ECDSA keys its SHA-256 digest, as "openssl dgst -sha256 -verify" expects.
+func WriteManifest(fileName string, signer crypto.Signer) error {
+	generatedFiles.Lock()
+	names := append([]string(nil), generatedFiles.names...)
+	generatedFiles.Unlock()
+	var manifest strings.Builder
+	for _, name := range names {
+		sum, err := fileChecksum(name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&manifest, "%s  %s\n", sum, name)
+	}
+	if err := os.WriteFile(fileName, []byte(manifest.String()), 0644); err != nil {
+		return err
+	}
+	if signer == nil {
+		return nil
+	}
+	message, hash := []byte(manifest.String()), crypto.Hash(0)
+	if _, ok := signer.(ed25519.PrivateKey); !ok {
+		digest := sha256.Sum256(message)
+		message, hash = digest[:], crypto.SHA256
+	}
+	signature, err := signer.Sign(rand.Reader, message, hash)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fileName+".sig", signature, 0644)
+}
+
+// checksum is a function that returns the SHA-256 checksum of data as a hexadecimal string.
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// fileChecksum is a function that returns the SHA-256 checksum of a file as a hexadecimal string.
+func fileChecksum(fileName string) (string, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
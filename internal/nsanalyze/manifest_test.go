@@ -0,0 +1,102 @@
+package nsanalyze
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeSigningKey is a function that writes a private key in PEM format for a test and returns its file name.
+func writeSigningKey(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+	fileName := filepath.Join(t.TempDir(), "signing.pem")
+	if err := os.WriteFile(fileName, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return fileName
+}
+
+func TestWriteManifestSigned(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edDer, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		blockType string
+		der       []byte
+		verify    func(manifest, signature []byte) error
+	}{
+		{"ed25519", "PRIVATE KEY", edDer, func(manifest, signature []byte) error {
+			if !ed25519.Verify(edKey.Public().(ed25519.PublicKey), manifest, signature) {
+				return errors.New("ed25519 verification failed")
+			}
+			return nil
+		}},
+		{"rsa", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey), func(manifest, signature []byte) error {
+			digest := sha256.Sum256(manifest)
+			return rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA256, digest[:], signature)
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			generatedFiles.Lock()
+			saved := generatedFiles.names
+			generatedFiles.names = nil
+			generatedFiles.Unlock()
+			t.Cleanup(func() {
+				generatedFiles.Lock()
+				generatedFiles.names = saved
+				generatedFiles.Unlock()
+			})
+			dir := t.TempDir()
+			report := filepath.Join(dir, "report.json")
+			if err := os.WriteFile(report, []byte("{}\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			RecordGeneratedFile(report)
+			signer, err := LoadSigningKey(writeSigningKey(t, test.blockType, test.der))
+			if err != nil {
+				t.Fatal(err)
+			}
+			fileName := filepath.Join(dir, "manifest.sha256")
+			if err := WriteManifest(fileName, signer); err != nil {
+				t.Fatal(err)
+			}
+			manifest, err := os.ReadFile(fileName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := checksum([]byte("{}\n")) + "  " + report + "\n"; string(manifest) != want {
+				t.Errorf("manifest = %q, want %q", manifest, want)
+			}
+			signature, err := os.ReadFile(fileName + ".sig")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := test.verify(manifest, signature); err != nil {
+				t.Errorf("signature does not verify: %v", err)
+			}
+			if err := test.verify([]byte(strings.ToUpper(string(manifest))), signature); err == nil {
+				t.Error("signature verifies a changed manifest")
+			}
+		})
+	}
+}
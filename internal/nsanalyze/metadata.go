@@ -0,0 +1,130 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// toolVersion is the version of the tool written into every report. Release builds set it with
+// -ldflags "-X github.com/ajenehall/vlanTrunkProject/internal/nsanalyze.toolVersion=v1.2.3", otherwise the module
+// version of the build is used when there is one.
+var toolVersion = "dev"
+
+// modulePath is the path of the module the tool is released as.
+const modulePath = "github.com/ajenehall/vlanTrunkProject"
+
+// Version is a function that returns the version of the tool: the one set when it was linked, otherwise the
+// version of the module it was built from, which is the release tag when the tool is installed at a tag or another
+// module requires it at one.
+func Version() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || toolVersion != "dev" {
+		return toolVersion
+	}
+	for _, module := range append([]*debug.Module{&info.Main}, info.Deps...) {
+		if module.Path == modulePath && module.Version != "" && module.Version != "(devel)" {
+			return module.Version
+		}
+	}
+	return toolVersion
+}
+
+// RunMetadata is a data structure for what a report was produced from: the version of the tool, when the run
+// started, the configurations analyzed and the command line options, so that a report can be traced back to the
+// exact input and parameters that produced it.
+type RunMetadata struct {
+	version string
+	started time.Time
+	options []string
+	inputs  []string
+}
+
+// InputChecksum is a data structure for a configuration analyzed by a run along with the SHA-256 checksum of its
+// contents as read, before line endings are normalized.
+type InputChecksum struct {
+	fileName string
+	sha256   string
+}
+
+// NewRunMetadata is a function that returns the metadata of a run that started now with the given command line
+// options and configurations. Credentials within configuration URLs are not kept.
+func NewRunMetadata(options, inputs []string) *RunMetadata {
+	redacted := make([]string, len(options))
+	for i, option := range options {
+		redacted[i] = redactSourceName(option)
+	}
+	return &RunMetadata{version: Version(), started: time.Now().UTC(), options: redacted, inputs: inputs}
+}
+
+// ForInput is a function that returns the metadata of the run narrowed to a single configuration, for the
+// outputs written per configuration. Having no metadata returns none.
+func (metadata *RunMetadata) ForInput(fileName string) *RunMetadata {
+	if metadata == nil {
+		return nil
+	}
+	narrowed := *metadata
+	narrowed.inputs = []string{fileName}
+	return &narrowed
+}
+
+// Timestamp is a function that returns when the run started in RFC 3339 format.
+func (metadata *RunMetadata) Timestamp() string {
+	return metadata.started.Format(time.RFC3339)
+}
+
+// redactSourceName is a function that returns a configuration name as it is written into reports, which leaves
+// out the credentials of pre-signed URLs and the passwords of other URLs.
+func redactSourceName(name string) string {
+	if !strings.Contains(name, "://") {
+		return name
+	}
+	return redactPresigned(name)
+}
+
+// sourceURL matches a URL within a message, such as the source of a configuration an error names, without the
+// punctuation that may follow it.
+var sourceURL = regexp.MustCompile(`[A-Za-z][A-Za-z0-9+.-]*://[^\s"'<>]*[^\s"'<>:;,.)]`)
+
+// redactSourceNames is a function that returns a message with every URL within it redacted as a configuration
+// name is, for messages such as errors that may name the source of a configuration with its credentials.
+func redactSourceNames(message string) string {
+	if !strings.Contains(message, "://") {
+		return message
+	}
+	return sourceURL.ReplaceAllStringFunc(message, redactSourceName)
+}
+
+// Checksums is a function that returns the checksum of every configuration of the run. A configuration that could
+// not be read has an empty checksum.
+func (metadata *RunMetadata) Checksums() []InputChecksum {
+	var checksums []InputChecksum
+	for _, input := range metadata.inputs {
+		checksums = append(checksums, InputChecksum{fileName: redactSourceName(input), sha256: GetFileChecksum(input)})
+	}
+	return checksums
+}
+
+// CommandLine is a function that returns the command line options of the run as a single line.
+func (metadata *RunMetadata) CommandLine() string {
+	return strings.Join(metadata.options, " ")
+}
+
+// Lines is a function that returns the metadata as "key: value" lines for the formats that have no structure of
+// their own to hold it, which write them as comments.
+func (metadata *RunMetadata) Lines() []string {
+	lines := []string{
+		fmt.Sprintf("%s: %s", Translate("tool version"), metadata.version),
+		fmt.Sprintf("%s: %s", Translate("run at"), metadata.Timestamp()),
+	}
+	for _, input := range metadata.Checksums() {
+		sum := "sha256:" + input.sha256
+		if input.sha256 == "" {
+			sum = "(" + Translate("unreadable") + ")"
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s %s", Translate("input"), input.fileName, sum))
+	}
+	return append(lines, fmt.Sprintf("%s: %s", Translate("options"), metadata.CommandLine()))
+}
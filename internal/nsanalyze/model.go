@@ -0,0 +1,155 @@
+package nsanalyze
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxParsedModels is how many configurations the parsed models are kept for, so that a long running server
+// analyzing many configurations does not grow without bound.
+const maxParsedModels = 32
+
+// parsedModels holds the objects parsed from every configuration, keyed by the checksum of the configuration and
+// then by the kind of object, so that analyzing a configuration again skips parsing it when it has not changed,
+// whatever name it is read under.
+var parsedModels = struct {
+	sync.Mutex
+	models map[string]map[string]any
+	order  []string
+}{models: make(map[string]map[string]any)}
+
+// cachedModel is a function that returns the objects of a kind parsed from a configuration, parsing them only
+// when the configuration has not been parsed with the same contents before. Every caller gets a copy of the
+// array, so sorting or changing it does not change what other callers get. The objects are shared by every
+// configuration with the same contents, whatever name it is read under, so they must not hold the name.
+func cachedModel[T any](fileName, kind string, parse func(fileName string) ([]T, error)) ([]T, error) {
+	if _, err := GetFile(fileName); err != nil {
+		return nil, err
+	}
+	checksum := GetFileChecksum(fileName)
+	parsedModels.Lock()
+	if objects, ok := parsedModels.models[checksum][kind]; ok && checksum != "" {
+		parsedModels.Unlock()
+		return slices.Clone(objects.([]T)), nil
+	}
+	parsedModels.Unlock()
+	objects, err := parse(fileName)
+	if err != nil || checksum == "" {
+		return objects, err
+	}
+	parsedModels.Lock()
+	defer parsedModels.Unlock()
+	model, ok := parsedModels.models[checksum]
+	if !ok {
+		if len(parsedModels.order) == maxParsedModels {
+			delete(parsedModels.models, parsedModels.order[0])
+			parsedModels.order = parsedModels.order[1:]
+		}
+		model = make(map[string]any)
+		parsedModels.models[checksum] = model
+		parsedModels.order = append(parsedModels.order, checksum)
+	}
+	model[kind] = slices.Clone(objects)
+	return objects, nil
+}
+
+// ForgetParsedModels is a function that drops every parsed model, so that configurations are parsed again even
+// when they have not changed.
+func ForgetParsedModels() {
+	parsedModels.Lock()
+	defer parsedModels.Unlock()
+	parsedModels.models = make(map[string]map[string]any)
+	parsedModels.order = nil
+}
+
+// ObjectID is a function that returns the stable identity of an object: its type, its name and the configuration
+// it comes from. It stays the same across runs for as long as the object keeps its name, whatever line it moves to.
+func ObjectID(kind, name, fileName string) string {
+	return kind + "/" + name + "@" + redactSourceName(fileName)
+}
+
+// ID is a function that returns the stable identity of a finding, made of its rule and the identity of the object
+// it is about, so that the same issue found by repeated runs can be told apart from a new one. A rule that reports
+// an object more than once adds a key telling the findings apart, such as the interface a VLAN is carried on.
+func (finding Finding) ID() string {
+	if finding.key == "" {
+		return ObjectID(finding.rule.id, finding.object, finding.fileName)
+	}
+	return ObjectID(finding.rule.id, finding.object+"#"+finding.key, finding.fileName)
+}
+
+// disambiguateFindings is a function that gives the findings of a configuration that would still share an ID the
+// line they are on as their key, followed by their position among those on the same line when even that is
+// shared, so that every finding has an ID of its own.
+func disambiguateFindings(findings []Finding) {
+	counts := make(map[string]int)
+	for _, finding := range findings {
+		counts[finding.ID()]++
+	}
+	for i, finding := range findings {
+		if counts[finding.ID()] > 1 {
+			findings[i].key = strings.TrimPrefix(finding.key+"/L"+strconv.Itoa(finding.line), "/")
+		}
+	}
+	seen := make(map[string]int)
+	for i, finding := range findings {
+		id := finding.ID()
+		seen[id]++
+		if seen[id] > 1 {
+			findings[i].key += "/" + strconv.Itoa(seen[id])
+		}
+	}
+}
+
+// ID is a function that returns the stable identity of a server.
+func (server Server) ID(fileName string) string {
+	return ObjectID("server", server.name, fileName)
+}
+
+// ID is a function that returns the stable identity of a service.
+func (service Service) ID(fileName string) string {
+	return ObjectID("service", service.name, fileName)
+}
+
+// ID is a function that returns the stable identity of a service group.
+func (group ServiceGroup) ID(fileName string) string {
+	return ObjectID("serviceGroup", group.name, fileName)
+}
+
+// ID is a function that returns the stable identity of a load balancing virtual server.
+func (vserver LbVserver) ID(fileName string) string {
+	return ObjectID("lbvserver", vserver.name, fileName)
+}
+
+// ID is a function that returns the stable identity of a SNIP or other address of the NetScaler, which is named by
+// its address.
+func (snip Snip) ID(fileName string) string {
+	return ObjectID("nsip", snip.ipAddress, fileName)
+}
+
+// ID is a function that returns the stable identity of a VLAN.
+func (vlan Vlan) ID(fileName string) string {
+	return ObjectID("vlan", vlan.id, fileName)
+}
+
+// ID is a function that returns the stable identity of the binding of a VLAN to an interface.
+func (binding VlanBinding) ID(fileName string) string {
+	return ObjectID("vlanBinding", binding.vlanID+"/"+binding.interfaceName, fileName)
+}
+
+// ID is a function that returns the stable identity of an interface.
+func (iface Interface) ID(fileName string) string {
+	return ObjectID("interface", iface.name, fileName)
+}
+
+// ID is a function that returns the stable identity of a static route, which is named by its network and gateway.
+func (route Route) ID(fileName string) string {
+	return ObjectID("route", route.network+"/"+route.netmask+"/"+route.gateway, fileName)
+}
+
+// ID is a function that returns the stable identity of a DNS record, which is named by its type and name.
+func (record DnsRecord) ID(fileName string) string {
+	return ObjectID("dns"+record.kind, record.name, fileName)
+}
@@ -0,0 +1,111 @@
+package nsanalyze
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindingIDsUnique(t *testing.T) {
+	fileName := writeConfig(t,
+		"add ns ip 10.0.0.10 255.255.255.0 -type SNIP",
+		"set interface 1/1 -mtu 1500",
+		"set interface 1/2 -mtu 1500",
+		"add vlan 10 -mtu 9000",
+		"bind vlan 10 -ifnum 1/1",
+		"bind vlan 10 -ifnum 1/2")
+	findings, err := GetFindings(fileName, AnalyzeOptions{mtu: 1500})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make(map[string]bool)
+	mtuFindings := 0
+	for _, finding := range findings {
+		if ids[finding.ID()] {
+			t.Errorf("ID %s is repeated", finding.ID())
+		}
+		ids[finding.ID()] = true
+		if finding.rule.id == RuleMtuMismatch.id && finding.object == "10" {
+			mtuFindings++
+		}
+	}
+	if mtuFindings != 3 {
+		t.Errorf("got %d MTU findings for VLAN 10, want 3", mtuFindings)
+	}
+	for _, want := range []string{"NS023/10@" + fileName, "NS023/10#1/1@" + fileName, "NS023/10#1/2@" + fileName} {
+		if !ids[want] {
+			t.Errorf("no finding with ID %s among %v", want, ids)
+		}
+	}
+}
+
+func TestDisambiguateFindings(t *testing.T) {
+	rule := RuleOrphanVlan
+	tests := []struct {
+		name     string
+		findings []Finding
+		want     []string
+	}{
+		{
+			"unique",
+			[]Finding{{rule: rule, object: "10", fileName: "ns.conf", line: 1}, {rule: rule, object: "20", fileName: "ns.conf", line: 2}},
+			[]string{"NS004/10@ns.conf", "NS004/20@ns.conf"},
+		},
+		{
+			"different lines",
+			[]Finding{{rule: rule, object: "10", fileName: "ns.conf", line: 1}, {rule: rule, object: "10", fileName: "ns.conf", line: 5}},
+			[]string{"NS004/10#L1@ns.conf", "NS004/10#L5@ns.conf"},
+		},
+		{
+			"same line",
+			[]Finding{{rule: rule, object: "10", fileName: "ns.conf", line: 3}, {rule: rule, object: "10", fileName: "ns.conf", line: 3}},
+			[]string{"NS004/10#L3@ns.conf", "NS004/10#L3/2@ns.conf"},
+		},
+		{
+			"keyed",
+			[]Finding{{rule: rule, object: "10", key: "1/1", fileName: "ns.conf", line: 3},
+				{rule: rule, object: "10", key: "1/1", fileName: "ns.conf", line: 4}},
+			[]string{"NS004/10#1/1/L3@ns.conf", "NS004/10#1/1/L4@ns.conf"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			disambiguateFindings(test.findings)
+			for i, finding := range test.findings {
+				if got := finding.ID(); got != test.want[i] {
+					t.Errorf("ID of finding %d = %s, want %s", i, got, test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFindingIDsSurviveShiftedLines(t *testing.T) {
+	lines := []string{
+		"add serviceGroup sg1 HTTP",
+		"bind serviceGroup sg1 web2 80",
+		"bind serviceGroup sg1 web3 80",
+	}
+	ids := func(lines ...string) map[string]bool {
+		fileName := writeConfig(t, lines...)
+		findings, err := GetFindings(fileName, AnalyzeOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := make(map[string]bool)
+		for _, finding := range findings {
+			if finding.rule.id == RuleMissingServer.id {
+				ids[strings.TrimSuffix(finding.ID(), fileName)] = true
+			}
+		}
+		return ids
+	}
+	want := ids(lines...)
+	if len(want) != 2 {
+		t.Fatalf("got IDs %v, want two", want)
+	}
+	got := ids(append([]string{"add ns ip 10.0.0.10 255.255.255.0 -type SNIP"}, lines...)...)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("IDs after shifting the lines = %v, want %v", got, want)
+	}
+}
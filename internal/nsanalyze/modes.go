@@ -0,0 +1,122 @@
+package nsanalyze
+
+import (
+	"regexp"
+	"strings"
+)
+
+// NsMode is a data structure for a NetScaler mode along with whether it is enabled and the line that last set it,
+// which is zero for a mode left at its default.
+type NsMode struct {
+	name    string
+	enabled bool
+	line    int
+}
+
+// defaultNsModes are the modes that a NetScaler enables out of the box.
+var defaultNsModes = []string{"FR", "L3", "EDGE", "USNIP", "PMTUD"}
+
+// nsModePattern matches the lines that enable or disable modes. "set ns mode" is accepted as an enable since
+// older scripts use it, and "unset ns mode" as a disable.
+var nsModePattern = regexp.MustCompile(`^(enable|disable|set|unset) ns mode (.*)`)
+
+// GetNsModes is a function that accepts a file name as a parameter for input and then returns the effective
+// modes of the configuration keyed by upper case mode name, starting from the defaults of the appliance and
+// applying every "enable ns mode" and "disable ns mode" line in order. Lines are only matched from their start, so
+// that "unset ns mode" is not taken for the "set ns mode" within it.
+func GetNsModes(fileName string) (map[string]NsMode, error) {
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	modes := make(map[string]NsMode)
+	for _, name := range defaultNsModes {
+		modes[name] = NsMode{name: name, enabled: true}
+	}
+	modeLines, err := GetConfigLines(file, "((enable|disable|set|unset) ns mode ).*")
+	if err != nil {
+		return nil, err
+	}
+	for _, modeLine := range modeLines {
+		match := nsModePattern.FindStringSubmatch(modeLine.text)
+		for _, name := range SplitConfigLine(match[2]) {
+			name = strings.ToUpper(name)
+			enabled := match[1] == "enable" || match[1] == "set"
+			modes[name] = NsMode{name: name, enabled: enabled, line: modeLine.number}
+		}
+	}
+	return modes, nil
+}
+
+// GetSourceSnips is a function that accepts a file name as a parameter for input and then returns the NetScaler
+// owned IPs with a valid subnet mask that server traffic can be sent from. With USNIP disabled the appliance
+// sends server traffic from its MIPs only, so SNIPs stop counting as coverage. The cluster IP only serves
+// management, and when checking from one cluster node the IPs spotted on other nodes are left out. CPX and BLX
+// deployments without any of these send server traffic from their NSIP, which is returned instead.
+func GetSourceSnips(fileName string, options AnalyzeOptions) ([]Snip, error) {
+	snips, err := GetSnips(fileName)
+	if err != nil {
+		return nil, err
+	}
+	validSnips, _ := FilterValidSnips(snips)
+	modes, err := GetNsModes(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var sourceSnips []Snip
+	for _, snip := range validSnips {
+		if snip.ipType == "CLIP" || (!modes["USNIP"].enabled && snip.ipType != "MIP") {
+			continue
+		}
+		if options.node != "" && snip.ownerNode != "" && snip.ownerNode != options.node {
+			continue
+		}
+		sourceSnips = append(sourceSnips, snip)
+	}
+	if len(sourceSnips) > 0 {
+		return sourceSnips, nil
+	}
+	platform, err := GetPlatform(fileName, options)
+	if err != nil || !IsContainerPlatform(platform) {
+		return sourceSnips, err
+	}
+	nsip, ok, err := GetNsip(fileName)
+	if err != nil || !ok {
+		return sourceSnips, err
+	}
+	return append(sourceSnips, nsip), nil
+}
+
+// GetModeFindings is a function that returns a finding for every mode setting that changes how servers are
+// reached, so the coverage results can be read with it in mind.
+func GetModeFindings(fileName string) ([]Finding, error) {
+	modes, err := GetNsModes(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	caveats := []struct {
+		name    string
+		enabled bool
+		message string
+	}{
+		{"USNIP", false, "USNIP mode is disabled, so servers are reached from MIPs and only MIP networks count as coverage"},
+		{"USIP", true, "USIP mode is enabled, so servers see client addresses and must route their replies back through the NetScaler"},
+		{"MBF", true, "MBF mode is enabled, so replies follow the MAC address requests arrived from rather than the routes"},
+		{"L3", false, "L3 mode is disabled, so the NetScaler does not forward traffic it does not own for servers that use it as their gateway"},
+	}
+	for _, caveat := range caveats {
+		mode := modes[caveat.name]
+		if mode.enabled != caveat.enabled {
+			continue
+		}
+		findings = append(findings, Finding{
+			rule:     RuleModeCaveat,
+			message:  caveat.message,
+			object:   caveat.name,
+			fileName: fileName,
+			line:     mode.line,
+		})
+	}
+	return findings, nil
+}
@@ -0,0 +1,34 @@
+package nsanalyze
+
+import "testing"
+
+func TestGetNsModes(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		mode    string
+		enabled bool
+	}{
+		{"default", nil, "USNIP", true},
+		{"not a default", nil, "USIP", false},
+		{"enable", []string{"enable ns mode USIP"}, "USIP", true},
+		{"disable", []string{"disable ns mode USNIP"}, "USNIP", false},
+		{"set enables", []string{"set ns mode MBF"}, "MBF", true},
+		{"unset disables", []string{"enable ns mode MBF", "unset ns mode MBF"}, "MBF", false},
+		{"unset is not set", []string{"unset ns mode USIP"}, "USIP", false},
+		{"case insensitive names", []string{"enable ns mode usip"}, "USIP", true},
+		{"several modes", []string{"disable ns mode FR L3 edge"}, "EDGE", false},
+		{"later line wins", []string{"disable ns mode L3", "enable ns mode L3"}, "L3", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			modes, err := GetNsModes(writeConfig(t, test.lines...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := modes[test.mode].enabled; got != test.enabled {
+				t.Errorf("mode %s enabled = %v, want %v", test.mode, got, test.enabled)
+			}
+		})
+	}
+}
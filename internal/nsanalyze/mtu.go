@@ -0,0 +1,84 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultMtu is the MTU of an interface that does not set one.
+const defaultMtu = 1500
+
+// EffectiveMtu is a function that returns the MTU the interface runs with.
+func (iface Interface) EffectiveMtu() int {
+	if iface.mtu == 0 {
+		return defaultMtu
+	}
+	return iface.mtu
+}
+
+// GetMtuFindings is a function that accepts a file name as a parameter for input and then returns the findings
+// for VLANs whose MTU is larger than that of an interface carrying them, which drops the larger frames without
+// any error. When the MTU the trunk is standardized on is given, interfaces running with another MTU and VLANs
+// setting another MTU are reported as well. The loopback interface is left out.
+func GetMtuFindings(fileName string, targetMtu int) ([]Finding, error) {
+	var findings []Finding
+	interfaces, err := GetInterfaces(fileName)
+	if err != nil {
+		return nil, err
+	}
+	vlans, err := GetVlans(fileName)
+	if err != nil {
+		return nil, err
+	}
+	bindings, err := GetVlanBindings(fileName)
+	if err != nil {
+		return nil, err
+	}
+	mtus := make(map[string]int)
+	for _, iface := range interfaces {
+		if strings.HasPrefix(iface.name, "LO/") {
+			continue
+		}
+		mtus[iface.name] = iface.EffectiveMtu()
+		if targetMtu != 0 && iface.EffectiveMtu() != targetMtu {
+			findings = append(findings, Finding{
+				rule:     RuleMtuMismatch,
+				message:  fmt.Sprintf("Interface %s has MTU %d but the trunk is standardized on MTU %d", iface.name, iface.EffectiveMtu(), targetMtu),
+				object:   iface.name,
+				fileName: fileName,
+				line:     iface.line,
+			})
+		}
+	}
+	for _, vlan := range vlans {
+		if vlan.mtu == 0 {
+			continue
+		}
+		if targetMtu != 0 && vlan.mtu != targetMtu {
+			findings = append(findings, Finding{
+				rule:     RuleMtuMismatch,
+				message:  fmt.Sprintf("VLAN %s has MTU %d but the trunk is standardized on MTU %d", vlan.Describe(), vlan.mtu, targetMtu),
+				object:   vlan.id,
+				fileName: fileName,
+				line:     vlan.line,
+			})
+		}
+		for _, binding := range bindings {
+			if binding.vlanID != vlan.id {
+				continue
+			}
+			if mtu, ok := mtus[binding.interfaceName]; ok && vlan.mtu > mtu {
+				findings = append(findings, Finding{
+					rule: RuleMtuMismatch,
+					message: fmt.Sprintf("VLAN %s has MTU %d but interface %s carrying it has MTU %d", vlan.Describe(), vlan.mtu,
+						binding.interfaceName, mtu),
+					object:   vlan.id,
+					key:      binding.interfaceName,
+					fileName: fileName,
+					line:     binding.line,
+				})
+			}
+		}
+	}
+	return findings, nil
+}
@@ -0,0 +1,213 @@
+package nsanalyze
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// configRetries is how many times reading a remote configuration is retried before the configuration counts as
+// failed. Local files are not retried, as failing to read one is not going to go away.
+const configRetries = 2
+
+// configRetryDelay is how long to wait before retrying to read a remote configuration.
+const configRetryDelay = time.Second
+
+// FileFailure is a data structure for a configuration of a multi-file run that could not be analyzed.
+type FileFailure struct {
+	fileName string
+	err      error
+}
+
+// RunAnalyzeFiles is a function that runs the coverage analysis for every configuration given. A single
+// configuration without an index is analyzed as before, failing on the first error. Otherwise a configuration
+// that cannot be read or analyzed does not stop the others: its error is reported as a finding and in a
+// failures section once every configuration has been tried, and the run fails at the end. When an index is
+// asked for, the reports of every configuration are also written next to it and the index links them along with
+// the coverage of each configuration. NDJSON alone is streamed to standard output instead, each configuration as
+// soon as it is analyzed, so no aggregate is kept. With a checkpoint, the results of every configuration analyzed
+// are recorded as it is, and a configuration analyzed by an earlier run is not read again: the results recorded
+// for it go into the aggregate and the index instead. The checkpoint is removed once none failed. Runs with remote
+// configurations or a checkpoint end with the status of every configuration.
+func RunAnalyzeFiles(fileNames []string, options AnalyzeOptions) error {
+	if options.stream == nil && IsStreamingFormat(options.format) {
+		options.stream = NewNDJSONStream(os.Stdout)
+		if err := options.stream.WriteMetadata(options.metadata); err != nil {
+			return err
+		}
+	}
+	if len(fileNames) == 1 && options.index == "" {
+		return RunAnalyze(fileNames[0], options)
+	}
+	reporters, text, err := GetFormatReporters(options.format)
+	if err != nil {
+		return err
+	}
+	var findings []Finding
+	var failures []FileFailure
+	var entries []IndexEntry
+	var statuses []FetchStatus
+	for _, fileName := range fileNames {
+		if options.checkpoint != nil && options.checkpoint.Completed(fileName) {
+			entry := options.checkpoint.Result(fileName)
+			if options.stream != nil {
+				if err := options.stream.WriteFindings(ReportedFindings(entry.findings)); err != nil {
+					return err
+				}
+			} else {
+				findings = append(findings, entry.findings...)
+			}
+			entries = append(entries, entry)
+			statuses = append(statuses, FetchStatus{fileName, FetchResumed})
+			continue
+		}
+		entry := IndexEntry{fileName: fileName}
+		entry.findings, err = analyzeWithRetry(fileName, options, len(reporters) > 0 || options.index != "", text)
+		if err == nil && options.index != "" {
+			if entry.summary, err = SummarizeRun(fileName, options); err == nil {
+				entry.reports, err = writeFileReports(fileName, reporters, options.metadata.ForInput(fileName), entry.findings)
+			}
+			if err == nil && text {
+				entry.reports = append(entry.reports, textReports(fileName)...)
+			}
+		}
+		if err != nil {
+			failures = append(failures, FileFailure{fileName, err})
+			failure := newUnreadableConfigFinding(fileName, err)
+			findings = append(findings, failure)
+			if options.stream != nil {
+				if err := options.stream.WriteFindings([]Finding{failure}); err != nil {
+					return err
+				}
+			}
+			entry = IndexEntry{fileName: fileName, err: err}
+			statuses = append(statuses, FetchStatus{fileName, FetchFailed})
+		} else {
+			if options.stream == nil {
+				findings = append(findings, entry.findings...)
+			}
+			if options.checkpoint != nil {
+				if err := options.checkpoint.Complete(entry); err != nil {
+					return err
+				}
+			}
+			statuses = append(statuses, FetchStatus{fileName, FetchSucceeded})
+		}
+		entries = append(entries, entry)
+	}
+	if options.stream == nil {
+		if err := WriteReports("aggregate", options.format, reporters, options.metadata, findings); err != nil {
+			return err
+		}
+	}
+	if options.index != "" {
+		if err := WriteIndex(options.index, options.metadata, entries); err != nil {
+			return err
+		}
+	}
+	if options.checkpoint != nil || hasRemoteSource(fileNames) {
+		PrintFetchStatus(os.Stderr, statuses)
+	}
+	if len(failures) == 0 {
+		if options.checkpoint != nil {
+			return options.checkpoint.Remove()
+		}
+		return nil
+	}
+	PrintFailures(os.Stderr, failures)
+	return fmt.Errorf("%d of %d configurations could not be analyzed", len(failures), len(fileNames))
+}
+
+// newUnreadableConfigFinding is a function that returns the finding of a configuration of a multi-file run that
+// could not be read or analyzed. The configuration is named and the error is worded with the credentials of its
+// source left out, as for every other field written into reports.
+func newUnreadableConfigFinding(fileName string, err error) Finding {
+	return Finding{
+		rule:     RuleUnreadableConfig,
+		message:  redactSourceNames(err.Error()),
+		object:   redactSourceName(fileName),
+		fileName: fileName,
+	}
+}
+
+// writeFileReports is a function that writes the findings of a single configuration of a multi-file run to a
+// file per format named after the configuration, and returns the names of the files written.
+func writeFileReports(fileName string, reporters []Reporter, metadata *RunMetadata, findings []Finding) ([]string, error) {
+	var reports []string
+	for _, reporter := range reporters {
+		report := OutputBaseName(fileName) + "-findings." + reporter.Extension()
+		if err := WriteReport(report, reporter, metadata, findings); err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// textReports is a function that returns the names of the text output files the analysis of a configuration
+// wrote during this run. Neither is written when there is nothing to list, and one left by an earlier run is not
+// linked.
+func textReports(fileName string) []string {
+	var reports []string
+	for _, suffix := range []string{"-server-output.txt", "-covered-output.txt"} {
+		if report := OutputBaseName(fileName) + suffix; IsGeneratedFile(report) {
+			reports = append(reports, report)
+		}
+	}
+	return reports
+}
+
+// analyzeWithRetry is a function that analyzes a configuration, first retrying to read it when it is remote. Every
+// fetch of a remote configuration, retries included, waits for the fetch rate limits. A configuration so corrupt
+// that parsing it panics is reported as a failure rather than ending the run, which then exits non-zero.
+func analyzeWithRetry(fileName string, options AnalyzeOptions, withFindings, text bool) (findings []Finding, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			findings, err = nil, reportPanic(os.Stderr, fileName, recovered)
+		}
+	}()
+	if err = options.limiter.Wait(fileName); err == nil {
+		_, err = GetFile(fileName)
+	}
+	var pathErr *fs.PathError
+	for attempt := 0; err != nil && !errors.As(err, &pathErr) && !errors.Is(err, ErrTimedOut) && attempt < configRetries; attempt++ {
+		time.Sleep(configRetryDelay)
+		if err = options.limiter.Wait(fileName); err == nil {
+			_, err = GetFile(fileName)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return AnalyzeFile(fileName, options, withFindings, text)
+}
+
+// reportPanic is a function that returns the error of a configuration whose analysis panicked, after writing the
+// panic along with the stack trace to w, so that the bug can be reported and fixed.
+func reportPanic(w io.Writer, fileName string, recovered interface{}) error {
+	fmt.Fprintf(w, "panic: %s: %v\n\n%s\n", redactSourceName(fileName), recovered, debug.Stack())
+	return fmt.Errorf("analysis panicked: %v", recovered)
+}
+
+// hasRemoteSource is a function that reports whether any configuration of a run is fetched from the network.
+func hasRemoteSource(fileNames []string) bool {
+	for _, fileName := range fileNames {
+		if strings.Contains(fileName, "://") {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintFailures is a function that writes the failures section of a multi-file run.
+func PrintFailures(w io.Writer, failures []FileFailure) {
+	fmt.Fprintf(w, "%s:\n", Translate("failures"))
+	for _, failure := range failures {
+		fmt.Fprintf(w, "\t%s: %s\n", redactSourceName(failure.fileName), redactSourceNames(failure.err.Error()))
+	}
+}
@@ -0,0 +1,322 @@
+package nsanalyze
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+// Acl6 is a data structure for an IPv6 access control list added through "add ns acl6". NAT64 rules pick the
+// IPv6 traffic they translate with one.
+type Acl6 struct {
+	name        string
+	action      string
+	source      string
+	destination string
+	line        int
+}
+
+// Nat64 is a data structure for a NAT64 rule added through "add nat64", which translates the IPv6 traffic allowed
+// by its ACL to IPv4, sourced from the address of its net profile when it has one.
+type Nat64 struct {
+	name       string
+	acl6Name   string
+	netProfile string
+	line       int
+}
+
+// NetProfile is a data structure for a net profile added through "add netProfile", which fixes the source
+// address of the traffic it is attached to.
+type NetProfile struct {
+	name     string
+	sourceIP string
+	line     int
+}
+
+// TranslationPrefix is a data structure for an IPv6 prefix that IPv4 addresses are embedded in, either the NAT64
+// prefix set with "set ipv6 -natprefix" or the prefix a DNS64 action synthesizes AAAA records under.
+type TranslationPrefix struct {
+	name   string
+	prefix string
+	line   int
+}
+
+// Network is a function that returns the network of the prefix, or nil when it is not valid.
+func (prefix TranslationPrefix) Network() *net.IPNet {
+	_, network, err := net.ParseCIDR(prefix.prefix)
+	if err != nil {
+		return nil
+	}
+	return network
+}
+
+// GetAcl6s is a function that accepts a file name as a parameter for input and then returns an array of the
+// IPv6 access control lists.
+func GetAcl6s(fileName string) ([]Acl6, error) {
+	var acls []Acl6
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	addAcl6Lines, err := GetConfigLines(file, "(add ns acl6 ).*")
+	if err != nil {
+		return nil, err
+	}
+	for _, addAcl6Line := range addAcl6Lines {
+		fields := SplitConfigLine(RemoveConfigKeywords(addAcl6Line.text, "add ns acl6 "))
+		if len(fields) < 2 {
+			continue
+		}
+		acls = append(acls, Acl6{
+			name:        fields[0],
+			action:      strings.ToUpper(fields[1]),
+			source:      getAclMatch(fields, "-srcIPv6"),
+			destination: getAclMatch(fields, "-destIPv6"),
+			line:        addAcl6Line.number,
+		})
+	}
+	return acls, nil
+}
+
+// getAclMatch is a function that returns what an ACL option matches, such as "2001:db8::/64" for
+// "-srcIPv6 = 2001:db8::/64", keeping the operator only when it negates the match. An option that is not set
+// matches anything.
+func getAclMatch(fields []string, option string) string {
+	values := GetOptionValues(fields, option)
+	if len(values) > 1 && values[0] == "=" {
+		values = values[1:]
+	}
+	if len(values) == 0 {
+		return "any"
+	}
+	return strings.Join(values, " ")
+}
+
+// GetNat64s is a function that accepts a file name as a parameter for input and then returns an array of the
+// NAT64 rules.
+func GetNat64s(fileName string) ([]Nat64, error) {
+	var rules []Nat64
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	addNat64Lines, err := GetConfigLines(file, "(?i)(add nat64 ).*")
+	if err != nil {
+		return nil, err
+	}
+	for _, addNat64Line := range addNat64Lines {
+		fields := SplitConfigLine(addNat64Line.text)
+		if len(fields) < 4 {
+			continue
+		}
+		rules = append(rules, Nat64{
+			name:       fields[2],
+			acl6Name:   fields[3],
+			netProfile: GetOption(fields, "-netProfile"),
+			line:       addNat64Line.number,
+		})
+	}
+	return rules, nil
+}
+
+// GetNetProfiles is a function that accepts a file name as a parameter for input and then returns an array of
+// the net profiles, indexed by name.
+func GetNetProfiles(fileName string) (map[string]NetProfile, error) {
+	profiles := make(map[string]NetProfile)
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	addNetProfileLines, err := GetConfigLines(file, "(?i)(add netProfile ).*")
+	if err != nil {
+		return nil, err
+	}
+	for _, addNetProfileLine := range addNetProfileLines {
+		fields := SplitConfigLine(addNetProfileLine.text)
+		if len(fields) < 3 {
+			continue
+		}
+		profiles[fields[2]] = NetProfile{
+			name:     fields[2],
+			sourceIP: GetOption(fields, "-srcIP"),
+			line:     addNetProfileLine.number,
+		}
+	}
+	return profiles, nil
+}
+
+// GetNat64Prefixes is a function that accepts a file name as a parameter for input and then returns the NAT64
+// prefix set with "set ipv6 -natprefix". A later line replaces an earlier one, as it does on the NetScaler.
+func GetNat64Prefixes(fileName string) ([]TranslationPrefix, error) {
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	setIpv6Lines, err := GetConfigLines(file, "(set ipv6 ).*")
+	if err != nil {
+		return nil, err
+	}
+	var prefixes []TranslationPrefix
+	for _, setIpv6Line := range setIpv6Lines {
+		fields := SplitConfigLine(setIpv6Line.text)
+		if prefix := GetOption(fields, "-natprefix"); prefix != "" {
+			prefixes = []TranslationPrefix{{name: "NAT64", prefix: prefix, line: setIpv6Line.number}}
+		}
+	}
+	return prefixes, nil
+}
+
+// GetDns64Prefixes is a function that accepts a file name as a parameter for input and then returns the prefix
+// of every DNS64 action added through "add dns action64".
+func GetDns64Prefixes(fileName string) ([]TranslationPrefix, error) {
+	var prefixes []TranslationPrefix
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	addAction64Lines, err := GetConfigLines(file, "(?i)(add dns action64 ).*")
+	if err != nil {
+		return nil, err
+	}
+	for _, addAction64Line := range addAction64Lines {
+		fields := SplitConfigLine(addAction64Line.text)
+		prefix := GetOption(fields, "-Prefix")
+		if len(fields) < 4 || prefix == "" {
+			continue
+		}
+		prefixes = append(prefixes, TranslationPrefix{name: fields[3], prefix: prefix, line: addAction64Line.number})
+	}
+	return prefixes, nil
+}
+
+// Nat64Source is a function that returns the IPv4 address that a NAT64 rule sources translated traffic from,
+// which is empty when the rule has no net profile and the NetScaler picks a SNIP of the server's subnet.
+func Nat64Source(rule Nat64, profiles map[string]NetProfile) string {
+	if rule.netProfile == "" {
+		return ""
+	}
+	return profiles[rule.netProfile].sourceIP
+}
+
+// SourcesFrom is a function that reports whether a net profile source, which is an address or a range of
+// addresses such as "10.0.0.1-10.0.0.4", includes an address.
+func SourcesFrom(sourceIP, ipAddress string) bool {
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return false
+	}
+	first, last, found := strings.Cut(sourceIP, "-")
+	if !found {
+		return ip.Equal(net.ParseIP(first))
+	}
+	start, end := net.ParseIP(first), net.ParseIP(last)
+	if start == nil || end == nil {
+		return false
+	}
+	return compareIPs(ip, start) >= 0 && compareIPs(ip, end) <= 0
+}
+
+// compareIPs is a function that compares two addresses of the same family byte by byte.
+func compareIPs(a, b net.IP) int {
+	if a4, b4 := a.To4(), b.To4(); a4 != nil && b4 != nil {
+		a, b = a4, b4
+	}
+	return strings.Compare(string(a.To16()), string(b.To16()))
+}
+
+// PrintNat64Report is a function that writes the translation prefixes of the configuration, whether every DNS64
+// prefix is translated by NAT64, and every NAT64 rule with the IPv6 traffic it translates and the IPv4 source and
+// VLAN of the translated traffic.
+func PrintNat64Report(w io.Writer, fileName string) error {
+	nat64Prefixes, err := GetNat64Prefixes(fileName)
+	if err != nil {
+		return err
+	}
+	dns64Prefixes, err := GetDns64Prefixes(fileName)
+	if err != nil {
+		return err
+	}
+	acls, err := GetAcl6s(fileName)
+	if err != nil {
+		return err
+	}
+	rules, err := GetNat64s(fileName)
+	if err != nil {
+		return err
+	}
+	profiles, err := GetNetProfiles(fileName)
+	if err != nil {
+		return err
+	}
+	snips, err := GetSnips(fileName)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%s:\n", Translate("translation prefixes"))
+	if len(nat64Prefixes)+len(dns64Prefixes) == 0 {
+		fmt.Fprintf(w, "\t%s\n", Translate("none"))
+	}
+	for _, prefix := range nat64Prefixes {
+		fmt.Fprintf(w, "\t%s %s (%s %d)\n", prefix.name, prefix.prefix, Translate("line"), prefix.line)
+	}
+	for _, prefix := range dns64Prefixes {
+		fmt.Fprintf(w, "\tDNS64 %s %s (%s %d)", prefix.name, prefix.prefix, Translate("line"), prefix.line)
+		if len(rules) > 0 && !translatedBy(prefix, nat64Prefixes) {
+			fmt.Fprintf(w, " %s", Translate("not translated by NAT64"))
+		}
+		fmt.Fprintln(w)
+	}
+	acl6s := make(map[string]Acl6)
+	for _, acl := range acls {
+		acl6s[acl.name] = acl
+	}
+	fmt.Fprintf(w, "%s:\n", Translate("NAT64 rules"))
+	if len(rules) == 0 {
+		fmt.Fprintf(w, "\t%s\n", Translate("none"))
+	}
+	for _, rule := range rules {
+		fmt.Fprintf(w, "\t%s acl6 %s", rule.name, rule.acl6Name)
+		if acl, ok := acl6s[rule.acl6Name]; ok {
+			fmt.Fprintf(w, " (%s %s -> %s)", acl.action, acl.source, acl.destination)
+		} else {
+			fmt.Fprintf(w, " (%s)", Translate("unknown"))
+		}
+		source := Nat64Source(rule, profiles)
+		if source == "" {
+			fmt.Fprintf(w, ", %s SNIP", Translate("source"))
+		} else {
+			fmt.Fprintf(w, ", %s %s", Translate("source"), source)
+			for _, snip := range snips {
+				if SourcesFrom(source, snip.ipAddress) && snip.vlan != "" {
+					fmt.Fprintf(w, " vlan %s", snip.vlan)
+					break
+				}
+			}
+		}
+		fmt.Fprintf(w, " (%s %d)\n", Translate("line"), rule.line)
+	}
+	return nil
+}
+
+// translatedBy is a function that reports whether the addresses a DNS64 prefix synthesizes fall within one of
+// the NAT64 prefixes, so that clients using them reach the IPv4 server.
+func translatedBy(prefix TranslationPrefix, nat64Prefixes []TranslationPrefix) bool {
+	network := prefix.Network()
+	for _, nat64Prefix := range nat64Prefixes {
+		if outer := nat64Prefix.Network(); network != nil && outer != nil && NetworkWithin(network, outer) {
+			return true
+		}
+	}
+	return false
+}
+
+// RunNat64 is a function that runs the nat64 subcommand.
+func RunNat64(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: nat64 filename")
+	}
+	return PrintNat64Report(os.Stdout, args[0])
+}
@@ -0,0 +1,157 @@
+package nsanalyze
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"sync"
+)
+
+// NDJSON record types, written in the type field of every record.
+const (
+	RecordMetadata = "metadata"
+	RecordServer   = "server"
+	RecordSnip     = "snip"
+	RecordWarning  = "warning"
+	RecordFinding  = "finding"
+)
+
+// ndjsonMetadata, ndjsonServer, ndjsonSnip, ndjsonWarning and ndjsonFinding are the NDJSON records of the
+// metadata of a run and of the objects of a configuration.
+type ndjsonMetadata struct {
+	Type string `json:"type"`
+	*metadataJSON
+}
+
+type ndjsonServer struct {
+	Type    string `json:"type"`
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Domain  string `json:"domain,omitempty"`
+	Comment string `json:"comment,omitempty"`
+	Covered bool   `json:"covered"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+}
+
+type ndjsonSnip struct {
+	Type      string   `json:"type"`
+	ID        string   `json:"id"`
+	Address   string   `json:"address"`
+	Mask      string   `json:"mask"`
+	IPType    string   `json:"ipType"`
+	Vlan      string   `json:"vlan,omitempty"`
+	OwnerNode string   `json:"ownerNode,omitempty"`
+	Vservers  []string `json:"vservers,omitempty"`
+	File      string   `json:"file"`
+	Line      int      `json:"line"`
+}
+
+type ndjsonWarning struct {
+	Type    string `json:"type"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+	Object  string `json:"object,omitempty"`
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+}
+
+type ndjsonFinding struct {
+	Type string `json:"type"`
+	findingJSON
+}
+
+// NDJSONStream is a data structure for writing the objects of an analysis as newline delimited JSON, one record
+// per line, each as soon as the analysis gets to it, so that large analyses can be piped into jq or a log pipeline
+// without the whole result being held first.
+type NDJSONStream struct {
+	sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewNDJSONStream is a function that returns a stream writing NDJSON records to w.
+func NewNDJSONStream(w io.Writer) *NDJSONStream {
+	return &NDJSONStream{encoder: json.NewEncoder(w)}
+}
+
+// IsStreamingFormat is a function that reports whether a format list asks for NDJSON alone, which is streamed to
+// standard output rather than written once the run is done.
+func IsStreamingFormat(format string) bool {
+	formats := ParseFormats(format)
+	return len(formats) == 1 && formats[0] == "ndjson"
+}
+
+// WriteMetadata is a function that writes the metadata of a run as a record, when there is any.
+func (stream *NDJSONStream) WriteMetadata(metadata *RunMetadata) error {
+	if metadata == nil {
+		return nil
+	}
+	stream.Lock()
+	defer stream.Unlock()
+	return stream.encoder.Encode(ndjsonMetadata{RecordMetadata, newMetadataJSON(metadata)})
+}
+
+// WriteObjects is a function that writes the servers of a configuration with their coverage, its SNIPs and its
+// warnings, a record each, as soon as they are parsed. Its findings follow as the analysis finds them, so the
+// records of configurations analyzed in parallel may interleave; every record names the configuration it is about.
+func (stream *NDJSONStream) WriteObjects(fileName string, servers []Server, networks []*net.IPNet, snips []Snip,
+	warnings []Warning) error {
+	stream.Lock()
+	defer stream.Unlock()
+	file := redactSourceName(fileName)
+	uncovered := make(map[string]bool)
+	for _, server := range GetUncoveredServers(networks, servers) {
+		uncovered[server.name] = true
+	}
+	for _, server := range includedServers(servers) {
+		record := ndjsonServer{RecordServer, server.ID(fileName), server.name, server.ipAddress, server.domainName, server.comment,
+			!uncovered[server.name], file, server.line}
+		if err := stream.encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	for _, snip := range snips {
+		record := ndjsonSnip{RecordSnip, snip.ID(fileName), snip.ipAddress, snip.subnetMask, snip.ipType, snip.vlan, snip.ownerNode,
+			snip.vservers, file, snip.line}
+		if err := stream.encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	for _, warning := range warnings {
+		record := ndjsonWarning{RecordWarning, warning.kind, redactSourceNames(warning.message),
+			redactSourceNames(warning.object), file, warning.line}
+		if err := stream.encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteFindings is a function that writes findings, a record each.
+func (stream *NDJSONStream) WriteFindings(findings []Finding) error {
+	stream.Lock()
+	defer stream.Unlock()
+	for _, finding := range findings {
+		if err := stream.encoder.Encode(ndjsonFinding{RecordFinding, newFindingJSON(finding)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NDJSONReporter is a data structure for writing findings as newline delimited JSON, headed by a metadata record,
+// for when NDJSON is written to a file along with other formats rather than streamed.
+type NDJSONReporter struct{}
+
+// Extension is a function that returns the file name extension for NDJSON output.
+func (NDJSONReporter) Extension() string { return "ndjson" }
+
+// Report is a function that writes findings as newline delimited JSON.
+func (NDJSONReporter) Report(w io.Writer, metadata *RunMetadata, findings []Finding) error {
+	stream := NewNDJSONStream(w)
+	if err := stream.WriteMetadata(metadata); err != nil {
+		return err
+	}
+	return stream.WriteFindings(ReportedFindings(findings))
+}
@@ -0,0 +1,290 @@
+package nsanalyze
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// nitroRetries is how many times a Nitro request is retried when the appliance is throttling or unavailable.
+const nitroRetries = 5
+
+// nitroBackoff is how long the first retry waits. Every later retry waits twice as long as the one before.
+const nitroBackoff = 500 * time.Millisecond
+
+// nitroMaxRetryAfter is the longest a retry waits for, whatever the Retry-After header of the appliance asks for.
+const nitroMaxRetryAfter = time.Minute
+
+// NitroObjectType is a data structure for a kind of Nitro configuration object along with the function that turns
+// one object into the CLI line the configuration would have for it.
+type NitroObjectType struct {
+	resource string
+	binding  bool
+	line     func(object map[string]interface{}) string
+}
+
+// GetNitroObjectTypes is a function that returns every object type fetched from the Nitro API in bulk mode.
+func GetNitroObjectTypes() []NitroObjectType {
+	return []NitroObjectType{
+		{"nshostname", false, func(o map[string]interface{}) string {
+			return cliLine("set ns hostName", nitroField(o, "hostname"))
+		}},
+		{"interface", false, func(o map[string]interface{}) string {
+			return cliLine("set interface", nitroField(o, "id"), nitroOption(o, "-ifAlias", "ifalias"),
+				nitroOption(o, "-lldpmode", "lldpmode"))
+		}},
+		{"vlan", false, func(o map[string]interface{}) string {
+			return cliLine("add vlan", nitroField(o, "id"))
+		}},
+		{"vlan_interface_binding", true, func(o map[string]interface{}) string {
+			tagged := ""
+			if nitroRaw(o, "tagged") == "true" {
+				tagged = "-tagged"
+			}
+			return cliLine("bind vlan", nitroField(o, "id"), "-ifnum", nitroField(o, "ifnum"), tagged)
+		}},
+		{"vlan_nsip_binding", true, func(o map[string]interface{}) string {
+			return cliLine("bind vlan", nitroField(o, "id"), "-IPAddress", nitroField(o, "ipaddress"),
+				nitroField(o, "netmask"))
+		}},
+		{"nsip", false, func(o map[string]interface{}) string {
+			if nitroRaw(o, "type") == "NSIP" {
+				return ""
+			}
+			return cliLine("add ns ip", nitroField(o, "ipaddress"), nitroField(o, "netmask"),
+				nitroOption(o, "-type", "type"), nitroOption(o, "-td", "td"),
+				nitroOption(o, "-ownerNode", "ownernode"), nitroOption(o, "-arp", "arp"), nitroOption(o, "-icmp", "icmp"))
+		}},
+		{"nsip6", false, func(o map[string]interface{}) string {
+			return cliLine("add ns ip6", nitroField(o, "ipv6address"), nitroOption(o, "-type", "type"),
+				nitroOption(o, "-vlan", "vlan"), nitroOption(o, "-td", "td"))
+		}},
+		{"route", false, func(o map[string]interface{}) string {
+			return cliLine("add route", nitroField(o, "network"), nitroField(o, "netmask"), nitroField(o, "gateway"))
+		}},
+		{"server", false, func(o map[string]interface{}) string {
+			address := nitroField(o, "ipaddress")
+			if address == "" {
+				address = nitroField(o, "domain")
+			}
+			return cliLine("add server", nitroField(o, "name"), address,
+				nitroOption(o, "-translationIp", "translationip"), nitroOption(o, "-translationMask", "translationmask"))
+		}},
+		{"service", false, func(o map[string]interface{}) string {
+			return cliLine("add service", nitroField(o, "name"), nitroField(o, "servername"),
+				nitroField(o, "servicetype"), nitroField(o, "port"))
+		}},
+		{"servicegroup", false, func(o map[string]interface{}) string {
+			return cliLine("add serviceGroup", nitroField(o, "servicegroupname"), nitroField(o, "servicetype"))
+		}},
+		{"servicegroup_servicegroupmember_binding", true, func(o map[string]interface{}) string {
+			return cliLine("bind serviceGroup", nitroField(o, "servicegroupname"), nitroField(o, "servername"),
+				nitroField(o, "port"), nitroOption(o, "-weight", "weight"))
+		}},
+		{"lbvserver", false, func(o map[string]interface{}) string {
+			return cliLine("add lb vserver", nitroField(o, "name"), nitroField(o, "servicetype"),
+				nitroField(o, "ipv46"), nitroField(o, "port"))
+		}},
+		{"lbvserver_service_binding", true, func(o map[string]interface{}) string {
+			return cliLine("bind lb vserver", nitroField(o, "name"), nitroField(o, "servicename"),
+				nitroOption(o, "-weight", "weight"))
+		}},
+		{"lbvserver_servicegroup_binding", true, func(o map[string]interface{}) string {
+			return cliLine("bind lb vserver", nitroField(o, "name"), nitroField(o, "servicegroupname"))
+		}},
+	}
+}
+
+// nitroField is a function that returns a field of a Nitro object as a configuration value, quoted when it
+// contains spaces, or an empty string when the object does not have it.
+func nitroField(object map[string]interface{}, key string) string {
+	return quoteCLIValue(nitroRaw(object, key))
+}
+
+// nitroRaw is a function that returns a field of a Nitro object as a string, or an empty string when the object
+// does not have it.
+func nitroRaw(object map[string]interface{}, key string) string {
+	value, ok := object[key]
+	if !ok || value == nil {
+		return ""
+	}
+	if number, ok := value.(float64); ok {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// nitroOption is a function that returns a CLI option along with the value of a field of a Nitro object, or an
+// empty string when the object does not have the field.
+func nitroOption(object map[string]interface{}, option, key string) string {
+	value := nitroRaw(object, key)
+	if value == "" {
+		return ""
+	}
+	return option + " " + quoteCLIValue(value)
+}
+
+// cliLine is a function that joins a command and its non-empty arguments into a configuration line.
+func cliLine(command string, arguments ...string) string {
+	parts := []string{command}
+	for _, argument := range arguments {
+		if argument != "" {
+			parts = append(parts, argument)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// quoteCLIValue is a function that wraps a value in double quotes when it contains spaces.
+func quoteCLIValue(value string) string {
+	if strings.ContainsAny(value, " \t\"") {
+		return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+	}
+	return value
+}
+
+// nitroGet is a function that sends a GET request to the Nitro API and decodes the response, retrying with
+// exponential backoff while the appliance answers 429 Too Many Requests or 503 Service Unavailable. A
+// Retry-After header given in seconds takes precedence over the backoff, up to nitroMaxRetryAfter. A retry that
+// would wait past the deadline of the context is not made.
+func (source NitroSource) nitroGet(ctx context.Context, client *http.Client, path string, result interface{}) error {
+	backoff := nitroBackoff
+	for attempt := 0; ; attempt++ {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, source.baseURL+path, nil)
+		if err != nil {
+			return err
+		}
+		request.Header.Set("X-NITRO-USER", source.user)
+		request.Header.Set("X-NITRO-PASS", source.password)
+		response, err := client.Do(request)
+		if err != nil {
+			return err
+		}
+		throttled := response.StatusCode == http.StatusTooManyRequests ||
+			response.StatusCode == http.StatusServiceUnavailable
+		if throttled && attempt < nitroRetries {
+			wait := retryWait(response.Header.Get("Retry-After"), backoff)
+			io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+			if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+				return fmt.Errorf("%s: %s: %s, retrying after %s would pass the deadline", source.Name(), path,
+					response.Status, wait)
+			}
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			backoff *= 2
+			continue
+		}
+		defer response.Body.Close()
+		var status struct {
+			ErrorCode int    `json:"errorcode"`
+			Message   string `json:"message"`
+		}
+		body, err := io.ReadAll(response.Body)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(body, &status); err != nil {
+			return fmt.Errorf("%s: %s", source.Name(), response.Status)
+		}
+		if status.ErrorCode != 0 || response.StatusCode != http.StatusOK {
+			return fmt.Errorf("%s: %s: %s", source.Name(), path, status.Message)
+		}
+		return json.Unmarshal(body, result)
+	}
+}
+
+// retryWait is a function that returns how long to wait before retrying a throttled request: the seconds of the
+// Retry-After header when it gives any, capped at nitroMaxRetryAfter, and the backoff otherwise.
+func retryWait(retryAfter string, backoff time.Duration) time.Duration {
+	seconds, err := strconv.Atoi(retryAfter)
+	if err != nil || seconds < 0 {
+		return backoff
+	}
+	if wait := time.Duration(seconds) * time.Second; wait < nitroMaxRetryAfter {
+		return wait
+	}
+	return nitroMaxRetryAfter
+}
+
+// fetchObjects is a function that fetches every object of a type, requesting one page at a time until a page
+// comes back short. Bindings are fetched all at once through bulk bindings since Nitro does not page them. Every
+// request waits for the fetch rate limits of the run.
+func (source NitroSource) fetchObjects(ctx context.Context, client *http.Client, objectType NitroObjectType) ([]map[string]interface{}, error) {
+	var objects []map[string]interface{}
+	for page := 1; ; page++ {
+		path := "/nitro/v1/config/" + objectType.resource
+		if objectType.binding {
+			path += "?bulkbindings=yes"
+		} else {
+			path += fmt.Sprintf("?pagesize=%d&pageno=%d", source.pageSize, page)
+		}
+		if err := runLimiter.Wait(source.baseURL + path); err != nil {
+			return nil, err
+		}
+		var response map[string]json.RawMessage
+		if err := source.nitroGet(ctx, client, path, &response); err != nil {
+			return nil, err
+		}
+		var pageObjects []map[string]interface{}
+		if raw, ok := response[objectType.resource]; ok {
+			if err := json.Unmarshal(raw, &pageObjects); err != nil {
+				// Singleton resources such as nshostname are returned as an object rather than an array.
+				var object map[string]interface{}
+				if err := json.Unmarshal(raw, &object); err != nil {
+					return nil, err
+				}
+				pageObjects = append(pageObjects, object)
+			}
+		}
+		objects = append(objects, pageObjects...)
+		if objectType.binding || len(pageObjects) < source.pageSize {
+			return objects, nil
+		}
+	}
+}
+
+// bulkConfig is a function that fetches every object type concurrently and returns the configuration lines
+// for them, in the order of the object types so that the result does not depend on timing.
+func (source NitroSource) bulkConfig(ctx context.Context, client *http.Client) (string, error) {
+	objectTypes := GetNitroObjectTypes()
+	lines := make([][]string, len(objectTypes))
+	errs := make([]error, len(objectTypes))
+	var wg sync.WaitGroup
+	for i, objectType := range objectTypes {
+		wg.Add(1)
+		go func(i int, objectType NitroObjectType) {
+			defer wg.Done()
+			objects, err := source.fetchObjects(ctx, client, objectType)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			for _, object := range objects {
+				if line := objectType.line(object); line != "" {
+					lines[i] = append(lines[i], line)
+				}
+			}
+		}(i, objectType)
+	}
+	wg.Wait()
+	var config strings.Builder
+	for i := range objectTypes {
+		if errs[i] != nil {
+			return "", errs[i]
+		}
+		for _, line := range lines[i] {
+			config.WriteString(line + "\n")
+		}
+	}
+	return config.String(), nil
+}
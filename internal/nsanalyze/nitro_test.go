@@ -0,0 +1,80 @@
+package nsanalyze
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryWait(t *testing.T) {
+	tests := []struct {
+		retryAfter string
+		want       time.Duration
+	}{
+		{"", nitroBackoff},
+		{"soon", nitroBackoff},
+		{"-5", nitroBackoff},
+		{"0", 0},
+		{"3", 3 * time.Second},
+		{"86400", nitroMaxRetryAfter},
+		{"99999999999999999999", nitroBackoff},
+	}
+	for _, test := range tests {
+		if got := retryWait(test.retryAfter, nitroBackoff); got != test.want {
+			t.Errorf("retryWait(%q) = %s, want %s", test.retryAfter, got, test.want)
+		}
+	}
+}
+
+func TestNitroGetRetryAfterPastDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	start := time.Now()
+	var result map[string]interface{}
+	err := NitroSource{baseURL: server.URL}.nitroGet(ctx, server.Client(), "/nitro/v1/config/nsip", &result)
+	if err == nil {
+		t.Fatal("a throttled request succeeds")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("gave up after %s, want at once", elapsed)
+	}
+}
+
+func TestFetchObjectsWaitsForLimiter(t *testing.T) {
+	var times []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		times = append(times, time.Now())
+		if r.URL.Query().Get("pageno") == "3" {
+			w.Write([]byte(`{"errorcode": 0, "server": []}`))
+			return
+		}
+		w.Write([]byte(`{"errorcode": 0, "server": [{"name": "web` + r.URL.Query().Get("pageno") + `"}]}`))
+	}))
+	defer server.Close()
+	limiter, err := NewFetchLimiter(0, 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetRunLimiter(limiter)
+	defer SetRunLimiter(nil)
+	source := NitroSource{baseURL: server.URL, pageSize: 1}
+	objects, err := source.fetchObjects(context.Background(), server.Client(), NitroObjectType{resource: "server"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 || len(times) != 3 {
+		t.Fatalf("fetched %d objects in %d requests, want 2 in 3", len(objects), len(times))
+	}
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap < 40*time.Millisecond {
+			t.Errorf("page %d requested %s after the one before, want the host interval", i+1, gap)
+		}
+	}
+}
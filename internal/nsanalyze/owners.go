@@ -0,0 +1,161 @@
+package nsanalyze
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// OwnerMap is a data structure for the mapping of addresses and networks to the team or application that owns
+// them.
+type OwnerMap struct {
+	networks []*net.IPNet
+	owners   []string
+}
+
+// LoadOwnerMap is a function that reads an owner mapping from a file. A file with a .json extension holds an
+// object of prefixes to owners, anything else is read as CSV with a prefix and an owner per row and an optional
+// header row. Prefixes without a length are single addresses.
+func LoadOwnerMap(fileName string) (*OwnerMap, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	ownerMap := &OwnerMap{}
+	if strings.EqualFold(filepath.Ext(fileName), ".json") {
+		var entries map[string]string
+		if err := json.NewDecoder(file).Decode(&entries); err != nil {
+			return nil, fmt.Errorf("%s: %v", fileName, err)
+		}
+		var prefixes []string
+		for prefix := range entries {
+			prefixes = append(prefixes, prefix)
+		}
+		sort.Strings(prefixes)
+		for _, prefix := range prefixes {
+			if err := ownerMap.add(prefix, entries[prefix]); err != nil {
+				return nil, fmt.Errorf("%s: %v", fileName, err)
+			}
+		}
+		return ownerMap, nil
+	}
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return ownerMap, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", fileName, err)
+		}
+		if err := ownerMap.add(record[0], record[1]); err != nil {
+			if row == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("%s: row %d: %v", fileName, row, err)
+		}
+	}
+}
+
+// add is a function that adds the owner of an address or network to the mapping.
+func (ownerMap *OwnerMap) add(prefix, owner string) error {
+	prefix = strings.TrimSpace(prefix)
+	if !strings.Contains(prefix, "/") {
+		ip := net.ParseIP(prefix)
+		if ip == nil {
+			return fmt.Errorf("invalid address %q", prefix)
+		}
+		bits := 128
+		if ip.To4() != nil {
+			bits = 32
+		}
+		prefix = fmt.Sprintf("%s/%d", prefix, bits)
+	}
+	_, network, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return err
+	}
+	ownerMap.networks = append(ownerMap.networks, network)
+	ownerMap.owners = append(ownerMap.owners, strings.TrimSpace(owner))
+	return nil
+}
+
+// Owner is a function that returns the owner of an address, taken from the most specific network containing it,
+// or an empty string when no network of the mapping contains it.
+func (ownerMap *OwnerMap) Owner(ipAddress string) string {
+	ip := net.ParseIP(ipAddress)
+	if ownerMap == nil || ip == nil {
+		return ""
+	}
+	owner := ""
+	longest := -1
+	for i, network := range ownerMap.networks {
+		if ones, _ := network.Mask.Size(); network.Contains(ip) && ones > longest {
+			owner = ownerMap.owners[i]
+			longest = ones
+		}
+	}
+	return owner
+}
+
+// PrintOwnerReport is a function that writes the uncovered servers grouped by owner, with the number of servers
+// each owner has and how many of them are uncovered. Servers no owner is mapped for are grouped as unowned.
+func PrintOwnerReport(w io.Writer, fileName string, options AnalyzeOptions) error {
+	networks, err := GetCoverageNetworks(fileName, options)
+	if err != nil {
+		return err
+	}
+	servers, err := GetAnalysisServers(fileName, options)
+	if err != nil {
+		return err
+	}
+	total := make(map[string]int)
+	for _, server := range includedServers(servers) {
+		if server.ipAddress != "" {
+			total[ownerOrUnowned(options.owners, server)]++
+		}
+	}
+	uncovered := make(map[string][]Server)
+	for _, server := range GetUncoveredServers(networks, servers) {
+		owner := ownerOrUnowned(options.owners, server)
+		uncovered[owner] = append(uncovered[owner], server)
+	}
+	var owners []string
+	for owner := range total {
+		owners = append(owners, owner)
+	}
+	sort.Strings(owners)
+	for _, owner := range owners {
+		fmt.Fprintf(w, "%s: "+Translate("%d of %d servers uncovered")+"\n", owner, len(uncovered[owner]), total[owner])
+		for _, server := range uncovered[owner] {
+			fmt.Fprintf(w, "\t%s\n", server.Describe())
+		}
+	}
+	return nil
+}
+
+// ownerOrUnowned is a function that returns the owner of a server, or "unowned" when none is mapped.
+func ownerOrUnowned(ownerMap *OwnerMap, server Server) string {
+	if owner := ownerMap.Owner(server.ipAddress); owner != "" {
+		return owner
+	}
+	return Translate("unowned")
+}
+
+// RunOwners is a function that runs the owners subcommand.
+func RunOwners(args []string, options AnalyzeOptions) error {
+	if len(args) != 1 || options.owners == nil {
+		return errors.New("usage: -owners file owners filename")
+	}
+	return PrintOwnerReport(os.Stdout, args[0], options)
+}
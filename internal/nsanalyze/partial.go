@@ -0,0 +1,10 @@
+package nsanalyze
+
+import "net"
+
+// ChecksCoverage is a function that reports whether coverage can be checked against the networks. A partial
+// configuration without any SNIP or policy network most likely left them out rather than having none, so every
+// server would wrongly be reported as uncovered.
+func ChecksCoverage(networks []*net.IPNet, options AnalyzeOptions) bool {
+	return !options.partial || len(networks) > 0
+}
@@ -0,0 +1,181 @@
+package nsanalyze
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+// remediationPlan is the JSON representation of the remediation decisions of an operator for a configuration.
+type remediationPlan struct {
+	Configuration string         `json:"configuration"`
+	Decisions     []planDecision `json:"decisions"`
+}
+
+// planDecision is the JSON representation of the decision taken for a network of uncovered servers.
+type planDecision struct {
+	Network string       `json:"network"`
+	Action  string       `json:"action"`
+	Address string       `json:"address,omitempty"`
+	Gateway string       `json:"gateway,omitempty"`
+	Vlan    string       `json:"vlan,omitempty"`
+	Servers []planServer `json:"servers"`
+}
+
+// planServer is the JSON representation of an uncovered server within a remediation plan.
+type planServer struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+// PromptRemediation is a function that walks the operator through every proposed SNIP action, offering the SNIP,
+// a route or excluding the servers instead, and returns the actions chosen. Networks the operator skips are left
+// out so they can be decided on a later run. The answer in brackets is taken when the operator presses enter.
+func PromptRemediation(in io.Reader, out io.Writer, actions []RemediationAction, gateway, vlan string) ([]RemediationAction, error) {
+	scanner := bufio.NewScanner(in)
+	ask := func(question, answer string) (string, error) {
+		if answer != "" {
+			fmt.Fprintf(out, "%s [%s]: ", question, answer)
+		} else {
+			fmt.Fprintf(out, "%s: ", question)
+		}
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return "", err
+			}
+			return "", errors.New("input ended before every network was decided")
+		}
+		if text := strings.TrimSpace(scanner.Text()); text != "" {
+			return text, nil
+		}
+		return answer, nil
+	}
+	var chosen []RemediationAction
+	for _, action := range actions {
+		fmt.Fprintf(out, "%s: %d uncovered server(s)\n", action.network, len(action.servers))
+		for _, server := range action.servers {
+			fmt.Fprintf(out, "  %s\n", server.Describe())
+		}
+		fmt.Fprintf(out, "  [s] add SNIP %s\n  [r] add a route\n  [e] exclude these servers\n  [k] skip, decide later\n",
+			action.address)
+		var choice string
+		for {
+			answer, err := ask("choice", "s")
+			if err != nil {
+				return nil, err
+			}
+			if choice = strings.ToLower(answer); strings.Contains("srek", choice) && len(choice) == 1 {
+				break
+			}
+			fmt.Fprintln(out, "answer s, r, e or k")
+		}
+		decided := RemediationAction{network: action.network, servers: action.servers}
+		switch choice {
+		case "s":
+			decided.kind = ActionSnip
+			for {
+				address, err := ask("address", action.address)
+				if err != nil {
+					return nil, err
+				}
+				if ip := net.ParseIP(address); ip != nil && action.network.Contains(ip) {
+					decided.address = address
+					break
+				}
+				fmt.Fprintf(out, "%s is not an address within %s\n", address, action.network)
+			}
+			answer, err := ask("vlan to bind to, - for none", vlan)
+			if err != nil {
+				return nil, err
+			}
+			if answer != "-" {
+				decided.vlan = answer
+			}
+		case "r":
+			decided.kind = ActionRoute
+			for {
+				address, err := ask("gateway", gateway)
+				if err != nil {
+					return nil, err
+				}
+				if net.ParseIP(address) != nil {
+					decided.gateway = address
+					break
+				}
+				fmt.Fprintf(out, "%q is not an address\n", address)
+			}
+		case "e":
+			decided.kind = ActionExclude
+		default:
+			continue
+		}
+		chosen = append(chosen, decided)
+	}
+	return chosen, nil
+}
+
+// WritePlan is a function that writes the remediation decisions for a configuration as a JSON plan.
+func WritePlan(fileName, configuration string, actions []RemediationAction) error {
+	plan := remediationPlan{Configuration: configuration, Decisions: []planDecision{}}
+	for _, action := range actions {
+		decision := planDecision{
+			Network: action.network.String(),
+			Action:  action.kind,
+			Address: action.address,
+			Gateway: action.gateway,
+			Vlan:    action.vlan,
+			Servers: []planServer{},
+		}
+		for _, server := range action.servers {
+			decision.Servers = append(decision.Servers, planServer{Name: server.name, Address: server.ipAddress})
+		}
+		plan.Decisions = append(plan.Decisions, decision)
+	}
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(fileName, func(w io.Writer) { w.Write(append(data, '\n')) })
+}
+
+// LoadPlan is a function that reads a remediation plan and returns the configuration it was made for along with
+// the actions decided.
+func LoadPlan(fileName string) (string, []RemediationAction, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return "", nil, err
+	}
+	var plan remediationPlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return "", nil, fmt.Errorf("%s: %v", fileName, err)
+	}
+	var actions []RemediationAction
+	for _, decision := range plan.Decisions {
+		_, network, err := net.ParseCIDR(decision.Network)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %v", fileName, err)
+		}
+		switch decision.Action {
+		case ActionSnip, ActionRoute, ActionExclude:
+		default:
+			return "", nil, fmt.Errorf("%s: unknown action %q for %s", fileName, decision.Action, decision.Network)
+		}
+		action := RemediationAction{
+			kind:    decision.Action,
+			network: network,
+			address: decision.Address,
+			gateway: decision.Gateway,
+			vlan:    decision.Vlan,
+		}
+		for _, server := range decision.Servers {
+			action.servers = append(action.servers, Server{name: server.Name, ipAddress: server.Address})
+		}
+		actions = append(actions, action)
+	}
+	return plan.Configuration, actions, nil
+}
@@ -0,0 +1,147 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// platforms are the deployments a configuration can come from. MPX and VPX appliances share a layout, while CPX
+// containers and BLX Linux deployments differ from it.
+var platforms = []string{"auto", "mpx", "vpx", "cpx", "blx"}
+
+// ParsePlatform is a function that checks the platform given with -platform.
+func ParsePlatform(platform string) (string, error) {
+	platform = strings.ToLower(platform)
+	if !containsString(platforms, platform) {
+		return "", fmt.Errorf("unknown platform %q, use one of %s", platform, strings.Join(platforms, ", "))
+	}
+	return platform, nil
+}
+
+// configMapKind matches the kind of a Kubernetes ConfigMap manifest.
+var configMapKind = regexp.MustCompile(`(?m)^kind:\s*ConfigMap\s*$`)
+
+// blockScalarKey matches a YAML key whose value is a literal block, such as "  cpx.conf: |".
+var blockScalarKey = regexp.MustCompile(`^(\s*)[\w.\-]+:\s*\|[-+]?\s*$`)
+
+// cpxSectionMarkers are the comments that split the cpx.conf of a CPX container into NetScaler and shell
+// commands.
+var cpxSectionMarkers = []string{"#NetScaler Commands", "#Shell Commands"}
+
+// DetectPlatform is a function that returns the platform a configuration looks like it comes from: cpx for a
+// ConfigMap or a cpx.conf with command sections, otherwise auto. BLX configurations look like those of an
+// appliance, so they can only be told apart with -platform.
+func DetectPlatform(file string) string {
+	if configMapKind.MatchString(file) {
+		return "cpx"
+	}
+	for _, marker := range cpxSectionMarkers {
+		if strings.Contains(file, marker) {
+			return "cpx"
+		}
+	}
+	return "auto"
+}
+
+// GetPlatform is a function that returns the platform of a configuration, which is the one given with -platform
+// unless that was left at auto.
+func GetPlatform(fileName string, options AnalyzeOptions) (string, error) {
+	if options.platform != "" && options.platform != "auto" {
+		return options.platform, nil
+	}
+	file, err := GetFile(fileName)
+	if err != nil {
+		return "", err
+	}
+	return DetectPlatform(file), nil
+}
+
+// IsContainerPlatform is a function that reports whether a platform runs on a host it shares, CPX in a container
+// and BLX on Linux, where the NSIP doubles as the source of server traffic when no SNIP is configured and saved
+// configurations seldom carry a firmware banner.
+func IsContainerPlatform(platform string) bool {
+	return platform == "cpx" || platform == "blx"
+}
+
+// ApplyPlatformQuirks is a function that turns the configuration layouts of containerized deployments into plain
+// ns.conf. The NetScaler commands of a ConfigMap are taken out of its literal blocks and dedented, and the shell
+// commands section of a cpx.conf is dropped. Every other line is blanked rather than removed so that reported
+// line numbers still match the original file.
+func ApplyPlatformQuirks(file string) string {
+	if DetectPlatform(file) != "cpx" {
+		return file
+	}
+	lines := strings.Split(file, "\n")
+	if configMapKind.MatchString(file) {
+		lines = extractBlockScalars(lines)
+	}
+	shell := false
+	for i, line := range lines {
+		switch strings.TrimSpace(line) {
+		case "#NetScaler Commands":
+			shell = false
+		case "#Shell Commands":
+			shell = true
+		}
+		if shell {
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// extractBlockScalars is a function that returns the contents of every literal block of a YAML document with its
+// indentation removed, blanking every line outside of them.
+func extractBlockScalars(lines []string) []string {
+	result := make([]string, len(lines))
+	keyIndent, blockIndent := -1, -1
+	for i, line := range lines {
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if keyIndent >= 0 && (strings.TrimSpace(line) == "" || indent > keyIndent) {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if blockIndent < 0 {
+				blockIndent = indent
+			}
+			if indent >= blockIndent {
+				result[i] = line[blockIndent:]
+			} else {
+				result[i] = strings.TrimSpace(line)
+			}
+			continue
+		}
+		keyIndent, blockIndent = -1, -1
+		if match := blockScalarKey.FindStringSubmatch(line); match != nil {
+			keyIndent = len(match[1])
+		}
+	}
+	return result
+}
+
+// GetNsip is a function that accepts a file name as a parameter for input and then returns the NSIP set with
+// "set ns config", which is false when the configuration does not set one.
+func GetNsip(fileName string) (Snip, bool, error) {
+	file, err := GetFile(fileName)
+	if err != nil {
+		return Snip{}, false, err
+	}
+	nsConfigLines, err := GetConfigLines(file, "(set ns config ).*")
+	if err != nil {
+		return Snip{}, false, err
+	}
+	var nsip Snip
+	found := false
+	for _, nsConfigLine := range nsConfigLines {
+		fields := SplitConfigLine(nsConfigLine.text)
+		if ipAddress := GetOption(fields, "-IPAddress"); ipAddress != "" {
+			nsip = Snip{ipAddress: ipAddress, ipType: "NSIP", td: "0", line: nsConfigLine.number}
+			found = true
+		}
+		if netmask := GetOption(fields, "-netmask"); netmask != "" && found {
+			nsip.subnetMask = netmask
+		}
+	}
+	return nsip, found && nsip.subnetMask != "", nil
+}
@@ -0,0 +1,86 @@
+package nsanalyze
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+)
+
+// Policy is a data structure for the organization specific rules of what counts as covered: networks that are
+// reachable without appearing in the configuration, servers that are left out of the analysis and whether a
+// default route makes every server reachable.
+type Policy struct {
+	coveringPrefixes []*net.IPNet
+	excludedServers  map[string]bool
+	defaultRoutes    bool
+}
+
+// policyFile is the JSON representation of a policy file, which is also how a policy is given in the settings
+// file.
+type policyFile struct {
+	CoveringPrefixes []string `json:"coveringPrefixes" yaml:"coveringPrefixes"`
+	ExcludedServers  []string `json:"excludedServers" yaml:"excludedServers"`
+	DefaultRoutes    bool     `json:"defaultRoutes" yaml:"defaultRoutes"`
+}
+
+// LoadPolicy is a function that reads a policy from a JSON file. Excluded servers may be given by name or by
+// address.
+func LoadPolicy(fileName string) (*Policy, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var file policyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %v", fileName, err)
+	}
+	return newPolicy(fileName, file)
+}
+
+// newPolicy is a function that returns the policy of a policy file, or of the policy settings of the settings
+// file, reporting errors against the file it was read from.
+func newPolicy(fileName string, file policyFile) (*Policy, error) {
+	policy := &Policy{excludedServers: make(map[string]bool), defaultRoutes: file.DefaultRoutes}
+	for _, prefix := range file.CoveringPrefixes {
+		_, network, err := net.ParseCIDR(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", fileName, err)
+		}
+		policy.coveringPrefixes = append(policy.coveringPrefixes, network)
+	}
+	for _, server := range file.ExcludedServers {
+		policy.excludedServers[server] = true
+	}
+	return policy, nil
+}
+
+// Excludes is a function that reports whether the policy leaves a server out of the analysis.
+func (policy *Policy) Excludes(server Server) bool {
+	if policy == nil {
+		return false
+	}
+	return policy.excludedServers[server.name] || (server.ipAddress != "" && policy.excludedServers[server.ipAddress])
+}
+
+// CoveringNetworks is a function that returns the networks that the policy adds to those of the SNIPs, which
+// includes every address when default routes count and the configuration has one.
+func (policy *Policy) CoveringNetworks(fileName string) ([]*net.IPNet, error) {
+	if policy == nil {
+		return nil, nil
+	}
+	networks := append([]*net.IPNet(nil), policy.coveringPrefixes...)
+	if !policy.defaultRoutes {
+		return networks, nil
+	}
+	routes, err := GetRoutes(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, route := range routes {
+		if route.IsDefault() {
+			return append(networks, &net.IPNet{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)}), nil
+		}
+	}
+	return networks, nil
+}
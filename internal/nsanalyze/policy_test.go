@@ -0,0 +1,40 @@
+package nsanalyze
+
+import "testing"
+
+func TestExcludedServersKeepReferences(t *testing.T) {
+	fileName := writeConfig(t,
+		"add ns ip 10.0.0.10 255.255.255.0 -type SNIP",
+		"add server web1 10.0.0.5",
+		"add server legacy 192.168.7.5",
+		"add service svc-legacy legacy HTTP 80",
+		"add service svc-web web1 HTTP 80",
+	)
+	policy, err := newPolicy("policy.json", policyFile{ExcludedServers: []string{"legacy"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		policy *Policy
+		rule   string
+		object string
+		want   bool
+	}{
+		{"uncovered without policy", nil, "NS001", "legacy", true},
+		{"excluded is not uncovered", policy, "NS001", "legacy", false},
+		{"excluded is not missing", policy, "NS009", "svc-legacy", false},
+		{"covered server", policy, "NS001", "web1", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			findings, err := GetFindings(fileName, AnalyzeOptions{policy: test.policy})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hasFinding(findings, test.rule, test.object); got != test.want {
+				t.Errorf("%s %s found = %v, want %v, findings %v", test.rule, test.object, got, test.want, findingKeys(findings))
+			}
+		})
+	}
+}
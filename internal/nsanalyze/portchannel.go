@@ -0,0 +1,247 @@
+package nsanalyze
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Channel is a data structure for a NetScaler link aggregation channel along with its member interfaces. Static
+// channels are added through "add channel" and "bind channel", while LACP channels are formed by setting a LACP
+// mode and key on the member interfaces, which puts them in channel LA/<key>. The LACP mode is empty for a static
+// channel.
+type Channel struct {
+	name     string
+	members  []string
+	lacpMode string
+	line     int
+}
+
+// GetChannels is a function that accepts a file name as a parameter for input and then returns an array of the
+// channels of the configuration with their member interfaces, ordered by name.
+func GetChannels(fileName string) ([]Channel, error) {
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	channelLines, err := GetConfigLines(file, "((add|bind) channel |set interface ).*")
+	if err != nil {
+		return nil, err
+	}
+	var channels []Channel
+	index := make(map[string]int)
+	channelFor := func(name string, line int) *Channel {
+		i, ok := index[name]
+		if !ok {
+			i = len(channels)
+			index[name] = i
+			channels = append(channels, Channel{name: name, line: line})
+		}
+		return &channels[i]
+	}
+	for _, channelLine := range channelLines {
+		fields := SplitConfigLine(channelLine.text)
+		if len(fields) < 3 {
+			continue
+		}
+		if fields[1] == "interface" {
+			lacpMode := strings.ToUpper(GetOption(fields, "-lacpMode"))
+			lacpKey := GetOption(fields, "-lacpKey")
+			if lacpKey == "" || lacpMode == "" || lacpMode == "DISABLED" {
+				continue
+			}
+			channel := channelFor("LA/"+lacpKey, channelLine.number)
+			channel.lacpMode = lacpMode
+			channel.members = appendMember(channel.members, fields[2])
+			continue
+		}
+		channel := channelFor(fields[2], channelLine.number)
+		members := GetOptionValues(fields, "-ifnum")
+		for _, field := range fields[3:] {
+			if fields[0] != "bind" || strings.HasPrefix(field, "-") {
+				break
+			}
+			members = append(members, field)
+		}
+		for _, member := range members {
+			channel.members = appendMember(channel.members, member)
+		}
+	}
+	sort.Slice(channels, func(i, j int) bool {
+		return channels[i].name < channels[j].name
+	})
+	return channels, nil
+}
+
+// appendMember is a function that adds an interface to the members of a channel unless it is a member already.
+func appendMember(members []string, member string) []string {
+	if containsString(members, member) {
+		return members
+	}
+	return append(members, member)
+}
+
+// Number is a function that returns the number of the channel, such as 1 for LA/1, which the switch side
+// port-channel is numbered after.
+func (channel Channel) Number() string {
+	_, number, found := strings.Cut(channel.name, "/")
+	if !found {
+		return channel.name
+	}
+	return number
+}
+
+// portChannelStub is the data the switch side templates are executed with.
+type portChannelStub struct {
+	Channel    string
+	Number     string
+	Lacp       bool
+	Mode       string
+	Members    []portChannelMember
+	Allowed    []string
+	NativeVlan string
+}
+
+// portChannelMember is a member interface of a channel along with the switch port it connects to.
+type portChannelMember struct {
+	Interface  string
+	SwitchPort string
+}
+
+// portChannelFuncs are the functions the port-channel templates call.
+var portChannelFuncs = template.FuncMap{"join": strings.Join}
+
+// portChannelTemplates are the templates of the switch side port-channel configuration, by vendor. A switch
+// bundles the ports LACP negotiates with in active mode, whatever LACP mode the NetScaler runs, and a static
+// channel in mode on.
+var portChannelTemplates = map[string]*template.Template{
+	"cisco": template.Must(template.New("cisco").Funcs(portChannelFuncs).Parse(
+		`interface Port-channel{{.Number}}
+ description NetScaler {{.Channel}}
+ switchport mode trunk
+{{if .NativeVlan}} switchport trunk native vlan {{.NativeVlan}}
+{{end}} switchport trunk allowed vlan {{if .Allowed}}{{join .Allowed ","}}{{else}}none{{end}}
+!
+{{range .Members}}interface {{.SwitchPort}}
+ description NetScaler {{.Interface}}
+ channel-group {{$.Number}} mode {{$.Mode}}
+!
+{{end}}`)),
+	"arista": template.Must(template.New("arista").Funcs(portChannelFuncs).Parse(
+		`interface Port-Channel{{.Number}}
+   description NetScaler {{.Channel}}
+   switchport mode trunk
+{{if .NativeVlan}}   switchport trunk native vlan {{.NativeVlan}}
+{{end}}   switchport trunk allowed vlan {{if .Allowed}}{{join .Allowed ","}}{{else}}none{{end}}
+!
+{{range .Members}}interface {{.SwitchPort}}
+   description NetScaler {{.Interface}}
+   channel-group {{$.Number}} mode {{$.Mode}}
+!
+{{end}}`)),
+	"junos": template.Must(template.New("junos").Funcs(portChannelFuncs).Parse(
+		`set interfaces ae{{.Number}} description "NetScaler {{.Channel}}"
+{{if .Lacp}}set interfaces ae{{.Number}} aggregated-ether-options lacp active
+{{end}}set interfaces ae{{.Number}} unit 0 family ethernet-switching interface-mode trunk
+{{if .Allowed}}set interfaces ae{{.Number}} unit 0 family ethernet-switching vlan members [ {{join .Allowed " "}} ]
+{{end}}{{if .NativeVlan}}set interfaces ae{{.Number}} native-vlan-id {{.NativeVlan}}
+{{end}}{{range .Members}}set interfaces {{.SwitchPort}} description "NetScaler {{.Interface}}"
+set interfaces {{.SwitchPort}} ether-options 802.3ad ae{{$.Number}}
+{{end}}`)),
+}
+
+// GetPortChannelStubs is a function that accepts a file name as a parameter for input and then returns the data
+// of the switch side port-channel of every channel with members: the switch ports of the members, taken from the
+// interface aliases, and the VLANs the channel carries tagged and untagged.
+func GetPortChannelStubs(fileName string) ([]portChannelStub, error) {
+	channels, err := GetChannels(fileName)
+	if err != nil {
+		return nil, err
+	}
+	ports, err := GetTrunkPorts(fileName)
+	if err != nil {
+		return nil, err
+	}
+	trunkPorts := make(map[string]TrunkPort)
+	for _, port := range ports {
+		trunkPorts[port.iface.name] = port
+	}
+	var stubs []portChannelStub
+	for _, channel := range channels {
+		if len(channel.members) == 0 {
+			continue
+		}
+		stub := portChannelStub{
+			Channel: channel.name,
+			Number:  channel.Number(),
+			Lacp:    channel.lacpMode != "",
+			Mode:    "on",
+			Allowed: trunkPorts[channel.name].tagged,
+		}
+		if stub.Lacp {
+			stub.Mode = "active"
+		}
+		if untagged := trunkPorts[channel.name].untagged; len(untagged) > 0 {
+			stub.NativeVlan = untagged[0]
+		}
+		for _, member := range channel.members {
+			switchPort := trunkPorts[member].iface.alias
+			if switchPort == "" {
+				switchPort = "<" + Translate("switch port of") + " " + member + ">"
+			}
+			stub.Members = append(stub.Members, portChannelMember{Interface: member, SwitchPort: switchPort})
+		}
+		stubs = append(stubs, stub)
+	}
+	return stubs, nil
+}
+
+// PrintPortChannels is a function that writes the switch side port-channel configuration of every channel of a
+// configuration with a vendor template.
+func PrintPortChannels(w io.Writer, fileName, vendor string) error {
+	portChannel, ok := portChannelTemplates[vendor]
+	if !ok {
+		return fmt.Errorf("unsupported vendor %q, use one of %s", vendor, strings.Join(sortedTemplateNames(), ", "))
+	}
+	stubs, err := GetPortChannelStubs(fileName)
+	if err != nil {
+		return err
+	}
+	if len(stubs) == 0 {
+		return fmt.Errorf("%s: no channel has member interfaces", fileName)
+	}
+	for _, stub := range stubs {
+		if err := portChannel.Execute(w, stub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedTemplateNames is a function that returns the vendors there is a port-channel template for, in order.
+func sortedTemplateNames() []string {
+	var names []string
+	for name := range portChannelTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RunPortChannel is a function that runs the port-channel subcommand.
+func RunPortChannel(args []string) error {
+	flags := flag.NewFlagSet("port-channel", flag.ContinueOnError)
+	vendor := flags.String("vendor", "cisco", "switch vendor to write the configuration for: "+strings.Join(sortedTemplateNames(), ", "))
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: port-channel [-vendor cisco|arista|junos] filename")
+	}
+	return PrintPortChannels(os.Stdout, flags.Arg(0), *vendor)
+}
@@ -0,0 +1,182 @@
+package nsanalyze
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// maxProbeRate is the most probes that can be started per second, well below the rate at which the interval
+// between probes would round down to nothing.
+const maxProbeRate = 10000
+
+// Prober is a data structure for live probing of server addresses from the machine running the tool, either by
+// ICMP echo or by opening a TCP connection to a port. Probes are started no faster than the rate and the result
+// for each address is kept so that an address is only probed once per run.
+type Prober struct {
+	spec    string
+	port    string
+	timeout time.Duration
+	rate    int
+	mutex   sync.Mutex
+	results map[string]bool
+}
+
+// NewProber is a function that returns a prober for a probe specification of "icmp" or "tcp:<port>".
+func NewProber(spec string, timeout time.Duration, rate int) (*Prober, error) {
+	if rate <= 0 || rate > maxProbeRate {
+		return nil, fmt.Errorf("probe rate must be between 1 and %d, got %d", maxProbeRate, rate)
+	}
+	prober := &Prober{spec: spec, timeout: timeout, rate: rate, results: make(map[string]bool)}
+	if spec == "icmp" {
+		return prober, nil
+	}
+	port, ok := strings.CutPrefix(spec, "tcp:")
+	if number, err := strconv.Atoi(port); !ok || err != nil || number < 1 || number > 65535 {
+		return nil, fmt.Errorf("unknown probe %q, use icmp or tcp:<port>", spec)
+	}
+	prober.port = port
+	return prober, nil
+}
+
+// Probe is a function that reports whether an address answers the probe. A refused connection counts as an
+// answer, as the server is reachable and only has nothing listening on the port, while a timeout or an
+// unreachable network counts as no answer. Failures to send the probe at all are returned as errors.
+func (prober *Prober) Probe(ipAddress string) (bool, error) {
+	if err := CheckRunContext(); err != nil {
+		return false, err
+	}
+	if prober.port != "" {
+		dialer := net.Dialer{Timeout: prober.timeout}
+		conn, err := dialer.DialContext(runContext, "tcp", net.JoinHostPort(ipAddress, prober.port))
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return true, nil
+		}
+		if err != nil {
+			return false, nil
+		}
+		conn.Close()
+		return true, nil
+	}
+	return prober.echo(ipAddress)
+}
+
+// echo is a function that sends an ICMP echo request to an address and waits for the reply. Raw ICMP sockets
+// need root or the CAP_NET_RAW capability.
+func (prober *Prober) echo(ipAddress string) (bool, error) {
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return false, fmt.Errorf("%q is not an IP address", ipAddress)
+	}
+	network, requestType, replyType := "ip4:icmp", byte(8), byte(0)
+	if ip.To4() == nil {
+		network, requestType, replyType = "ip6:ipv6-icmp", 128, 129
+	}
+	conn, err := net.ListenPacket(network, "")
+	if err != nil {
+		return false, fmt.Errorf("icmp probing needs raw socket privileges: %v", err)
+	}
+	defer conn.Close()
+	id := uint16(os.Getpid())
+	message := make([]byte, 8)
+	message[0] = requestType
+	binary.BigEndian.PutUint16(message[4:], id)
+	binary.BigEndian.PutUint16(message[6:], 1)
+	if requestType == 8 {
+		// The kernel fills in the checksum of ICMPv6 messages, as it covers a pseudo header.
+		binary.BigEndian.PutUint16(message[2:], icmpChecksum(message))
+	}
+	if _, err := conn.WriteTo(message, &net.IPAddr{IP: ip}); err != nil {
+		return false, err
+	}
+	deadline := time.Now().Add(prober.timeout)
+	conn.SetReadDeadline(deadline)
+	reply := make([]byte, 1500)
+	for {
+		n, from, err := conn.ReadFrom(reply)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return false, nil
+			}
+			return false, err
+		}
+		source, ok := from.(*net.IPAddr)
+		if n >= 8 && ok && source.IP.Equal(ip) && reply[0] == replyType && binary.BigEndian.Uint16(reply[4:]) == id {
+			return true, nil
+		}
+	}
+}
+
+// icmpChecksum is a function that returns the internet checksum of an ICMP message.
+func icmpChecksum(message []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(message); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(message[i:]))
+	}
+	if len(message)%2 == 1 {
+		sum += uint32(message[len(message)-1]) << 8
+	}
+	for sum > 0xffff {
+		sum = sum>>16 + sum&0xffff
+	}
+	return ^uint16(sum)
+}
+
+// ProbeServers is a function that probes the address of every server concurrently, starting no more probes per
+// second than the rate, and returns whether each address answered.
+func (prober *Prober) ProbeServers(servers []Server) (map[string]bool, error) {
+	ticker := time.NewTicker(time.Second / time.Duration(prober.rate))
+	defer ticker.Stop()
+	var wg sync.WaitGroup
+	var firstErr error
+	launched := make(map[string]bool)
+	for _, server := range servers {
+		prober.mutex.Lock()
+		_, done := prober.results[server.ipAddress]
+		prober.mutex.Unlock()
+		if server.ipAddress == "" || done || launched[server.ipAddress] {
+			continue
+		}
+		launched[server.ipAddress] = true
+		<-ticker.C
+		wg.Add(1)
+		go func(ipAddress string) {
+			defer wg.Done()
+			alive, err := prober.Probe(ipAddress)
+			prober.mutex.Lock()
+			defer prober.mutex.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			prober.results[ipAddress] = alive
+		}(server.ipAddress)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	results := make(map[string]bool)
+	for _, server := range servers {
+		results[server.ipAddress] = prober.results[server.ipAddress]
+	}
+	return results, nil
+}
+
+// Describe is a function that returns whether an address answered the probe, for annotating reports.
+func (prober *Prober) Describe(alive bool) string {
+	if alive {
+		return "answers " + prober.spec
+	}
+	return "does not answer " + prober.spec
+}
@@ -0,0 +1,67 @@
+package nsanalyze
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNewProber(t *testing.T) {
+	tests := []struct {
+		spec  string
+		rate  int
+		valid bool
+	}{
+		{"icmp", 10, true},
+		{"tcp:443", 10, true},
+		{"tcp:443", maxProbeRate, true},
+		{"tcp:443", 0, false},
+		{"tcp:443", -1, false},
+		{"tcp:443", maxProbeRate + 1, false},
+		{"tcp:443", 2000000000, false},
+		{"tcp:0", 10, false},
+		{"tcp:65536", 10, false},
+		{"udp:53", 10, false},
+	}
+	for _, test := range tests {
+		_, err := NewProber(test.spec, time.Second, test.rate)
+		if (err == nil) != test.valid {
+			t.Errorf("NewProber(%q, %d) error = %v, want valid %v", test.spec, test.rate, err, test.valid)
+		}
+	}
+}
+
+func TestProbeTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	open := listener.Addr().(*net.TCPAddr).Port
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	refused := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+	defer listener.Close()
+	tests := []struct {
+		name string
+		port int
+	}{
+		{"listening", open},
+		{"refused", refused},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			prober, err := NewProber("tcp:"+strconv.Itoa(test.port), time.Second, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			alive, err := prober.Probe("127.0.0.1")
+			if err != nil || !alive {
+				t.Errorf("Probe = %v, %v, want reachable", alive, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Profile is a data structure for a named set of rules, so that a team can run the checks relevant to it without
+// listing them.
+type Profile struct {
+	name        string
+	description string
+	rules       []Rule
+}
+
+// GetProfiles is a function that returns every profile known to the tool. The full audit profile has no rule
+// list of its own and runs every rule, including rules added later.
+func GetProfiles() []Profile {
+	return []Profile{
+		{"coverage-only", "servers not covered by any SNIP network and what keeps them from being checked", []Rule{
+			RuleUncoveredServer, RuleUnknownMask, RuleUnresolvedServer, RuleMissingServer, RuleUnresolvedReference,
+			RuleSpottedCoverage, RuleDnsDiscrepancy,
+		}},
+		{"full-audit", "every rule", nil},
+		{"vlan-migration", "everything that loses reachability when SNIPs and VLANs move", []Rule{
+			RuleUncoveredServer, RuleOverlappingSubnet, RuleUnknownMask, RuleOrphanVlan, RuleNativeVlanConflict,
+			RuleNativeVlanMismatch, RuleUnreachableCollector, RulePartialPersistenceGroup, RuleUnreachableSnmp,
+			RulePolicyAddress, RuleDanglingReference, RuleUnreachableInfrastructure, RuleModeCaveat,
+			RuleUncoveredSetMember, RuleSpottedCoverage, RuleUncoveredListenPolicy, RuleMtuMismatch,
+			RuleUnreachableRpcNode, RuleUnreachableAuthServer, RuleTrunkModeMismatch, RuleUncoveredBackupVserver,
+			RuleProfileAddress, RuleUnreachableCertificateValidation, RuleSplitBridgeGroup,
+			RuleNonAdjacentDsrServer,
+		}},
+		{"security", "addresses and settings that expose or misroute traffic", []Rule{
+			RuleBogusAddress, RuleUnreachableSnmp, RulePolicyAddress, RuleDanglingReference, RuleModeCaveat,
+			RuleUncoveredSetMember, RuleSubnetEdgeAddress,
+		}},
+	}
+}
+
+// GetProfile is a function that returns the profile with the given name.
+func GetProfile(name string) (*Profile, error) {
+	var names []string
+	for _, profile := range GetProfiles() {
+		if profile.name == name {
+			return &profile, nil
+		}
+		names = append(names, profile.name)
+	}
+	return nil, fmt.Errorf("unknown profile %q, use one of %s", name, strings.Join(names, ", "))
+}
+
+// Includes is a function that reports whether the profile runs a rule. Having no profile runs every rule.
+func (profile *Profile) Includes(rule Rule) bool {
+	if profile == nil || profile.rules == nil {
+		return true
+	}
+	for _, included := range profile.rules {
+		if included.id == rule.id {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterFindings is a function that returns the findings of the rules the profile runs.
+func (profile *Profile) FilterFindings(findings []Finding) []Finding {
+	var filtered []Finding
+	for _, finding := range findings {
+		if profile.Includes(finding.rule) {
+			filtered = append(filtered, finding)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,140 @@
+package nsanalyze
+
+import "fmt"
+
+// Reference is a data structure for a reference from one object of a configuration to another, such as a
+// service to its server or a VLAN to the interface it is bound to.
+type Reference struct {
+	kind          string
+	name          string
+	referenceKind string
+	reference     string
+	line          int
+}
+
+// GetDanglingReferences is a function that accepts a file name as a parameter for input and then returns every
+// reference to an object that the configuration never adds: servers of services and service group members,
+// services and service groups bound to virtual servers, virtual servers bound to LB groups and VLANs and
+// channels of VLAN bindings. Physical interfaces are not checked, since the appliance leaves the interfaces that
+// were never changed out of the saved configuration, so a missing "set interface" line does not mean the
+// interface is missing.
+func GetDanglingReferences(fileName string, servers []Server) ([]Reference, error) {
+	var references []Reference
+	known := make(map[Node]bool)
+	for _, server := range servers {
+		known[Node{kind: NodeServer, name: server.name}] = true
+	}
+	targets, err := GetServiceTargets(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, target := range targets {
+		if !known[Node{kind: NodeServer, name: target.serverName}] {
+			references = append(references, Reference{target.kind, target.name, NodeServer, target.serverName, target.line})
+		}
+	}
+	services, err := GetServices(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, service := range services {
+		known[Node{kind: NodeService, name: service.name}] = true
+	}
+	groups, err := GetServiceGroups(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		// Bindings to virtual servers do not say whether they bind a service or a service group.
+		known[Node{kind: NodeService, name: group.name}] = true
+	}
+	bindings, err := GetLbBindings(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, binding := range bindings {
+		if !known[Node{kind: NodeService, name: binding.serviceName}] {
+			references = append(references, Reference{NodeLbVserver, binding.vserverName, "service or serviceGroup",
+				binding.serviceName, binding.line})
+		}
+	}
+	vservers, err := GetLbVservers(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, vserver := range vservers {
+		known[Node{kind: NodeLbVserver, name: vserver.name}] = true
+	}
+	members, err := GetLbGroupMembers(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		if !known[Node{kind: NodeLbVserver, name: member.vserverName}] {
+			references = append(references, Reference{NodeLbGroup, member.groupName, NodeLbVserver, member.vserverName,
+				member.line})
+		}
+	}
+	vlans, err := GetVlans(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, vlan := range vlans {
+		known[Node{kind: "vlan", name: vlan.id}] = true
+	}
+	channels, err := GetDefinedChannels(fileName)
+	if err != nil {
+		return nil, err
+	}
+	vlanBindings, err := GetVlanBindings(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, binding := range vlanBindings {
+		// VLAN 1 always exists and the NSVLAN is created by "set ns config" itself.
+		if binding.vlanID != "1" && !binding.nsvlan && !known[Node{kind: "vlan", name: binding.vlanID}] {
+			references = append(references, Reference{"interface", binding.interfaceName, "vlan", binding.vlanID,
+				binding.line})
+		}
+		if IsChannelInterface(binding.interfaceName) && !channels[binding.interfaceName] {
+			references = append(references, Reference{"vlan", binding.vlanID, "interface", binding.interfaceName,
+				binding.line})
+		}
+	}
+	return references, nil
+}
+
+// GetReferenceFindings is a function that returns a finding for every dangling reference of the configuration.
+// Within a partial configuration the referenced objects are usually defined on lines that were not included,
+// so they are warnings rather than errors.
+func GetReferenceFindings(fileName string, servers []Server, options AnalyzeOptions) ([]Finding, error) {
+	var findings []Finding
+	references, err := GetDanglingReferences(fileName, servers)
+	if err != nil {
+		return nil, err
+	}
+	for _, reference := range references {
+		finding := Finding{
+			rule: RuleDanglingReference,
+			message: fmt.Sprintf("%s %s references %s %s which is never added", reference.kind, reference.name,
+				reference.referenceKind, reference.reference),
+			object:     reference.name,
+			objectType: reference.kind,
+			key:        reference.referenceKind + "/" + reference.reference,
+			fileName:   fileName,
+			line:       reference.line,
+		}
+		switch {
+		case options.partial:
+			finding.rule = RuleUnresolvedReference
+			finding.message = fmt.Sprintf("%s %s references %s %s which is not in the input", reference.kind,
+				reference.name, reference.referenceKind, reference.reference)
+		case reference.referenceKind == NodeServer:
+			finding.rule = RuleMissingServer
+			finding.message = fmt.Sprintf("Server %s targeted by %s %s is never added", reference.reference,
+				reference.kind, reference.name)
+		}
+		findings = append(findings, finding)
+	}
+	return findings, nil
+}
@@ -0,0 +1,313 @@
+package nsanalyze
+
+import (
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Remediation action kinds. Excluded servers are those an operator chose to leave uncovered, which need no
+// commands.
+const (
+	ActionSnip    = "snip"
+	ActionRoute   = "route"
+	ActionExclude = "exclude"
+)
+
+// RemediationAction is a data structure for a change that brings a network of uncovered servers back into
+// reach, either a new SNIP within the network or a route towards it, along with the servers that motivate it.
+type RemediationAction struct {
+	kind    string
+	network *net.IPNet
+	address string
+	gateway string
+	vlan    string
+	servers []Server
+}
+
+// Commands is a function that returns the NetScaler commands that apply the action.
+func (action RemediationAction) Commands() []string {
+	if action.kind == ActionExclude {
+		return nil
+	}
+	mask := net.IP(action.network.Mask).String()
+	if action.kind == ActionRoute {
+		return []string{fmt.Sprintf("add route %s %s %s", action.network.IP, mask, action.gateway)}
+	}
+	commands := []string{fmt.Sprintf("add ns ip %s %s -type SNIP", action.address, mask)}
+	if action.vlan != "" {
+		commands = append(commands, fmt.Sprintf("bind vlan %s -IPAddress %s %s", action.vlan, action.address, mask))
+	}
+	return commands
+}
+
+// RollbackCommands is a function that returns the commands that undo the action, in the order they have to be
+// run, which is the reverse of the order the action was applied in.
+func (action RemediationAction) RollbackCommands() []string {
+	if action.kind == ActionExclude {
+		return nil
+	}
+	mask := net.IP(action.network.Mask).String()
+	if action.kind == ActionRoute {
+		return []string{fmt.Sprintf("rm route %s %s %s", action.network.IP, mask, action.gateway)}
+	}
+	var commands []string
+	if action.vlan != "" {
+		commands = append(commands, fmt.Sprintf("unbind vlan %s -IPAddress %s %s", action.vlan, action.address, mask))
+	}
+	return append(commands, fmt.Sprintf("rm ns ip %s", action.address))
+}
+
+// GetRemediationActions is a function that groups the uncovered servers into networks of the given prefix length
+// and returns an action for each network. With a gateway the actions are routes, otherwise they are SNIPs using
+// the highest address of the network that is not already in use, optionally bound to a VLAN. It fails when a network
+// has no address left for a SNIP.
+func GetRemediationActions(uncovered []Server, used map[string]bool, prefixLength int, gateway, vlan string) ([]RemediationAction, error) {
+	var actions []RemediationAction
+	index := make(map[string]int)
+	for _, server := range uncovered {
+		ip := net.ParseIP(server.ipAddress).To4()
+		if ip == nil {
+			continue
+		}
+		network := &net.IPNet{IP: ip.Mask(net.CIDRMask(prefixLength, 32)), Mask: net.CIDRMask(prefixLength, 32)}
+		i, ok := index[network.String()]
+		if !ok {
+			i = len(actions)
+			index[network.String()] = i
+			action := RemediationAction{kind: ActionSnip, network: network, vlan: vlan}
+			if gateway != "" {
+				action.kind = ActionRoute
+				action.gateway = gateway
+			}
+			actions = append(actions, action)
+		}
+		actions[i].servers = append(actions[i].servers, server)
+	}
+	for i := range actions {
+		if actions[i].kind != ActionSnip {
+			continue
+		}
+		address, err := freeAddress(actions[i].network, used)
+		if err != nil {
+			return nil, err
+		}
+		actions[i].address = address
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		return binary.BigEndian.Uint32(actions[i].network.IP) < binary.BigEndian.Uint32(actions[j].network.IP)
+	})
+	return actions, nil
+}
+
+// freeAddress is a function that returns the highest host address of a network that is not in use, leaving out
+// the network and broadcast addresses for networks that have them. Both addresses of a /31 are host addresses, and
+// the one address of a /32 is the uncovered server itself. It fails when every host address is in use.
+func freeAddress(network *net.IPNet, used map[string]bool) (string, error) {
+	ones, bits := network.Mask.Size()
+	first := binary.BigEndian.Uint32(network.IP)
+	last := first | (1<<uint(bits-ones) - 1)
+	if bits-ones > 1 {
+		first++
+		last--
+	}
+	for candidate := last; ; candidate-- {
+		address := make(net.IP, 4)
+		binary.BigEndian.PutUint32(address, candidate)
+		if !used[address.String()] {
+			return address.String(), nil
+		}
+		if candidate == first {
+			return "", fmt.Errorf("%s: every host address is in use, so there is none left for a SNIP; use -gateway to add a route or a shorter -prefix", network)
+		}
+	}
+}
+
+// WriteRemediation is a function that writes the commands of every action along with the servers that motivate
+// them. Excluded servers are listed without commands.
+func WriteRemediation(w io.Writer, actions []RemediationAction) {
+	for _, action := range actions {
+		if action.kind == ActionExclude {
+			fmt.Fprintf(w, "# %s: %d uncovered server(s), excluded\n", action.network, len(action.servers))
+		} else {
+			fmt.Fprintf(w, "# %s: %d uncovered server(s)\n", action.network, len(action.servers))
+		}
+		for _, server := range action.servers {
+			fmt.Fprintf(w, "#   %s\n", server.Describe())
+		}
+		for _, command := range action.Commands() {
+			fmt.Fprintln(w, command)
+		}
+	}
+}
+
+// WriteRollback is a function that writes the commands that undo every action, undoing the last action first.
+func WriteRollback(w io.Writer, actions []RemediationAction) {
+	for i := len(actions) - 1; i >= 0; i-- {
+		if actions[i].kind == ActionExclude {
+			continue
+		}
+		fmt.Fprintf(w, "# %s\n", actions[i].network)
+		for _, command := range actions[i].RollbackCommands() {
+			fmt.Fprintln(w, command)
+		}
+	}
+}
+
+// RunRemediate is a function that runs the remediate subcommand, writing the remediation commands and the
+// matching rollback commands to separate files named after the configuration. In interactive mode the operator
+// decides what to do for each network instead and the decisions are written to a plan, which a later run renders
+// to the same files.
+func RunRemediate(args []string, options AnalyzeOptions) error {
+	flags := flag.NewFlagSet("remediate", flag.ContinueOnError)
+	prefixLength := flags.Int("prefix", 24, "prefix length of the networks that uncovered servers are grouped into")
+	gateway := flags.String("gateway", "", "add routes through this gateway instead of new SNIPs")
+	vlan := flags.String("vlan", "", "bind new SNIPs to this VLAN")
+	interactive := flags.Bool("interactive", false, "ask what to do for each network and write the answers to a plan")
+	planFile := flags.String("plan", "", "plan file to write in interactive mode, defaults to one named after the configuration")
+	render := flags.String("render", "", "write the commands of a plan written in interactive mode")
+	bundleDir := flags.String("bundle", "", "also write a directory per VLAN of the new SNIPs into this directory, for the change tickets")
+	vendor := flags.String("vendor", "cisco", "switch vendor to write the trunk commands of VLAN bundles for: "+strings.Join(sortedTemplateNames(), ", "))
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *render != "" && flags.NArg() == 0 && !*interactive {
+		return renderPlan(*render, *bundleDir, *vendor)
+	}
+	if flags.NArg() != 1 || *prefixLength < 1 || *prefixLength > 32 || *render != "" || (*planFile != "" && !*interactive) || (*bundleDir != "" && *interactive) {
+		return errors.New("usage: remediate [-prefix length] [-gateway ip] [-vlan id] [-bundle dir [-vendor name]] [-interactive [-plan file]] filename\n" +
+			"       remediate -render plan [-bundle dir [-vendor name]]")
+	}
+	fileName := flags.Arg(0)
+	snips, err := GetSnips(fileName)
+	if err != nil {
+		return err
+	}
+	networks, err := GetCoverageNetworks(fileName, options)
+	if err != nil {
+		return err
+	}
+	servers, err := GetAnalysisServers(fileName, options)
+	if err != nil {
+		return err
+	}
+	used := make(map[string]bool)
+	for _, server := range servers {
+		used[server.ipAddress] = true
+	}
+	for _, snip := range snips {
+		used[snip.ipAddress] = true
+	}
+	uncovered := GetUncoveredServers(networks, servers)
+	if *interactive {
+		actions, err := GetRemediationActions(uncovered, used, *prefixLength, "", *vlan)
+		if err != nil {
+			return err
+		}
+		chosen, err := PromptRemediation(os.Stdin, os.Stdout, actions, *gateway, *vlan)
+		if err != nil {
+			return err
+		}
+		if *planFile == "" {
+			*planFile = OutputBaseName(fileName) + "-plan.json"
+		}
+		if err := WritePlan(*planFile, fileName, chosen); err != nil {
+			return err
+		}
+		fmt.Printf("%d of %d network(s) decided, plan written to %s\n", len(chosen), len(actions), *planFile)
+		return nil
+	}
+	actions, err := GetRemediationActions(uncovered, used, *prefixLength, *gateway, *vlan)
+	if err != nil {
+		return err
+	}
+	if err := writeRemediationFiles(OutputBaseName(fileName), actions); err != nil {
+		return err
+	}
+	return writeBundles(*bundleDir, fileName, *vendor, actions)
+}
+
+// renderPlan is a function that writes the remediation and rollback commands of a plan to files named after the
+// configuration the plan was made for, along with the VLAN bundles of the plan when a bundle directory is given.
+func renderPlan(fileName, bundleDir, vendor string) error {
+	configuration, actions, err := LoadPlan(fileName)
+	if err != nil {
+		return err
+	}
+	if err := writeRemediationFiles(OutputBaseName(configuration), actions); err != nil {
+		return err
+	}
+	return writeBundles(bundleDir, configuration, vendor, actions)
+}
+
+// writeBundles is a function that writes the VLAN bundles of the actions into a directory, doing nothing when no
+// directory is given.
+func writeBundles(dir, fileName, vendor string, actions []RemediationAction) error {
+	if dir == "" {
+		return nil
+	}
+	bundles, err := GetVlanBundles(fileName, actions)
+	if err != nil {
+		return err
+	}
+	if len(bundles) == 0 {
+		return fmt.Errorf("%s: no new SNIP is bound to a VLAN, so there is no VLAN bundle to write", fileName)
+	}
+	dirs, err := WriteVlanBundles(dir, fileName, vendor, bundles)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d VLAN bundle(s) written to %s\n", len(dirs), dir)
+	return nil
+}
+
+// writeRemediationFiles is a function that writes the remediation and rollback commands of the actions to files
+// with the given base name.
+func writeRemediationFiles(base string, actions []RemediationAction) error {
+	if err := writeFile(base+"-remediation.txt", func(w io.Writer) { WriteRemediation(w, actions) }); err != nil {
+		return err
+	}
+	return writeFile(base+"-rollback.txt", func(w io.Writer) { WriteRollback(w, actions) })
+}
+
+// checkedWriter is a data structure for a writer that keeps the first error of a write, so that a function that
+// writes a file piece by piece need not check every write.
+type checkedWriter struct {
+	w   io.Writer
+	err error
+}
+
+// Write is a function that writes to the underlying writer until a write fails, and then writes nothing more.
+func (cw *checkedWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.err = err
+	return n, err
+}
+
+// writeFile is a function that creates or replaces a file and fills it using the given function. It fails when a
+// write fails, as on a full disk, so that a truncated set of commands is never taken for a complete one.
+func writeFile(fileName string, write func(w io.Writer)) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	RecordGeneratedFile(fileName)
+	checked := &checkedWriter{w: file}
+	write(checked)
+	if err := file.Close(); err != nil && checked.err == nil {
+		checked.err = err
+	}
+	if checked.err != nil {
+		return fmt.Errorf("%s: %v", fileName, checked.err)
+	}
+	return nil
+}
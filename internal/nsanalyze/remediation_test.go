@@ -0,0 +1,64 @@
+package nsanalyze
+
+import (
+	"io"
+	"net"
+	"os"
+	"testing"
+)
+
+func TestFreeAddress(t *testing.T) {
+	tests := []struct {
+		network string
+		used    []string
+		want    string
+	}{
+		{"10.0.0.0/24", nil, "10.0.0.254"},
+		{"10.0.0.0/24", []string{"10.0.0.254", "10.0.0.253"}, "10.0.0.252"},
+		{"10.0.0.0/30", []string{"10.0.0.2"}, "10.0.0.1"},
+		{"10.0.0.0/30", []string{"10.0.0.1", "10.0.0.2"}, ""},
+		{"10.0.0.0/31", []string{"10.0.0.1"}, "10.0.0.0"},
+		{"10.0.0.0/31", []string{"10.0.0.0", "10.0.0.1"}, ""},
+		{"10.0.0.5/32", []string{"10.0.0.5"}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.network, func(t *testing.T) {
+			_, network, err := net.ParseCIDR(test.network)
+			if err != nil {
+				t.Fatal(err)
+			}
+			used := make(map[string]bool)
+			for _, address := range test.used {
+				used[address] = true
+			}
+			got, err := freeAddress(network, used)
+			if got != test.want {
+				t.Errorf("freeAddress(%s, %v) = %q, want %q", test.network, test.used, got, test.want)
+			}
+			if (err != nil) != (test.want == "") {
+				t.Errorf("freeAddress(%s, %v) error = %v", test.network, test.used, err)
+			}
+		})
+	}
+}
+
+func TestGetRemediationActionsNoFreeAddress(t *testing.T) {
+	uncovered := []Server{{name: "web1", ipAddress: "10.9.9.9"}}
+	if _, err := GetRemediationActions(uncovered, map[string]bool{"10.9.9.9": true}, 32, "", ""); err == nil {
+		t.Error("a /32 of an uncovered server yields no error")
+	}
+	actions, err := GetRemediationActions(uncovered, map[string]bool{"10.9.9.9": true}, 32, "10.0.0.1", "")
+	if err != nil || len(actions) != 1 || actions[0].kind != ActionRoute {
+		t.Errorf("routes = %v, %v, want one route", actions, err)
+	}
+}
+
+func TestWriteFileFailedWrite(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("no /dev/full")
+	}
+	err := writeFile("/dev/full", func(w io.Writer) { io.WriteString(w, "add ns ip 10.0.0.1 255.255.255.0\n") })
+	if err == nil {
+		t.Error("a write to a full device succeeds")
+	}
+}
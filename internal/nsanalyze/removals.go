@@ -0,0 +1,155 @@
+package nsanalyze
+
+import (
+	"strings"
+)
+
+// commandGroups holds the CLI groups whose commands name the type of object with a second keyword, as in "add lb
+// vserver" or "rm ns ip", as opposed to those such as "add server" where the name follows the first keyword.
+var commandGroups = map[string]bool{
+	"aaa": true, "appflow": true, "appfw": true, "audit": true, "authentication": true, "authorization": true,
+	"bot": true, "cache": true, "cmp": true, "cr": true, "cs": true, "db": true, "dns": true, "feo": true,
+	"filter": true, "gslb": true, "ha": true, "ica": true, "ipsec": true, "lb": true, "lsn": true, "ns": true,
+	"ntp": true, "policy": true, "responder": true, "rewrite": true, "smpp": true, "snmp": true, "spillover": true,
+	"ssl": true, "stream": true, "subscriber": true, "system": true, "tm": true, "transform": true, "vpn": true,
+}
+
+// objectKeywords is a function that returns how many of the fields following the verb of a command are keywords
+// naming the type of object, which the CLI compares case insensitively, before the object name and the rest,
+// which it compares as they are.
+func objectKeywords(fields []string) int {
+	if len(fields) > 1 && commandGroups[strings.ToLower(fields[0])] {
+		return 2
+	}
+	return 1
+}
+
+// removalKey is a function that returns the key of the first count fields following the verb of a command or
+// line, with the keywords and options folded to lower case as the CLI compares them, so that fields start with
+// those of a command when the keys of as many of them are equal.
+func removalKey(fields []string, count int) string {
+	keywords := objectKeywords(fields)
+	var key strings.Builder
+	for i, field := range fields[:count] {
+		if i < keywords || strings.HasPrefix(field, "-") {
+			field = strings.ToLower(field)
+		}
+		key.WriteString(field)
+		key.WriteByte(0)
+	}
+	return key.String()
+}
+
+// ApplyRemovals is a function that applies the "rm" and "unbind" lines of a configuration to the lines before
+// them, as exports that are really command logs remove objects after adding them. Lines taken out are blanked
+// rather than removed so that the line numbers of the rest of the configuration do not change, and an object
+// added again after its removal is kept. The commands are gathered in a first pass, by the key of their fields
+// along with the last line each appears on, so that the second pass only looks up the start of every line. It
+// fails once the run is past its timeout.
+func ApplyRemovals(file string) (string, error) {
+	if !strings.Contains(file, "rm ") && !strings.Contains(file, "unbind ") {
+		return file, nil
+	}
+	lines := strings.Split(file, "\n")
+	removals := make(map[string]int)
+	unbindings := make(map[string]int)
+	removedIps := make(map[string]int)
+	longest := 0
+	for i, line := range lines {
+		if i%contextCheckInterval == 0 {
+			if err := CheckRunContext(); err != nil {
+				return "", err
+			}
+		}
+		if !strings.HasPrefix(line, "rm ") && !strings.HasPrefix(line, "unbind ") {
+			continue
+		}
+		fields := SplitConfigLine(line)
+		if len(fields) < 3 {
+			continue
+		}
+		key := removalKey(fields[1:], len(fields)-1)
+		if fields[0] == "rm" {
+			removals[key] = i
+			if len(fields) >= 4 && strings.EqualFold(fields[1], "ns") && strings.EqualFold(fields[2], "ip") {
+				removedIps[fields[3]] = i
+			}
+		} else {
+			unbindings[key] = i
+		}
+		if len(fields)-1 > longest {
+			longest = len(fields) - 1
+		}
+	}
+	changed := false
+	for i, line := range lines {
+		if i%contextCheckInterval == 0 {
+			if err := CheckRunContext(); err != nil {
+				return "", err
+			}
+		}
+		if !strings.HasPrefix(line, "add ") && !strings.HasPrefix(line, "bind ") {
+			continue
+		}
+		fields := SplitConfigLine(line)
+		if removedAfter(fields, i, removals, unbindings, removedIps, longest) {
+			lines[i] = ""
+			changed = true
+		}
+	}
+	if !changed {
+		return file, nil
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// removedAfter is a function that reports whether an "add" or "bind" line on line i is taken out by a command on a
+// later line: an "rm" of the object it adds or binds, an "unbind" whose fields it starts with, or for a VLAN
+// binding the "rm ns ip" of its address.
+func removedAfter(fields []string, i int, removals, unbindings, removedIps map[string]int, longest int) bool {
+	if len(fields) < 3 {
+		return false
+	}
+	if fields[0] == "bind" && strings.EqualFold(fields[1], "vlan") {
+		if last, ok := removedIps[GetOption(fields, "-IPAddress")]; ok && last > i {
+			return true
+		}
+	}
+	for count := 2; count < len(fields) && count <= longest; count++ {
+		key := removalKey(fields[1:], count)
+		if last, ok := removals[key]; ok && last > i {
+			return true
+		}
+		if last, ok := unbindings[key]; ok && last > i && fields[0] == "bind" {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveLines is a function that blanks the lines an "rm" or "unbind" command takes out and returns how many it
+// took out. Removing an object takes out its "add" line and its own "bind" lines, unbinding takes out the "bind"
+// lines whose fields start with those of the command, and removing an IP also takes out its VLAN bindings.
+func RemoveLines(lines []string, command []string) int {
+	removed := 0
+	for i, line := range lines {
+		lineFields := SplitConfigLine(line)
+		if len(lineFields) == 0 {
+			continue
+		}
+		var matches bool
+		switch {
+		case command[0] == "rm" && lineFields[0] == "add":
+			matches = hasFieldPrefix(lineFields[1:], command[1:])
+		case command[0] == "rm" && lineFields[0] == "bind":
+			matches = hasFieldPrefix(lineFields[1:], command[1:]) || removesIpBinding(command, lineFields)
+		case command[0] == "unbind" && lineFields[0] == "bind":
+			matches = hasFieldPrefix(lineFields[1:], command[1:])
+		}
+		if matches {
+			lines[i] = ""
+			removed++
+		}
+	}
+	return removed
+}
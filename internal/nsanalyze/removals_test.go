@@ -0,0 +1,152 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyRemovals(t *testing.T) {
+	tests := []struct {
+		name   string
+		config []string
+		want   []string
+	}{
+		{
+			"removed server",
+			[]string{"add server web1 10.0.0.5", "add server web2 10.0.0.6", "rm server web1"},
+			[]string{"", "add server web2 10.0.0.6", "rm server web1"},
+		},
+		{
+			"names are case sensitive",
+			[]string{"add server web1 10.0.0.5", "add server Web1 10.0.0.6", "rm server Web1"},
+			[]string{"add server web1 10.0.0.5", "", "rm server Web1"},
+		},
+		{
+			"keywords are case insensitive",
+			[]string{"add lb vserver vs1 HTTP 10.0.0.100 80", "rm LB VServer vs1"},
+			[]string{"", "rm LB VServer vs1"},
+		},
+		{
+			"added again",
+			[]string{"add server web1 10.0.0.5", "rm server web1", "add server web1 10.0.0.7"},
+			[]string{"", "rm server web1", "add server web1 10.0.0.7"},
+		},
+		{
+			"unbind",
+			[]string{"bind lb vserver vs1 svc1", "bind lb vserver vs1 svc2", "unbind lb vserver vs1 svc1"},
+			[]string{"", "bind lb vserver vs1 svc2", "unbind lb vserver vs1 svc1"},
+		},
+		{
+			"unbind keeps the add",
+			[]string{"add vlan 10", "bind vlan 10 -ifnum 1/1", "unbind vlan 10 -ifnum 1/1"},
+			[]string{"add vlan 10", "", "unbind vlan 10 -ifnum 1/1"},
+		},
+		{
+			"rm ns ip takes its vlan binding",
+			[]string{"add ns ip 10.0.0.10 255.255.255.0", "bind vlan 10 -IPAddress 10.0.0.10 255.255.255.0",
+				"rm ns ip 10.0.0.10"},
+			[]string{"", "", "rm ns ip 10.0.0.10"},
+		},
+		{
+			"prefix of another name",
+			[]string{"add server web10 10.0.0.5", "rm server web1"},
+			[]string{"add server web10 10.0.0.5", "rm server web1"},
+		},
+		{
+			"later lines are kept",
+			[]string{"rm server web1", "add server web1 10.0.0.5"},
+			[]string{"rm server web1", "add server web1 10.0.0.5"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ApplyRemovals(strings.Join(test.config, "\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := strings.Join(test.want, "\n"); got != want {
+				t.Errorf("ApplyRemovals() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestApplyRemovalsLarge(t *testing.T) {
+	var lines []string
+	for i := 0; i < 40000; i++ {
+		lines = append(lines, fmt.Sprintf("add server web%d 10.%d.%d.%d", i, i>>16&255, i>>8&255, i&255))
+	}
+	for i := 0; i < 5000; i++ {
+		lines = append(lines, fmt.Sprintf("rm server web%d", i*8))
+	}
+	started := time.Now()
+	file, err := ApplyRemovals(strings.Join(lines, "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Split(file, "\n")
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("ApplyRemovals took %s", elapsed)
+	}
+	for i := 0; i < 40000; i++ {
+		if removed := got[i] == ""; removed != (i%8 == 0) {
+			t.Fatalf("line %d = %q, removed %v", i+1, got[i], removed)
+		}
+	}
+}
+
+func TestUnbindLinesAreNotBindings(t *testing.T) {
+	tests := []struct {
+		name   string
+		config []string
+		count  func(fileName string) (int, error)
+	}{
+		{
+			"service group member",
+			[]string{"add ns ip 10.0.0.10 255.255.255.0 -type SNIP", "add serviceGroup sg1 HTTP",
+				"bind serviceGroup sg1 192.168.9.9 80", "unbind serviceGroup sg1 192.168.9.9 80"},
+			func(fileName string) (int, error) {
+				members, err := GetServiceGroupMembers(fileName)
+				return len(members), err
+			},
+		},
+		{
+			"vlan binding",
+			[]string{"add vlan 10", "bind vlan 10 -ifnum 1/1", "unbind vlan 10 -ifnum 1/1"},
+			func(fileName string) (int, error) {
+				bindings, err := GetVlanBindings(fileName)
+				return len(bindings), err
+			},
+		},
+		{
+			"lb vserver binding",
+			[]string{"add lb vserver vs1 HTTP 10.0.0.100 80", "bind lb vserver vs1 svc1", "unbind lb vserver vs1 svc1"},
+			func(fileName string) (int, error) {
+				bindings, err := GetLbBindings(fileName)
+				return len(bindings), err
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			count, err := test.count(writeConfig(t, test.config...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != 0 {
+				t.Errorf("%d bindings left after the unbind, want none", count)
+			}
+		})
+	}
+	fileName := writeConfig(t, "add ns ip 10.0.0.10 255.255.255.0 -type SNIP", "add serviceGroup sg1 HTTP",
+		"bind serviceGroup sg1 192.168.9.9 80", "unbind serviceGroup sg1 192.168.9.9 80")
+	findings, err := GetFindings(fileName, AnalyzeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasFinding(findings, "NS001", "192.168.9.9") {
+		t.Errorf("findings = %v, want the unbound member left out", findingKeys(findings))
+	}
+}
@@ -0,0 +1,191 @@
+package nsanalyze
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Reporter is an interface for writing findings in an output format.
+type Reporter interface {
+	// Extension is the file name extension for output in the format.
+	Extension() string
+	// Report writes the findings to w, headed by the metadata of the run when there is any.
+	Report(w io.Writer, metadata *RunMetadata, findings []Finding) error
+}
+
+// JSONReporter is a data structure for writing findings as a JSON document.
+type JSONReporter struct{}
+
+// Extension is a function that returns the file name extension for JSON output.
+func (JSONReporter) Extension() string { return "json" }
+
+// Report is a function that writes findings as a JSON document.
+func (JSONReporter) Report(w io.Writer, metadata *RunMetadata, findings []Finding) error {
+	return WriteFindingsJSON(w, metadata, findings)
+}
+
+// SarifReporter is a data structure for writing findings as a SARIF 2.1.0 log.
+type SarifReporter struct{}
+
+// Extension is a function that returns the file name extension for SARIF output.
+func (SarifReporter) Extension() string { return "sarif" }
+
+// Report is a function that writes findings as a SARIF 2.1.0 log.
+func (SarifReporter) Report(w io.Writer, metadata *RunMetadata, findings []Finding) error {
+	return WriteSarif(w, metadata, findings)
+}
+
+// CSVReporter is a data structure for writing findings as comma separated values with a header row, preceded by
+// the metadata of the run as lines starting with #.
+type CSVReporter struct{}
+
+// Extension is a function that returns the file name extension for CSV output.
+func (CSVReporter) Extension() string { return "csv" }
+
+// Report is a function that writes findings as comma separated values with a header row, after a comment line
+// with the format version, the metadata comments of the run and a comment line with how many findings were left
+// out by a suppression or an exception.
+func (CSVReporter) Report(w io.Writer, metadata *RunMetadata, findings []Finding) error {
+	if _, err := fmt.Fprintf(w, "# format version %d\n", ReportFormatVersion); err != nil {
+		return err
+	}
+	if err := WriteMetadataComments(w, metadata); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "# %s: %d\n", Translate("suppressed findings"), len(SuppressedFindings(findings))); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"rule_id", "rule", "severity", "message", "object", "file", "line", "comment"})
+	for _, finding := range ReportedFindings(findings) {
+		writer.Write([]string{finding.rule.id, finding.rule.name, finding.rule.severity, finding.message,
+			finding.object, redactSourceName(finding.fileName), strconv.Itoa(finding.line), finding.comment})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// HTMLReporter is a data structure for writing findings as a standalone HTML page.
+type HTMLReporter struct{}
+
+// htmlReport is the template for the HTML page, which receives the metadata lines of the run and the findings
+// converted to findingJSON.
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{"t": Translate}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{t "Coverage findings"}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+.error { color: #b00; }
+.warning { color: #b60; }
+.metadata { color: #555; font-size: smaller; }
+</style>
+</head>
+<body>
+<h1>{{t "Coverage findings"}}</h1>
+{{with .Metadata}}<ul class="metadata">
+{{range .}}<li>{{.}}</li>
+{{end}}</ul>
+{{end}}<p>{{len .Findings}} {{t "finding(s)"}}</p>
+<table>
+<tr><th>{{t "Rule"}}</th><th>{{t "Severity"}}</th><th>{{t "Message"}}</th><th>{{t "Object"}}</th><th>{{t "Comment"}}</th><th>{{t "Location"}}</th></tr>
+{{range .Findings}}<tr class="{{.Severity}}"><td>{{.RuleID}} {{.Rule}}</td><td>{{.Severity}}</td><td>{{.Message}}</td><td>{{.Object}}</td><td>{{.Comment}}</td><td>{{.File}}:{{.Line}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// Extension is a function that returns the file name extension for HTML output.
+func (HTMLReporter) Extension() string { return "html" }
+
+// Report is a function that writes findings as a standalone HTML page.
+func (HTMLReporter) Report(w io.Writer, metadata *RunMetadata, findings []Finding) error {
+	var page struct {
+		Metadata []string
+		Findings []findingJSON
+	}
+	if metadata != nil {
+		page.Metadata = metadata.Lines()
+	}
+	for _, finding := range ReportedFindings(findings) {
+		page.Findings = append(page.Findings, findingJSON{
+			RuleID:   finding.rule.id,
+			Rule:     finding.rule.name,
+			Severity: finding.rule.severity,
+			Message:  finding.message,
+			Object:   finding.object,
+			Comment:  finding.comment,
+			File:     redactSourceName(finding.fileName),
+			Line:     finding.line,
+		})
+	}
+	return htmlReport.Execute(w, page)
+}
+
+// GetReporter is a function that returns the reporter for an output format.
+func GetReporter(format string) (Reporter, error) {
+	switch format {
+	case "json":
+		return JSONReporter{}, nil
+	case "ndjson":
+		return NDJSONReporter{}, nil
+	case "sarif":
+		return SarifReporter{}, nil
+	case "csv":
+		return CSVReporter{}, nil
+	case "html":
+		return HTMLReporter{}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// ParseFormats is a function that splits a comma separated list of output formats, dropping empty entries and
+// duplicates.
+func ParseFormats(formats string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, format := range strings.Split(formats, ",") {
+		format = strings.TrimSpace(format)
+		if format != "" && !seen[format] {
+			seen[format] = true
+			result = append(result, format)
+		}
+	}
+	return result
+}
+
+// WriteMetadataComments is a function that writes the metadata of a run as lines starting with #, for the formats
+// that have no header of their own. Nothing is written without metadata.
+func WriteMetadataComments(w io.Writer, metadata *RunMetadata) error {
+	if metadata == nil {
+		return nil
+	}
+	for _, line := range metadata.Lines() {
+		if _, err := fmt.Fprintf(w, "# %s\n", line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteReport is a function that creates or replaces a file and writes the findings to it using a reporter.
+func WriteReport(fileName string, reporter Reporter, metadata *RunMetadata, findings []Finding) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	RecordGeneratedFile(fileName)
+	if err := reporter.Report(file, metadata, findings); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,50 @@
+package nsanalyze
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// resolveTimeout is how long a single domain based server is given to resolve.
+const resolveTimeout = 5 * time.Second
+
+// NewResolver is a function that returns a DNS resolver. An empty address uses the system resolver, otherwise
+// every query is sent to the given DNS server.
+func NewResolver(address string) *net.Resolver {
+	if address == "" {
+		return net.DefaultResolver
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, address)
+		},
+	}
+}
+
+// ResolveServers is a function that resolves the domain name of every domain based server and uses the first
+// address returned as the server address. Servers that fail to resolve keep an empty address, and servers that
+// already have one, such as those resolved from the DNS records of the configuration, are left alone. Resolving
+// stops once the context is done.
+func ResolveServers(ctx context.Context, servers []Server, resolver *net.Resolver) {
+	for i, server := range servers {
+		if ctx.Err() != nil {
+			return
+		}
+		if !server.domainBased || server.domainName == "" || server.ipAddress != "" {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+		addresses, err := resolver.LookupHost(ctx, server.domainName)
+		cancel()
+		if err != nil || len(addresses) == 0 {
+			continue
+		}
+		servers[i].ipAddress = addresses[0]
+	}
+}
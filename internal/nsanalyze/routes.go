@@ -0,0 +1,169 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// Default route metrics, as the appliance leaves them out of the saved configuration.
+const (
+	defaultRouteDistance = 1
+	defaultRouteWeight   = 1
+	defaultRouteCost     = 0
+)
+
+// Route is a data structure for NetScaler static route data. Among the routes to the longest matching prefix the
+// one with the lowest administrative distance and then the lowest cost is used, and routes tied on both share the
+// traffic in proportion to their weights. A connected route stands for a SNIP subnet, which the NetScaler
+// reaches directly rather than through a gateway.
+type Route struct {
+	network   string
+	netmask   string
+	gateway   string
+	distance  int
+	weight    int
+	cost      int
+	line      int
+	connected bool
+}
+
+// connectedRoute is a function that returns the connected route of a SNIP subnet, which has an administrative
+// distance of zero, so that it wins over static routes to the same prefix.
+func connectedRoute(network *net.IPNet) Route {
+	return Route{network: network.IP.String(), netmask: net.IP(network.Mask).String(), weight: defaultRouteWeight,
+		connected: true}
+}
+
+// IsDefault is a function that reports whether the route is a default route.
+func (route Route) IsDefault() bool {
+	return route.network == "0.0.0.0" && route.netmask == "0.0.0.0"
+}
+
+// Network is a function that returns the network the route leads to, or nil when its network or netmask is not
+// a valid IPv4 address.
+func (route Route) Network() *net.IPNet {
+	network := &net.IPNet{IP: net.ParseIP(route.network).To4(), Mask: net.IPMask(net.ParseIP(route.netmask).To4())}
+	if network.IP == nil || network.Mask == nil {
+		return nil
+	}
+	return network
+}
+
+// GetRoutes is a function that accepts a file name as a parameter for input and then returns an array of static
+// routes.
+func GetRoutes(fileName string) ([]Route, error) {
+	return cachedModel(fileName, "routes", parseRoutes)
+}
+
+// parseRoutes is a function that parses the routes of a configuration for GetRoutes.
+func parseRoutes(fileName string) ([]Route, error) {
+	var routes []Route
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	addRouteLines, err := GetConfigLines(file, "(add route ).*")
+	if err != nil {
+		return nil, err
+	}
+	for _, addRouteLine := range addRouteLines {
+		fields := SplitConfigLine(RemoveConfigKeywords(addRouteLine.text, "add route "))
+		if len(fields) < 3 || net.ParseIP(fields[0]) == nil {
+			continue
+		}
+		routes = append(routes, Route{
+			network:  fields[0],
+			netmask:  fields[1],
+			gateway:  fields[2],
+			distance: routeMetric(fields, "-distance", defaultRouteDistance),
+			weight:   routeMetric(fields, "-weight", defaultRouteWeight),
+			cost:     routeMetric(fields, "-cost", defaultRouteCost),
+			line:     addRouteLine.number,
+		})
+	}
+	return routes, nil
+}
+
+// routeMetric is a function that returns the value of a numeric route option, or its default when the route
+// leaves it out or gives something other than a number.
+func routeMetric(fields []string, option string, defaultValue int) int {
+	value, err := strconv.Atoi(GetOption(fields, option))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// String is a function that returns the route the way reports show it, with its metrics.
+func (route Route) String() string {
+	if route.connected {
+		return fmt.Sprintf("%s %s %s", route.network, route.netmask, Translate("connected"))
+	}
+	return fmt.Sprintf("%s %s %s %s distance %d weight %d cost %d", route.network, route.netmask, Translate("via"),
+		route.gateway, route.distance, route.weight, route.cost)
+}
+
+// SelectRoutes is a function that returns the routes traffic to an address actually takes: those to the longest
+// prefix holding the address with the lowest distance and then the lowest cost. The connected networks, the SNIP
+// subnets, take part as connected routes, so that a server on one of them is reached directly rather than through
+// a static or default route to a shorter prefix. More than one route is returned when they are tied, in which case
+// they share the traffic by weight. No route is returned when none holds the address.
+func SelectRoutes(routes []Route, connected []*net.IPNet, ip net.IP) []Route {
+	var candidates []Route
+	for _, network := range connected {
+		if network.Contains(ip) {
+			candidates = append(candidates, connectedRoute(network))
+		}
+	}
+	var selected []Route
+	bestPrefix := -1
+	for _, route := range append(candidates, routes...) {
+		network := route.Network()
+		if network == nil || !network.Contains(ip) {
+			continue
+		}
+		prefix, _ := network.Mask.Size()
+		if len(selected) > 0 {
+			best := selected[0]
+			switch {
+			case prefix < bestPrefix, prefix == bestPrefix && route.distance > best.distance,
+				prefix == bestPrefix && route.distance == best.distance && route.cost > best.cost:
+				continue
+			case prefix == bestPrefix && route.distance == best.distance && route.cost == best.cost:
+				selected = append(selected, route)
+				continue
+			}
+		}
+		selected, bestPrefix = []Route{route}, prefix
+	}
+	return selected
+}
+
+// TrafficShare is a function that returns the percentage of traffic a route selected along with others takes,
+// based on the weights of the routes.
+func TrafficShare(route Route, selected []Route) float64 {
+	total := 0
+	for _, other := range selected {
+		total += other.weight
+	}
+	if total == 0 {
+		return 100 / float64(len(selected))
+	}
+	return 100 * float64(route.weight) / float64(total)
+}
+
+// DescribeRouteSelection is a function that returns whether a route is among those selected for an address and,
+// when several are, the share of the traffic it takes.
+func DescribeRouteSelection(route Route, selected []Route) string {
+	for _, winner := range selected {
+		if winner != route {
+			continue
+		}
+		if len(selected) == 1 {
+			return Translate("effective")
+		}
+		return fmt.Sprintf("%s, %.0f%% %s", Translate("effective"), TrafficShare(route, selected), Translate("of traffic"))
+	}
+	return Translate("not used")
+}
@@ -0,0 +1,41 @@
+package nsanalyze
+
+import (
+	"net"
+	"testing"
+)
+
+func TestSelectRoutes(t *testing.T) {
+	_, connected, _ := net.ParseCIDR("10.0.0.0/24")
+	defaultRoute := Route{network: "0.0.0.0", netmask: "0.0.0.0", gateway: "10.0.0.1", distance: 1, weight: 1, line: 1}
+	staticRoute := Route{network: "10.0.0.0", netmask: "255.255.255.0", gateway: "10.0.0.2", distance: 1, weight: 1, line: 2}
+	hostRoute := Route{network: "10.0.0.5", netmask: "255.255.255.255", gateway: "10.0.0.3", distance: 1, weight: 1, line: 3}
+	tests := []struct {
+		name      string
+		routes    []Route
+		connected []*net.IPNet
+		ip        string
+		want      string
+	}{
+		{"default route only", []Route{defaultRoute}, nil, "10.0.0.5", defaultRoute.String()},
+		{"connected beats default route", []Route{defaultRoute}, []*net.IPNet{connected}, "10.0.0.5",
+			"10.0.0.0 255.255.255.0 connected"},
+		{"connected beats static route to the same prefix", []Route{staticRoute}, []*net.IPNet{connected}, "10.0.0.5",
+			"10.0.0.0 255.255.255.0 connected"},
+		{"longer static route beats connected", []Route{hostRoute}, []*net.IPNet{connected}, "10.0.0.5", hostRoute.String()},
+		{"connected elsewhere", []Route{defaultRoute}, []*net.IPNet{connected}, "172.16.0.5", defaultRoute.String()},
+		{"no route", nil, []*net.IPNet{connected}, "172.16.0.5", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selected := SelectRoutes(test.routes, test.connected, net.ParseIP(test.ip))
+			got := ""
+			if len(selected) > 0 {
+				got = selected[0].String()
+			}
+			if len(selected) > 1 || got != test.want {
+				t.Errorf("SelectRoutes() = %v, want %q", selected, test.want)
+			}
+		})
+	}
+}
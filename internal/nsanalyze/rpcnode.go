@@ -0,0 +1,169 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// RpcNode is a data structure for an RPC node set through "set ns rpcNode", a peer that the NetScaler exchanges
+// GSLB metrics and HA synchronization with. The NetScaler sources RPC traffic from its NSIP unless the node sets
+// a source address of its own.
+type RpcNode struct {
+	ipAddress string
+	sourceIP  string
+	secure    bool
+	line      int
+}
+
+// GetRpcNodes is a function that accepts a file name as a parameter for input and then returns an array of the
+// RPC nodes. A later line for the same address updates the node, as it does on the NetScaler, and the line of the
+// node is that of the line that set its source address.
+func GetRpcNodes(fileName string) ([]RpcNode, error) {
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	rpcNodeLines, err := GetConfigLines(file, "(?i)((add|set) ns rpcNode ).*")
+	if err != nil {
+		return nil, err
+	}
+	var nodes []RpcNode
+	index := make(map[string]int)
+	for _, rpcNodeLine := range rpcNodeLines {
+		fields := SplitConfigLine(rpcNodeLine.text)
+		if len(fields) < 4 || net.ParseIP(fields[3]) == nil {
+			continue
+		}
+		i, ok := index[fields[3]]
+		if !ok {
+			i = len(nodes)
+			index[fields[3]] = i
+			nodes = append(nodes, RpcNode{ipAddress: fields[3], line: rpcNodeLine.number})
+		}
+		if sourceIP := GetOption(fields, "-srcIP"); sourceIP != "" {
+			nodes[i].sourceIP = sourceIP
+			nodes[i].line = rpcNodeLine.number
+		}
+		if secure := GetOption(fields, "-secure"); secure != "" {
+			nodes[i].secure = strings.EqualFold(secure, "YES")
+		}
+	}
+	return nodes, nil
+}
+
+// GetRpcNodeNetworks is a function that returns the networks RPC peers are reached through: the coverage
+// networks along with the network of the NSIP, which RPC traffic is sourced from by default. An NSIP whose
+// netmask is not valid adds no network.
+func GetRpcNodeNetworks(fileName string, networks []*net.IPNet) ([]*net.IPNet, error) {
+	nsip, found, err := GetNsip(fileName)
+	if err != nil || !found {
+		return networks, err
+	}
+	nsipNetworks, err := GetNetworks([]Snip{nsip})
+	if err != nil {
+		return networks, nil
+	}
+	return append(append([]*net.IPNet{}, networks...), nsipNetworks...), nil
+}
+
+// GetOwnedAddresses is a function that accepts a file name as a parameter for input and then returns the
+// addresses the NetScaler owns, which are its NSIP and every address added through "add ns ip".
+func GetOwnedAddresses(fileName string) (map[string]bool, error) {
+	owned := make(map[string]bool)
+	snips, err := GetSnips(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, snip := range snips {
+		owned[snip.ipAddress] = true
+	}
+	nsip, _, err := GetNsip(fileName)
+	if err != nil {
+		return nil, err
+	}
+	if nsip.ipAddress != "" {
+		owned[nsip.ipAddress] = true
+	}
+	return owned, nil
+}
+
+// UnreachableRpcNodes is a function that returns the RPC nodes of peers that the NetScaler reaches neither
+// directly, within one of the networks, nor through the route it selects for them, whose gateway must then be
+// within one of the networks, along with the nodes sourced from an address the NetScaler does not own, with why
+// each is unreachable. The nodes of the NetScaler's own addresses are left out.
+func UnreachableRpcNodes(nodes []RpcNode, networks []*net.IPNet, routes []Route, owned map[string]bool) ([]RpcNode, []string) {
+	var peers []Server
+	for _, node := range nodes {
+		peers = append(peers, Server{name: node.ipAddress, ipAddress: node.ipAddress})
+	}
+	covered := GetCoverage(networks, peers)
+	var unreachable []RpcNode
+	var reasons []string
+	for i, node := range nodes {
+		switch {
+		case owned[node.ipAddress]:
+		case node.sourceIP != "" && !owned[node.sourceIP]:
+			unreachable = append(unreachable, node)
+			reasons = append(reasons, fmt.Sprintf("is sourced from %s, which is not an address of the NetScaler", node.sourceIP))
+		case !covered[i] && !routedThrough(routes, node.ipAddress, networks):
+			unreachable = append(unreachable, node)
+			reasons = append(reasons, "is not covered by any SNIP network or reached through a route")
+		}
+	}
+	return unreachable, reasons
+}
+
+// routedThrough is a function that reports whether an address is reached through one of the routes selected for
+// it whose gateway is within one of the networks, as a remote GSLB site usually is.
+func routedThrough(routes []Route, ipAddress string, networks []*net.IPNet) bool {
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return false
+	}
+	for _, route := range SelectRoutes(routes, networks, ip) {
+		if route.connected {
+			return true
+		}
+		gateway := net.ParseIP(route.gateway)
+		for _, network := range networks {
+			if gateway != nil && network.Contains(gateway) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetRpcNodeFindings is a function that returns a finding for every RPC peer that the NetScaler would no longer
+// reach, which silently stops GSLB metric exchange and HA synchronization with it.
+func GetRpcNodeFindings(fileName string, networks []*net.IPNet) ([]Finding, error) {
+	nodes, err := GetRpcNodes(fileName)
+	if err != nil || len(nodes) == 0 {
+		return nil, err
+	}
+	rpcNetworks, err := GetRpcNodeNetworks(fileName, networks)
+	if err != nil {
+		return nil, err
+	}
+	owned, err := GetOwnedAddresses(fileName)
+	if err != nil {
+		return nil, err
+	}
+	routes, err := GetRoutes(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	unreachable, reasons := UnreachableRpcNodes(nodes, rpcNetworks, routes, owned)
+	for i, node := range unreachable {
+		findings = append(findings, Finding{
+			rule:     RuleUnreachableRpcNode,
+			message:  fmt.Sprintf("RPC node %s %s", node.ipAddress, reasons[i]),
+			object:   node.ipAddress,
+			fileName: fileName,
+			line:     node.line,
+		})
+	}
+	return findings, nil
+}
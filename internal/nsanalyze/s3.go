@@ -0,0 +1,204 @@
+package nsanalyze
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// emptyPayloadHash is the SHA-256 checksum of an empty request body, which is what a GET request signs.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Source is a data structure for a configuration stored as an object in Amazon S3 or an S3 compatible object
+// store. Requests are signed with the credentials of the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN environment variables, and sent unsigned when there are none, for public buckets. The region
+// comes from the region query parameter, AWS_REGION or AWS_DEFAULT_REGION, and AWS_ENDPOINT_URL points the
+// requests at another object store, addressing the bucket within the path.
+type S3Source struct {
+	bucket   string
+	key      string
+	region   string
+	endpoint string
+}
+
+// awsCredentials is a data structure for the credentials that S3 requests are signed with.
+type awsCredentials struct {
+	accessKey    string
+	secretKey    string
+	sessionToken string
+}
+
+// NewS3Source is a function that returns the source for an s3://bucket/key URL.
+func NewS3Source(location *url.URL) (S3Source, error) {
+	source := S3Source{
+		bucket:   location.Host,
+		key:      strings.TrimPrefix(location.Path, "/"),
+		region:   location.Query().Get("region"),
+		endpoint: strings.TrimSuffix(os.Getenv("AWS_ENDPOINT_URL"), "/"),
+	}
+	if source.bucket == "" || source.key == "" {
+		return source, fmt.Errorf("S3 URL %q must name a bucket and a key", location.Redacted())
+	}
+	for _, variable := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if source.region == "" {
+			source.region = os.Getenv(variable)
+		}
+	}
+	if source.region == "" {
+		source.region = "us-east-1"
+	}
+	return source, nil
+}
+
+// objectURL is a function that returns the URL the object is fetched from.
+func (source S3Source) objectURL() string {
+	path := "/" + escapeS3Key(source.key)
+	if source.endpoint != "" {
+		return source.endpoint + "/" + source.bucket + path
+	}
+	return "https://" + source.bucket + ".s3." + source.region + ".amazonaws.com" + path
+}
+
+// escapeS3Key is a function that escapes every segment of an object key the way S3 expects within a signed path.
+func escapeS3Key(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = strings.ReplaceAll(url.PathEscape(segment), "+", "%2B")
+	}
+	return strings.Join(segments, "/")
+}
+
+// Open is a function that downloads the object.
+func (source S3Source) Open(ctx context.Context) (io.ReadCloser, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, source.objectURL(), nil)
+	if err != nil {
+		return nil, err
+	}
+	credentials := awsCredentials{
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if credentials.accessKey != "" && credentials.secretKey != "" {
+		SignS3Request(request, credentials, source.region, time.Now())
+	}
+	client := &http.Client{Timeout: sourceTimeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, fmt.Errorf("%s: %s", source.Name(), response.Status)
+	}
+	return response.Body, nil
+}
+
+// Name is a function that returns the S3 URL of the object.
+func (source S3Source) Name() string {
+	return "s3://" + source.bucket + "/" + source.key
+}
+
+// SignS3Request is a function that signs a request without a body with AWS Signature Version 4, adding the
+// headers that S3 requires along with the Authorization header.
+func SignS3Request(request *http.Request, credentials awsCredentials, region string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	if credentials.sessionToken != "" {
+		request.Header.Set("X-Amz-Security-Token", credentials.sessionToken)
+	}
+	headers := map[string]string{"host": request.URL.Host}
+	for name, values := range request.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	var names []string
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+	path := request.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{request.Method, path, canonicalQuery(request.URL.Query()),
+		canonicalHeaders.String(), signedHeaders, emptyPayloadHash}, "\n")
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256(canonicalRequest)
+	key := []byte("AWS4" + credentials.secretKey)
+	for _, part := range []string{date, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	request.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		credentials.accessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery is a function that returns the query parameters of a request sorted and escaped the way AWS
+// Signature Version 4 expects.
+func canonicalQuery(query url.Values) string {
+	var pairs []string
+	for name, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, awsEscape(name)+"="+awsEscape(value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// awsEscape is a function that escapes a query parameter name or value, leaving only unreserved characters as
+// they are.
+func awsEscape(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+// hexSHA256 is a function that returns the SHA-256 checksum of a string as a hexadecimal string.
+func hexSHA256(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 is a function that returns the HMAC-SHA256 of a string with a key.
+func hmacSHA256(key []byte, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+// presignedParameters are the query parameters that carry the credentials of a pre-signed URL, which are left
+// out of the name of the source so that they do not end up in reports and file names.
+var presignedParameters = []string{"X-Amz-Credential", "X-Amz-Signature", "X-Amz-Security-Token", "Signature",
+	"AWSAccessKeyId", "sig", "se", "sv", "sp", "X-Goog-Signature", "X-Goog-Credential"}
+
+// redactPresigned is a function that drops the query of a URL when it is pre-signed, keeping it otherwise as the
+// query may select the configuration.
+func redactPresigned(rawURL string) string {
+	location, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := location.Query()
+	for _, parameter := range presignedParameters {
+		if query.Has(parameter) {
+			location.RawQuery = ""
+			return location.Redacted()
+		}
+	}
+	return location.Redacted()
+}
@@ -0,0 +1,243 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// maxUserPatternLength is the longest pattern accepted from a user rather than from the extractors.
+const maxUserPatternLength = 1024
+
+// maxUserPatternSize is the most instructions the compiled program of a user pattern may have. Go regular
+// expressions never backtrack, so matching a line takes time proportional to the length of the line times the
+// size of the program, and bounding the program bounds the cost of every line. Counted repetitions such as
+// (a{100}){100} are what make programs large.
+const maxUserPatternSize = 10000
+
+// userPatternCheckInterval is how many lines are matched between checks of whether a user pattern has run out of
+// time.
+const userPatternCheckInterval = 1024
+
+// ConfigPattern is a data structure for a compiled configuration line pattern along with a literal that every
+// match contains, so that lines without it can be skipped before the regular expression is run. A pattern with no
+// such literal has an empty one and is run against every line. A pattern that ends in ".*" matches at most once
+// per line, up to its end, and one that is nothing but its literal followed by ".*" needs no regular expression
+// at all. A pattern anchored to the start of the line only matches a line starting with its literal.
+type ConfigPattern struct {
+	regexer     *regexp.Regexp
+	literal     string
+	foldCase    bool
+	toLineEnd   bool
+	literalOnly bool
+	anchored    bool
+}
+
+// configPatterns holds every pattern compiled so far, as each extractor asks for the same few patterns again for
+// every configuration.
+var configPatterns sync.Map
+
+// GetConfigPattern is a function that returns the compiled form of a configuration line pattern, compiling it
+// the first time it is asked for. Every pattern of the extractors is anchored to the start of the line, as the
+// keywords of a configuration line come first: matched anywhere, "bind vlan " would also match the "unbind vlan"
+// line that undoes the binding, and "add server" the "rm server" line naming it in a comment.
+func GetConfigPattern(pattern string) (*ConfigPattern, error) {
+	if compiled, ok := configPatterns.Load(pattern); ok {
+		return compiled.(*ConfigPattern), nil
+	}
+	anchored := "^(?:" + pattern + ")"
+	regexer, err := regexp.Compile(anchored)
+	if err != nil {
+		return nil, err
+	}
+	compiled := &ConfigPattern{regexer: regexer}
+	if parsed, err := syntax.Parse(anchored, syntax.Perl); err == nil {
+		parsed = parsed.Simplify()
+		compiled.literal, compiled.foldCase = requiredLiteral(parsed)
+		compiled.toLineEnd, compiled.literalOnly, compiled.anchored = lineEndShape(parsed, compiled.literal)
+	}
+	configPatterns.Store(pattern, compiled)
+	return compiled, nil
+}
+
+// CompileUserPattern is a function that compiles a configuration line pattern given by a user, rejecting
+// patterns that are too long or compile to too large a program to be matched against a large configuration.
+// User patterns are not kept with those of the extractors.
+func CompileUserPattern(pattern string) (*ConfigPattern, error) {
+	if len(pattern) > maxUserPatternLength {
+		return nil, fmt.Errorf("pattern is %d characters long, the limit is %d", len(pattern), maxUserPatternLength)
+	}
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	parsed = parsed.Simplify()
+	program, err := syntax.Compile(parsed)
+	if err != nil {
+		return nil, err
+	}
+	if len(program.Inst) > maxUserPatternSize {
+		return nil, fmt.Errorf("pattern %q is too complex to match, simplify its repetitions", pattern)
+	}
+	regexer, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	compiled := &ConfigPattern{regexer: regexer}
+	compiled.literal, compiled.foldCase = requiredLiteral(parsed)
+	compiled.toLineEnd, compiled.literalOnly, compiled.anchored = lineEndShape(parsed, compiled.literal)
+	return compiled, nil
+}
+
+// GetConfigLinesWithin is a function that returns the matches of a compiled pattern within a NetScaler
+// configuration along with their line numbers, like GetConfigLines, but gives up with an error once matching has
+// taken longer than the timeout. A timeout of zero or less never gives up.
+func GetConfigLinesWithin(file string, pattern *ConfigPattern, timeout time.Duration) ([]ConfigLine, error) {
+	var results []ConfigLine
+	started := time.Now()
+	for lineNumber, start := 1, 0; start < len(file); lineNumber++ {
+		if timeout > 0 && lineNumber%userPatternCheckInterval == 0 && time.Since(started) > timeout {
+			return nil, fmt.Errorf("pattern %q did not finish matching within %s, stopped at line %d",
+				pattern.regexer.String(), timeout, lineNumber)
+		}
+		end := strings.IndexByte(file[start:], '\n')
+		if end < 0 {
+			end = len(file)
+		} else {
+			end += start
+		}
+		results = pattern.AppendMatches(results, file[start:end], lineNumber)
+		start = end + 1
+	}
+	return results, nil
+}
+
+// MayMatch is a function that reports whether a line may contain the literal of the pattern, so that the regular
+// expression has to be run against it. It does not allocate.
+func (pattern *ConfigPattern) MayMatch(line string) bool {
+	if pattern.literal == "" {
+		return true
+	}
+	if !pattern.foldCase {
+		return strings.Contains(line, pattern.literal)
+	}
+	for i := 0; i+len(pattern.literal) <= len(line); i++ {
+		if strings.EqualFold(line[i:i+len(pattern.literal)], pattern.literal) {
+			return true
+		}
+	}
+	// Some letters fold to characters outside of ASCII, such as k to the Kelvin sign, which a byte window of the
+	// length of the literal cannot line up with.
+	return !isASCII(line)
+}
+
+// AppendMatches is a function that appends the matches of the pattern within a line to the results. Only the
+// array of results is allocated, unless the regular expression has to be run.
+func (pattern *ConfigPattern) AppendMatches(results []ConfigLine, line string, number int) []ConfigLine {
+	if !pattern.MayMatch(line) {
+		return results
+	}
+	if pattern.literalOnly {
+		if start := pattern.indexLiteral(line); start == 0 || start > 0 && !pattern.anchored {
+			return append(results, ConfigLine{text: line[start:], number: number})
+		}
+		if isASCII(line) {
+			return results
+		}
+	}
+	if pattern.toLineEnd {
+		if match := pattern.regexer.FindStringIndex(line); match != nil {
+			results = append(results, ConfigLine{text: line[match[0]:match[1]], number: number})
+		}
+		return results
+	}
+	for _, match := range pattern.regexer.FindAllStringIndex(line, -1) {
+		results = append(results, ConfigLine{text: line[match[0]:match[1]], number: number})
+	}
+	return results
+}
+
+// indexLiteral is a function that returns where the literal of the pattern first occurs within an ASCII line, or
+// -1 when it does not occur or the line is not ASCII and so is left to the regular expression.
+func (pattern *ConfigPattern) indexLiteral(line string) int {
+	if !pattern.foldCase {
+		return strings.Index(line, pattern.literal)
+	}
+	if !isASCII(line) {
+		return -1
+	}
+	for i := 0; i+len(pattern.literal) <= len(line); i++ {
+		if strings.EqualFold(line[i:i+len(pattern.literal)], pattern.literal) {
+			return i
+		}
+	}
+	return -1
+}
+
+// lineEndShape is a function that reports whether a parsed regular expression ends in ".*", so that a match runs
+// to the end of the line, whether it is nothing but the literal followed by ".*", and whether it is anchored to
+// the start of the line.
+func lineEndShape(re *syntax.Regexp, literal string) (toLineEnd, literalOnly, anchored bool) {
+	if re.Op != syntax.OpConcat || len(re.Sub) == 0 {
+		return false, false, false
+	}
+	subs := re.Sub
+	for len(subs) > 0 && (subs[0].Op == syntax.OpBeginText || subs[0].Op == syntax.OpBeginLine) {
+		subs, anchored = subs[1:], true
+	}
+	if len(subs) == 0 {
+		return false, false, anchored
+	}
+	last := subs[len(subs)-1]
+	if last.Op != syntax.OpStar || last.Sub[0].Op != syntax.OpAnyCharNotNL {
+		return false, false, anchored
+	}
+	if len(subs) != 2 || literal == "" {
+		return true, false, anchored
+	}
+	first := subs[0]
+	for first.Op == syntax.OpCapture {
+		first = first.Sub[0]
+	}
+	return true, first.Op == syntax.OpLiteral && string(first.Rune) == literal, anchored
+}
+
+// requiredLiteral is a function that returns the longest literal that every match of a parsed regular expression
+// contains, and whether it is matched case insensitively. Only literals reached through concatenations and
+// captures count, which covers the "(add server ).*" style patterns the extractors use. Case insensitive literals
+// other than ASCII are not used.
+func requiredLiteral(re *syntax.Regexp) (string, bool) {
+	var best string
+	var bestFold bool
+	var collect func(re *syntax.Regexp)
+	collect = func(re *syntax.Regexp) {
+		switch re.Op {
+		case syntax.OpCapture, syntax.OpConcat:
+			for _, sub := range re.Sub {
+				collect(sub)
+			}
+		case syntax.OpLiteral:
+			text := string(re.Rune)
+			fold := re.Flags&syntax.FoldCase != 0
+			if (!fold || isASCII(text)) && len(text) > len(best) {
+				best, bestFold = text, fold
+			}
+		}
+	}
+	collect(re)
+	return best, bestFold
+}
+
+// isASCII is a function that reports whether a string holds ASCII characters only.
+func isASCII(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,191 @@
+package nsanalyze
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// ServiceNowClient is a data structure for the connection to a ServiceNow instance's Table API.
+type ServiceNowClient struct {
+	instance string
+	table    string
+	user     string
+	password string
+	client   *http.Client
+}
+
+// serviceNowRecord is the representation of a server within the ServiceNow table.
+type serviceNowRecord struct {
+	Name      string `json:"name"`
+	IPAddress string `json:"ip_address"`
+	FQDN      string `json:"fqdn,omitempty"`
+	Device    string `json:"u_netscaler"`
+	Status    string `json:"u_trunk_migration_status"`
+}
+
+// serviceNowResult is the subset of a Table API query response that identifies existing records.
+type serviceNowResult struct {
+	Result []struct {
+		SysID string `json:"sys_id"`
+	} `json:"result"`
+}
+
+// NewServiceNowClient is a function that returns a client for a table of a ServiceNow instance. The credentials
+// are read from the SERVICENOW_USER and SERVICENOW_PASSWORD environment variables, which may refer to Vault
+// secrets.
+func NewServiceNowClient(instance, table string) (*ServiceNowClient, error) {
+	user, err := GetCredential("SERVICENOW_USER")
+	if err != nil {
+		return nil, err
+	}
+	password, err := GetCredential("SERVICENOW_PASSWORD")
+	if err != nil {
+		return nil, err
+	}
+	if user == "" || password == "" {
+		return nil, errors.New("SERVICENOW_USER and SERVICENOW_PASSWORD must be set")
+	}
+	return &ServiceNowClient{
+		instance: strings.TrimSuffix(instance, "/"),
+		table:    table,
+		user:     user,
+		password: password,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// do is a function that sends a request to the Table API and decodes the JSON response into result.
+func (sn *ServiceNowClient) do(method, path string, body interface{}, result interface{}) error {
+	var payload io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(encoded)
+	}
+	request, err := http.NewRequestWithContext(runContext, method, sn.instance+path, payload)
+	if err != nil {
+		return err
+	}
+	request.SetBasicAuth(sn.user, sn.password)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Content-Type", "application/json")
+	response, err := sn.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("servicenow %s %s: %s", method, path, response.Status)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(response.Body).Decode(result)
+}
+
+// escapeServiceNowQuery is a function that escapes a value for an encoded query, in which a caret separates the
+// conditions and a literal one is written twice.
+func escapeServiceNowQuery(value string) string {
+	return strings.ReplaceAll(value, "^", "^^")
+}
+
+// Upsert is a function that updates the record for a server on a device, creating it when none exists yet. Records
+// are keyed by the server name and the device, as servers that are not resolved yet share an empty IP address.
+func (sn *ServiceNowClient) Upsert(record serviceNowRecord) error {
+	query := url.Values{}
+	query.Set("sysparm_query", "name="+escapeServiceNowQuery(record.Name)+"^u_netscaler="+escapeServiceNowQuery(record.Device))
+	query.Set("sysparm_fields", "sys_id")
+	query.Set("sysparm_limit", "1")
+	var existing serviceNowResult
+	if err := sn.do(http.MethodGet, "/api/now/table/"+sn.table+"?"+query.Encode(), nil, &existing); err != nil {
+		return err
+	}
+	if len(existing.Result) > 0 {
+		return sn.do(http.MethodPatch, "/api/now/table/"+sn.table+"/"+existing.Result[0].SysID, record, nil)
+	}
+	return sn.do(http.MethodPost, "/api/now/table/"+sn.table, record, nil)
+}
+
+// GetServiceNowRecords is a function that accepts a file name as a parameter for input and then returns the
+// ServiceNow record for every server along with its coverage status.
+func GetServiceNowRecords(fileName string, options AnalyzeOptions) ([]serviceNowRecord, error) {
+	networks, err := GetCoverageNetworks(fileName, options)
+	if err != nil {
+		return nil, err
+	}
+	servers, err := GetAnalysisServers(fileName, options)
+	if err != nil {
+		return nil, err
+	}
+	device, err := GetDeviceName(fileName)
+	if err != nil {
+		return nil, err
+	}
+	uncovered := make(map[string]bool)
+	for _, server := range GetUncoveredServers(networks, servers) {
+		uncovered[server.name] = true
+	}
+	var records []serviceNowRecord
+	for _, server := range includedServers(servers) {
+		status := "covered"
+		switch {
+		case server.ipAddress == "":
+			status = "unresolved"
+		case uncovered[server.name]:
+			status = "uncovered"
+		}
+		records = append(records, serviceNowRecord{
+			Name:      server.name,
+			IPAddress: server.ipAddress,
+			FQDN:      server.domainName,
+			Device:    device,
+			Status:    status,
+		})
+	}
+	return records, nil
+}
+
+// RunServiceNow is a function that runs the servicenow subcommand, pushing every server to the CMDB table, or
+// printing the records that would be pushed when running dry.
+func RunServiceNow(args []string, options AnalyzeOptions) error {
+	flags := flag.NewFlagSet("servicenow", flag.ContinueOnError)
+	instance := flags.String("instance", "", "ServiceNow instance URL, for example https://example.service-now.com")
+	table := flags.String("table", "cmdb_ci_server", "table that servers are written to")
+	dryRun := flags.Bool("dry-run", false, "print the records instead of sending them")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || (*instance == "" && !*dryRun) {
+		return errors.New("usage: servicenow -instance url [-table name] [-dry-run] filename")
+	}
+	records, err := GetServiceNowRecords(flags.Arg(0), options)
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	}
+	client, err := NewServiceNowClient(*instance, *table)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := client.Upsert(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
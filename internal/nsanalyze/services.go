@@ -0,0 +1,477 @@
+package nsanalyze
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Service is a data structure for NetScaler service data.
+type Service struct {
+	name       string
+	serverName string
+	protocol   string
+	port       string
+	comment    string
+	line       int
+}
+
+// ServiceGroup is a data structure for NetScaler service group data.
+type ServiceGroup struct {
+	name     string
+	protocol string
+	comment  string
+	line     int
+}
+
+// ServiceGroupMember is a data structure for the binding of a server to a NetScaler service group. The weight
+// is the share of the group's traffic the member gets, 1 unless the binding gives another.
+type ServiceGroupMember struct {
+	groupName  string
+	serverName string
+	port       string
+	weight     int
+	line       int
+}
+
+// LbVserver is a data structure for NetScaler load balancing virtual server data. The backup virtual server
+// takes the traffic over when the virtual server is down, and also the traffic beyond the spillover threshold when
+// a spillover method other than NONE is set. The redirection mode is how requests are forwarded to the servers,
+// IP unless -m gives MAC, IPTUNNEL or TOS for direct server return.
+type LbVserver struct {
+	name               string
+	protocol           string
+	ipAddress          string
+	port               string
+	redirectionMode    string
+	backupVserver      string
+	spilloverMethod    string
+	spilloverThreshold string
+	comment            string
+	line               int
+}
+
+// ForwardsByMac is a function that returns whether the virtual server forwards requests to its servers by
+// rewriting the destination MAC address only, for direct server return, which only reaches servers on a directly
+// connected subnet.
+func (vserver LbVserver) ForwardsByMac() bool {
+	return vserver.redirectionMode == "MAC"
+}
+
+// SpillsOver is a function that returns whether the virtual server sends the traffic beyond a threshold to its
+// backup virtual server while it is up.
+func (vserver LbVserver) SpillsOver() bool {
+	return vserver.spilloverMethod != "" && vserver.spilloverMethod != "NONE"
+}
+
+// LbBinding is a data structure for the binding of a service or service group to a load balancing virtual server.
+// The weight is the share of the virtual server's traffic a bound service gets, 1 unless the binding gives another.
+type LbBinding struct {
+	vserverName string
+	serviceName string
+	weight      int
+	line        int
+}
+
+// GetServices is a function that accepts a file name as a parameter for input and then returns an array of services.
+func GetServices(fileName string) ([]Service, error) {
+	return cachedModel(fileName, "services", parseServices)
+}
+
+// parseServices is a function that parses the services of a configuration for GetServices.
+func parseServices(fileName string) ([]Service, error) {
+	var services []Service
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	addServiceLines, err := GetConfigLines(file, "(add service ).*")
+	if err != nil {
+		return nil, err
+	}
+	services = make([]Service, 0, len(addServiceLines))
+	var fields []string
+	for _, addServiceLine := range addServiceLines {
+		fields = AppendConfigFields(fields[:0], RemoveConfigKeywords(addServiceLine.text, "add service "))
+		if len(fields) < 2 {
+			continue
+		}
+		var service Service
+		service.name = fields[0]
+		service.serverName = fields[1]
+		if len(fields) > 3 {
+			service.protocol = fields[2]
+			service.port = fields[3]
+		}
+		service.comment = GetOption(fields, "-comment")
+		service.line = addServiceLine.number
+		services = append(services, service)
+	}
+	index := make(map[string]int)
+	for i, service := range services {
+		index[service.name] = i
+	}
+	if err := applyComments(file, "service", index, func(i int) *string { return &services[i].comment }); err != nil {
+		return nil, err
+	}
+	return services, nil
+}
+
+// GetServiceGroups is a function that accepts a file name as a parameter for input and then returns an array of
+// service groups.
+func GetServiceGroups(fileName string) ([]ServiceGroup, error) {
+	var groups []ServiceGroup
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	addServiceGroupLines, err := GetConfigLines(file, "(?i)(add serviceGroup ).*")
+	if err != nil {
+		return nil, err
+	}
+	for _, addServiceGroupLine := range addServiceGroupLines {
+		fields := SplitConfigLine(addServiceGroupLine.text)[2:]
+		if len(fields) == 0 {
+			continue
+		}
+		var group ServiceGroup
+		group.name = fields[0]
+		if len(fields) > 1 {
+			group.protocol = fields[1]
+		}
+		group.comment = GetOption(fields, "-comment")
+		group.line = addServiceGroupLine.number
+		groups = append(groups, group)
+	}
+	index := make(map[string]int)
+	for i, group := range groups {
+		index[group.name] = i
+	}
+	if err := applyComments(file, "serviceGroup", index, func(i int) *string { return &groups[i].comment }); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// GetServiceGroupMembers is a function that accepts a file name as a parameter for input and then returns an
+// array of the servers bound to service groups.
+func GetServiceGroupMembers(fileName string) ([]ServiceGroupMember, error) {
+	return cachedModel(fileName, "service group members", parseServiceGroupMembers)
+}
+
+// parseServiceGroupMembers is a function that parses the service group members of a configuration for GetServiceGroupMembers.
+func parseServiceGroupMembers(fileName string) ([]ServiceGroupMember, error) {
+	var members []ServiceGroupMember
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	bindServiceGroupLines, err := GetConfigLines(file, "(?i)(bind serviceGroup ).*")
+	if err != nil {
+		return nil, err
+	}
+	members = make([]ServiceGroupMember, 0, len(bindServiceGroupLines))
+	var lineFields []string
+	for _, bindServiceGroupLine := range bindServiceGroupLines {
+		lineFields = AppendConfigFields(lineFields[:0], bindServiceGroupLine.text)
+		fields := lineFields[2:]
+		if len(fields) < 2 || strings.HasPrefix(fields[1], "-") {
+			continue
+		}
+		var member ServiceGroupMember
+		member.groupName = fields[0]
+		member.serverName = fields[1]
+		if len(fields) > 2 && !strings.HasPrefix(fields[2], "-") {
+			member.port = fields[2]
+		}
+		member.weight = GetWeight(fields)
+		member.line = bindServiceGroupLine.number
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// GetLbVservers is a function that accepts a file name as a parameter for input and then returns an array of
+// load balancing virtual servers.
+func GetLbVservers(fileName string) ([]LbVserver, error) {
+	return cachedModel(fileName, "load balancing virtual servers", parseLbVservers)
+}
+
+// parseLbVservers is a function that parses the load balancing virtual servers of a configuration for GetLbVservers.
+func parseLbVservers(fileName string) ([]LbVserver, error) {
+	var vservers []LbVserver
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	lbVserverLines, err := GetConfigLines(file, "((add|set|unset) lb vserver ).*")
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]int)
+	for _, lbVserverLine := range lbVserverLines {
+		if strings.HasPrefix(lbVserverLine.text, "unset ") {
+			fields := SplitConfigLine(RemoveConfigKeywords(lbVserverLine.text, "unset lb vserver "))
+			if len(fields) == 0 {
+				continue
+			}
+			if i, ok := index[fields[0]]; ok {
+				unsetLbVserverOptions(&vservers[i], fields[1:])
+			}
+			continue
+		}
+		if strings.HasPrefix(lbVserverLine.text, "set ") {
+			fields := SplitConfigLine(RemoveConfigKeywords(lbVserverLine.text, "set lb vserver "))
+			if len(fields) == 0 {
+				continue
+			}
+			if i, ok := index[fields[0]]; ok {
+				setLbVserverOptions(&vservers[i], fields)
+			}
+			continue
+		}
+		fields := SplitConfigLine(RemoveConfigKeywords(lbVserverLine.text, "add lb vserver "))
+		if len(fields) == 0 {
+			continue
+		}
+		vserver := LbVserver{name: fields[0], redirectionMode: "IP"}
+		if len(fields) > 1 {
+			vserver.protocol = fields[1]
+		}
+		if len(fields) > 3 && !strings.HasPrefix(fields[2], "-") {
+			vserver.ipAddress = fields[2]
+			vserver.port = fields[3]
+		}
+		setLbVserverOptions(&vserver, fields)
+		vserver.line = lbVserverLine.number
+		index[vserver.name] = len(vservers)
+		vservers = append(vservers, vserver)
+	}
+	return vservers, nil
+}
+
+// setLbVserverOptions is a function that sets the redirection mode, backup virtual server, spillover settings and
+// comment of a load balancing virtual server from the options of an add or set line, keeping the settings the line
+// does not give.
+func setLbVserverOptions(vserver *LbVserver, fields []string) {
+	if mode := GetOption(fields, "-m"); mode != "" {
+		vserver.redirectionMode = strings.ToUpper(mode)
+	}
+	if backupVserver := GetOption(fields, "-backupVServer"); backupVserver != "" {
+		vserver.backupVserver = backupVserver
+	}
+	if method := GetOption(fields, "-soMethod"); method != "" {
+		vserver.spilloverMethod = strings.ToUpper(method)
+	}
+	if threshold := GetOption(fields, "-soThreshold"); threshold != "" {
+		vserver.spilloverThreshold = threshold
+	}
+	if comment := GetOption(fields, "-comment"); comment != "" {
+		vserver.comment = comment
+	}
+}
+
+// unsetLbVserverOptions is a function that puts the options an unset line names back to their defaults, the ones
+// setLbVserverOptions reads: IP redirection mode, and no backup virtual server, spillover or comment.
+func unsetLbVserverOptions(vserver *LbVserver, options []string) {
+	for _, option := range options {
+		switch strings.ToLower(option) {
+		case "-m":
+			vserver.redirectionMode = "IP"
+		case "-backupvserver":
+			vserver.backupVserver = ""
+		case "-somethod":
+			vserver.spilloverMethod = ""
+		case "-sothreshold":
+			vserver.spilloverThreshold = ""
+		case "-comment":
+			vserver.comment = ""
+		}
+	}
+}
+
+// GetLbBindings is a function that accepts a file name as a parameter for input and then returns an array of the
+// services and service groups bound to load balancing virtual servers.
+func GetLbBindings(fileName string) ([]LbBinding, error) {
+	return cachedModel(fileName, "load balancing bindings", parseLbBindings)
+}
+
+// parseLbBindings is a function that parses the load balancing bindings of a configuration for GetLbBindings.
+func parseLbBindings(fileName string) ([]LbBinding, error) {
+	var bindings []LbBinding
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	bindLbVserverLines, err := GetConfigLines(file, "(bind lb vserver ).*")
+	if err != nil {
+		return nil, err
+	}
+	bindings = make([]LbBinding, 0, len(bindLbVserverLines))
+	var fields []string
+	for _, bindLbVserverLine := range bindLbVserverLines {
+		fields = AppendConfigFields(fields[:0], RemoveConfigKeywords(bindLbVserverLine.text, "bind lb vserver "))
+		if len(fields) < 2 || strings.HasPrefix(fields[1], "-") {
+			continue
+		}
+		bindings = append(bindings, LbBinding{
+			vserverName: fields[0],
+			serviceName: fields[1],
+			weight:      GetWeight(fields),
+			line:        bindLbVserverLine.number,
+		})
+	}
+	return bindings, nil
+}
+
+// GetWeight is a function that returns the -weight option of a binding, or 1, the weight NetScaler gives a
+// binding without one or with one it cannot parse.
+func GetWeight(fields []string) int {
+	weight, err := strconv.Atoi(GetOption(fields, "-weight"))
+	if err != nil || weight < 1 {
+		return 1
+	}
+	return weight
+}
+
+// ServiceTarget is a data structure for a server that a service or service group sends traffic to, along with
+// the protocol and port that traffic uses.
+type ServiceTarget struct {
+	kind       string
+	name       string
+	protocol   string
+	port       string
+	serverName string
+	line       int
+}
+
+// GetServiceTargets is a function that accepts a file name as a parameter for input and then returns the server
+// targeted by every service and every service group member.
+func GetServiceTargets(fileName string) ([]ServiceTarget, error) {
+	var targets []ServiceTarget
+	services, err := GetServices(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, service := range services {
+		targets = append(targets, ServiceTarget{
+			kind:       NodeService,
+			name:       service.name,
+			protocol:   service.protocol,
+			port:       service.port,
+			serverName: service.serverName,
+			line:       service.line,
+		})
+	}
+	groups, err := GetServiceGroups(fileName)
+	if err != nil {
+		return nil, err
+	}
+	protocols := make(map[string]string)
+	for _, group := range groups {
+		protocols[group.name] = group.protocol
+	}
+	members, err := GetServiceGroupMembers(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		targets = append(targets, ServiceTarget{
+			kind:       NodeServiceGroup,
+			name:       member.groupName,
+			protocol:   protocols[member.groupName],
+			port:       member.port,
+			serverName: member.serverName,
+			line:       member.line,
+		})
+	}
+	return targets, nil
+}
+
+// PrintServiceReport is a function that writes the services and service group members targeting uncovered
+// servers, broken down by protocol and port, followed by the targets whose server is never added and the servers
+// of MAC mode virtual servers that are not on a directly connected subnet.
+func PrintServiceReport(w io.Writer, fileName string, options AnalyzeOptions) error {
+	targets, err := GetServiceTargets(fileName)
+	if err != nil {
+		return err
+	}
+	networks, err := GetCoverageNetworks(fileName, options)
+	if err != nil {
+		return err
+	}
+	servers, err := GetAnalysisServers(fileName, options)
+	if err != nil {
+		return err
+	}
+	serversByName := make(map[string]Server)
+	for _, server := range servers {
+		serversByName[server.name] = server
+	}
+	uncovered := make(map[string]bool)
+	for _, server := range GetUncoveredServers(networks, servers) {
+		uncovered[server.name] = true
+	}
+	type protocolPort struct{ protocol, port string }
+	byPort := make(map[protocolPort][]ServiceTarget)
+	var keys []protocolPort
+	var missing []ServiceTarget
+	for _, target := range targets {
+		if _, ok := serversByName[target.serverName]; !ok {
+			missing = append(missing, target)
+			continue
+		}
+		if !uncovered[target.serverName] {
+			continue
+		}
+		key := protocolPort{target.protocol, target.port}
+		if byPort[key] == nil {
+			keys = append(keys, key)
+		}
+		byPort[key] = append(byPort[key], target)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].protocol != keys[j].protocol {
+			return keys[i].protocol < keys[j].protocol
+		}
+		a, _ := strconv.Atoi(keys[i].port)
+		b, _ := strconv.Atoi(keys[j].port)
+		return a < b
+	})
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s %s: %d %s\n", key.protocol, key.port, len(byPort[key]), Translate("target(s) on uncovered servers"))
+		for _, target := range byPort[key] {
+			fmt.Fprintf(w, "\t%s %s -> %s\n", target.kind, target.name, serversByName[target.serverName].Describe())
+		}
+	}
+	if len(missing) > 0 {
+		fmt.Fprintf(w, "%s: %d\n", Translate("targets of servers that are never added"), len(missing))
+		for _, target := range missing {
+			fmt.Fprintf(w, "\t%s %s -> %s (%s %d)\n", target.kind, target.name, target.serverName, Translate("line"),
+				target.line)
+		}
+	}
+	dsrServers, err := GetNonAdjacentDsrServers(fileName, servers, options)
+	if err != nil {
+		return err
+	}
+	if len(dsrServers) > 0 {
+		fmt.Fprintf(w, "%s: %d\n", Translate("MAC mode servers not on a directly connected subnet"), len(dsrServers))
+		for _, dsrServer := range dsrServers {
+			fmt.Fprintf(w, "\t%s %s -> %s\n", NodeLbVserver, strings.Join(dsrServer.vservers, ", "), dsrServer.server.Describe())
+		}
+	}
+	return nil
+}
+
+// RunServices is a function that runs the services subcommand.
+func RunServices(args []string, options AnalyzeOptions) error {
+	if len(args) != 1 {
+		return errors.New("usage: services filename")
+	}
+	return PrintServiceReport(os.Stdout, args[0], options)
+}
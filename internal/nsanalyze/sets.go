@@ -0,0 +1,173 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Kinds of address sets.
+const (
+	SetIP   = "ip set"
+	SetData = "data set"
+)
+
+// SetMember is a data structure for an address, subnet or address range bound to an IP set or a policy data
+// set. A single address is held as a network of one address.
+type SetMember struct {
+	kind     string
+	set      string
+	value    string
+	network  *net.IPNet
+	endRange net.IP
+	line     int
+}
+
+// GetSetMembers is a function that accepts a file name as a parameter for input and then returns the members of
+// every IP set and every IPv4 or IPv6 policy data set. Data sets of numbers, strings or MAC addresses are left
+// out.
+func GetSetMembers(fileName string) ([]SetMember, error) {
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var members []SetMember
+	bindIpsetLines, err := GetConfigLines(file, "(bind ipset ).*")
+	if err != nil {
+		return nil, err
+	}
+	for _, bindIpsetLine := range bindIpsetLines {
+		fields := SplitConfigLine(RemoveConfigKeywords(bindIpsetLine.text, "bind ipset "))
+		if len(fields) < 2 {
+			continue
+		}
+		value := GetOption(fields, "-IPAddress")
+		if value == "" {
+			value = fields[1]
+		}
+		if member, ok := newSetMember(SetIP, fields[0], value, bindIpsetLine.number); ok {
+			members = append(members, member)
+		}
+	}
+	addDatasetLines, err := GetConfigLines(file, "(add policy dataset ).*")
+	if err != nil {
+		return nil, err
+	}
+	addressSets := make(map[string]bool)
+	for _, addDatasetLine := range addDatasetLines {
+		fields := SplitConfigLine(RemoveConfigKeywords(addDatasetLine.text, "add policy dataset "))
+		if len(fields) >= 2 && (strings.EqualFold(fields[1], "ipv4") || strings.EqualFold(fields[1], "ipv6")) {
+			addressSets[fields[0]] = true
+		}
+	}
+	bindDatasetLines, err := GetConfigLines(file, "(bind policy dataset ).*")
+	if err != nil {
+		return nil, err
+	}
+	for _, bindDatasetLine := range bindDatasetLines {
+		fields := SplitConfigLine(RemoveConfigKeywords(bindDatasetLine.text, "bind policy dataset "))
+		if len(fields) < 2 || !addressSets[fields[0]] {
+			continue
+		}
+		member, ok := newSetMember(SetData, fields[0], fields[1], bindDatasetLine.number)
+		if !ok {
+			continue
+		}
+		if endRange := GetOption(fields, "-endRange"); endRange != "" {
+			member.value += "-" + endRange
+			member.endRange = net.ParseIP(endRange)
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// newSetMember is a function that returns the member for an address or CIDR subnet, reporting false for values
+// that are neither.
+func newSetMember(kind, set, value string, line int) (SetMember, bool) {
+	member := SetMember{kind: kind, set: set, value: value, line: line}
+	if strings.Contains(value, "/") {
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return member, false
+		}
+		member.network = network
+		return member, true
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return member, false
+	}
+	bits := 128
+	if ip.To4() != nil {
+		ip = ip.To4()
+		bits = 32
+	}
+	member.network = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+	return member, true
+}
+
+// Contains is a function that reports whether an address falls within the member.
+func (member SetMember) Contains(ip net.IP) bool {
+	if member.endRange == nil {
+		return member.network.Contains(ip)
+	}
+	return addressBetween(ip, member.network.IP, member.endRange)
+}
+
+// CoveredBy is a function that reports whether the whole member falls within a single one of the networks, which
+// for a subnet means a network at least as large and for a range one that holds both ends.
+func (member SetMember) CoveredBy(networks []*net.IPNet) bool {
+	for _, network := range networks {
+		if NetworkWithin(member.network, network) && (member.endRange == nil || network.Contains(member.endRange)) {
+			return true
+		}
+	}
+	return false
+}
+
+// NetworkWithin is a function that reports whether a network lies entirely within another network of the same
+// address family.
+func NetworkWithin(inner, outer *net.IPNet) bool {
+	ones, bits := inner.Mask.Size()
+	outerOnes, outerBits := outer.Mask.Size()
+	return outerBits == bits && outerOnes <= ones && outer.Contains(inner.IP)
+}
+
+// addressBetween is a function that reports whether an address lies between two addresses of the same family,
+// both included.
+func addressBetween(ip, start, end net.IP) bool {
+	if v4 := ip.To4(); v4 != nil && start.To4() != nil && end.To4() != nil {
+		ip, start, end = v4, start.To4(), end.To4()
+	} else {
+		ip, start, end = ip.To16(), start.To16(), end.To16()
+	}
+	if ip == nil || start == nil || end == nil {
+		return false
+	}
+	return string(ip) >= string(start) && string(ip) <= string(end)
+}
+
+// GetSetMemberFindings is a function that returns a finding for every IP set or data set member that does not
+// fall within any of the networks, so refers to addresses that will no longer be reachable.
+func GetSetMemberFindings(fileName string, networks []*net.IPNet) ([]Finding, error) {
+	members, err := GetSetMembers(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, member := range members {
+		if member.CoveredBy(networks) {
+			continue
+		}
+		findings = append(findings, Finding{
+			rule:     RuleUncoveredSetMember,
+			message:  fmt.Sprintf("%s %s member %s is not covered by any SNIP network", member.kind, member.set, member.value),
+			object:   member.set,
+			key:      member.value,
+			fileName: fileName,
+			line:     member.line,
+		})
+	}
+	return findings, nil
+}
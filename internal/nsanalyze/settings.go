@@ -0,0 +1,171 @@
+package nsanalyze
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultSettingsFile is the settings file read from the home directory when -config is not given.
+const defaultSettingsFile = ".nsanalyze.yaml"
+
+// Settings is a data structure for the settings shared by every run of a team or a scheduled job: default values
+// of command line options, the inventory of devices to analyze and the policy of what counts as covered.
+type Settings struct {
+	fileName string
+	defaults map[string]string
+	devices  []Device
+	policy   *Policy
+}
+
+// Device is a data structure for an appliance of the inventory. The source is a file name or URL as given on the
+// command line, and the password to log in with is taken from an environment variable, so that the settings file
+// can be shared without sharing credentials.
+type Device struct {
+	name        string
+	source      string
+	passwordEnv string
+}
+
+// settingsFile is the YAML representation of a settings file.
+type settingsFile struct {
+	Defaults map[string]string `yaml:"defaults"`
+	Devices  []struct {
+		Name        string `yaml:"name"`
+		Source      string `yaml:"source"`
+		PasswordEnv string `yaml:"passwordEnv"`
+	} `yaml:"devices"`
+	Policy *policyFile `yaml:"policy"`
+}
+
+// DefaultSettingsPath is a function that returns the path of the settings file in the home directory, which is
+// empty when there is no home directory.
+func DefaultSettingsPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, defaultSettingsFile)
+}
+
+// LoadSettings is a function that reads a settings file. The defaults are keyed by the name of the command line
+// option they set, such as format or lang. A missing file is not an error unless it was asked for.
+func LoadSettings(fileName string, required bool) (*Settings, error) {
+	settings := &Settings{fileName: fileName}
+	if fileName == "" {
+		return settings, nil
+	}
+	data, err := os.ReadFile(fileName)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file settingsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %v", fileName, err)
+	}
+	settings.defaults = file.Defaults
+	names := make(map[string]bool)
+	for _, device := range file.Devices {
+		if device.Name == "" || device.Source == "" {
+			return nil, fmt.Errorf("%s: every device needs a name and a source", fileName)
+		}
+		if names[device.Name] {
+			return nil, fmt.Errorf("%s: device %s is listed twice", fileName, device.Name)
+		}
+		names[device.Name] = true
+		settings.devices = append(settings.devices, Device{device.Name, device.Source, device.PasswordEnv})
+	}
+	if file.Policy != nil {
+		if settings.policy, err = newPolicy(fileName, *file.Policy); err != nil {
+			return nil, err
+		}
+	}
+	return settings, nil
+}
+
+// ApplyDefaults is a function that sets every option of a flag set that the settings give a default for and that
+// the command line leaves out, so that options given on the command line always win.
+func (settings *Settings) ApplyDefaults(flags *flag.FlagSet) error {
+	given := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	var names []string
+	for name := range settings.defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if flags.Lookup(name) == nil || name == "config" {
+			return fmt.Errorf("%s: unknown option %q in defaults", settings.fileName, name)
+		}
+		if given[name] {
+			continue
+		}
+		if err := flags.Set(name, settings.defaults[name]); err != nil {
+			return fmt.Errorf("%s: option %s: %v", settings.fileName, name, err)
+		}
+	}
+	return nil
+}
+
+// Source is a function that returns the source to read the configuration of a device from. The password is read
+// from its environment variable, or the Vault secret the variable refers to, only once the source is opened, so
+// that it is never part of the source, which names the configuration in every report and error.
+func (device Device) Source() (string, error) {
+	if device.passwordEnv == "" {
+		return device.source, nil
+	}
+	if os.Getenv(device.passwordEnv) == "" {
+		return "", fmt.Errorf("device %s: %s is not set", device.name, device.passwordEnv)
+	}
+	location, err := url.Parse(device.source)
+	if err != nil || location.User == nil {
+		return "", fmt.Errorf("device %s: a password can only be added to a source URL with a user", device.name)
+	}
+	setSourcePasswordVariable(device.source, device.passwordEnv)
+	return device.source, nil
+}
+
+// ResolveDevices is a function that replaces the names of devices of the inventory among the configurations to
+// analyze with their sources. Names that are also files are left as they are, and no configurations at all
+// stands for every device of the inventory.
+func (settings *Settings) ResolveDevices(fileNames []string) ([]string, error) {
+	devices := make(map[string]Device)
+	for _, device := range settings.devices {
+		devices[device.name] = device
+	}
+	if len(fileNames) == 0 {
+		for _, device := range settings.devices {
+			fileNames = append(fileNames, device.name)
+		}
+	}
+	var resolved []string
+	for _, fileName := range fileNames {
+		device, ok := devices[fileName]
+		if _, err := os.Stat(fileName); !ok || err == nil {
+			resolved = append(resolved, fileName)
+			continue
+		}
+		source, err := device.Source()
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, source)
+	}
+	return resolved, nil
+}
+
+// HasDevices is a function that reports whether the settings list any devices.
+func (settings *Settings) HasDevices() bool {
+	return len(settings.devices) > 0
+}
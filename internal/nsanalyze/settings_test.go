@@ -0,0 +1,65 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInventoryDeviceFindingRedacted(t *testing.T) {
+	t.Setenv("LB01_PASSWORD", "hunter2")
+	device := Device{name: "lb01", source: "https://nsroot@lb01.example.com", passwordEnv: "LB01_PASSWORD"}
+	source, err := device.Source()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(source, "hunter2") {
+		t.Fatalf("source %q holds the password of the device", source)
+	}
+	tests := []error{
+		fmt.Errorf("%s: 401 Unauthorized", source),
+		fmt.Errorf("Get %q: connection refused", source),
+	}
+	for _, fetchErr := range tests {
+		finding := newUnreadableConfigFinding(source, fetchErr)
+		for field, value := range map[string]string{"id": finding.ID(), "object": finding.object, "message": finding.message} {
+			if strings.Contains(value, "hunter2") {
+				t.Errorf("%s leaks the password of the device: %s", field, value)
+			}
+			if !strings.Contains(value, "lb01.example.com") {
+				t.Errorf("%s = %q, want the device named", field, value)
+			}
+		}
+	}
+}
+
+func TestInventoryDevicePasswordOnOpen(t *testing.T) {
+	t.Setenv("LB02_PASSWORD", "hunter2")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "nsroot" || password != "hunter2" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintln(w, "add server web1 10.0.0.5")
+	}))
+	defer server.Close()
+	device := Device{name: "lb02", source: strings.Replace(server.URL, "://", "://nsroot@", 1) + "/ns.conf",
+		passwordEnv: "LB02_PASSWORD"}
+	source, err := device.Source()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ForgetFile(source)
+	if strings.Contains(source, "hunter2") {
+		t.Fatalf("source %q holds the password of the device", source)
+	}
+	servers, err := GetServers(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(servers) != 1 || servers[0].name != "web1" {
+		t.Errorf("servers = %v, want web1 read with the password of the device", servers)
+	}
+}
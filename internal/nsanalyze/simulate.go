@@ -0,0 +1,248 @@
+package nsanalyze
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+// Simulation is a data structure for the coverage of a configuration before and after a proposed patch: the
+// summaries of both, the SNIPs and routes the patch adds or removes and the servers whose coverage changes.
+type Simulation struct {
+	before         RunSummary
+	after          RunSummary
+	addedSnips     []Snip
+	removedSnips   []Snip
+	addedRoutes    []Route
+	removedRoutes  []Route
+	newlyUncovered []Server
+	newlyCovered   []Server
+	routed         map[string][]Server
+}
+
+// ApplyPatch is a function that applies a file of proposed commands to a configuration without touching the
+// appliance. Commands starting with "rm" or "unbind" take out the lines RemoveLines finds for them, and every
+// other command is appended.
+func ApplyPatch(config, patch string) (string, error) {
+	lines := strings.Split(config, "\n")
+	for number, patchLine := range strings.Split(NormalizeConfig(patch), "\n") {
+		text := strings.TrimSpace(patchLine)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := SplitConfigLine(text)
+		if fields[0] != "rm" && fields[0] != "unbind" {
+			lines = append(lines, text)
+			continue
+		}
+		if len(fields) < 3 {
+			return "", fmt.Errorf("patch line %d: %q names nothing to remove", number+1, text)
+		}
+		if RemoveLines(lines, fields) == 0 {
+			return "", fmt.Errorf("patch line %d: nothing to remove for %q", number+1, text)
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// hasFieldPrefix is a function that reports whether the fields of a line start with the given fields, comparing
+// the keywords and options case insensitively as the CLI does and the object names as they are.
+func hasFieldPrefix(fields, prefix []string) bool {
+	if len(fields) < len(prefix) {
+		return false
+	}
+	return removalKey(fields, len(prefix)) == removalKey(prefix, len(prefix))
+}
+
+// removesIpBinding is a function that reports whether an "rm ns ip" command takes out a VLAN binding line, as
+// removing an IP from the NetScaler also removes its bindings.
+func removesIpBinding(command, lineFields []string) bool {
+	if len(command) < 4 || !strings.EqualFold(command[1], "ns") || !strings.EqualFold(command[2], "ip") {
+		return false
+	}
+	return len(lineFields) > 2 && strings.EqualFold(lineFields[1], "vlan") && GetOption(lineFields, "-IPAddress") == command[3]
+}
+
+// Simulate is a function that applies a patch to a configuration and returns its coverage before and after. The
+// patched configuration is only ever held in memory.
+func Simulate(fileName, patchFile string, options AnalyzeOptions) (Simulation, error) {
+	var simulation Simulation
+	patch, err := os.ReadFile(patchFile)
+	if err != nil {
+		return simulation, err
+	}
+	config, err := GetFile(fileName)
+	if err != nil {
+		return simulation, err
+	}
+	patched, err := ApplyPatch(config, string(patch))
+	if err != nil {
+		return simulation, fmt.Errorf("%s: %v", patchFile, err)
+	}
+	if options.platform, err = GetPlatform(fileName, options); err != nil {
+		return simulation, err
+	}
+	afterName := fileName + " patched with " + patchFile
+	if err := CacheFile(afterName, patched); err != nil {
+		return simulation, err
+	}
+	defer ForgetFile(afterName)
+	if simulation.before, err = SummarizeRun(fileName, options); err != nil {
+		return simulation, err
+	}
+	if simulation.after, err = SummarizeRun(afterName, options); err != nil {
+		return simulation, err
+	}
+	beforeSnips, err := GetSourceSnips(fileName, options)
+	if err != nil {
+		return simulation, err
+	}
+	afterSnips, err := GetSourceSnips(afterName, options)
+	if err != nil {
+		return simulation, err
+	}
+	simulation.addedSnips = snipDifference(afterSnips, beforeSnips)
+	simulation.removedSnips = snipDifference(beforeSnips, afterSnips)
+	beforeRoutes, err := GetRoutes(fileName)
+	if err != nil {
+		return simulation, err
+	}
+	afterRoutes, err := GetRoutes(afterName)
+	if err != nil {
+		return simulation, err
+	}
+	simulation.addedRoutes = routeDifference(afterRoutes, beforeRoutes)
+	simulation.removedRoutes = routeDifference(beforeRoutes, afterRoutes)
+	servers, err := GetAnalysisServers(afterName, options)
+	if err != nil {
+		return simulation, err
+	}
+	uncoveredBefore := make(map[string]bool)
+	for _, name := range simulation.before.uncovered {
+		uncoveredBefore[name] = true
+	}
+	uncoveredAfter := make(map[string]bool)
+	for _, name := range simulation.after.uncovered {
+		uncoveredAfter[name] = true
+	}
+	simulation.routed = make(map[string][]Server)
+	for _, server := range servers {
+		switch {
+		case uncoveredAfter[server.name] && !uncoveredBefore[server.name]:
+			simulation.newlyUncovered = append(simulation.newlyUncovered, server)
+		case uncoveredBefore[server.name] && !uncoveredAfter[server.name] && server.ipAddress != "":
+			simulation.newlyCovered = append(simulation.newlyCovered, server)
+		}
+		if !uncoveredAfter[server.name] {
+			continue
+		}
+		for _, route := range simulation.addedRoutes {
+			if network := route.Network(); network != nil && network.Contains(net.ParseIP(server.ipAddress)) {
+				key := route.network + " " + route.netmask
+				simulation.routed[key] = append(simulation.routed[key], server)
+			}
+		}
+	}
+	return simulation, nil
+}
+
+// snipDifference is a function that returns the SNIPs of the first array whose address and mask are not among
+// those of the second.
+func snipDifference(snips, others []Snip) []Snip {
+	present := make(map[string]bool)
+	for _, other := range others {
+		present[other.ipAddress+" "+other.subnetMask] = true
+	}
+	var difference []Snip
+	for _, snip := range snips {
+		if !present[snip.ipAddress+" "+snip.subnetMask] {
+			difference = append(difference, snip)
+		}
+	}
+	return difference
+}
+
+// routeDifference is a function that returns the routes of the first array that are not among the second.
+func routeDifference(routes, others []Route) []Route {
+	present := make(map[string]bool)
+	for _, other := range others {
+		present[other.network+" "+other.netmask+" "+other.gateway] = true
+	}
+	var difference []Route
+	for _, route := range routes {
+		if !present[route.network+" "+route.netmask+" "+route.gateway] {
+			difference = append(difference, route)
+		}
+	}
+	return difference
+}
+
+// PrintSimulation is a function that writes the coverage before and after a patch along with the changes that
+// explain the difference, worded so that it can be pasted into a change ticket.
+func PrintSimulation(w io.Writer, simulation Simulation) {
+	for _, state := range []struct {
+		label   string
+		summary RunSummary
+	}{{"before", simulation.before}, {"after", simulation.after}} {
+		fmt.Fprintf(w, "%s: %s %.1f%%, "+Translate("%d of %d servers uncovered")+"\n", Translate(state.label),
+			Translate("coverage"), state.summary.Coverage(), len(state.summary.uncovered), state.summary.servers)
+	}
+	printSnips := func(label string, snips []Snip) {
+		fmt.Fprintf(w, "%s:\n", Translate(label))
+		if len(snips) == 0 {
+			fmt.Fprintf(w, "\t%s\n", Translate("none"))
+		}
+		for _, snip := range snips {
+			fmt.Fprintf(w, "\t%s %s %s\n", snip.ipType, snip.ipAddress, snip.subnetMask)
+		}
+	}
+	printSnips("SNIPs added", simulation.addedSnips)
+	printSnips("SNIPs removed", simulation.removedSnips)
+	printRoutes := func(label string, routes []Route) {
+		fmt.Fprintf(w, "%s:\n", Translate(label))
+		if len(routes) == 0 {
+			fmt.Fprintf(w, "\t%s\n", Translate("none"))
+		}
+		for _, route := range routes {
+			fmt.Fprintf(w, "\t%s %s %s %s\n", route.network, route.netmask, Translate("via"), route.gateway)
+			for _, server := range simulation.routed[route.network+" "+route.netmask] {
+				fmt.Fprintf(w, "\t\t%s %s\n", Translate("reaches uncovered"), server.Describe())
+			}
+		}
+	}
+	printRoutes("routes added", simulation.addedRoutes)
+	printRoutes("routes removed", simulation.removedRoutes)
+	printServers := func(label string, servers []Server) {
+		fmt.Fprintf(w, "%s:\n", Translate(label))
+		if len(servers) == 0 {
+			fmt.Fprintf(w, "\t%s\n", Translate("none"))
+		}
+		for _, server := range servers {
+			fmt.Fprintf(w, "\t%s\n", server.Describe())
+		}
+	}
+	printServers("newly uncovered", simulation.newlyUncovered)
+	printServers("newly covered", simulation.newlyCovered)
+}
+
+// RunSimulate is a function that runs the simulate subcommand.
+func RunSimulate(args []string, options AnalyzeOptions) error {
+	flags := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	patch := flags.String("patch", "", "file of proposed commands, such as a remediation script")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || *patch == "" {
+		return errors.New("usage: simulate -patch file filename")
+	}
+	simulation, err := Simulate(flags.Arg(0), *patch, options)
+	if err != nil {
+		return err
+	}
+	PrintSimulation(os.Stdout, simulation)
+	return nil
+}
@@ -0,0 +1,111 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// stripedOwnerNode is the owner node the appliance writes for IPs striped across every cluster node.
+const stripedOwnerNode = "255"
+
+// maxClusterNode is the highest ID a cluster node can have.
+const maxClusterNode = 31
+
+// ValidOwnerNode is a function that reports whether an owner node annotation of an ns ip names a cluster node ID
+// or the striped owner node.
+func ValidOwnerNode(ownerNode string) bool {
+	if ownerNode == stripedOwnerNode {
+		return true
+	}
+	node, err := strconv.Atoi(ownerNode)
+	return err == nil && node >= 0 && node <= maxClusterNode
+}
+
+// ValidVserverState is a function that reports whether the -vServer option of an "add ns ip" or "set ns ip" line
+// is a state the appliance accepts.
+func ValidVserverState(state string) bool {
+	return state == "ENABLED" || state == "DISABLED"
+}
+
+// clusterOwner is a function that returns the owner node of an ns ip, which is empty for IPs striped across every
+// cluster node, as those are owned by all of them.
+func clusterOwner(ownerNode string) string {
+	if ownerNode == stripedOwnerNode {
+		return ""
+	}
+	return ownerNode
+}
+
+// applySnipAnnotations is a function that applies the "bind ns ip" annotations that 13.x firmware writes after the
+// ns ip objects, binding an IP to the virtual servers that listen on it or to the cluster node that owns it, and
+// saying whether the IP answers while its owner node is down, which does not affect the model.
+// Annotations of IPs that are not added, with an owner node that is not valid, or that bind nothing are left out,
+// with a warning each.
+func applySnipAnnotations(file string, snips []Snip, index map[string]int) ([]Warning, error) {
+	bindNsIpLines, err := GetConfigLines(file, "(bind ns ip ).*")
+	if err != nil {
+		return nil, err
+	}
+	var warnings []Warning
+	for _, bindNsIpLine := range bindNsIpLines {
+		fields := SplitConfigLine(RemoveConfigKeywords(bindNsIpLine.text, "bind ns ip "))
+		if len(fields) == 0 {
+			continue
+		}
+		ownerNode := GetOption(fields, "-ownerNode")
+		vserver := GetOption(fields, "-vServer")
+		i, ok := index[fields[0]]
+		switch {
+		case !ok:
+			warnings = append(warnings, skippedAnnotationWarning(bindNsIpLine, fields[0],
+				fmt.Sprintf("binds ns ip %s, which is not added", fields[0]), "it"))
+			continue
+		case ownerNode != "" && !ValidOwnerNode(ownerNode):
+			warnings = append(warnings, skippedAnnotationWarning(bindNsIpLine, fields[0],
+				fmt.Sprintf("binds ns ip %s to owner node %s, which is not a cluster node", fields[0], ownerNode), "it"))
+			continue
+		case ownerNode == "" && vserver == "" && GetOption(fields, "-ownerDownResponse") == "":
+			warnings = append(warnings, skippedAnnotationWarning(bindNsIpLine, fields[0],
+				fmt.Sprintf("binds nothing to ns ip %s", fields[0]), "it"))
+			continue
+		}
+		if ownerNode != "" {
+			snips[i].ownerNode = clusterOwner(ownerNode)
+		}
+		if vserver != "" {
+			snips[i].vservers = append(snips[i].vservers, vserver)
+		}
+	}
+	return warnings, nil
+}
+
+// skippedAnnotationWarning is a function that returns the warning for an ns ip annotation on a line that the SNIP
+// model leaves out, saying what the line does wrong and what is skipped. The warning names no configuration, as
+// the SNIP model is shared by every configuration with the same contents; getSnipModel names it.
+func skippedAnnotationWarning(line ConfigLine, address, reason, skipped string) Warning {
+	return Warning{kind: WarningSkippedLine, object: address, line: line.number,
+		message: fmt.Sprintf("line %d %s, so %s is skipped", line.number, reason, skipped)}
+}
+
+// invalidOwnerNodeWarning is a function that returns the warning for an "add ns ip" or "set ns ip" line giving an
+// owner node that is not a cluster node ID.
+func invalidOwnerNodeWarning(line ConfigLine, address, ownerNode string) Warning {
+	return skippedAnnotationWarning(line, address,
+		fmt.Sprintf("gives ns ip %s owner node %s, which is not a cluster node", address, ownerNode), "the owner node")
+}
+
+// invalidVserverStateWarning is a function that returns the warning for an "add ns ip" or "set ns ip" line giving
+// a -vServer state other than ENABLED and DISABLED.
+func invalidVserverStateWarning(line ConfigLine, address, vserver string) Warning {
+	return skippedAnnotationWarning(line, address,
+		fmt.Sprintf("gives ns ip %s -vServer %s, which is neither ENABLED nor DISABLED", address, vserver), "the option")
+}
+
+// GetSnipAnnotationWarnings is a function that returns a warning for every ns ip annotation that is left out of
+// the SNIP model: owner nodes that are not a cluster node ID, -vServer states other than ENABLED and DISABLED, and
+// "bind ns ip" lines that name an IP that is not added or that bind nothing. The warnings are those the SNIP parser
+// gave while building the model, so the lines are not parsed again.
+func GetSnipAnnotationWarnings(fileName string) ([]Warning, error) {
+	model, err := getSnipModel(fileName)
+	return model.warnings, err
+}
@@ -0,0 +1,381 @@
+package nsanalyze
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// ConfigSource is an interface for the places a NetScaler configuration can be read from. Remote sources give up
+// once the context is done.
+type ConfigSource interface {
+	Open(ctx context.Context) (io.ReadCloser, error)
+	Name() string
+}
+
+// FileSource is a data structure for a configuration stored in a local file.
+type FileSource struct {
+	path string
+}
+
+// StdinSource is a data structure for a configuration piped in through standard input.
+type StdinSource struct{}
+
+// HTTPSource is a data structure for a configuration downloaded from an HTTP or HTTPS URL.
+type HTTPSource struct {
+	url         string
+	passwordEnv string
+}
+
+// SSHSource is a data structure for the running configuration of an appliance read over SSH.
+type SSHSource struct {
+	address     string
+	user        string
+	password    string
+	passwordEnv string
+}
+
+// SCPSource is a data structure for a configuration file copied from an appliance over SCP.
+type SCPSource struct {
+	address     string
+	user        string
+	password    string
+	passwordEnv string
+	path        string
+}
+
+// NitroSource is a data structure for the running configuration of an appliance read through the Nitro API.
+// With a page size the configuration is rebuilt from paginated bulk fetches of the object types instead, which
+// works on appliances too large to render their running configuration in one response.
+type NitroSource struct {
+	baseURL     string
+	user        string
+	password    string
+	passwordEnv string
+	pageSize    int
+}
+
+// sourceTimeout is how long a remote source is given to connect and respond.
+const sourceTimeout = 60 * time.Second
+
+// NewConfigSource is a function that returns the source for a configuration name given on the command line.
+// The name "-" reads standard input, URLs with an http, https, s3, ssh, scp or nitro scheme read from the
+// network and anything else is treated as a local file. Appliances whose URL leaves out the user or password are
+// logged in to with those of NSANALYZE_USER and NSANALYZE_PASSWORD, read when the source is opened.
+func NewConfigSource(name string) (ConfigSource, error) {
+	if name == "-" {
+		return StdinSource{}, nil
+	}
+	if !strings.Contains(name, "://") {
+		return FileSource{path: name}, nil
+	}
+	location, err := url.Parse(name)
+	if err != nil {
+		return nil, err
+	}
+	user := location.User.Username()
+	password, _ := location.User.Password()
+	passwordEnv := sourcePasswordVariable(name)
+	switch location.Scheme {
+	case "http", "https":
+		return HTTPSource{url: name, passwordEnv: passwordEnv}, nil
+	case "s3":
+		return NewS3Source(location)
+	case "ssh":
+		return SSHSource{address: hostWithPort(location.Host, "22"), user: user, password: password,
+			passwordEnv: passwordEnv}, nil
+	case "scp":
+		path := location.Path
+		if path == "" || path == "/" {
+			path = "/nsconfig/ns.conf"
+		}
+		return SCPSource{address: hostWithPort(location.Host, "22"), user: user, password: password,
+			passwordEnv: passwordEnv, path: path}, nil
+	case "nitro":
+		source := NitroSource{baseURL: "https://" + location.Host, user: user, password: password,
+			passwordEnv: passwordEnv}
+		if pageSize := location.Query().Get("pagesize"); pageSize != "" {
+			source.pageSize, err = strconv.Atoi(pageSize)
+			if err != nil || source.pageSize < 1 {
+				return nil, fmt.Errorf("invalid Nitro page size %q", pageSize)
+			}
+		}
+		return source, nil
+	}
+	return nil, fmt.Errorf("unsupported configuration source %q", location.Scheme)
+}
+
+// hostWithPort is a function that adds a default port to a host that does not specify one.
+func hostWithPort(host, port string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// Open is a function that opens the local file.
+func (source FileSource) Open(ctx context.Context) (io.ReadCloser, error) {
+	return os.Open(source.path)
+}
+
+// Name is a function that returns the path of the local file.
+func (source FileSource) Name() string {
+	return source.path
+}
+
+// Open is a function that returns standard input. Closing it leaves standard input open.
+func (source StdinSource) Open(ctx context.Context) (io.ReadCloser, error) {
+	return io.NopCloser(os.Stdin), nil
+}
+
+// Name is a function that returns the name used for standard input.
+func (source StdinSource) Name() string {
+	return "stdin"
+}
+
+// Open is a function that downloads the configuration. The source of a device of the inventory logs in with the
+// user of its URL and the password of the device.
+func (source HTTPSource) Open(ctx context.Context) (io.ReadCloser, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, source.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if source.passwordEnv != "" {
+		user, password, err := resolveCredentials(request.URL.User.Username(), "", source.passwordEnv)
+		if err != nil {
+			return nil, err
+		}
+		request.SetBasicAuth(user, password)
+	}
+	client := &http.Client{Timeout: sourceTimeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, fmt.Errorf("%s: %s", source.Name(), response.Status)
+	}
+	return response.Body, nil
+}
+
+// Name is a function that returns the URL of the configuration, without the credentials of a pre-signed URL.
+func (source HTTPSource) Name() string {
+	return redactPresigned(source.url)
+}
+
+// Open is a function that runs "show ns runningConfig" on the appliance and returns its output.
+func (source SSHSource) Open(ctx context.Context) (io.ReadCloser, error) {
+	user, password, err := resolveCredentials(source.user, source.password, source.passwordEnv)
+	if err != nil {
+		return nil, err
+	}
+	client, err := dialSSH(ctx, source.address, user, password)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+	output, err := session.Output("show ns runningConfig")
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(output)), nil
+}
+
+// Name is a function that returns the address of the appliance.
+func (source SSHSource) Name() string {
+	return "ssh://" + source.address
+}
+
+// Open is a function that copies the configuration file from the appliance using the sink side of the SCP
+// protocol: every message from the remote side is acknowledged with a zero byte.
+func (source SCPSource) Open(ctx context.Context) (io.ReadCloser, error) {
+	user, password, err := resolveCredentials(source.user, source.password, source.passwordEnv)
+	if err != nil {
+		return nil, err
+	}
+	client, err := dialSSH(ctx, source.address, user, password)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := session.Start("scp -f " + source.path); err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(stdout)
+	if _, err := stdin.Write([]byte{0}); err != nil {
+		return nil, err
+	}
+	header, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(header, "C") {
+		return nil, fmt.Errorf("scp %s: %s", source.path, strings.TrimSpace(strings.TrimLeft(header, "\x01\x02")))
+	}
+	headerFields := strings.Fields(header)
+	if len(headerFields) < 3 {
+		return nil, fmt.Errorf("scp %s: unexpected header %q", source.path, header)
+	}
+	size, err := strconv.ParseInt(headerFields[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stdin.Write([]byte{0}); err != nil {
+		return nil, err
+	}
+	contents := make([]byte, size)
+	if _, err := io.ReadFull(reader, contents); err != nil {
+		return nil, err
+	}
+	if status, err := reader.ReadByte(); err != nil || status != 0 {
+		return nil, fmt.Errorf("scp %s: transfer did not complete", source.path)
+	}
+	stdin.Write([]byte{0})
+	stdin.Close()
+	session.Wait()
+	return io.NopCloser(bytes.NewReader(contents)), nil
+}
+
+// Name is a function that returns the address of the appliance and path of the configuration file.
+func (source SCPSource) Name() string {
+	return "scp://" + source.address + source.path
+}
+
+// dialSSH is a function that connects to an appliance over SSH. A password from the source URL or the environment
+// is tried along with any unencrypted private keys in ~/.ssh, and the host key must be present in ~/.ssh/known_hosts. The
+// connection is closed once the context is done, which ends any session on it.
+func dialSSH(ctx context.Context, address, user, password string) (*ssh.Client, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	hostKeyCallback, err := knownhosts.New(filepath.Join(home, ".ssh", "known_hosts"))
+	if err != nil {
+		return nil, err
+	}
+	var methods []ssh.AuthMethod
+	if password != "" {
+		methods = append(methods, ssh.Password(password))
+	}
+	var signers []ssh.Signer
+	for _, keyName := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+		key, err := os.ReadFile(filepath.Join(home, ".ssh", keyName))
+		if err != nil {
+			continue
+		}
+		if signer, err := ssh.ParsePrivateKey(key); err == nil {
+			signers = append(signers, signer)
+		}
+	}
+	if len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+	if len(methods) == 0 {
+		return nil, errors.New("no SSH password or private key available for " + address)
+	}
+	dialer := net.Dialer{Timeout: sourceTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	clientConn, channels, requests, err := ssh.NewClientConn(conn, address, &ssh.ClientConfig{
+		User:            user,
+		Auth:            methods,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         sourceTimeout,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	client := ssh.NewClient(clientConn, channels, requests)
+	context.AfterFunc(ctx, func() { client.Close() })
+	return client, nil
+}
+
+// nitroResponse is the subset of a Nitro nsrunningconfig response that holds the configuration.
+type nitroResponse struct {
+	RunningConfig struct {
+		Response string `json:"response"`
+	} `json:"nsrunningconfig"`
+}
+
+// Open is a function that fetches the running configuration from the Nitro API.
+func (source NitroSource) Open(ctx context.Context) (io.ReadCloser, error) {
+	var err error
+	if source.user, source.password, err = resolveCredentials(source.user, source.password, source.passwordEnv); err != nil {
+		return nil, err
+	}
+	client := &http.Client{
+		Timeout:   sourceTimeout,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{MinVersion: tls.VersionTLS12}},
+	}
+	if source.pageSize > 0 {
+		config, err := source.bulkConfig(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(strings.NewReader(config)), nil
+	}
+	var body nitroResponse
+	if err := source.nitroGet(ctx, client, "/nitro/v1/config/nsrunningconfig", &body); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader(body.RunningConfig.Response)), nil
+}
+
+// Name is a function that returns the address of the appliance.
+func (source NitroSource) Name() string {
+	return "nitro://" + strings.TrimPrefix(source.baseURL, "https://")
+}
+
+// unsafeFileCharacters matches the characters of a source name that cannot be used within a file name.
+var unsafeFileCharacters = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// OutputBaseName is a function that returns the name that output files for a configuration are named after.
+// Local files keep their path, while other sources are turned into a file name in the working directory.
+func OutputBaseName(fileName string) string {
+	source, err := NewConfigSource(fileName)
+	if err != nil {
+		return unsafeFileCharacters.ReplaceAllString(fileName, "_")
+	}
+	if fileSource, ok := source.(FileSource); ok {
+		return fileSource.path
+	}
+	return strings.Trim(unsafeFileCharacters.ReplaceAllString(source.Name(), "_"), "_")
+}
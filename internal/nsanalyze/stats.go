@@ -0,0 +1,117 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// linesScanned counts the lines every scan of a configuration has gone through, so that -stats can tell how much
+// work parsing took, since most objects are found by a scan of their own.
+var linesScanned atomic.Int64
+
+// statsObjectTypes are the types of object that -stats counts, named the way the CLI names them, along with the
+// functions that parse them.
+var statsObjectTypes = []struct {
+	kind  string
+	count func(fileName string) (int, error)
+}{
+	{"server", countOf(GetServers)},
+	{"service", countOf(GetServices)},
+	{"serviceGroup", countOf(GetServiceGroups)},
+	{"serviceGroup member", countOf(GetServiceGroupMembers)},
+	{"lb vserver", countOf(GetLbVservers)},
+	{"lb vserver binding", countOf(GetLbBindings)},
+	{"cs vserver", countOf(GetCsVservers)},
+	{"ns ip", countOf(GetSnips)},
+	{"vlan", countOf(GetVlans)},
+	{"interface", countOf(GetInterfaces)},
+	{"channel", countOf(GetChannels)},
+	{"route", countOf(GetRoutes)},
+	{"dns addRec", countOf(GetDnsRecords)},
+}
+
+// countOf is a function that turns a function parsing objects of a type into one counting them.
+func countOf[T any](get func(fileName string) ([]T, error)) func(fileName string) (int, error) {
+	return func(fileName string) (int, error) {
+		objects, err := get(fileName)
+		return len(objects), err
+	}
+}
+
+// RunStats is a data structure for the statistics of a run: how many configurations it analyzed and how many
+// lines and objects of each type they hold, and how long parsing and analyzing them took. Parsing is timed up
+// to the point every object type is parsed, and analysis from there on.
+type RunStats struct {
+	sync.Mutex
+	configurations int
+	lines          int
+	objects        map[string]int
+	parse          time.Duration
+	analysis       time.Duration
+}
+
+// NewRunStats is a function that returns the statistics of a run that has not analyzed anything yet.
+func NewRunStats() *RunStats {
+	return &RunStats{objects: make(map[string]int)}
+}
+
+// ParseObjects is a function that reads a configuration and parses every object type counted, adding the
+// configuration and its objects to the statistics. The parsed objects are cached, so the analysis that follows
+// does not parse them again.
+func (stats *RunStats) ParseObjects(fileName string) error {
+	file, err := GetFile(fileName)
+	if err != nil {
+		return err
+	}
+	counts := make(map[string]int)
+	for _, objectType := range statsObjectTypes {
+		if counts[objectType.kind], err = objectType.count(fileName); err != nil {
+			return err
+		}
+	}
+	stats.Lock()
+	defer stats.Unlock()
+	stats.configurations++
+	stats.lines += strings.Count(file, "\n")
+	if file != "" && !strings.HasSuffix(file, "\n") {
+		stats.lines++
+	}
+	for kind, count := range counts {
+		stats.objects[kind] += count
+	}
+	return nil
+}
+
+// AddDurations is a function that adds the time a configuration took to parse and to analyze.
+func (stats *RunStats) AddDurations(parse, analysis time.Duration) {
+	stats.Lock()
+	defer stats.Unlock()
+	stats.parse += parse
+	stats.analysis += analysis
+}
+
+// PrintStats is a function that writes the statistics of a run, along with the lines scanned and the peak memory
+// of the process, which is the memory the Go runtime has obtained from the operating system, as the runtime keeps
+// it once obtained.
+func PrintStats(w io.Writer, stats *RunStats) {
+	stats.Lock()
+	defer stats.Unlock()
+	var memory runtime.MemStats
+	runtime.ReadMemStats(&memory)
+	fmt.Fprintf(w, "%s:\n", Translate("statistics"))
+	fmt.Fprintf(w, "\t%s: %d\n", Translate("configurations"), stats.configurations)
+	fmt.Fprintf(w, "\t%s: %d\n", Translate("lines"), stats.lines)
+	fmt.Fprintf(w, "\t%s: %d\n", Translate("lines scanned"), linesScanned.Load())
+	fmt.Fprintf(w, "\t%s:\n", Translate("objects"))
+	for _, objectType := range statsObjectTypes {
+		fmt.Fprintf(w, "\t\t%s: %d\n", objectType.kind, stats.objects[objectType.kind])
+	}
+	fmt.Fprintf(w, "\t%s: %s\n", Translate("parse time"), stats.parse.Round(time.Microsecond))
+	fmt.Fprintf(w, "\t%s: %s\n", Translate("analysis time"), stats.analysis.Round(time.Microsecond))
+	fmt.Fprintf(w, "\t%s: %.1f MiB\n", Translate("peak memory"), float64(memory.Sys)/(1<<20))
+}
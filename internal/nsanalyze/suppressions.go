@@ -0,0 +1,125 @@
+package nsanalyze
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// defaultSuppressionFile is the suppression file read from the working directory when no other is given.
+const defaultSuppressionFile = ".nsanalyze-ignore"
+
+// Suppression is a data structure for an accepted exception: findings of a rule about an object, or about any
+// object when the object is *, that are left out of every report. The JSON and CSV reports account for them.
+type Suppression struct {
+	rule   Rule
+	object string
+	line   int
+}
+
+// Suppressions is a data structure for the accepted exceptions read from a suppression file.
+type Suppressions struct {
+	fileName     string
+	suppressions []Suppression
+}
+
+// LoadSuppressions is a function that reads a suppression file. Each line holds a rule, by ID or by name,
+// followed by the object to suppress its findings for, which runs to the end of the line and may contain spaces.
+// Lines starting with # are comments, as is anything after a # preceded by a space, which is the place to note why
+// the exception is accepted. A missing file is not an error unless it was asked for.
+func LoadSuppressions(fileName string, required bool) (*Suppressions, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && !required {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+	result := &Suppressions{fileName: fileName}
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		text := scanner.Text()
+		if index := strings.Index(text, " #"); index >= 0 {
+			text = text[:index]
+		}
+		text = strings.TrimSpace(text)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("%s:%d: expected a rule and an object", fileName, number)
+		}
+		rule, ok := GetRule(fields[0])
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: unknown rule %q", fileName, number, fields[0])
+		}
+		object := strings.TrimSpace(strings.TrimPrefix(text, fields[0]))
+		result.suppressions = append(result.suppressions, Suppression{rule: rule, object: object, line: number})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetRule is a function that returns the rule with the given ID or name.
+func GetRule(idOrName string) (Rule, bool) {
+	for _, rule := range GetRules() {
+		if strings.EqualFold(rule.id, idOrName) || rule.name == idOrName {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Suppresses is a function that reports whether a finding is an accepted exception. Having no suppressions
+// suppresses nothing.
+func (suppressions *Suppressions) Suppresses(finding Finding) bool {
+	_, ok := suppressions.Match(finding)
+	return ok
+}
+
+// Match is a function that returns the suppression a finding falls under, if any.
+func (suppressions *Suppressions) Match(finding Finding) (Suppression, bool) {
+	if suppressions == nil {
+		return Suppression{}, false
+	}
+	for _, suppression := range suppressions.suppressions {
+		if suppression.rule.id == finding.rule.id && (suppression.object == "*" || suppression.object == finding.object) {
+			return suppression, true
+		}
+	}
+	return Suppression{}, false
+}
+
+// Describe is a function that returns where a suppression comes from, so that a report can show why a finding was
+// left out.
+func (suppressions *Suppressions) Describe(suppression Suppression) string {
+	return fmt.Sprintf("%s:%d %s %s", suppressions.fileName, suppression.line, suppression.rule.id, suppression.object)
+}
+
+// MarkFindings is a function that marks the findings that are accepted exceptions with the suppression they fall
+// under, unless they are left out already.
+func (suppressions *Suppressions) MarkFindings(findings []Finding) {
+	for i, finding := range findings {
+		if suppression, ok := suppressions.Match(finding); ok && finding.suppressedBy == "" {
+			findings[i].suppressedBy = suppressions.Describe(suppression)
+		}
+	}
+}
+
+// FilterFindings is a function that returns the findings that are not accepted exceptions.
+func (suppressions *Suppressions) FilterFindings(findings []Finding) []Finding {
+	var filtered []Finding
+	for _, finding := range findings {
+		if !suppressions.Suppresses(finding) {
+			filtered = append(filtered, finding)
+		}
+	}
+	return filtered
+}
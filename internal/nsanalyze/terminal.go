@@ -0,0 +1,145 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+)
+
+// The ANSI escape sequences the terminal report is colored with. Every color is the same length, so that the
+// columns of colored rows stay aligned with each other and with the bold heading.
+const (
+	ansiBold   = "\x1b[01m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiReset  = "\x1b[0m"
+)
+
+// ParseColorMode is a function that returns whether terminal output is colored for a -color value: always,
+// never, or auto, which colors only when the output is a terminal and the NO_COLOR environment variable is not
+// set.
+func ParseColorMode(mode string, output *os.File) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+			return false, nil
+		}
+		return IsTerminal(output), nil
+	}
+	return false, fmt.Errorf("unsupported color mode %q, use one of auto, always, never", mode)
+}
+
+// IsTerminal is a function that reports whether a file is a terminal rather than a pipe or a regular file.
+func IsTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorize is a function that wraps text in a color when coloring is turned on.
+func colorize(text, color string, colored bool) string {
+	if !colored {
+		return text
+	}
+	return color + text + ansiReset
+}
+
+// serverStatus is a data structure for a row of the terminal report.
+type serverStatus struct {
+	server Server
+	status string
+	color  string
+	order  int
+}
+
+// PrintTerminalReport is a function that writes every server of a configuration with whether it is covered,
+// aligned in columns along with how many objects depend on it: uncovered servers first in red, those with the
+// largest blast radius first, then the servers that are uncovered but suppressed or that have no address to check
+// in yellow, and the covered servers in green. A summary of the coverage closes the report.
+func PrintTerminalReport(w io.Writer, fileName string, networks []*net.IPNet, servers []Server, radii map[string][]Node,
+	options AnalyzeOptions, colored bool) {
+	covered := GetCoverage(networks, servers)
+	var rows []serverStatus
+	uncovered := 0
+	for i, server := range servers {
+		if server.excluded {
+			continue
+		}
+		row := serverStatus{server: server, status: "covered", color: ansiGreen, order: 3}
+		switch {
+		case server.ipAddress == "":
+			row = serverStatus{server: server, status: "unresolved", color: ansiYellow, order: 2}
+		case covered[i]:
+		case options.SuppressedBy(server) != "":
+			row = serverStatus{server: server, status: "suppressed", color: ansiYellow, order: 1}
+		default:
+			row = serverStatus{server: server, status: "uncovered", color: ansiRed, order: 0}
+			uncovered++
+		}
+		rows = append(rows, row)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].order != rows[j].order {
+			return rows[i].order < rows[j].order
+		}
+		if rows[i].order != 0 {
+			return false
+		}
+		a, b := len(radii[rows[i].server.name]), len(radii[rows[j].server.name])
+		return a > b || (a == b && rows[i].server.weight > rows[j].server.weight)
+	})
+	fmt.Fprintf(w, "%s\n", fileName)
+	table := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	heading := Translate("STATUS") + "\t" + Translate("NAME") + "\t" + Translate("ADDRESS") + "\t" + Translate("DEPENDENTS")
+	if options.owners != nil {
+		heading += "\t" + Translate("OWNER")
+	}
+	commented := false
+	for _, server := range servers {
+		commented = commented || server.comment != ""
+	}
+	if commented {
+		heading += "\t" + Translate("COMMENT")
+	}
+	fmt.Fprintf(table, "%s\t\n", colorize(heading, ansiBold, colored))
+	for _, row := range rows {
+		line := Translate(row.status) + "\t" + row.server.name + "\t" + row.server.ipAddress + "\t" +
+			strconv.Itoa(len(radii[row.server.name]))
+		if options.owners != nil {
+			line += "\t" + ownerOrUnowned(options.owners, row.server)
+		}
+		if commented {
+			line += "\t" + row.server.comment
+		}
+		fmt.Fprintf(table, "%s\t\n", colorize(line, row.color, colored))
+	}
+	table.Flush()
+	heading = ""
+	for _, row := range rows {
+		if row.status != "suppressed" {
+			continue
+		}
+		if heading == "" {
+			heading = Translate("suppressed")
+			fmt.Fprintf(w, "%s:\n", colorize(heading, ansiBold, colored))
+		}
+		fmt.Fprintf(w, "\t%s %s: %s\n", row.server.name, row.server.ipAddress, options.SuppressedBy(row.server))
+	}
+	coverage, color := 100.0, ansiGreen
+	if len(servers) > 0 {
+		coverage = float64(len(servers)-uncovered) * 100 / float64(len(servers))
+	}
+	if uncovered > 0 {
+		color = ansiRed
+	}
+	fmt.Fprintln(w, colorize(fmt.Sprintf("%s %.1f%%, "+Translate("%d of %d servers uncovered"), Translate("coverage"),
+		coverage, uncovered, len(servers)), color, colored))
+}
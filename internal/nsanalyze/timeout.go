@@ -0,0 +1,72 @@
+package nsanalyze
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// contextCheckInterval is how many lines are scanned between checks of whether the run has run out of time.
+const contextCheckInterval = 4096
+
+// ErrTimedOut is the error a run fails with once it takes longer than -timeout.
+var ErrTimedOut = errors.New("timed out")
+
+// runContext is the context every fetch, lookup and scan of the run is bound by, carrying the deadline of
+// -timeout. It is set once by main before any configuration is read and only read afterwards.
+var runContext = context.Background()
+
+// runTimeout is the timeout the run context was given, so that the error can say what ran out.
+var runTimeout time.Duration
+
+// SetRunTimeout is a function that bounds the rest of the run by a timeout and returns the function releasing
+// it. A timeout of zero or less never gives up.
+func SetRunTimeout(timeout time.Duration) context.CancelFunc {
+	if timeout <= 0 {
+		return func() {}
+	}
+	var cancel context.CancelFunc
+	runContext, cancel = context.WithTimeout(context.Background(), timeout)
+	runTimeout = timeout
+	return cancel
+}
+
+// CheckRunContext is a function that returns an error wrapping ErrTimedOut once the run is past its timeout,
+// so that long scans and fetches stop with an error saying so rather than one about a cancelled context.
+func CheckRunContext() error {
+	err := runContext.Err()
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %s, raise -timeout to allow longer", ErrTimedOut, runTimeout)
+	}
+	return err
+}
+
+// analysisContext is a function that returns the context an analysis is bound by: the one given through
+// WithContext, or the context of the run otherwise.
+func (options AnalyzeOptions) analysisContext() context.Context {
+	if options.ctx != nil {
+		return options.ctx
+	}
+	return runContext
+}
+
+// checkContext is a function that returns an error once the context given through WithContext is done or the
+// run is past its timeout, so that an analysis stops at the next check.
+func (options AnalyzeOptions) checkContext() error {
+	if options.ctx != nil {
+		if err := options.ctx.Err(); err != nil {
+			return fmt.Errorf("analysis stopped: %w", err)
+		}
+	}
+	return CheckRunContext()
+}
+
+// runContextError is a function that returns the error of reading a source, replaced by the timeout error when
+// reading failed because the run ran out of time.
+func runContextError(name string, err error) error {
+	if timeoutErr := CheckRunContext(); timeoutErr != nil {
+		return fmt.Errorf("%s: %w", name, timeoutErr)
+	}
+	return err
+}
@@ -0,0 +1,26 @@
+package nsanalyze
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestAnalysisStopsWithContext(t *testing.T) {
+	fileName := writeConfig(t, "add ns ip 10.0.0.10 255.255.255.0 -type SNIP", "add server web1 10.0.0.5")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	options := AnalyzeOptions{}.WithContext(ctx)
+	if _, err := GetFindings(fileName, options); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetFindings() error = %v, want context.Canceled", err)
+	}
+	if _, err := GetWarnings(fileName, options); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetWarnings() error = %v, want context.Canceled", err)
+	}
+	servers := []Server{{name: "web1", domainName: "web1.example.com", resolvedLocally: true}}
+	_, err := GetDnsDiscrepancyFindings(ctx, fileName, servers, NewLocalZone(nil), net.DefaultResolver)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("GetDnsDiscrepancyFindings() error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,113 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"io"
+	"net"
+)
+
+// coverageCandidate is a data structure for a network that servers are tested against, along with a description
+// of where the network comes from and whether it is a SNIP subnet the NetScaler is connected to.
+type coverageCandidate struct {
+	network   *net.IPNet
+	source    string
+	connected bool
+}
+
+// getCoverageCandidates is a function that returns the networks GetCoverageNetworks checks coverage with, in the
+// same order, each described by the SNIP or policy prefix it comes from.
+func getCoverageCandidates(fileName string, options AnalyzeOptions) ([]coverageCandidate, error) {
+	sourceSnips, err := GetSourceSnips(fileName, options)
+	if err != nil {
+		return nil, err
+	}
+	networks, err := GetNetworks(sourceSnips)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []coverageCandidate
+	for i, network := range networks {
+		candidates = append(candidates, coverageCandidate{network, fmt.Sprintf("%s %s %s %s %s (%s %d)",
+			sourceSnips[i].ipType, Translate("network"), network, Translate("of"), sourceSnips[i].ipAddress,
+			Translate("line"), sourceSnips[i].line), true})
+	}
+	policyNetworks, err := options.policy.CoveringNetworks(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, network := range policyNetworks {
+		candidates = append(candidates, coverageCandidate{network, fmt.Sprintf("%s %s", Translate("policy prefix"), network),
+			false})
+	}
+	return candidates, nil
+}
+
+// PrintCoverageTrace is a function that writes, for every server, each network its address was tested against
+// and whether the network holds it, followed by the verdict, so that the owner of an uncovered server can see
+// that no network of the NetScaler reaches it. When several routes lead to a server, the route its traffic actually
+// takes is marked, as that is the path a remediation plan has to account for. Domain based servers without an
+// address are reported as unresolved, as there is nothing to test.
+func PrintCoverageTrace(w io.Writer, fileName string, servers []Server, options AnalyzeOptions) error {
+	candidates, err := getCoverageCandidates(fileName, options)
+	if err != nil {
+		return err
+	}
+	routes, err := GetRoutes(fileName)
+	if err != nil {
+		return err
+	}
+	var connected []*net.IPNet
+	for _, candidate := range candidates {
+		if candidate.connected {
+			connected = append(connected, candidate.network)
+		}
+	}
+	for _, server := range servers {
+		address := server.ipAddress
+		if address == "" {
+			address = server.domainName
+		}
+		fmt.Fprintf(w, "server %s %s (%s %d)\n", server.name, address, Translate("line"), server.line)
+		ip := net.ParseIP(server.ipAddress)
+		if ip == nil {
+			fmt.Fprintf(w, "\t%s\n", Translate("unresolved"))
+			continue
+		}
+		covered := false
+		for _, candidate := range candidates {
+			if candidate.network.Contains(ip) {
+				covered = true
+				fmt.Fprintf(w, "\t%s: %s\n", candidate.source, Translate("matched"))
+			} else {
+				fmt.Fprintf(w, "\t%s: %s\n", candidate.source, Translate("no match"))
+			}
+		}
+		if len(candidates) == 0 {
+			fmt.Fprintf(w, "\t%s\n", Translate("no networks to test"))
+		}
+		var covering []Route
+		for _, route := range routes {
+			if network := route.Network(); network != nil && network.Contains(ip) {
+				covering = append(covering, route)
+			}
+		}
+		selected := SelectRoutes(covering, connected, ip)
+		if len(covering) > 1 || len(covering) > 0 && selected[0].connected {
+			for _, route := range selected {
+				if route.connected {
+					fmt.Fprintf(w, "\t%s %s: %s\n", Translate("route"), route, DescribeRouteSelection(route, selected))
+				}
+			}
+			for _, route := range covering {
+				fmt.Fprintf(w, "\t%s %s (%s %d): %s\n", Translate("route"), route, Translate("line"), route.line,
+					DescribeRouteSelection(route, selected))
+			}
+		}
+		if covered {
+			fmt.Fprintf(w, "\t=> %s\n", Translate("covered"))
+		} else {
+			fmt.Fprintf(w, "\t=> %s\n", Translate("uncovered"))
+		}
+	}
+	return nil
+}
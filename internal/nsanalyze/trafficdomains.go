@@ -0,0 +1,61 @@
+package nsanalyze
+
+import "strings"
+
+// TrafficDomain is a data structure for a NetScaler traffic domain along with the VLANs bound to it. A traffic
+// domain with a VMAC answers on its VLANs with a virtual MAC address of its own, which is what keeps overlapping
+// traffic domains apart on a shared layer 2 domain.
+type TrafficDomain struct {
+	id    string
+	alias string
+	vmac  bool
+	vlans []string
+	line  int
+}
+
+// GetTrafficDomains is a function that accepts a file name as a parameter for input and then returns an array of
+// traffic domains, each with the VLANs bound to it.
+func GetTrafficDomains(fileName string) ([]TrafficDomain, error) {
+	var domains []TrafficDomain
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	addTrafficDomainLines, err := GetConfigLines(file, "(?i)(add ns trafficDomain ).*")
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]int)
+	for _, addTrafficDomainLine := range addTrafficDomainLines {
+		fields := SplitConfigLine(addTrafficDomainLine.text)[3:]
+		if len(fields) == 0 {
+			continue
+		}
+		index[fields[0]] = len(domains)
+		domains = append(domains, TrafficDomain{
+			id:    fields[0],
+			alias: GetOption(fields, "-aliasName"),
+			vmac:  strings.EqualFold(GetOption(fields, "-vmac"), "ENABLED"),
+			line:  addTrafficDomainLine.number,
+		})
+	}
+	bindTrafficDomainLines, err := GetConfigLines(file, "(?i)(bind ns trafficDomain ).*")
+	if err != nil {
+		return nil, err
+	}
+	for _, bindTrafficDomainLine := range bindTrafficDomainLines {
+		fields := SplitConfigLine(bindTrafficDomainLine.text)[3:]
+		if len(fields) == 0 {
+			continue
+		}
+		i, ok := index[fields[0]]
+		if !ok {
+			continue
+		}
+		domains[i].vlans = append(domains[i].vlans, GetOptionValues(fields, "-vlan")...)
+	}
+	for i := range domains {
+		SortVlanIDs(domains[i].vlans)
+	}
+	return domains, nil
+}
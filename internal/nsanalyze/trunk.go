@@ -0,0 +1,356 @@
+package nsanalyze
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TrunkPort is a data structure for the VLANs that a switch port facing a NetScaler interface has to carry.
+type TrunkPort struct {
+	iface    Interface
+	tagged   []string
+	untagged []string
+	nsvlan   string
+}
+
+// GetTrunkPorts is a function that accepts a file name as a parameter for input and then returns the VLANs
+// carried by every interface of the NetScaler.
+func GetTrunkPorts(fileName string) ([]TrunkPort, error) {
+	interfaces, err := GetInterfaces(fileName)
+	if err != nil {
+		return nil, err
+	}
+	bindings, err := GetVlanBindings(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var ports []TrunkPort
+	for _, iface := range interfaces {
+		port := TrunkPort{iface: iface}
+		for _, binding := range bindings {
+			if binding.interfaceName != iface.name {
+				continue
+			}
+			if binding.nsvlan {
+				port.nsvlan = binding.vlanID
+			}
+			if binding.tagged {
+				port.tagged = append(port.tagged, binding.vlanID)
+			} else {
+				port.untagged = append(port.untagged, binding.vlanID)
+			}
+		}
+		SortVlanIDs(port.tagged)
+		SortVlanIDs(port.untagged)
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
+
+// SortVlanIDs is a function that sorts VLAN IDs numerically.
+func SortVlanIDs(vlanIDs []string) {
+	sort.Slice(vlanIDs, func(i, j int) bool {
+		a, errA := strconv.Atoi(vlanIDs[i])
+		b, errB := strconv.Atoi(vlanIDs[j])
+		if errA != nil || errB != nil {
+			return vlanIDs[i] < vlanIDs[j]
+		}
+		return a < b
+	})
+}
+
+// SwitchPort is a function that returns the name of the switch port an interface connects to, taken from
+// the interface alias since that is where the switch and port are recorded on the appliance.
+func (port TrunkPort) SwitchPort() string {
+	if port.iface.alias == "" {
+		return Translate("unknown")
+	}
+	return port.iface.alias
+}
+
+// PrintTrunkReport is a function that writes the trunk report for a configuration file, listing the switch
+// port each interface connects to, its MTU and the VLANs that the switch side has to allow on it, followed by the
+// subnets and traffic domain of every VLAN with addresses bound to it and the MTU of every VLAN that sets one,
+// the VLANs every bridge group bridges, and then the VLAN that every large scale NAT pool and subscriber network
+// is reached through.
+func PrintTrunkReport(w io.Writer, fileName string) error {
+	ports, err := GetTrunkPorts(fileName)
+	if err != nil {
+		return err
+	}
+	for _, port := range ports {
+		lldpMode := port.iface.lldpMode
+		if lldpMode == "" {
+			lldpMode = "NONE"
+		}
+		fmt.Fprintf(w, "%s %s -> %s (lldp %s, mtu %d", Translate("interface"), port.iface.name, port.SwitchPort(),
+			lldpMode, port.iface.EffectiveMtu())
+		for _, setting := range []struct{ name, value string }{
+			{"trunk", port.iface.trunk}, {"tagall", port.iface.tagAll}, {"hamonitor", port.iface.haMonitor},
+		} {
+			if setting.value != "" {
+				fmt.Fprintf(w, ", %s %s", setting.name, setting.value)
+			}
+		}
+		fmt.Fprintln(w, ")")
+		if len(port.untagged) > 0 {
+			fmt.Fprintf(w, "\t%s %s\n", Translate("native vlan"), strings.Join(port.untagged, ","))
+		}
+		if len(port.tagged) > 0 {
+			fmt.Fprintf(w, "\t%s %s\n", Translate("allowed vlan"), strings.Join(port.tagged, ","))
+		}
+		if port.nsvlan != "" {
+			fmt.Fprintf(w, "\tnsvlan %s\n", port.nsvlan)
+		}
+	}
+	subnets, err := GetVlanSubnets(fileName)
+	if err != nil {
+		return err
+	}
+	domains, err := GetTrafficDomains(fileName)
+	if err != nil {
+		return err
+	}
+	trafficDomains := make(map[string]TrafficDomain)
+	for _, domain := range domains {
+		for _, vlan := range domain.vlans {
+			trafficDomains[vlan] = domain
+		}
+	}
+	vlans, err := GetVlans(fileName)
+	if err != nil {
+		return err
+	}
+	mtus := make(map[string]int)
+	names := make(map[string]Vlan)
+	for _, vlan := range vlans {
+		mtus[vlan.id] = vlan.mtu
+		names[vlan.id] = vlan
+	}
+	var vlanIDs []string
+	for vlan := range subnets {
+		vlanIDs = append(vlanIDs, vlan)
+	}
+	for vlan, mtu := range mtus {
+		if _, ok := subnets[vlan]; !ok && mtu != 0 {
+			vlanIDs = append(vlanIDs, vlan)
+		}
+	}
+	SortVlanIDs(vlanIDs)
+	for _, vlan := range vlanIDs {
+		fmt.Fprintf(w, "vlan %s", DescribeVlan(vlan, names))
+		if td, ok := trafficDomains[vlan]; ok && td.vmac {
+			fmt.Fprintf(w, " (td %s vmac)", td.id)
+		} else if ok {
+			fmt.Fprintf(w, " (td %s)", td.id)
+		}
+		if mtus[vlan] != 0 {
+			fmt.Fprintf(w, " mtu %d", mtus[vlan])
+		}
+		if len(subnets[vlan]) > 0 {
+			fmt.Fprintf(w, " %s %s", Translate("subnets"), strings.Join(subnets[vlan], ", "))
+		}
+		fmt.Fprintln(w)
+	}
+	groups, err := GetBridgeGroups(fileName)
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		var described []string
+		for _, vlan := range group.vlans {
+			described = append(described, DescribeVlan(vlan, names))
+		}
+		fmt.Fprintf(w, "bridgegroup %s %s %s", group.id, Translate("vlans"), strings.Join(described, ", "))
+		if len(group.ipAddresses) > 0 {
+			fmt.Fprintf(w, " %s %s", Translate("addresses"), strings.Join(group.ipAddresses, ", "))
+		}
+		fmt.Fprintln(w)
+	}
+	return PrintLsnPlan(w, fileName)
+}
+
+// GetVlanSubnets is a function that accepts a file name as a parameter for input and then returns the IPv4 and
+// IPv6 subnets reached through each VLAN, which is what dual-stack planning of the VLAN needs.
+func GetVlanSubnets(fileName string) (map[string][]string, error) {
+	subnets := make(map[string][]string)
+	snips, err := GetSnips(fileName)
+	if err != nil {
+		return nil, err
+	}
+	validSnips, _ := FilterValidSnips(snips)
+	networks, err := GetNetworks(validSnips)
+	if err != nil {
+		return nil, err
+	}
+	for i, snip := range validSnips {
+		if snip.vlan != "" {
+			subnets[snip.vlan] = append(subnets[snip.vlan], networks[i].String())
+		}
+	}
+	snip6s, err := GetSnip6s(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, snip := range snip6s {
+		if network := snip.Network(); network != nil && snip.vlan != "" {
+			subnets[snip.vlan] = append(subnets[snip.vlan], network.String())
+		}
+	}
+	return subnets, nil
+}
+
+// GetNativeVlanFindings is a function that accepts a file name as a parameter for input and then returns the
+// findings for interfaces carrying more than one untagged VLAN. When the VLAN expected untagged on the trunk is
+// given, trunk interfaces whose native VLAN differs from it or that carry it tagged are reported as well.
+// Interfaces without an untagged binding carry VLAN 1 untagged.
+func GetNativeVlanFindings(fileName, nativeVlan string) ([]Finding, error) {
+	var findings []Finding
+	bindings, err := GetVlanBindings(fileName)
+	if err != nil {
+		return nil, err
+	}
+	names, err := GetVlanNames(fileName)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(bindings, func(i, j int) bool {
+		return bindings[i].line < bindings[j].line
+	})
+	untagged := make(map[string]VlanBinding)
+	tagged := make(map[string][]VlanBinding)
+	var interfaceNames []string
+	for _, binding := range bindings {
+		if _, ok := untagged[binding.interfaceName]; !ok && tagged[binding.interfaceName] == nil {
+			interfaceNames = append(interfaceNames, binding.interfaceName)
+		}
+		if binding.tagged {
+			tagged[binding.interfaceName] = append(tagged[binding.interfaceName], binding)
+			continue
+		}
+		first, ok := untagged[binding.interfaceName]
+		if !ok {
+			untagged[binding.interfaceName] = binding
+			continue
+		}
+		if first.vlanID == binding.vlanID {
+			continue
+		}
+		findings = append(findings, Finding{
+			rule: RuleNativeVlanConflict,
+			message: fmt.Sprintf("Interface %s carries VLAN %s untagged but VLAN %s is already untagged on it",
+				binding.interfaceName, describeVlanBinding(binding, names), describeVlanBinding(first, names)),
+			object:   binding.interfaceName,
+			key:      binding.vlanID,
+			fileName: fileName,
+			line:     binding.line,
+		})
+	}
+	if nativeVlan == "" {
+		return findings, nil
+	}
+	for _, interfaceName := range interfaceNames {
+		if len(tagged[interfaceName]) == 0 {
+			continue
+		}
+		for _, binding := range tagged[interfaceName] {
+			if binding.vlanID == nativeVlan {
+				findings = append(findings, Finding{
+					rule: RuleNativeVlanMismatch,
+					message: fmt.Sprintf("VLAN %s is tagged on interface %s but the trunk expects it untagged",
+						describeVlanBinding(binding, names), interfaceName),
+					object:   interfaceName,
+					key:      binding.vlanID,
+					fileName: fileName,
+					line:     binding.line,
+				})
+			}
+		}
+		native, ok := untagged[interfaceName]
+		if !ok {
+			native = VlanBinding{vlanID: "1", interfaceName: interfaceName, line: tagged[interfaceName][0].line}
+		}
+		if native.vlanID != nativeVlan {
+			findings = append(findings, Finding{
+				rule: RuleNativeVlanMismatch,
+				message: fmt.Sprintf("Interface %s has native VLAN %s but the trunk expects VLAN %s untagged",
+					interfaceName, describeVlanBinding(native, names), DescribeVlan(nativeVlan, names)),
+				object:   interfaceName,
+				key:      native.vlanID,
+				fileName: fileName,
+				line:     native.line,
+			})
+		}
+	}
+	return findings, nil
+}
+
+// GetTrunkModeFindings is a function that accepts a file name as a parameter for input and then returns the
+// findings for interfaces whose trunk and tag all settings do not match the VLANs bound to them: an interface
+// with trunking turned off cannot carry its tagged VLANs, and an interface tagging all VLANs sends the VLANs bound
+// to it untagged tagged as well, so the switch port has to expect its native VLAN tagged.
+func GetTrunkModeFindings(fileName string) ([]Finding, error) {
+	ports, err := GetTrunkPorts(fileName)
+	if err != nil {
+		return nil, err
+	}
+	names, err := GetVlanNames(fileName)
+	if err != nil {
+		return nil, err
+	}
+	describe := func(vlanIDs []string) string {
+		var described []string
+		for _, vlanID := range vlanIDs {
+			described = append(described, DescribeVlan(vlanID, names))
+		}
+		return strings.Join(described, ", ")
+	}
+	var findings []Finding
+	for _, port := range ports {
+		if port.iface.trunk == "OFF" && len(port.tagged) > 0 {
+			findings = append(findings, Finding{
+				rule: RuleTrunkModeMismatch,
+				message: fmt.Sprintf("Interface %s has trunking turned off but carries VLAN %s tagged", port.iface.name,
+					describe(port.tagged)),
+				object:   port.iface.name,
+				key:      "trunk",
+				fileName: fileName,
+				line:     port.iface.line,
+			})
+		}
+		if port.iface.tagAll == "ON" && len(port.untagged) > 0 {
+			findings = append(findings, Finding{
+				rule: RuleTrunkModeMismatch,
+				message: fmt.Sprintf("Interface %s tags all VLANs, so VLAN %s bound to it untagged is sent tagged",
+					port.iface.name, describe(port.untagged)),
+				object:   port.iface.name,
+				key:      "tagAll",
+				fileName: fileName,
+				line:     port.iface.line,
+			})
+		}
+	}
+	return findings, nil
+}
+
+// describeVlanBinding is a function that returns the VLAN of a binding along with its alias name, marking the
+// NSVLAN as such.
+func describeVlanBinding(binding VlanBinding, names map[string]Vlan) string {
+	if binding.nsvlan {
+		return DescribeVlan(binding.vlanID, names) + " (NSVLAN)"
+	}
+	return DescribeVlan(binding.vlanID, names)
+}
+
+// RunTrunk is a function that runs the trunk subcommand.
+func RunTrunk(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: trunk filename")
+	}
+	return PrintTrunkReport(os.Stdout, args[0])
+}
@@ -0,0 +1,172 @@
+package nsanalyze
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"text/tabwriter"
+)
+
+// NetworkUtilization is a data structure for how much of a SNIP network is in use.
+type NetworkUtilization struct {
+	network *net.IPNet
+	size    uint64
+	servers int
+	vips    int
+	snips   int
+}
+
+// Used is a function that returns the number of addresses of the network that are in use.
+func (utilization NetworkUtilization) Used() int {
+	return utilization.servers + utilization.vips + utilization.snips
+}
+
+// Percentage is a function that returns the percentage of the usable addresses of the network in use.
+func (utilization NetworkUtilization) Percentage() float64 {
+	if utilization.size == 0 {
+		return 100
+	}
+	return float64(utilization.Used()) * 100 / float64(utilization.size)
+}
+
+// UsableAddresses is a function that returns the number of host addresses in a network, which leaves out the
+// network and broadcast addresses except for /31 and /32 networks.
+func UsableAddresses(network *net.IPNet) uint64 {
+	ones, bits := network.Mask.Size()
+	hostBits := bits - ones
+	if hostBits > 63 {
+		hostBits = 63
+	}
+	if hostBits <= 1 {
+		return uint64(1) << uint(hostBits)
+	}
+	return uint64(1)<<uint(hostBits) - 2
+}
+
+// GetVips is a function that accepts a file name as a parameter for input and then returns the distinct
+// addresses of the load balancing virtual servers and the VIPs added through "add ns ip".
+func GetVips(fileName string) ([]string, error) {
+	var vips []string
+	seen := make(map[string]bool)
+	add := func(ipAddress string) {
+		if net.ParseIP(ipAddress) != nil && !seen[ipAddress] {
+			seen[ipAddress] = true
+			vips = append(vips, ipAddress)
+		}
+	}
+	vservers, err := GetLbVservers(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, vserver := range vservers {
+		add(vserver.ipAddress)
+	}
+	snips, err := GetSnips(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for _, snip := range snips {
+		if snip.ipType == "VIP" {
+			add(snip.ipAddress)
+		}
+	}
+	return vips, nil
+}
+
+// GetNetworkUtilization is a function that accepts a file name as a parameter for input and then returns the
+// utilization of every distinct SNIP network. Each address is only counted once per network.
+func GetNetworkUtilization(fileName string) ([]NetworkUtilization, error) {
+	snips, err := GetSnips(fileName)
+	if err != nil {
+		return nil, err
+	}
+	validSnips, _ := FilterValidSnips(snips)
+	networks, err := GetNetworks(validSnips)
+	if err != nil {
+		return nil, err
+	}
+	servers, err := GetServers(fileName)
+	if err != nil {
+		return nil, err
+	}
+	vips, err := GetVips(fileName)
+	if err != nil {
+		return nil, err
+	}
+	vipSet := make(map[string]bool)
+	for _, vip := range vips {
+		vipSet[vip] = true
+	}
+	var utilizations []NetworkUtilization
+	seen := make(map[string]bool)
+	for _, network := range networks {
+		if seen[network.String()] {
+			continue
+		}
+		seen[network.String()] = true
+		utilization := NetworkUtilization{network: network, size: UsableAddresses(network)}
+		counted := make(map[string]bool)
+		count := func(ipAddress string) bool {
+			ip := net.ParseIP(ipAddress)
+			if ip == nil || counted[ipAddress] || !network.Contains(ip) {
+				return false
+			}
+			counted[ipAddress] = true
+			return true
+		}
+		for _, snip := range validSnips {
+			if snip.ipType != "VIP" && count(snip.ipAddress) {
+				utilization.snips++
+			}
+		}
+		for _, vip := range vips {
+			if count(vip) {
+				utilization.vips++
+			}
+		}
+		for _, server := range servers {
+			if !vipSet[server.ipAddress] && count(server.ipAddress) {
+				utilization.servers++
+			}
+		}
+		utilizations = append(utilizations, utilization)
+	}
+	return utilizations, nil
+}
+
+// PrintUtilizationReport is a function that writes the utilization of every network as a table, marking the
+// networks whose utilization is at or above the threshold percentage.
+func PrintUtilizationReport(w io.Writer, utilizations []NetworkUtilization, threshold float64) error {
+	table := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(table, "%s\t%s\t%s\tVIPS\tSNIPS\t%s\t\n", Translate("NETWORK"), Translate("SIZE"), Translate("SERVERS"),
+		Translate("USED"))
+	for _, utilization := range utilizations {
+		status := ""
+		if utilization.Percentage() >= threshold {
+			status = Translate("NEAR EXHAUSTION")
+		}
+		fmt.Fprintf(table, "%s\t%d\t%d\t%d\t%d\t%.1f%%\t%s\n", utilization.network, utilization.size,
+			utilization.servers, utilization.vips, utilization.snips, utilization.Percentage(), status)
+	}
+	return table.Flush()
+}
+
+// RunUtilization is a function that runs the utilization subcommand.
+func RunUtilization(args []string) error {
+	flags := flag.NewFlagSet("utilization", flag.ContinueOnError)
+	threshold := flags.Float64("threshold", 80, "utilization percentage at which a network is near exhaustion")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: utilization [-threshold percent] filename")
+	}
+	utilizations, err := GetNetworkUtilization(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	return PrintUtilizationReport(os.Stdout, utilizations, *threshold)
+}
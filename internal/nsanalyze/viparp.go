@@ -0,0 +1,82 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"io"
+	"net"
+)
+
+// MovingVip is a data structure for a VIP with ARP disabled on a subnet that the IPAM assigns to another VLAN
+// than the one the NetScaler has the subnet on, along with both VLANs and the IPAM row.
+type MovingVip struct {
+	vip  Snip
+	from string
+	to   string
+	row  int
+}
+
+// GetMovingArpVips is a function that returns the VIPs with ARP disabled that are within an IPAM subnet whose VLAN
+// differs from the VLAN the VIP is on now. Such a VIP does not answer ARP on the new VLAN, so whatever announces
+// it instead, such as a route on the upstream router or the peer of an HA pair, needs to move along with it at
+// cutover. A VIP that is not bound to a VLAN is on the VLAN of the subnet of the configuration it is in, and on
+// VLAN 1 when it is in none. When subnets nest, the most specific one that holds the VIP is the one that counts, on
+// both the configuration and the IPAM side.
+func GetMovingArpVips(fileName string, ipam []IpamSubnet) ([]MovingVip, error) {
+	snips, err := GetSnips(fileName)
+	if err != nil {
+		return nil, err
+	}
+	subnets, err := GetConfigSubnets(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var vips []MovingVip
+	for _, snip := range snips {
+		if snip.ipType != "VIP" || snip.arp != "DISABLED" {
+			continue
+		}
+		ip := net.ParseIP(snip.ipAddress)
+		if ip == nil {
+			continue
+		}
+		from := snip.vlan
+		if from == "" {
+			longest := -1
+			for _, subnet := range subnets {
+				if ones, _ := subnet.network.Mask.Size(); subnet.network.Contains(ip) && ones > longest {
+					from, longest = subnet.vlan, ones
+				}
+			}
+		}
+		if from == "" {
+			from = "1"
+		}
+		var to IpamSubnet
+		longest := -1
+		for _, ipamSubnet := range ipam {
+			if ones, _ := ipamSubnet.network.Mask.Size(); ipamSubnet.network.Contains(ip) && ones > longest {
+				to, longest = ipamSubnet, ones
+			}
+		}
+		if to.vlan != "" && to.vlan != from {
+			vips = append(vips, MovingVip{vip: snip, from: from, to: to.vlan, row: to.row})
+		}
+	}
+	return vips, nil
+}
+
+// PrintMovingArpVips is a function that writes the VIPs with ARP disabled on subnets that move to another VLAN,
+// noting those that do not answer ICMP either, as a ping after cutover does not tell whether they are reachable.
+func PrintMovingArpVips(w io.Writer, vips []MovingVip) {
+	fmt.Fprintf(w, "%s:\n", Translate("VIPs with ARP disabled on moving subnets"))
+	if len(vips) == 0 {
+		fmt.Fprintf(w, "\t%s\n", Translate("none"))
+	}
+	for _, vip := range vips {
+		fmt.Fprintf(w, "\t%s vlan %s, IPAM vlan %s", vip.vip.ipAddress, vip.from, vip.to)
+		if vip.vip.icmp == "DISABLED" {
+			fmt.Fprintf(w, ", %s", Translate("ICMP disabled"))
+		}
+		fmt.Fprintf(w, " (%s %d, %s %d)\n", Translate("line"), vip.vip.line, Translate("row"), vip.row)
+	}
+}
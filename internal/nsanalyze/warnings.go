@@ -0,0 +1,115 @@
+package nsanalyze
+
+import (
+	"fmt"
+	"io"
+)
+
+// Warning kinds.
+const (
+	WarningSkippedLine = "skipped-line"
+	WarningFirmware    = "firmware"
+	WarningCoverage    = "coverage"
+)
+
+// Warning is a data structure for an issue met while parsing a configuration that the analysis carries on past,
+// such as a line that was skipped. Warnings are returned as values rather than printed, so that callers of the
+// analysis engine, such as the gRPC service, can surface them their own way. Issues that a rule reports, such as
+// an unknown subnet mask or an unresolved reference, are findings rather than warnings, so each shows up once.
+type Warning struct {
+	kind     string
+	message  string
+	object   string
+	fileName string
+	line     int
+}
+
+// Kind is a function that returns the kind of the warning, such as skipped-line.
+func (warning Warning) Kind() string {
+	return warning.kind
+}
+
+// Message is a function that returns what the warning says.
+func (warning Warning) Message() string {
+	return warning.message
+}
+
+// Object is a function that returns the name of the object the warning is about, if any.
+func (warning Warning) Object() string {
+	return warning.object
+}
+
+// FileName is a function that returns the name of the configuration the warning was met in.
+func (warning Warning) FileName() string {
+	return warning.fileName
+}
+
+// Line is a function that returns the line of the configuration the warning was met on, if any.
+func (warning Warning) Line() int {
+	return warning.line
+}
+
+// GetWarnings is a function that accepts a file name as a parameter for input and then returns the warnings for
+// a configuration: server lines too short to parse, ns ip annotations left out of the SNIP model, a missing or
+// unknown firmware banner and a partial configuration without SNIPs, whose coverage is not checked. It fails once
+// the context of the options is done.
+func GetWarnings(fileName string, options AnalyzeOptions) ([]Warning, error) {
+	if err := options.checkContext(); err != nil {
+		return nil, err
+	}
+	var warnings []Warning
+	networks, err := GetCoverageNetworks(fileName, options)
+	if err != nil {
+		return nil, err
+	}
+	if !ChecksCoverage(networks, options) {
+		warnings = append(warnings, Warning{kind: WarningCoverage, fileName: fileName,
+			message: "the partial configuration has no SNIPs, so coverage is not checked"})
+	}
+	file, err := GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	platform, err := GetPlatform(fileName, options)
+	if err != nil {
+		return nil, err
+	}
+	if version := GetFirmwareVersion(file); version.release == "" && !options.partial && !IsContainerPlatform(platform) {
+		warnings = append(warnings, Warning{kind: WarningFirmware, fileName: fileName,
+			message: "the configuration has no firmware banner, so it is parsed as 13.x"})
+	} else if version.release != "" && !version.Known() {
+		warnings = append(warnings, Warning{kind: WarningFirmware, fileName: fileName,
+			message: fmt.Sprintf("firmware version %s is unknown, so the configuration is parsed as 13.x", version)})
+	}
+	addServerLines, err := GetConfigLines(file, "(add server).*")
+	if err != nil {
+		return nil, err
+	}
+	for i, addServerLine := range addServerLines {
+		if i%contextCheckInterval == 0 {
+			if err := options.checkContext(); err != nil {
+				return nil, err
+			}
+		}
+		if len(SplitConfigLine(RemoveConfigKeywords(addServerLine.text, "add server "))) < 2 {
+			warnings = append(warnings, Warning{kind: WarningSkippedLine, fileName: fileName, line: addServerLine.number,
+				message: fmt.Sprintf("line %d does not have both a server name and an address, so it is skipped", addServerLine.number)})
+		}
+	}
+	if err := options.checkContext(); err != nil {
+		return nil, err
+	}
+	annotationWarnings, err := GetSnipAnnotationWarnings(fileName)
+	if err != nil {
+		return nil, err
+	}
+	warnings = append(warnings, annotationWarnings...)
+	return warnings, nil
+}
+
+// PrintWarnings is a function that writes warnings the way the command line reports them on standard error.
+func PrintWarnings(w io.Writer, warnings []Warning) {
+	for _, warning := range warnings {
+		fmt.Fprintf(w, "warning: %s: %s\n", warning.fileName, warning.message)
+	}
+}
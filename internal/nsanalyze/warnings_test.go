@@ -0,0 +1,85 @@
+package nsanalyze
+
+import "testing"
+
+func TestGetWarningsLeaveFindingsOut(t *testing.T) {
+	fileName := writeConfig(t, "add ns ip 10.0.0.10 255.255.255.0 -type SNIP",
+		"add ns ip 10.5.0.10 255.0.255.0 -type SNIP",
+		"add server lonely",
+		"add service svc1 ghost HTTP 80")
+	options := AnalyzeOptions{}.WithPartial()
+	warnings, err := GetWarnings(fileName, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := make(map[string]int)
+	for _, warning := range warnings {
+		kinds[warning.Kind()]++
+	}
+	if kinds[WarningSkippedLine] != 1 || len(kinds) != 1 {
+		t.Errorf("warning kinds = %v, want one %s", kinds, WarningSkippedLine)
+	}
+	findings, err := GetFindings(fileName, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasFinding(findings, "NS003", "10.5.0.10") || !hasFinding(findings, "NS014", "svc1") {
+		t.Errorf("findings = %v, want NS003 10.5.0.10 and NS014 svc1", findingKeys(findings))
+	}
+}
+
+func TestGetSnipAnnotationWarnings(t *testing.T) {
+	tests := []struct {
+		line    string
+		message string
+	}{
+		{"add ns ip 10.0.0.11 255.255.255.0 -ownerNode 40",
+			"line 2 gives ns ip 10.0.0.11 owner node 40, which is not a cluster node, so the owner node is skipped"},
+		{"add ns ip 10.0.0.11 255.255.255.0 -vServer MAYBE",
+			"line 2 gives ns ip 10.0.0.11 -vServer MAYBE, which is neither ENABLED nor DISABLED, so the option is skipped"},
+		{"set ns ip 10.0.0.10 -ownerNode x",
+			"line 2 gives ns ip 10.0.0.10 owner node x, which is not a cluster node, so the owner node is skipped"},
+		{"bind ns ip 10.9.9.9 -vServer vs1", "line 2 binds ns ip 10.9.9.9, which is not added, so it is skipped"},
+		{"bind ns ip 10.0.0.10 -ownerNode 99",
+			"line 2 binds ns ip 10.0.0.10 to owner node 99, which is not a cluster node, so it is skipped"},
+		{"bind ns ip 10.0.0.10", "line 2 binds nothing to ns ip 10.0.0.10, so it is skipped"},
+		{"bind ns ip 10.0.0.10 -ownerNode 255 -vServer vs1", ""},
+	}
+	for _, test := range tests {
+		fileName := writeConfig(t, "add ns ip 10.0.0.10 255.255.255.0 -type SNIP", test.line)
+		warnings, err := GetSnipAnnotationWarnings(fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case test.message == "" && len(warnings) != 0:
+			t.Errorf("%s: warnings = %v, want none", test.line, warnings)
+		case test.message != "" && (len(warnings) != 1 || warnings[0].message != test.message || warnings[0].line != 2):
+			t.Errorf("%s: warnings = %v, want %q", test.line, warnings, test.message)
+		}
+	}
+}
+
+func TestGetWarningsNameTheConfiguration(t *testing.T) {
+	config := "add ns ip 10.0.0.10 255.255.255.0 -type SNIP -ownerNode 40\n"
+	for _, fileName := range []string{"lb-primary.conf", "lb-dr.conf"} {
+		if err := CacheFile(fileName, config); err != nil {
+			t.Fatal(err)
+		}
+		defer ForgetFile(fileName)
+	}
+	for _, fileName := range []string{"lb-primary.conf", "lb-dr.conf"} {
+		warnings, err := GetWarnings(fileName, AnalyzeOptions{}.WithPartial())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(warnings) == 0 {
+			t.Fatalf("%s has no warnings, want the owner node skipped", fileName)
+		}
+		for _, warning := range warnings {
+			if warning.FileName() != fileName {
+				t.Errorf("warning %q names %s, want %s", warning.message, warning.FileName(), fileName)
+			}
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: analysis.proto
+
+package api
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Analysis_Analyze_FullMethodName     = "/vlantrunk.v1.Analysis/Analyze"
+	Analysis_GetServers_FullMethodName  = "/vlantrunk.v1.Analysis/GetServers"
+	Analysis_GetSnips_FullMethodName    = "/vlantrunk.v1.Analysis/GetSnips"
+	Analysis_GetFindings_FullMethodName = "/vlantrunk.v1.Analysis/GetFindings"
+)
+
+// AnalysisClient is the client API for Analysis service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AnalysisClient interface {
+	// Analyze parses a configuration and returns its servers, SNIPs, networks, uncovered servers and findings.
+	Analyze(ctx context.Context, in *AnalyzeRequest, opts ...grpc.CallOption) (*AnalyzeResponse, error)
+	// GetServers returns the servers of a configuration.
+	GetServers(ctx context.Context, in *AnalyzeRequest, opts ...grpc.CallOption) (*ServersResponse, error)
+	// GetSnips returns the NetScaler owned IPs of a configuration.
+	GetSnips(ctx context.Context, in *AnalyzeRequest, opts ...grpc.CallOption) (*SnipsResponse, error)
+	// GetFindings returns every finding for a configuration.
+	GetFindings(ctx context.Context, in *AnalyzeRequest, opts ...grpc.CallOption) (*FindingsResponse, error)
+}
+
+type analysisClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAnalysisClient(cc grpc.ClientConnInterface) AnalysisClient {
+	return &analysisClient{cc}
+}
+
+func (c *analysisClient) Analyze(ctx context.Context, in *AnalyzeRequest, opts ...grpc.CallOption) (*AnalyzeResponse, error) {
+	out := new(AnalyzeResponse)
+	err := c.cc.Invoke(ctx, Analysis_Analyze_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *analysisClient) GetServers(ctx context.Context, in *AnalyzeRequest, opts ...grpc.CallOption) (*ServersResponse, error) {
+	out := new(ServersResponse)
+	err := c.cc.Invoke(ctx, Analysis_GetServers_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *analysisClient) GetSnips(ctx context.Context, in *AnalyzeRequest, opts ...grpc.CallOption) (*SnipsResponse, error) {
+	out := new(SnipsResponse)
+	err := c.cc.Invoke(ctx, Analysis_GetSnips_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *analysisClient) GetFindings(ctx context.Context, in *AnalyzeRequest, opts ...grpc.CallOption) (*FindingsResponse, error) {
+	out := new(FindingsResponse)
+	err := c.cc.Invoke(ctx, Analysis_GetFindings_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AnalysisServer is the server API for Analysis service.
+// All implementations must embed UnimplementedAnalysisServer
+// for forward compatibility
+type AnalysisServer interface {
+	// Analyze parses a configuration and returns its servers, SNIPs, networks, uncovered servers and findings.
+	Analyze(context.Context, *AnalyzeRequest) (*AnalyzeResponse, error)
+	// GetServers returns the servers of a configuration.
+	GetServers(context.Context, *AnalyzeRequest) (*ServersResponse, error)
+	// GetSnips returns the NetScaler owned IPs of a configuration.
+	GetSnips(context.Context, *AnalyzeRequest) (*SnipsResponse, error)
+	// GetFindings returns every finding for a configuration.
+	GetFindings(context.Context, *AnalyzeRequest) (*FindingsResponse, error)
+	mustEmbedUnimplementedAnalysisServer()
+}
+
+// UnimplementedAnalysisServer must be embedded to have forward compatible implementations.
+type UnimplementedAnalysisServer struct {
+}
+
+func (UnimplementedAnalysisServer) Analyze(context.Context, *AnalyzeRequest) (*AnalyzeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Analyze not implemented")
+}
+func (UnimplementedAnalysisServer) GetServers(context.Context, *AnalyzeRequest) (*ServersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetServers not implemented")
+}
+func (UnimplementedAnalysisServer) GetSnips(context.Context, *AnalyzeRequest) (*SnipsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSnips not implemented")
+}
+func (UnimplementedAnalysisServer) GetFindings(context.Context, *AnalyzeRequest) (*FindingsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFindings not implemented")
+}
+func (UnimplementedAnalysisServer) mustEmbedUnimplementedAnalysisServer() {}
+
+// UnsafeAnalysisServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AnalysisServer will
+// result in compilation errors.
+type UnsafeAnalysisServer interface {
+	mustEmbedUnimplementedAnalysisServer()
+}
+
+func RegisterAnalysisServer(s grpc.ServiceRegistrar, srv AnalysisServer) {
+	s.RegisterService(&Analysis_ServiceDesc, srv)
+}
+
+func _Analysis_Analyze_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AnalyzeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalysisServer).Analyze(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Analysis_Analyze_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalysisServer).Analyze(ctx, req.(*AnalyzeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Analysis_GetServers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AnalyzeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalysisServer).GetServers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Analysis_GetServers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalysisServer).GetServers(ctx, req.(*AnalyzeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Analysis_GetSnips_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AnalyzeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalysisServer).GetSnips(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Analysis_GetSnips_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalysisServer).GetSnips(ctx, req.(*AnalyzeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Analysis_GetFindings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AnalyzeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalysisServer).GetFindings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Analysis_GetFindings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalysisServer).GetFindings(ctx, req.(*AnalyzeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Analysis_ServiceDesc is the grpc.ServiceDesc for Analysis service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Analysis_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "vlantrunk.v1.Analysis",
+	HandlerType: (*AnalysisServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Analyze",
+			Handler:    _Analysis_Analyze_Handler,
+		},
+		{
+			MethodName: "GetServers",
+			Handler:    _Analysis_GetServers_Handler,
+		},
+		{
+			MethodName: "GetSnips",
+			Handler:    _Analysis_GetSnips_Handler,
+		},
+		{
+			MethodName: "GetFindings",
+			Handler:    _Analysis_GetFindings_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "analysis.proto",
+}
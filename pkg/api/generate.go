@@ -0,0 +1,4 @@
+// Package api holds the protobuf models and the gRPC service of the analysis engine.
+package api
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative analysis.proto
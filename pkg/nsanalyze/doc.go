@@ -0,0 +1,30 @@
+// Package nsanalyze is the stable API of the analysis engine of the nsanalyze command, which checks NetScaler
+// configurations for servers, endpoints and settings that lose reachability when SNIPs and VLANs move to a trunk.
+//
+// Configurations are named by file name or by source URL, as on the command line, and read once per process;
+// CacheFile adds a configuration held in memory under a name of its own. A minimal analysis is
+//
+//	findings, err := nsanalyze.GetFindings("ns.conf", nsanalyze.AnalyzeOptions{})
+//
+// # Stable API
+//
+// The module follows semantic versioning and releases are tagged vMAJOR.MINOR.PATCH. The identifiers of this
+// package are the stable API: they keep their signatures and meaning across minor and patch releases and only
+// change in a new major version, which gets a new module path. Of the methods of its types, these are stable:
+//
+//   - AnalyzeOptions: its zero value and its WithResolver, WithNativeVlan, WithPartial, WithProfile and
+//     WithContext methods
+//   - Finding: its ID, Rule, Message, Object, FileName, Line and Comment methods
+//   - Rule: its ID, Name, Description and Severity methods
+//   - Profile: its Includes method
+//   - Warning: its Kind, Message, Object, FileName and Line methods
+//   - Server: its Name, IPAddress, Line and Describe methods
+//   - Snip: its IPAddress, SubnetMask and Line methods
+//
+// The protobuf messages and gRPC service of package api are stable too. The types are those of the engine, so
+// they have further methods, which serve the command line tool and may change in any release, as may everything
+// under internal.
+//
+// Minor releases may add rules, warning kinds, options and fields, and the messages of findings and warnings may
+// be reworded in any release. Rule IDs and protobuf field numbers are never reused.
+package nsanalyze
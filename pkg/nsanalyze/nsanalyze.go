@@ -0,0 +1,147 @@
+package nsanalyze
+
+import (
+	"net"
+
+	engine "github.com/ajenehall/vlanTrunkProject/internal/nsanalyze"
+)
+
+// AnalyzeOptions is a data structure for the options of an analysis. The zero value analyzes a configuration
+// with the defaults of the command line tool.
+type AnalyzeOptions = engine.AnalyzeOptions
+
+// Finding is a data structure for an issue a rule reports about an object of a configuration.
+type Finding = engine.Finding
+
+// Rule is a data structure for a check of the analysis, with its stable ID, name, description and severity.
+type Rule = engine.Rule
+
+// Profile is a data structure for a named set of rules.
+type Profile = engine.Profile
+
+// Warning is a data structure for an issue met while parsing a configuration that the analysis carries on past.
+type Warning = engine.Warning
+
+// Server is a data structure for a server of a configuration.
+type Server = engine.Server
+
+// Snip is a data structure for an address the NetScaler owns, such as a SNIP.
+type Snip = engine.Snip
+
+// Severities of rules.
+const (
+	SeverityError   = engine.SeverityError
+	SeverityWarning = engine.SeverityWarning
+	SeverityNote    = engine.SeverityNote
+)
+
+// Warning kinds.
+const (
+	WarningSkippedLine = engine.WarningSkippedLine
+	WarningFirmware    = engine.WarningFirmware
+	WarningCoverage    = engine.WarningCoverage
+)
+
+// Rules of the analysis.
+var (
+	RuleUncoveredServer                  = engine.RuleUncoveredServer
+	RuleOverlappingSubnet                = engine.RuleOverlappingSubnet
+	RuleUnknownMask                      = engine.RuleUnknownMask
+	RuleOrphanVlan                       = engine.RuleOrphanVlan
+	RuleUnresolvedServer                 = engine.RuleUnresolvedServer
+	RuleNativeVlanConflict               = engine.RuleNativeVlanConflict
+	RuleNativeVlanMismatch               = engine.RuleNativeVlanMismatch
+	RuleUnreachableCollector             = engine.RuleUnreachableCollector
+	RuleMissingServer                    = engine.RuleMissingServer
+	RuleBogusAddress                     = engine.RuleBogusAddress
+	RulePartialPersistenceGroup          = engine.RulePartialPersistenceGroup
+	RuleUnreachableSnmp                  = engine.RuleUnreachableSnmp
+	RulePolicyAddress                    = engine.RulePolicyAddress
+	RuleUnresolvedReference              = engine.RuleUnresolvedReference
+	RuleDanglingReference                = engine.RuleDanglingReference
+	RuleUnreachableInfrastructure        = engine.RuleUnreachableInfrastructure
+	RuleModeCaveat                       = engine.RuleModeCaveat
+	RuleUncoveredSetMember               = engine.RuleUncoveredSetMember
+	RuleSpottedCoverage                  = engine.RuleSpottedCoverage
+	RuleUnreadableConfig                 = engine.RuleUnreadableConfig
+	RuleUncoveredListenPolicy            = engine.RuleUncoveredListenPolicy
+	RuleDnsDiscrepancy                   = engine.RuleDnsDiscrepancy
+	RuleMtuMismatch                      = engine.RuleMtuMismatch
+	RuleUnreachableRpcNode               = engine.RuleUnreachableRpcNode
+	RuleUnreachableAuthServer            = engine.RuleUnreachableAuthServer
+	RuleSubnetEdgeAddress                = engine.RuleSubnetEdgeAddress
+	RuleTrunkModeMismatch                = engine.RuleTrunkModeMismatch
+	RuleProfileAddress                   = engine.RuleProfileAddress
+	RuleUnreachableCertificateValidation = engine.RuleUnreachableCertificateValidation
+	RuleSplitBridgeGroup                 = engine.RuleSplitBridgeGroup
+	RuleUncoveredBackupVserver           = engine.RuleUncoveredBackupVserver
+	RuleNonAdjacentDsrServer             = engine.RuleNonAdjacentDsrServer
+)
+
+// Version is a function that returns the version of the tool.
+func Version() string {
+	return engine.Version()
+}
+
+// CacheFile is a function that stores the contents of a configuration under a name, so that the analysis reads
+// it from memory instead of resolving the name to a source.
+func CacheFile(fileName, file string) error {
+	return engine.CacheFile(fileName, file)
+}
+
+// ForgetFile is a function that drops a configuration from the cache so that the next access reads it again.
+func ForgetFile(fileName string) {
+	engine.ForgetFile(fileName)
+}
+
+// GetFindings is a function that returns every finding for a configuration, ordered by line number. It fails
+// once the context of the options is done.
+func GetFindings(fileName string, options AnalyzeOptions) ([]Finding, error) {
+	return engine.GetFindings(fileName, options)
+}
+
+// GetRules is a function that returns every rule in rule ID order.
+func GetRules() []Rule {
+	return engine.GetRules()
+}
+
+// GetProfile is a function that returns the profile with the given name.
+func GetProfile(name string) (*Profile, error) {
+	return engine.GetProfile(name)
+}
+
+// GetProfiles is a function that returns every profile.
+func GetProfiles() []Profile {
+	return engine.GetProfiles()
+}
+
+// GetWarnings is a function that returns the warnings for a configuration. It fails once the context of the
+// options is done.
+func GetWarnings(fileName string, options AnalyzeOptions) ([]Warning, error) {
+	return engine.GetWarnings(fileName, options)
+}
+
+// GetServers is a function that returns the servers of a configuration.
+func GetServers(fileName string) ([]Server, error) {
+	return engine.GetServers(fileName)
+}
+
+// GetAnalysisServers is a function that returns the servers to check for coverage, resolved as the options ask.
+func GetAnalysisServers(fileName string, options AnalyzeOptions) ([]Server, error) {
+	return engine.GetAnalysisServers(fileName, options)
+}
+
+// GetSnips is a function that returns the addresses a configuration gives the NetScaler.
+func GetSnips(fileName string) ([]Snip, error) {
+	return engine.GetSnips(fileName)
+}
+
+// GetCoverageNetworks is a function that returns the networks servers are checked for coverage against.
+func GetCoverageNetworks(fileName string, options AnalyzeOptions) ([]*net.IPNet, error) {
+	return engine.GetCoverageNetworks(fileName, options)
+}
+
+// GetUncoveredServers is a function that returns the servers that none of the networks holds.
+func GetUncoveredServers(networks []*net.IPNet, servers []Server) []Server {
+	return engine.GetUncoveredServers(networks, servers)
+}
@@ -0,0 +1,18 @@
+package nsanalyze
+
+import "testing"
+
+func TestGetFindings(t *testing.T) {
+	const fileName = "stable api test"
+	if err := CacheFile(fileName, "add ns ip 10.0.0.10 255.255.255.0 -type SNIP\nadd server far1 172.16.0.5\n"); err != nil {
+		t.Fatal(err)
+	}
+	defer ForgetFile(fileName)
+	findings, err := GetFindings(fileName, AnalyzeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || findings[0].Rule().ID() != RuleUncoveredServer.ID() || findings[0].Object() != "far1" {
+		t.Errorf("findings = %v, want the uncovered server far1", findings)
+	}
+}